@@ -0,0 +1,225 @@
+// Package app runs applications in a fixed-timestep loop. The application
+// implements App, and a Window, e.g. the one of package app/window or
+// Headless, provides the device and shows the frames.
+package app
+
+import (
+	"fmt"
+	"math"
+
+	"capture"
+	"clock"
+	"device"
+	"input"
+	"pace"
+)
+
+// App is an application run by Loop.
+type App interface {
+	// Init creates the resources of the application on d.
+	Init(d device.Device) error
+	// Update advances the application by dt seconds, always the step of
+	// the loop.
+	Update(dt float64)
+	// Render draws a frame. alpha, from 0 to 1, is how far the frame is
+	// from the previous update to the last one, to interpolate between
+	// their states.
+	Render(alpha float64)
+	// Resize is called before the first frame and whenever the size of
+	// the window changes.
+	Resize(width, height int)
+	// Shutdown frees the resources of the application.
+	Shutdown()
+}
+
+// Quitter is an App which can end the loop.
+type Quitter interface {
+	// Quit is called once per frame, before its updates, and reports
+	// whether to stop.
+	Quit() bool
+}
+
+// Framer is an App with work to do once per frame however many updates
+// it runs, e.g. moving the camera or uploading loaded assets.
+type Framer interface {
+	// Frame is called before the updates of every frame with the time
+	// since the last frame in seconds. It follows the base of the clock,
+	// so it goes on while the clock is paused or slowed down.
+	Frame(dt float64)
+}
+
+// Window shows the frames of a device.
+type Window interface {
+	Device() device.Device
+	Size() (width, height int)
+	// Swap shows the frame drawn and reports whether the window is still
+	// open.
+	Swap() bool
+}
+
+type Config struct {
+	Title         string
+	Width, Height int
+	// AlphaBits and DepthBits are the sizes of the alpha and depth
+	// buffers of the window.
+	AlphaBits, DepthBits int
+	// NoResize keeps the window at its size.
+	NoResize bool
+	// Fullscreen covers the screen, at the mode closest to the size.
+	Fullscreen bool
+	// Samples is the number of samples a pixel for multisample
+	// antialiasing, 0 to turn it off.
+	Samples int
+	// GLMajor and GLMinor are the OpenGL version to ask for, 3.3 if 0.
+	GLMajor, GLMinor int
+	// Profile is the OpenGL profile, "core" (the default) or "compat".
+	Profile string
+	// Debug asks for an OpenGL debug context.
+	Debug bool
+
+	// Step is the duration of an update in seconds, 1/60 by default.
+	Step float64
+	// Clock is the clock of the loop, a clock.Real by default.
+	Clock clock.Clock
+
+	// Pacer paces the frames, by vsync if nil.
+	Pacer *pace.Pacer
+	// ShowStats prints frame time statistics every second, and shows them
+	// in the title of windows which have one.
+	ShowStats bool
+
+	// Input, if not nil, starts an input frame every frame, before Frame,
+	// Quit and the updates, so that each event is seen in one frame.
+	// Windows add their events to it as a source. Loop closes it after
+	// shutting the app down.
+	Input *input.Input
+	// Capture, if not nil, saves frames. Its key binding sees the frames
+	// of Input. Loop closes it after shutting the app down.
+	Capture *capture.Capturer
+}
+
+// titler is a window with a title.
+type titler interface {
+	SetTitle(title string)
+}
+
+// maxFrame bounds the time a frame may take, so that a slow frame does not
+// cause a burst of updates which make the next frame slow too.
+const maxFrame = 0.25
+
+// statsFrames is the number of frames ShowStats shows statistics over.
+const statsFrames = 120
+
+// Loop initializes a in w and runs it until w is closed or a quits, then
+// shuts it down. Updates run every c.Step seconds of c.Clock, as many as fit in the
+// time of the frame, and each frame is rendered once. Input, quitting and
+// captures are handled once per frame, so they work while the clock is
+// paused. Errors recording c.Input or saving c.Capture are returned.
+func Loop(a App, w Window, c Config) (err error) {
+	step := c.Step
+	if step <= 0 {
+		step = 1.0 / 60
+	}
+	clk := c.Clock
+	if clk == nil {
+		clk = clock.NewReal()
+	}
+	pacer := c.Pacer
+	if pacer == nil {
+		pacer = &pace.Pacer{}
+	}
+	if c.ShowStats && pacer.Stats == nil {
+		pacer.Stats = pace.NewStats(statsFrames)
+	}
+	if c.Input != nil {
+		defer func() {
+			if cerr := c.Input.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}()
+	}
+	if c.Capture != nil {
+		defer func() {
+			if cerr := c.Capture.Close(); cerr != nil && err == nil {
+				err = cerr
+			}
+		}()
+	}
+	if err := a.Init(w.Device()); err != nil {
+		return err
+	}
+	defer a.Shutdown()
+
+	width, height := w.Size()
+	a.Resize(width, height)
+	base := clock.Base(clk)
+	last, lastBase := clk.Now(), base.Now()
+	accumulated := 0.0
+	shown := 0.0
+	for {
+		clk.Tick()
+		frame := math.Min(clk.Now()-last, maxFrame)
+		real := math.Min(base.Now()-lastBase, maxFrame)
+		last, lastBase = clk.Now(), base.Now()
+
+		if c.Input != nil {
+			c.Input.Frame()
+			if c.Capture != nil {
+				c.Capture.Update(c.Input)
+			}
+		}
+		if f, ok := a.(Framer); ok {
+			f.Frame(real)
+		}
+		if q, ok := a.(Quitter); ok && q.Quit() {
+			break
+		}
+		accumulated += frame
+		for accumulated >= step {
+			a.Update(step)
+			accumulated -= step
+		}
+		a.Render(accumulated / step)
+		if c.Capture != nil {
+			c.Capture.Frame(w.Device(), width, height)
+		}
+		pacer.Wait()
+		if c.ShowStats && pacer.Time()-shown >= 1 {
+			shown = pacer.Time()
+			fmt.Println(pacer.Stats)
+			if t, ok := w.(titler); ok {
+				t.SetTitle(c.Title + " - " + pacer.Stats.String())
+			}
+		}
+		if !w.Swap() {
+			break
+		}
+		if nw, nh := w.Size(); nw != width || nh != height {
+			width, height = nw, nh
+			a.Resize(width, height)
+		}
+	}
+	return nil
+}
+
+// Headless is a window without a screen, for tests and rendering offline
+// with a device like soft.Device. It closes after Frames frames.
+type Headless struct {
+	Dev           device.Device
+	Width, Height int
+	Frames        int
+	frame         int
+}
+
+func (h *Headless) Device() device.Device {
+	return h.Dev
+}
+
+func (h *Headless) Size() (int, int) {
+	return h.Width, h.Height
+}
+
+func (h *Headless) Swap() bool {
+	h.frame++
+	return h.frame < h.Frames
+}
@@ -0,0 +1,198 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"capture"
+	"clock"
+	"device"
+	"input"
+)
+
+// recorder records what Loop calls.
+type recorder struct {
+	in      *input.Input
+	updates int
+	renders int
+	dx      []int
+	frames  []float64
+}
+
+func (r *recorder) Init(d device.Device) error { return nil }
+
+func (r *recorder) Update(dt float64) {
+	r.updates++
+	dx, _ := r.in.MouseDelta()
+	r.dx = append(r.dx, dx)
+}
+
+func (r *recorder) Frame(dt float64) { r.frames = append(r.frames, dt) }
+
+func (r *recorder) Render(alpha float64) { r.renders++ }
+
+func (r *recorder) Resize(width, height int) {}
+
+func (r *recorder) Shutdown() {}
+
+func (r *recorder) Quit() bool {
+	return r.in.Pressed(input.Escape)
+}
+
+// The clock advances 4 steps a frame, so that each frame runs 4 updates.
+func loopConfig(in *input.Input) Config {
+	return Config{Step: 0.0625, Clock: clock.NewFixed(0, 0.25), Input: in}
+}
+
+func TestLoopInputPerFrame(t *testing.T) {
+	s := input.NewScript()
+	for i := 0; i < 2; i++ {
+		s.Add(i, input.Event{Kind: input.Move, X: 10 * i})
+	}
+	in := input.New(s)
+	r := &recorder{in: in}
+	if err := Loop(r, &Headless{Frames: 2}, loopConfig(in)); err != nil {
+		t.Fatal(err)
+	}
+	if r.updates != 8 || r.renders != 2 || len(r.frames) != 2 {
+		t.Fatalf("got %d updates, %d renders and %d frames, want 8, 2 and 2", r.updates, r.renders, len(r.frames))
+	}
+	// The updates of a frame see the movement since the frame before
+	want := []int{0, 0, 0, 0, 10, 10, 10, 10}
+	for i := range want {
+		if r.dx[i] != want[i] {
+			t.Fatalf("updates saw mouse deltas %v, want %v", r.dx, want)
+		}
+	}
+}
+
+func TestLoopQuit(t *testing.T) {
+	s := input.NewScript()
+	s.Add(1, input.Event{Kind: input.KeyDown, Key: input.Escape})
+	in := input.New(s)
+	r := &recorder{in: in}
+	if err := Loop(r, &Headless{Frames: 10}, loopConfig(in)); err != nil {
+		t.Fatal(err)
+	}
+	// The frame which sees Escape neither updates nor renders
+	if r.updates != 4 || r.renders != 1 {
+		t.Fatalf("got %d updates and %d renders, want 4 and 1", r.updates, r.renders)
+	}
+}
+
+// While the clock is paused frames go on: Frame gets the time of the
+// clock under it, and Escape quits.
+func TestLoopPaused(t *testing.T) {
+	s := input.NewScript()
+	s.Add(2, input.Event{Kind: input.KeyDown, Key: input.Escape})
+	in := input.New(s)
+	r := &recorder{in: in}
+	c := loopConfig(in)
+	paused := clock.NewPausable(c.Clock)
+	paused.Paused = true
+	c.Clock = paused
+	if err := Loop(r, &Headless{Frames: 10}, c); err != nil {
+		t.Fatal(err)
+	}
+	if r.updates != 0 || r.renders != 2 {
+		t.Fatalf("got %d updates and %d renders, want 0 and 2", r.updates, r.renders)
+	}
+	for _, dt := range r.frames {
+		if dt != 0.25 {
+			t.Fatalf("frames got times %v, want 0.25 each", r.frames)
+		}
+	}
+}
+
+// failing is a recording which cannot be written.
+type failing struct{ closed bool }
+
+func (f *failing) Write(p []byte) (int, error) { return 0, errors.New("disk full") }
+
+func (f *failing) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestLoopClosesRecording(t *testing.T) {
+	s := input.NewScript()
+	s.Add(0, input.Event{Kind: input.KeyDown, Key: input.Left})
+	in := input.New(s)
+	f := &failing{}
+	in.Record(f)
+	if err := Loop(&recorder{in: in}, &Headless{Frames: 1}, loopConfig(in)); err == nil {
+		t.Error("no error")
+	}
+	if !f.closed {
+		t.Error("recording not closed")
+	}
+}
+
+func TestScene(t *testing.T) {
+	s := input.NewScript()
+	s.Add(5, input.Event{Kind: input.KeyDown, Key: input.Escape})
+	in := input.New(s)
+	d := device.NewRecorder()
+	var calls []string
+	updates := 0
+	scene := Scene{
+		Init: func(d device.Device) error { calls = append(calls, "init"); return nil },
+		Update: func(dt float64, in *input.Input, actions *input.Actions) {
+			updates++
+		},
+		Resize: func(width, height int) {
+			// The viewport is already set
+			calls = append(calls, d.Calls[len(d.Calls)-1].String())
+		},
+		Shutdown: func() { calls = append(calls, "shutdown") },
+	}
+	if err := Loop(scene.App(in, input.DefaultActions()), &Headless{Dev: d, Width: 4, Height: 3, Frames: 10}, loopConfig(in)); err != nil {
+		t.Fatal(err)
+	}
+	// The frame which sees the quit action does not update
+	if updates != 20 {
+		t.Errorf("got %d updates, want 20", updates)
+	}
+	want := []string{"init", "Viewport(0, 0, 4, 3)", "shutdown"}
+	if len(calls) != len(want) {
+		t.Fatalf("got calls %v, want %v", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Fatalf("got calls %v, want %v", calls, want)
+		}
+	}
+}
+
+func TestLoopCapture(t *testing.T) {
+	s := input.NewScript()
+	s.Add(1, input.Event{Kind: input.KeyDown, Key: input.F12})
+	in := input.New(s)
+	d := device.NewRecorder()
+	dir := t.TempDir()
+	c := loopConfig(in)
+	c.Capture = capture.New(4)
+	c.Capture.Actions = input.DefaultActions()
+	c.Capture.Pattern = filepath.Join(dir, "shot%d.png")
+	if err := Loop(&recorder{in: in}, &Headless{Dev: d, Width: 4, Height: 3, Frames: 3}, c); err != nil {
+		t.Fatal(err)
+	}
+	// F12 takes one screenshot
+	reads := 0
+	for _, call := range d.Calls {
+		if call.Name == "StartReadPixels" {
+			reads++
+		}
+	}
+	if reads != 1 {
+		t.Errorf("%d frames read back, want 1", reads)
+	}
+	for _, err := range d.Errors {
+		t.Error(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "shot0.png")); err != nil {
+		t.Error(err)
+	}
+}
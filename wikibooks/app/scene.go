@@ -0,0 +1,79 @@
+package app
+
+import (
+	"device"
+	"input"
+)
+
+// Scene is an App made of the parts which differ between programs, so
+// that they need not write the rest again. Any function may be nil.
+type Scene struct {
+	Init func(d device.Device) error
+	// Frame runs once per frame before its updates, with the time since
+	// the last frame, even while the clock is paused. in holds the input
+	// of the frame and actions are the key bindings.
+	Frame func(dt float64, in *input.Input, actions *input.Actions)
+	// Update advances the scene by dt seconds. in holds the input of the
+	// frame and actions are the key bindings.
+	Update func(dt float64, in *input.Input, actions *input.Actions)
+	Render func(alpha float64)
+	// Resize is called after the viewport is set to cover the window.
+	Resize   func(width, height int)
+	Shutdown func()
+}
+
+// App returns an App running s with the input in and the key bindings
+// actions. It quits on the "quit" action.
+func (s Scene) App(in *input.Input, actions *input.Actions) App {
+	return &sceneApp{scene: s, in: in, actions: actions}
+}
+
+type sceneApp struct {
+	scene   Scene
+	in      *input.Input
+	actions *input.Actions
+	dev     device.Device
+}
+
+func (a *sceneApp) Init(d device.Device) error {
+	a.dev = d
+	if a.scene.Init == nil {
+		return nil
+	}
+	return a.scene.Init(d)
+}
+
+func (a *sceneApp) Frame(dt float64) {
+	if a.scene.Frame != nil {
+		a.scene.Frame(dt, a.in, a.actions)
+	}
+}
+
+func (a *sceneApp) Update(dt float64) {
+	if a.scene.Update != nil {
+		a.scene.Update(dt, a.in, a.actions)
+	}
+}
+
+func (a *sceneApp) Render(alpha float64) {
+	if a.scene.Render != nil {
+		a.scene.Render(alpha)
+	}
+}
+
+func (a *sceneApp) Resize(width, height int) {
+	a.dev.Viewport(0, 0, width, height)
+	if a.scene.Resize != nil {
+		a.scene.Resize(width, height)
+	}
+}
+
+func (a *sceneApp) Quit() bool {
+	return a.actions.Pressed(a.in, "quit")
+}
+
+func (a *sceneApp) Shutdown() {
+	if a.scene.Shutdown != nil {
+		a.scene.Shutdown()
+	}
+}
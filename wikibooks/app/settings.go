@@ -0,0 +1,310 @@
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"pace"
+)
+
+// EnvPrefix starts the names of the environment variables which set the
+// window settings and -config, like TUTORIAL_WIDTH for -width. Empty
+// variables are ignored.
+var EnvPrefix = "TUTORIAL_"
+
+var (
+	configFile = flag.String("config", "", "file of settings, JSON if named *.json and TOML otherwise")
+	width      = flag.Int("width", 0, "width of the window")
+	height     = flag.Int("height", 0, "height of the window")
+	fullscreen = flag.Bool("fullscreen", false, "cover the screen")
+	samples    = flag.Int("samples", 0, "samples a pixel for multisample antialiasing")
+	glVersion  = flag.String("gl", "", "OpenGL version to ask for, like 3.3")
+	profile    = flag.String("profile", "", "OpenGL profile: core or compat")
+	debug      = flag.Bool("debug", false, "ask for an OpenGL debug context")
+
+	// Dump asks to print the effective settings instead of running.
+	Dump = flag.Bool("dump-config", false, "print the effective settings and exit")
+)
+
+// settings name the flags of the window settings and the pacer, which can
+// also be set by the environment and the file of -config.
+var settings = []string{"width", "height", "fullscreen", "samples", "gl", "profile", "debug", "pacing", "fps", "stats"}
+
+func isSetting(name string) bool {
+	for _, s := range settings {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfigFromFlags returns c with the window settings and the pacer chosen
+// with the flags, after flag.Parse. Settings not on the command line take
+// their value from the environment variable of their name, or else from
+// the file of -config. Other flags are only read from the command line.
+// The settings are validated.
+func ConfigFromFlags(c Config) (Config, error) {
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return configFrom(c, set)
+}
+
+// configFrom is ConfigFromFlags with the flags set on the command line.
+func configFrom(c Config, set map[string]bool) (Config, error) {
+	filename := *configFile
+	if !set["config"] {
+		if v := os.Getenv(envName("config")); v != "" {
+			filename = v
+		}
+	}
+	var file map[string]string
+	if filename != "" {
+		var err error
+		if file, err = LoadSettings(filename); err != nil {
+			return c, err
+		}
+		for name := range file {
+			if !isSetting(name) {
+				if flag.Lookup(name) != nil {
+					return c, fmt.Errorf("app: %s: %s is not a window setting, give it on the command line", filename, name)
+				}
+				return c, fmt.Errorf("app: %s: unknown setting %q", filename, name)
+			}
+		}
+	}
+
+	for _, name := range settings {
+		if set[name] {
+			continue
+		}
+		source := envName(name)
+		v := os.Getenv(source)
+		if v == "" {
+			var ok bool
+			if v, ok = file[name]; !ok {
+				continue
+			}
+			source = filename
+		}
+		if err := flag.Set(name, v); err != nil {
+			return c, fmt.Errorf("app: %s: invalid value %q for %s: %v", source, v, name, err)
+		}
+		set[name] = true
+	}
+
+	var err error
+	if set["width"] {
+		c.Width = *width
+	}
+	if set["height"] {
+		c.Height = *height
+	}
+	if set["fullscreen"] {
+		c.Fullscreen = *fullscreen
+	}
+	if set["samples"] {
+		c.Samples = *samples
+	}
+	if set["gl"] {
+		if c.GLMajor, c.GLMinor, err = parseVersion(*glVersion); err != nil {
+			return c, err
+		}
+	}
+	if set["profile"] {
+		c.Profile = *profile
+	}
+	if set["debug"] {
+		c.Debug = *debug
+	}
+	c.Pacer = pace.FromFlags()
+	c.ShowStats = *pace.Show
+	if err = c.Validate(); err != nil {
+		return c, err
+	}
+
+	// The flags show the effective settings to DumpConfig
+	flag.Set("width", strconv.Itoa(c.Width))
+	flag.Set("height", strconv.Itoa(c.Height))
+	flag.Set("fullscreen", strconv.FormatBool(c.Fullscreen))
+	flag.Set("samples", strconv.Itoa(c.Samples))
+	major, minor := c.glVersion()
+	flag.Set("gl", fmt.Sprintf("%d.%d", major, minor))
+	flag.Set("profile", c.profile())
+	flag.Set("debug", strconv.FormatBool(c.Debug))
+	return c, nil
+}
+
+func envName(flagName string) string {
+	return EnvPrefix + strings.ToUpper(strings.Replace(flagName, "-", "_", -1))
+}
+
+func parseVersion(s string) (major, minor int, err error) {
+	i := strings.Index(s, ".")
+	if i >= 0 {
+		major, err = strconv.Atoi(s[:i])
+		if err == nil {
+			minor, err = strconv.Atoi(s[i+1:])
+		}
+	}
+	if i < 0 || err != nil {
+		return 0, 0, fmt.Errorf("app: invalid OpenGL version %q, expected major.minor", s)
+	}
+	return major, minor, nil
+}
+
+// glVersion returns the OpenGL version to ask for.
+func (c *Config) glVersion() (major, minor int) {
+	if c.GLMajor == 0 {
+		return 3, 3
+	}
+	return c.GLMajor, c.GLMinor
+}
+
+// profile returns the OpenGL profile to ask for.
+func (c *Config) profile() string {
+	if c.Profile == "" {
+		return "core"
+	}
+	return c.Profile
+}
+
+// Validate reports the first setting of the window which cannot work.
+func (c *Config) Validate() error {
+	if c.Width <= 0 || c.Height <= 0 {
+		return fmt.Errorf("app: invalid window size %dx%d", c.Width, c.Height)
+	}
+	if c.Samples < 0 || c.Samples > 16 {
+		return fmt.Errorf("app: invalid number of samples %d, expected 0 to 16", c.Samples)
+	}
+	// The devices need OpenGL 3.3
+	if major, minor := c.glVersion(); major < 3 || major == 3 && minor < 3 {
+		return fmt.Errorf("app: OpenGL version %d.%d is older than 3.3", major, minor)
+	}
+	if p := c.profile(); p != "core" && p != "compat" {
+		return fmt.Errorf("app: unknown OpenGL profile %q, expected core or compat", p)
+	}
+	if c.Step < 0 {
+		return fmt.Errorf("app: negative step %v", c.Step)
+	}
+	if c.AlphaBits < 0 || c.DepthBits < 0 {
+		return fmt.Errorf("app: negative buffer size %d, %d", c.AlphaBits, c.DepthBits)
+	}
+	return nil
+}
+
+// LoadSettings reads settings from the named file, in JSON if its name ends
+// in .json and in TOML otherwise. The settings are named like the flags.
+func LoadSettings(filename string) (map[string]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if strings.EqualFold(filepath.Ext(filename), ".json") {
+		return readJSON(f, filename)
+	}
+	return readTOML(f, filename)
+}
+
+// readJSON reads settings from a JSON object of numbers, strings and
+// booleans.
+func readJSON(r io.Reader, name string) (map[string]string, error) {
+	var values map[string]interface{}
+	if err := json.NewDecoder(r).Decode(&values); err != nil {
+		return nil, fmt.Errorf("app: %s: %v", name, err)
+	}
+	settings := make(map[string]string)
+	for k, v := range values {
+		switch v := v.(type) {
+		case string:
+			settings[k] = v
+		case float64:
+			settings[k] = strconv.FormatFloat(v, 'g', -1, 64)
+		case bool:
+			settings[k] = strconv.FormatBool(v)
+		default:
+			return nil, fmt.Errorf("app: %s: %s is not a number, string or boolean", name, k)
+		}
+	}
+	return settings, nil
+}
+
+// readTOML reads settings from the part of TOML with only keys and values
+// like
+//
+//	width = 800
+//	profile = "core"
+//
+// Empty lines and comments starting with # are skipped.
+func readTOML(r io.Reader, name string) (map[string]string, error) {
+	settings := make(map[string]string)
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		i := strings.Index(text, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("app: %s:%d: expected key = value", name, line)
+		}
+		key := strings.TrimSpace(text[:i])
+		value := strings.TrimSpace(text[i+1:])
+		quoted := strings.HasPrefix(value, `"`)
+		if quoted {
+			end := 1
+			for end < len(value) && value[end] != '"' {
+				// Skip what is escaped, which may be a backslash
+				if value[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(value) {
+				return nil, fmt.Errorf("app: %s:%d: unterminated string", name, line)
+			}
+			if rest := strings.TrimSpace(value[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+				return nil, fmt.Errorf("app: %s:%d: text after the string", name, line)
+			}
+			v, err := strconv.Unquote(value[:end+1])
+			if err != nil {
+				return nil, fmt.Errorf("app: %s:%d: invalid string %s", name, line, value)
+			}
+			value = v
+		} else if j := strings.Index(value, "#"); j >= 0 {
+			value = strings.TrimSpace(value[:j])
+		}
+		if key == "" || value == "" && !quoted {
+			return nil, fmt.Errorf("app: %s:%d: expected key = value", name, line)
+		}
+		settings[key] = value
+	}
+	return settings, s.Err()
+}
+
+// DumpConfig writes the window settings in the TOML LoadSettings reads,
+// after ConfigFromFlags: the effective settings of the program.
+func DumpConfig(w io.Writer) {
+	for _, name := range settings {
+		f := flag.Lookup(name)
+		v := f.Value.String()
+		if b, ok := f.Value.(interface {
+			IsBoolFlag() bool
+		}); !ok || !b.IsBoolFlag() {
+			if _, err := strconv.ParseFloat(v, 64); err != nil {
+				v = strconv.Quote(v)
+			}
+		}
+		fmt.Fprintf(w, "%s = %s\n", f.Name, v)
+	}
+}
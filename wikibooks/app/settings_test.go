@@ -0,0 +1,149 @@
+package app
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// configWith runs configFrom with the flags of args given on the command
+// line and the settings file text, if any.
+func configWith(t *testing.T, args map[string]string, text string) (Config, error) {
+	set := make(map[string]bool)
+	if text != "" {
+		name := filepath.Join(t.TempDir(), "settings.toml")
+		if err := ioutil.WriteFile(name, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		args["config"] = name
+	}
+	for name, v := range args {
+		if err := flag.Set(name, v); err != nil {
+			t.Fatal(err)
+		}
+		set[name] = true
+	}
+	defer flag.Set("config", "")
+	return configFrom(Config{Width: 640, Height: 480}, set)
+}
+
+func TestSettingsPrecedence(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          map[string]string
+		env           string
+		file          string
+		width, height int
+	}{
+		{"defaults", map[string]string{}, "", "", 640, 480},
+		{"file", map[string]string{}, "", "width = 100\nheight = 200\n", 100, 200},
+		{"environment over file", map[string]string{}, "300", "width = 100\nheight = 200\n", 300, 200},
+		{"flag over environment", map[string]string{"width": "500"}, "300", "width = 100\nheight = 200\n", 500, 200},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("TUTORIAL_WIDTH", test.env)
+			c, err := configWith(t, test.args, test.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Width != test.width || c.Height != test.height {
+				t.Errorf("got %dx%d, want %dx%d", c.Width, c.Height, test.width, test.height)
+			}
+		})
+	}
+}
+
+func TestSettingsRejected(t *testing.T) {
+	tests := []struct {
+		file, err string
+	}{
+		{"bogus = 1\n", "unknown setting"},
+		{"config = \"other.toml\"\n", "not a window setting"},
+		{"record = \"input.txt\"\n", "not a window setting"},
+		{"width = wide\n", "invalid value"},
+	}
+	for _, test := range tests {
+		_, err := configWith(t, map[string]string{}, test.file)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%q: got error %v, want %q", test.file, err, test.err)
+		}
+	}
+}
+
+// Flags other than the settings are not read from the environment.
+func TestSettingsEnvironment(t *testing.T) {
+	t.Setenv("TUTORIAL_RECORD", "input.txt")
+	t.Setenv("TUTORIAL_SAMPLES", "4")
+	c, err := configWith(t, map[string]string{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Samples != 4 {
+		t.Errorf("got %d samples, want 4", c.Samples)
+	}
+	if v := flag.Lookup("record").Value.String(); v != "" {
+		t.Errorf("-record is %q, want it unset", v)
+	}
+}
+
+func TestDumpConfig(t *testing.T) {
+	var buf bytes.Buffer
+	DumpConfig(&buf)
+	var names []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		names = append(names, strings.Fields(line)[0])
+	}
+	if strings.Join(names, " ") != strings.Join(settings, " ") {
+		t.Errorf("dumped %v, want %v", names, settings)
+	}
+	// What it dumps reads back
+	settings, err := readTOML(&buf, "dump")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(settings) != len(names) {
+		t.Errorf("read back %d settings, want %d", len(settings), len(names))
+	}
+}
+
+func TestReadTOML(t *testing.T) {
+	tests := []struct {
+		text, value, err string
+	}{
+		{`v = 5`, "5", ""},
+		{`v = 5 # comment`, "5", ""},
+		{`v = "core"`, "core", ""},
+		{`v = ""`, "", ""},
+		{`v = "# not a comment" # comment`, "# not a comment", ""},
+		{`v = "a \"quoted\" word"`, `a "quoted" word`, ""},
+		// Ends with an escaped backslash
+		{`v = "C:\\"`, `C:\`, ""},
+		{`v = "C:\\" # comment`, `C:\`, ""},
+		{`v = "\\\""`, `\"`, ""},
+		{`v = "C:\\" x`, "", "text after the string"},
+		{`v = "core`, "", "unterminated string"},
+		{`v = "core\"`, "", "unterminated string"},
+		{`v = "core\`, "", "unterminated string"},
+		{`v = "\q"`, "", "invalid string"},
+		{`v =`, "", "expected key = value"},
+		{`= 5`, "", "expected key = value"},
+	}
+	for _, test := range tests {
+		settings, err := readTOML(strings.NewReader(test.text), "settings.toml")
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), "settings.toml:1: "+test.err) {
+				t.Errorf("%s: got error %v, want %q", test.text, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.text, err)
+		} else if v, ok := settings["v"]; !ok || v != test.value {
+			t.Errorf("%s: got %q, want %q", test.text, v, test.value)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package window
+
+import (
+	"input"
+
+	"github.com/jteeuwen/glfw"
+)
+
+// events collects the input events of the window.
+type events struct {
+	queue []input.Event
+	wheel int
+}
+
+func (e *events) Events() []input.Event {
+	queue := e.queue
+	e.queue = nil
+	return queue
+}
+
+var specialKeys = map[int]input.Key{
+	glfw.KeyEsc:       input.Escape,
+	glfw.KeyEnter:     input.Enter,
+	glfw.KeyTab:       input.Tab,
+	glfw.KeyBackspace: input.Backspace,
+	glfw.KeyInsert:    input.Insert,
+	glfw.KeyDel:       input.Delete,
+	glfw.KeyUp:        input.Up,
+	glfw.KeyDown:      input.Down,
+	glfw.KeyLeft:      input.Left,
+	glfw.KeyRight:     input.Right,
+	glfw.KeyPageup:    input.PageUp,
+	glfw.KeyPagedown:  input.PageDown,
+	glfw.KeyHome:      input.Home,
+	glfw.KeyEnd:       input.End,
+	glfw.KeyLshift:    input.LeftShift,
+	glfw.KeyRshift:    input.RightShift,
+	glfw.KeyLctrl:     input.LeftCtrl,
+	glfw.KeyRctrl:     input.RightCtrl,
+}
+
+var mouseButtons = map[int]input.Key{
+	glfw.MouseLeft:   input.MouseLeft,
+	glfw.MouseRight:  input.MouseRight,
+	glfw.MouseMiddle: input.MouseMiddle,
+}
+
+// key converts a GLFW key, which is its character for printable keys.
+func key(k int) (input.Key, bool) {
+	if k >= glfw.KeyF1 && k < glfw.KeyF1+12 {
+		return input.F1 + input.Key(k-glfw.KeyF1), true
+	}
+	if ik, ok := specialKeys[k]; ok {
+		return ik, true
+	}
+	if k >= ' ' && k < 128 {
+		return input.Key(k), true
+	}
+	return 0, false
+}
+
+func upOrDown(state int) input.EventKind {
+	if state == glfw.KeyPress {
+		return input.KeyDown
+	}
+	return input.KeyUp
+}
+
+// listen sets the GLFW callbacks to collect events.
+func (e *events) listen() {
+	glfw.SetKeyCallback(func(k, state int) {
+		if ik, ok := key(k); ok {
+			e.queue = append(e.queue, input.Event{Kind: upOrDown(state), Key: ik})
+		}
+	})
+	glfw.SetMouseButtonCallback(func(button, state int) {
+		if ik, ok := mouseButtons[button]; ok {
+			e.queue = append(e.queue, input.Event{Kind: upOrDown(state), Key: ik})
+		}
+	})
+	glfw.SetMousePosCallback(func(x, y int) {
+		e.queue = append(e.queue, input.Event{Kind: input.Move, X: x, Y: y})
+	})
+	// GLFW gives the position of the wheel rather than how far it turned
+	glfw.SetMouseWheelCallback(func(pos int) {
+		e.queue = append(e.queue, input.Event{Kind: input.Scroll, Y: pos - e.wheel})
+		e.wheel = pos
+	})
+}
@@ -0,0 +1,173 @@
+// Package window runs apps in a GLFW window drawing with OpenGL 3.3.
+package window
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+
+	"app"
+	"capture"
+	"clock"
+	"device"
+	"device/gl33"
+	"input"
+	"pace"
+
+	"github.com/jteeuwen/glfw"
+)
+
+// Window is the GLFW window. GLFW has only one, so only one can be open.
+type Window struct {
+	dev           *gl33.Device
+	width, height int
+}
+
+// Open opens a window as described by c and initializes OpenGL in it.
+func Open(c app.Config) (*Window, error) {
+	if err := glfw.Init(); err != nil {
+		return nil, fmt.Errorf("window: %v", err)
+	}
+
+	if c.NoResize {
+		glfw.OpenWindowHint(glfw.WindowNoResize, 1)
+	}
+	if c.Debug {
+		glfw.OpenWindowHint(glfw.OpenGLDebugContext, 1)
+	}
+	if c.Samples > 0 {
+		glfw.OpenWindowHint(glfw.FsaaSamples, c.Samples)
+	}
+	major, minor := 3, 3
+	if c.GLMajor != 0 {
+		major, minor = c.GLMajor, c.GLMinor
+	}
+	glfw.OpenWindowHint(glfw.OpenGLVersionMajor, major)
+	glfw.OpenWindowHint(glfw.OpenGLVersionMinor, minor)
+	if c.Profile == "compat" {
+		glfw.OpenWindowHint(glfw.OpenGLProfile, glfw.OpenGLCompatProfile)
+	} else {
+		glfw.OpenWindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
+	}
+
+	mode := glfw.Windowed
+	if c.Fullscreen {
+		mode = glfw.Fullscreen
+	}
+	err := glfw.OpenWindow(c.Width, c.Height, 0, 0, 0, c.AlphaBits, c.DepthBits, 0, mode)
+	if err != nil {
+		glfw.Terminate()
+		return nil, fmt.Errorf("window: %v", err)
+	}
+	w := &Window{width: c.Width, height: c.Height}
+	glfw.SetWindowTitle(c.Title)
+	glfw.SetWindowSizeCallback(func(width, height int) {
+		w.width, w.height = width, height
+	})
+	// Wait for the vertical blank unless the pacer paces the frames
+	if c.Pacer == nil || c.Pacer.Mode == pace.VSync {
+		glfw.SwapInterval(1)
+	} else {
+		glfw.SwapInterval(0)
+	}
+
+	if c.Input != nil {
+		e := &events{}
+		e.listen()
+		c.Input.AddSource(e)
+	}
+
+	gotMajor, gotMinor, rev := glfw.GLVersion()
+	if gotMajor < major || gotMajor == major && gotMinor < minor {
+		w.Close()
+		return nil, fmt.Errorf("window: OpenGL %d.%d is not supported, the version is %d.%d.%d", major, minor, gotMajor, gotMinor, rev)
+	}
+
+	// Init extension loading
+	w.dev, err = gl33.New()
+	if err != nil {
+		w.Close()
+		return nil, fmt.Errorf("window: init OpenGL extension loading: %v", err)
+	}
+	return w, nil
+}
+
+func (w *Window) Device() device.Device {
+	return w.dev
+}
+
+func (w *Window) Size() (int, int) {
+	return w.width, w.height
+}
+
+func (w *Window) SetTitle(title string) {
+	glfw.SetWindowTitle(title)
+}
+
+func (w *Window) Swap() bool {
+	glfw.SwapBuffers()
+	return glfw.WindowParam(glfw.Opened) == 1
+}
+
+func (w *Window) Close() {
+	glfw.CloseWindow()
+	glfw.Terminate()
+}
+
+// Run opens a window, runs a in it until it is closed and closes it.
+// c.Input and c.Capture are closed like app.Loop closes them, also when the
+// window does not open.
+func Run(a app.App, c app.Config) error {
+	// OpenGL calls must come from the thread which created the context
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	w, err := Open(c)
+	if err != nil {
+		if c.Input != nil {
+			c.Input.Close()
+		}
+		if c.Capture != nil {
+			c.Capture.Close()
+		}
+		return err
+	}
+	defer w.Close()
+	return app.Loop(a, w, c)
+}
+
+// Main is the main function of a program showing s in a window. It parses
+// the flags and runs s with the settings, clock, input and key bindings
+// they choose, starting from c. It prints errors and exits.
+func Main(c app.Config, s app.Scene) {
+	if err := runFlags(c, s); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func runFlags(c app.Config, s app.Scene) error {
+	flag.Parse()
+	c, err := app.ConfigFromFlags(c)
+	if err != nil {
+		return err
+	}
+	if *app.Dump {
+		app.DumpConfig(os.Stdout)
+		return nil
+	}
+	c.Clock = clock.FromFlags()
+	actions, err := input.ActionsFromFlags()
+	if err != nil {
+		return err
+	}
+	if c.Input, err = input.FromFlags(); err != nil {
+		return err
+	}
+	if c.Capture, err = capture.FromFlags(actions); err != nil {
+		c.Input.Close()
+		return err
+	}
+	return Run(s.App(c.Input, actions), c)
+}
@@ -0,0 +1,45 @@
+package asset
+
+import (
+	"fmt"
+	"image"
+	"time"
+)
+
+// FakeBackend stands in for OpenGL in tests. Like a real GL context it is
+// not safe for concurrent use and deliberately has no locking, so running
+// the tests with the race detector reports any upload done off the GL
+// thread.
+type FakeBackend struct {
+	// UploadDelay simulates a slow upload.
+	UploadDelay time.Duration
+	// Textures holds the size of every live texture.
+	Textures map[uint32]image.Rectangle
+	Uploads  int
+	Deletes  int
+
+	next uint32
+}
+
+func NewFakeBackend() *FakeBackend {
+	return &FakeBackend{Textures: make(map[uint32]image.Rectangle)}
+}
+
+func (b *FakeBackend) UploadTexture(img image.Image) (uint32, error) {
+	if img.Bounds().Empty() {
+		return 0, fmt.Errorf("asset: empty image")
+	}
+	time.Sleep(b.UploadDelay)
+	b.next++
+	b.Uploads++
+	b.Textures[b.next] = img.Bounds()
+	return b.next, nil
+}
+
+func (b *FakeBackend) DeleteTexture(texture uint32) {
+	if _, ok := b.Textures[texture]; !ok {
+		panic(fmt.Sprintf("asset: deleting unknown texture %d", texture))
+	}
+	b.Deletes++
+	delete(b.Textures, texture)
+}
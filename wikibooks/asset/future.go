@@ -0,0 +1,97 @@
+package asset
+
+import (
+	"sync"
+)
+
+// A Future is the result of an asset which is still loading.
+type Future struct {
+	name string
+	done chan struct{}
+
+	mu        sync.Mutex
+	value     interface{}
+	err       error
+	finished  bool
+	callbacks []func(value interface{}, err error)
+}
+
+func newFuture(name string) *Future {
+	return &Future{name: name, done: make(chan struct{})}
+}
+
+func (f *Future) Name() string {
+	return f.name
+}
+
+// Done returns a channel which is closed once the asset is uploaded or
+// failed to load.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+func (f *Future) Ready() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.finished
+}
+
+// Result returns the uploaded asset, or nil and no error if it is not
+// ready yet.
+func (f *Future) Result() (interface{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.value, f.err
+}
+
+// Wait blocks until the asset is ready. Never call it from the GL thread,
+// because the upload happens there in Loader.Update.
+func (f *Future) Wait() (interface{}, error) {
+	<-f.done
+	return f.Result()
+}
+
+// Then registers a callback which is run on the GL thread from
+// Loader.Update once the asset is ready. If it is already ready, the
+// callback is run immediately.
+func (f *Future) Then(callback func(value interface{}, err error)) {
+	f.mu.Lock()
+	if !f.finished {
+		f.callbacks = append(f.callbacks, callback)
+		f.mu.Unlock()
+		return
+	}
+	value, err := f.value, f.err
+	f.mu.Unlock()
+	callback(value, err)
+}
+
+func (f *Future) resolve(value interface{}, err error) {
+	f.mu.Lock()
+	f.value, f.err = value, err
+	f.finished = true
+	callbacks := f.callbacks
+	f.callbacks = nil
+	f.mu.Unlock()
+	close(f.done)
+	for _, callback := range callbacks {
+		callback(value, err)
+	}
+}
+
+// A TextureFuture is a texture which is still loading. Until it is ready
+// it hands out the loader's placeholder texture.
+type TextureFuture struct {
+	*Future
+	placeholder uint32
+}
+
+// Texture returns the texture name, or the placeholder while loading or
+// if loading failed.
+func (f *TextureFuture) Texture() uint32 {
+	value, err := f.Result()
+	if value == nil || err != nil {
+		return f.placeholder
+	}
+	return value.(uint32)
+}
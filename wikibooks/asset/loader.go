@@ -0,0 +1,245 @@
+package asset
+
+import (
+	"errors"
+	"image"
+	"io"
+	"os"
+	"sync"
+	"time"
+	// For image loading
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// ErrClosed is the error of assets which were still queued when the loader
+// was closed.
+var ErrClosed = errors.New("asset: loader closed")
+
+// Backend does the GL work of a Loader. Its methods are only called on the
+// GL thread, from NewLoader, Loader.Update and Loader.Close.
+type Backend interface {
+	UploadTexture(img image.Image) (uint32, error)
+	DeleteTexture(texture uint32)
+}
+
+// A Job loads one asset. Decode runs on a worker goroutine and must not
+// touch OpenGL. Upload gets the decoded data on the GL thread; its result
+// becomes the value of the Future.
+type Job struct {
+	Name   string
+	Decode func() (interface{}, error)
+	Upload func(data interface{}) (interface{}, error)
+}
+
+type result struct {
+	job    Job
+	future *Future
+	data   interface{}
+	err    error
+}
+
+// Loader decodes assets on worker goroutines and uploads them on the GL
+// thread. Decoded assets wait in a bounded queue, so workers stall instead
+// of piling up decoded images when the GL thread falls behind.
+type Loader struct {
+	backend     Backend
+	placeholder uint32
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending []result
+	loading int
+	closed  bool
+
+	results chan result
+	workers sync.WaitGroup
+}
+
+// NewLoader starts workers goroutines. Decoded assets are kept in a queue
+// of queueSize entries. It must be called on the GL thread, since it
+// uploads the placeholder texture.
+func NewLoader(backend Backend, workers, queueSize int) (*Loader, error) {
+	placeholder, err := backend.UploadTexture(Placeholder())
+	if err != nil {
+		return nil, err
+	}
+	l := &Loader{
+		backend:     backend,
+		placeholder: placeholder,
+		results:     make(chan result, queueSize),
+	}
+	l.cond = sync.NewCond(&l.mu)
+	for i := 0; i < workers; i++ {
+		l.workers.Add(1)
+		go l.work()
+	}
+	return l, nil
+}
+
+// Placeholder returns the magenta and black checkerboard shown while
+// textures are loading.
+func Placeholder() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			i := img.PixOffset(x, y)
+			if (x/4+y/4)%2 == 0 {
+				img.Pix[i], img.Pix[i+2] = 255, 255
+			}
+			img.Pix[i+3] = 255
+		}
+	}
+	return img
+}
+
+func (l *Loader) PlaceholderTexture() uint32 {
+	return l.placeholder
+}
+
+// Load queues a job. It never blocks, so it can be called from the GL
+// thread.
+func (l *Loader) Load(job Job) *Future {
+	f := newFuture(job.Name)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		f.resolve(nil, ErrClosed)
+		return f
+	}
+	l.pending = append(l.pending, result{job: job, future: f})
+	l.loading++
+	l.cond.Signal()
+	return f
+}
+
+// LoadTexture decodes an image file and uploads it through the backend.
+func (l *Loader) LoadTexture(name string) *TextureFuture {
+	f := l.Load(Job{
+		Name: name,
+		Decode: func() (interface{}, error) {
+			file, err := os.Open(name)
+			if err != nil {
+				return nil, err
+			}
+			defer file.Close()
+			img, _, err := image.Decode(file)
+			return img, err
+		},
+		Upload: func(data interface{}) (interface{}, error) {
+			return l.backend.UploadTexture(data.(image.Image))
+		},
+	})
+	return &TextureFuture{Future: f, placeholder: l.placeholder}
+}
+
+// LoadFile parses a file, e.g. a mesh, on a worker and passes the result to
+// upload on the GL thread.
+func (l *Loader) LoadFile(name string, parse func(r io.Reader) (interface{}, error), upload func(data interface{}) (interface{}, error)) *Future {
+	return l.Load(Job{
+		Name: name,
+		Decode: func() (interface{}, error) {
+			file, err := os.Open(name)
+			if err != nil {
+				return nil, err
+			}
+			defer file.Close()
+			return parse(file)
+		},
+		Upload: upload,
+	})
+}
+
+func (l *Loader) work() {
+	defer l.workers.Done()
+	for {
+		l.mu.Lock()
+		for len(l.pending) == 0 && !l.closed {
+			l.cond.Wait()
+		}
+		if l.closed {
+			l.mu.Unlock()
+			return
+		}
+		r := l.pending[0]
+		l.pending = l.pending[1:]
+		l.mu.Unlock()
+
+		r.data, r.err = r.job.Decode()
+		l.results <- r
+	}
+}
+
+// Update uploads decoded assets and runs their callbacks until budget is
+// used up. At least one asset is uploaded per call if one is ready, so
+// loading always makes progress. Call it once per frame on the GL thread.
+// It returns the number of finished assets.
+func (l *Loader) Update(budget time.Duration) int {
+	start := time.Now()
+	n := 0
+	for {
+		select {
+		case r := <-l.results:
+			l.finish(r)
+			n++
+		default:
+			return n
+		}
+		if time.Since(start) >= budget {
+			return n
+		}
+	}
+}
+
+func (l *Loader) finish(r result) {
+	l.mu.Lock()
+	l.loading--
+	l.mu.Unlock()
+	if r.err != nil || r.job.Upload == nil {
+		r.future.resolve(r.data, r.err)
+		return
+	}
+	r.future.resolve(r.job.Upload(r.data))
+}
+
+// Pending returns the number of assets which are not finished yet.
+func (l *Loader) Pending() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.loading
+}
+
+// Close stops the workers and fails all queued jobs with ErrClosed. Assets
+// which are already decoded are still uploaded. Call it on the GL thread;
+// the placeholder texture is deleted.
+func (l *Loader) Close() {
+	l.mu.Lock()
+	l.closed = true
+	pending := l.pending
+	l.pending = nil
+	l.cond.Broadcast()
+	l.mu.Unlock()
+
+	// Workers may be blocked on a full result queue
+	done := make(chan bool)
+	go func() {
+		l.workers.Wait()
+		close(done)
+	}()
+	for waiting := true; waiting; {
+		select {
+		case r := <-l.results:
+			l.finish(r)
+		case <-done:
+			waiting = false
+		}
+	}
+	for len(l.results) > 0 {
+		l.finish(<-l.results)
+	}
+	for _, r := range pending {
+		l.finish(result{job: r.job, future: r.future, err: ErrClosed})
+	}
+	l.backend.DeleteTexture(l.placeholder)
+}
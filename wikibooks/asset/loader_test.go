@@ -0,0 +1,162 @@
+package asset
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeImages writes n PNG files of 1 to n pixels wide and returns their
+// names.
+func writeImages(t *testing.T, n int) []string {
+	dir := t.TempDir()
+	names := make([]string, n)
+	for i := range names {
+		names[i] = filepath.Join(dir, string(rune('a'+i))+".png")
+		f, err := os.Create(names[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = png.Encode(f, image.NewRGBA(image.Rect(0, 0, i+1, 1)))
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return names
+}
+
+// finish updates l, as the GL thread would once per frame, until nothing
+// is pending.
+func finish(t *testing.T, l *Loader) {
+	deadline := time.Now().Add(10 * time.Second)
+	for l.Pending() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d assets still pending", l.Pending())
+		}
+		l.Update(time.Millisecond)
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// The backend is only called from this goroutine, which the race detector
+// checks, and the queue of one entry makes the workers wait on it.
+func TestLoadTextures(t *testing.T) {
+	b := NewFakeBackend()
+	l, err := NewLoader(b, 4, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := writeImages(t, 8)
+	futures := make([]*TextureFuture, len(names))
+	for i, name := range names {
+		futures[i] = l.LoadTexture(name)
+		if futures[i].Texture() != l.PlaceholderTexture() {
+			t.Errorf("%s: no placeholder while loading", name)
+		}
+	}
+	missing := l.LoadTexture("missing.png")
+	finish(t, l)
+
+	for i, f := range futures {
+		if _, err := f.Result(); err != nil {
+			t.Errorf("%s: %v", f.Name(), err)
+			continue
+		}
+		if got := b.Textures[f.Texture()].Dx(); got != i+1 {
+			t.Errorf("%s: texture is %d pixels wide, want %d", f.Name(), got, i+1)
+		}
+	}
+	if _, err := missing.Result(); err == nil {
+		t.Error("missing.png: no error")
+	}
+	if missing.Texture() != l.PlaceholderTexture() {
+		t.Error("missing.png: no placeholder")
+	}
+	if b.Uploads != len(names)+1 {
+		t.Errorf("%d uploads, want %d", b.Uploads, len(names)+1)
+	}
+
+	l.Close()
+	if _, ok := b.Textures[l.PlaceholderTexture()]; ok {
+		t.Error("placeholder not deleted")
+	}
+}
+
+// Update uploads at least one asset however small the budget.
+func TestBudget(t *testing.T) {
+	b := NewFakeBackend()
+	b.UploadDelay = 5 * time.Millisecond
+	l, err := NewLoader(b, 2, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	for _, name := range writeImages(t, 4) {
+		l.LoadTexture(name)
+	}
+	for len(l.results) < 4 {
+		time.Sleep(time.Millisecond)
+	}
+	if n := l.Update(time.Nanosecond); n != 1 {
+		t.Errorf("tiny budget: %d uploads, want 1", n)
+	}
+	if n := l.Update(time.Second); n != 3 {
+		t.Errorf("large budget: %d uploads, want 3", n)
+	}
+}
+
+func TestClose(t *testing.T) {
+	b := NewFakeBackend()
+	l, err := NewLoader(b, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var futures []*TextureFuture
+	for _, name := range writeImages(t, 8) {
+		futures = append(futures, l.LoadTexture(name))
+	}
+	l.Close()
+
+	// Every asset either loaded or failed with ErrClosed
+	for _, f := range futures {
+		select {
+		case <-f.Done():
+		default:
+			t.Fatalf("%s: not finished after Close", f.Name())
+		}
+		if _, err := f.Result(); err != nil && err != ErrClosed {
+			t.Errorf("%s: %v", f.Name(), err)
+		}
+	}
+	if len(b.Textures) != b.Uploads-1 {
+		t.Errorf("%d textures left of %d uploads, want all but the placeholder", len(b.Textures), b.Uploads)
+	}
+	if _, err := l.LoadTexture("late.png").Result(); err != ErrClosed {
+		t.Errorf("loading after Close: got %v, want ErrClosed", err)
+	}
+}
+
+func TestEmptyImage(t *testing.T) {
+	b := NewFakeBackend()
+	l, err := NewLoader(b, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	f := l.Load(Job{
+		Name:   "empty",
+		Decode: func() (interface{}, error) { return image.NewRGBA(image.Rectangle{}), nil },
+		Upload: func(data interface{}) (interface{}, error) {
+			return b.UploadTexture(data.(image.Image))
+		},
+	})
+	finish(t, l)
+	if _, err := f.Result(); err == nil || !strings.Contains(err.Error(), "empty image") {
+		t.Errorf("got error %v, want an empty image", err)
+	}
+}
@@ -0,0 +1,99 @@
+// Package camera places a perspective camera in the world and moves it
+// with controllers driven by input: Orbit turns around a target like an
+// arcball, Fly moves freely and FPS walks with the y axis up.
+package camera
+
+import (
+	"math"
+
+	"math3d"
+)
+
+// Camera is a perspective camera. Like in OpenGL it looks down its -z
+// axis, with its y axis up.
+type Camera struct {
+	Position math3d.Vector3
+	// Orientation rotates the axes of the camera into the world.
+	Orientation math3d.Quaternion
+
+	// FovY is the vertical field of view in radians.
+	FovY      float32
+	Aspect    float32
+	Near, Far float32
+}
+
+// New returns a camera at the origin looking down -z, with a field of view
+// of 45 degrees.
+func New(aspect float32) *Camera {
+	return &Camera{
+		Position:    math3d.Vector3{0, 0, 0},
+		Orientation: math3d.MakeIdentityQuaternion(),
+		FovY:        math.Pi / 4,
+		Aspect:      aspect,
+		Near:        0.1,
+		Far:         100,
+	}
+}
+
+// YawPitch returns the orientation turned by yaw around the y axis of the
+// world, after pitch around the x axis of the camera. A positive pitch
+// looks up.
+func YawPitch(yaw, pitch float32) math3d.Quaternion {
+	return math3d.MakeAxisAngleQuaternion(yaw, math3d.Vector3{0, 1, 0}).
+		Multiply(math3d.MakeAxisAngleQuaternion(pitch, math3d.Vector3{1, 0, 0}))
+}
+
+// Forward returns the direction the camera looks in.
+func (c *Camera) Forward() math3d.Vector3 {
+	return c.Orientation.Rotate(math3d.Vector3{0, 0, -1})
+}
+
+func (c *Camera) Right() math3d.Vector3 {
+	return c.Orientation.Rotate(math3d.Vector3{1, 0, 0})
+}
+
+func (c *Camera) Up() math3d.Vector3 {
+	return c.Orientation.Rotate(math3d.Vector3{0, 1, 0})
+}
+
+// LookAt turns the camera towards target, keeping the y axis of the world
+// up.
+func (c *Camera) LookAt(target math3d.Vector3) {
+	yaw, pitch := yawPitchTowards(*target.Sub(c.Position))
+	c.Orientation = YawPitch(yaw, pitch)
+}
+
+// yawPitchTowards returns the yaw and pitch of YawPitch looking along d.
+func yawPitchTowards(d math3d.Vector3) (yaw, pitch float32) {
+	horizontal := math.Hypot(float64(d[0]), float64(d[2]))
+	yaw = float32(math.Atan2(float64(-d[0]), float64(-d[2])))
+	pitch = float32(math.Atan2(float64(d[1]), horizontal))
+	return yaw, pitch
+}
+
+// View returns the matrix from world to camera coordinates. Like all
+// math3d matrices it is row major: transpose it for OpenGL.
+func (c *Camera) View() math3d.Matrix4 {
+	inverse := c.Orientation.Conjugate()
+	m := inverse.Matrix()
+	t := inverse.Rotate(c.Position)
+	m[3], m[7], m[11] = -t[0], -t[1], -t[2]
+	return m
+}
+
+// Projection returns the perspective projection, like gluPerspective with
+// the field of view in radians.
+func (c *Camera) Projection() math3d.Matrix4 {
+	f := float32(1 / math.Tan(float64(c.FovY)/2))
+	return math3d.Matrix4{
+		f / c.Aspect, 0, 0, 0,
+		0, f, 0, 0,
+		0, 0, (c.Far + c.Near) / (c.Near - c.Far), 2 * c.Far * c.Near / (c.Near - c.Far),
+		0, 0, -1, 0,
+	}
+}
+
+// ViewProjection returns the projection of the view.
+func (c *Camera) ViewProjection() math3d.Matrix4 {
+	return c.Projection().Multiply(c.View())
+}
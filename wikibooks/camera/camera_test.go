@@ -0,0 +1,177 @@
+package camera
+
+import (
+	"math"
+	"testing"
+
+	"math3d"
+)
+
+// s is the sine and cosine of 45 degrees.
+var s = float32(math.Sqrt(0.5))
+
+func equal(a, b math3d.Matrix4) bool {
+	for i := range b {
+		if math.Abs(float64(a[i]-b[i])) > 1e-5 {
+			return false
+		}
+	}
+	return true
+}
+
+// The rows of the rotation of a view are the right, up and back axes of
+// the camera in the world; its last column moves the camera to the origin.
+func TestView(t *testing.T) {
+	tests := []struct {
+		name       string
+		controller Controller
+		position   math3d.Vector3
+		motion     Motion
+		want       math3d.Matrix4
+	}{
+		{"orbit", NewOrbit(math3d.Vector3{0, 0, 0}, 5, 0, 0), nil, Motion{}, math3d.Matrix4{
+			1, 0, 0, 0,
+			0, 1, 0, 0,
+			0, 0, 1, -5,
+			0, 0, 0, 1,
+		}},
+		// At (5, 0, 0) looking down -x
+		{"orbit turned", NewOrbit(math3d.Vector3{0, 0, 0}, 5, math.Pi/2, 0), nil, Motion{}, math3d.Matrix4{
+			0, 0, -1, 0,
+			0, 1, 0, 0,
+			1, 0, 0, -5,
+			0, 0, 0, 1,
+		}},
+		// At (1, 3, 4) looking down at 45 degrees
+		{"orbit looking down", NewOrbit(math3d.Vector3{1, 2, 3}, float32(math.Sqrt2), 0, -math.Pi/4), nil, Motion{}, math3d.Matrix4{
+			1, 0, 0, -1,
+			0, s, -s, s,
+			0, s, s, -7 * s,
+			0, 0, 0, 1,
+		}},
+		// Dragging from the middle of a 200 pixel ball to its right edge
+		// turns the front of the target to the right, so the camera goes
+		// to (-5, 0, 0) looking down +x
+		{"orbit dragged", arcball(NewOrbit(math3d.Vector3{0, 0, 0}, 5, 0, 0)), nil, Motion{LookX: 100, X: 200, Y: 100}, math3d.Matrix4{
+			0, 0, 1, 0,
+			0, 1, 0, 0,
+			-1, 0, 0, -5,
+			0, 0, 0, 1,
+		}},
+		// Dragging up rolls the front of the target up, so the camera
+		// looks up at it from (0, -5, 0)
+		{"orbit dragged up", arcball(NewOrbit(math3d.Vector3{0, 0, 0}, 5, 0, 0)), nil, Motion{LookY: -100, X: 100, Y: 0}, math3d.Matrix4{
+			1, 0, 0, 0,
+			0, 0, 1, 0,
+			0, -1, 0, -5,
+			0, 0, 0, 1,
+		}},
+		// Two units forward from (1, 2, 3) down -z
+		{"fly", NewFly(), math3d.Vector3{1, 2, 3}, Motion{Forward: 0.8}, math3d.Matrix4{
+			1, 0, 0, -1,
+			0, 1, 0, -2,
+			0, 0, 1, -1,
+			0, 0, 0, 1,
+		}},
+		// Turned left to look down -x first, so at (-1, 2, 3)
+		{"fly turned", NewFly(), math3d.Vector3{1, 2, 3}, Motion{Forward: 0.8, LookX: -math.Pi / 2 / 0.005}, math3d.Matrix4{
+			0, 0, -1, 3,
+			0, 1, 0, -2,
+			1, 0, 0, 1,
+			0, 0, 0, 1,
+		}},
+		// Looking up does not lift the walk: 1.5 units forward and right
+		// to (1.5, 1.5, -1.5)
+		{"fps", NewFPS(math3d.Vector3{0, 1.5, 0}, 0, 0), nil, Motion{Forward: 1, Right: 1, LookY: -math.Pi / 4 / 0.005}, math3d.Matrix4{
+			1, 0, 0, -1.5,
+			0, s, s, 0,
+			0, -s, s, 3 * s,
+			0, 0, 0, 1,
+		}},
+	}
+	for _, test := range tests {
+		c := New(1)
+		if test.position != nil {
+			c.Position = test.position
+		}
+		test.controller.Update(c, test.motion, 0.5)
+		if got := c.View(); !equal(got, test.want) {
+			t.Errorf("%s: view is %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+// arcball fits o into a 200 by 200 viewport.
+func arcball(o *Orbit) *Orbit {
+	o.Resize(200, 200)
+	return o
+}
+
+// The point grabbed stays under the cursor while dragging inside the ball.
+func TestArcball(t *testing.T) {
+	for _, p := range [][2]float32{{130, 80}, {150, 110}, {100, 140}} {
+		o := arcball(NewOrbit(math3d.Vector3{0, 0, 0}, 5, 0.3, -0.2))
+		c := New(1)
+		o.Update(c, Motion{}, 0)
+		// The point grabbed at 120, 90 in the world, where the ball
+		// does not turn
+		point := c.Orientation.Rotate(o.ballPoint(120, 90))
+		o.Update(c, Motion{LookX: p[0] - 120, LookY: p[1] - 90, X: p[0], Y: p[1]}, 0)
+		got := c.Orientation.Conjugate().Rotate(point)
+		want := o.ballPoint(p[0], p[1])
+		for i := range want {
+			if math.Abs(float64(got[i]-want[i])) > 1e-5 {
+				t.Errorf("at %v the point grabbed is at %v, want %v", p, got, want)
+				break
+			}
+		}
+	}
+}
+
+// Moving the camera does not move the controllers, nor the other way round.
+func TestNoAliasing(t *testing.T) {
+	position := math3d.Vector3{1, 2, 3}
+	for _, controller := range []Controller{NewOrbit(position, 4, 0, 0), NewFly(), NewFPS(position, 0, 0)} {
+		c := New(1)
+		c.Position = math3d.Vector3{1, 2, 3}
+		controller.Update(c, Motion{}, 0)
+		before := append(math3d.Vector3(nil), c.Position...)
+		c.Position[0] += 10
+		position[1] += 10
+		controller.Update(c, Motion{}, 0)
+		for i := range before {
+			if c.Position[i] != before[i] {
+				t.Errorf("%T: camera moved from %v to %v", controller, before, c.Position)
+				break
+			}
+		}
+		position[1] -= 10
+	}
+}
+
+func TestProjection(t *testing.T) {
+	c := New(2)
+	c.FovY, c.Near, c.Far = math.Pi/2, 1, 3
+	want := math3d.Matrix4{
+		0.5, 0, 0, 0,
+		0, 1, 0, 0,
+		0, 0, -2, -3,
+		0, 0, -1, 0,
+	}
+	if got := c.Projection(); !equal(got, want) {
+		t.Errorf("projection is %v, want %v", got, want)
+	}
+}
+
+// LookAt gives the orientation the orbit has around the same target.
+func TestLookAt(t *testing.T) {
+	o := NewOrbit(math3d.Vector3{1, 2, 3}, 4, 0.3, -0.6)
+	c := New(1)
+	o.Update(c, Motion{}, 0)
+	l := New(1)
+	l.Position = c.Position
+	l.LookAt(math3d.Vector3{1, 2, 3})
+	if !equal(l.View(), c.View()) {
+		t.Errorf("view is %v, want %v", l.View(), c.View())
+	}
+}
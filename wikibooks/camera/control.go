@@ -0,0 +1,297 @@
+package camera
+
+import (
+	"math"
+
+	"input"
+	"math3d"
+)
+
+// Motion is what moves a camera during an update.
+type Motion struct {
+	// LookX and LookY turn the camera, in pixels of mouse movement with y
+	// counting downwards.
+	LookX, LookY float32
+	// X and Y are the position of the mouse in pixels from the top left
+	// corner, after the movement.
+	X, Y float32
+	// PanX and PanY move the camera or the target of an orbit sideways,
+	// in pixels.
+	PanX, PanY float32
+	// Zoom is in steps of the mouse wheel, positive towards the target.
+	Zoom float32
+	// Forward, Right and Up move along the axes of the camera, from -1 to
+	// 1.
+	Forward, Right, Up float32
+}
+
+// FromInput returns the motion of an update: moving the mouse with "look"
+// held turns, with "pan" held pans, the wheel zooms and "forward", "back",
+// "left", "right", "up" and "down" move.
+func FromInput(in *input.Input, a *input.Actions) Motion {
+	var m Motion
+	dx, dy := in.MouseDelta()
+	x, y := in.Mouse()
+	m.X, m.Y = float32(x), float32(y)
+	if a.Held(in, "look") {
+		m.LookX, m.LookY = float32(dx), float32(dy)
+	}
+	if a.Held(in, "pan") {
+		m.PanX, m.PanY = float32(dx), float32(dy)
+	}
+	m.Zoom = float32(in.Scroll())
+	axis := func(negative, positive string) float32 {
+		v := float32(0)
+		if a.Held(in, negative) {
+			v--
+		}
+		if a.Held(in, positive) {
+			v++
+		}
+		return v
+	}
+	m.Forward = axis("back", "forward")
+	m.Right = axis("left", "right")
+	m.Up = axis("down", "up")
+	return m
+}
+
+// Controller moves a camera.
+type Controller interface {
+	// Update applies the motion of an update dt seconds long.
+	Update(c *Camera, m Motion, dt float32)
+}
+
+// follow returns how far to move from the current state to the goal in dt
+// seconds, for following with a time constant of smoothing seconds.
+func follow(dt, smoothing float32) float32 {
+	if smoothing <= 0 {
+		return 1
+	}
+	return 1 - float32(math.Exp(float64(-dt/smoothing)))
+}
+
+func lerp(a, b, t float32) float32 {
+	return a + (b-a)*t
+}
+
+// clone copies v, so that a camera and its controller do not share it.
+func clone(v math3d.Vector3) math3d.Vector3 {
+	return append(math3d.Vector3(nil), v...)
+}
+
+func lerpVector(a, b math3d.Vector3, t float32) math3d.Vector3 {
+	return math3d.Vector3{lerp(a[0], b[0], t), lerp(a[1], b[1], t), lerp(a[2], b[2], t)}
+}
+
+// maxPitch keeps pitch short of straight up and down, where yaw turns
+// around the view direction.
+const maxPitch = math.Pi/2 - 0.01
+
+func clamp(v, min, max float32) float32 {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// Orbit turns the camera around Target at Distance like an arcball: the
+// viewport holds a ball around the target, and dragging rolls it so that
+// the point grabbed stays under the cursor.
+type Orbit struct {
+	Target   math3d.Vector3
+	Distance float32
+	// Orientation is the orientation of the camera, which looks at the
+	// target.
+	Orientation math3d.Quaternion
+	// Width and Height are the size of the viewport in pixels; the ball
+	// fills its smaller side. Set them with Resize.
+	Width, Height float32
+
+	MinDistance, MaxDistance float32
+	// PanSpeed is in distances a pixel and ZoomSpeed the factor a wheel
+	// step changes the distance by.
+	PanSpeed, ZoomSpeed float32
+	// Smoothing is the time constant in seconds the camera follows
+	// changes with, 0 to follow at once.
+	Smoothing float32
+
+	started bool
+	current orbitState
+}
+
+type orbitState struct {
+	target      math3d.Vector3
+	distance    float32
+	orientation math3d.Quaternion
+}
+
+// NewOrbit returns an orbit starting at the orientation of YawPitch, for
+// an 800 by 600 viewport.
+func NewOrbit(target math3d.Vector3, distance, yaw, pitch float32) *Orbit {
+	return &Orbit{
+		Target:      clone(target),
+		Distance:    distance,
+		Orientation: YawPitch(yaw, pitch),
+		Width:       800,
+		Height:      600,
+		MinDistance: 0.1,
+		MaxDistance: 1000,
+		PanSpeed:    0.002,
+		ZoomSpeed:   1.1,
+	}
+}
+
+// Resize fits the ball into a viewport of width by height pixels.
+func (o *Orbit) Resize(width, height int) {
+	o.Width, o.Height = float32(width), float32(height)
+}
+
+// ballPoint returns the point of the ball, in camera coordinates with a
+// radius of 1, under the pixel x, y. Points outside the ball are on its
+// silhouette.
+func (o *Orbit) ballPoint(x, y float32) math3d.Vector3 {
+	r := o.Width / 2
+	if o.Height < o.Width {
+		r = o.Height / 2
+	}
+	p := math3d.Vector3{(x - o.Width/2) / r, (o.Height/2 - y) / r, 0}
+	d := p[0]*p[0] + p[1]*p[1]
+	if d > 1 {
+		l := float32(math.Sqrt(float64(d)))
+		return math3d.Vector3{p[0] / l, p[1] / l, 0}
+	}
+	p[2] = float32(math.Sqrt(float64(1 - d)))
+	return p
+}
+
+// arc returns the shortest rotation turning the unit vector a into b.
+func arc(a, b math3d.Vector3) math3d.Quaternion {
+	axis := a.Cross(b)
+	w := 1 + a.Dot(b)
+	if w < 1e-6 {
+		// Opposite points: no shortest arc
+		return math3d.MakeIdentityQuaternion()
+	}
+	return math3d.Quaternion{X: axis[0], Y: axis[1], Z: axis[2], W: w}.Normalized()
+}
+
+func (o *Orbit) Update(c *Camera, m Motion, dt float32) {
+	if m.LookX != 0 || m.LookY != 0 {
+		// The ball turns from the point grabbed to the cursor, so the
+		// camera turns the other way around the target
+		from := o.ballPoint(m.X-m.LookX, m.Y-m.LookY)
+		to := o.ballPoint(m.X, m.Y)
+		o.Orientation = o.Orientation.Multiply(arc(to, from)).Normalized()
+	}
+	if m.Zoom != 0 {
+		o.Distance /= float32(math.Pow(float64(o.ZoomSpeed), float64(m.Zoom)))
+	}
+	o.Distance = clamp(o.Distance, o.MinDistance, o.MaxDistance)
+	if m.PanX != 0 || m.PanY != 0 {
+		// Dragging moves the target with the mouse
+		pan := o.PanSpeed * o.Distance
+		right := o.Orientation.Rotate(math3d.Vector3{1, 0, 0}).Scaled(-m.PanX * pan)
+		up := o.Orientation.Rotate(math3d.Vector3{0, 1, 0}).Scaled(m.PanY * pan)
+		o.Target = o.Target.Added(right).Added(up)
+	}
+
+	goal := orbitState{o.Target, o.Distance, o.Orientation}
+	if !o.started {
+		o.current = orbitState{clone(goal.target), goal.distance, goal.orientation}
+		o.started = true
+	}
+	t := follow(dt, o.Smoothing)
+	o.current.target = lerpVector(o.current.target, goal.target, t)
+	o.current.distance = lerp(o.current.distance, goal.distance, t)
+	o.current.orientation = math3d.Slerp(o.current.orientation, goal.orientation, t)
+
+	c.Orientation = o.current.orientation
+	back := c.Orientation.Rotate(math3d.Vector3{0, 0, o.current.distance})
+	c.Position = o.current.target.Added(back)
+}
+
+// Fly moves the camera freely along its own axes and turns it around them.
+type Fly struct {
+	// Speed is in units a second and LookSpeed in radians a pixel.
+	Speed, LookSpeed float32
+	// Smoothing is the time constant in seconds the camera follows
+	// changes with, 0 to follow at once.
+	Smoothing float32
+
+	started     bool
+	position    math3d.Vector3
+	orientation math3d.Quaternion
+}
+
+func NewFly() *Fly {
+	return &Fly{Speed: 5, LookSpeed: 0.005}
+}
+
+func (f *Fly) Update(c *Camera, m Motion, dt float32) {
+	if !f.started {
+		f.position = clone(c.Position)
+		f.orientation = c.Orientation
+		f.started = true
+	}
+	yaw := math3d.MakeAxisAngleQuaternion(-m.LookX*f.LookSpeed, math3d.Vector3{0, 1, 0})
+	pitch := math3d.MakeAxisAngleQuaternion(-m.LookY*f.LookSpeed, math3d.Vector3{1, 0, 0})
+	f.orientation = f.orientation.Multiply(yaw).Multiply(pitch).Normalized()
+
+	step := f.Speed * dt
+	move := math3d.Vector3{m.Right * step, m.Up * step, -m.Forward * step}
+	f.position = f.position.Added(f.orientation.Rotate(move))
+
+	t := follow(dt, f.Smoothing)
+	c.Position = lerpVector(c.Position, f.position, t)
+	c.Orientation = math3d.Slerp(c.Orientation, f.orientation, t)
+}
+
+// FPS walks the camera on the plane of the x and z axes and turns it like
+// a head, which cannot roll nor look further than straight up or down.
+// Up and down are ignored.
+type FPS struct {
+	Position   math3d.Vector3
+	Yaw, Pitch float32
+
+	// Speed is in units a second and LookSpeed in radians a pixel.
+	Speed, LookSpeed float32
+	// Smoothing is the time constant in seconds the camera follows
+	// changes with, 0 to follow at once.
+	Smoothing float32
+
+	started    bool
+	position   math3d.Vector3
+	yaw, pitch float32
+}
+
+func NewFPS(position math3d.Vector3, yaw, pitch float32) *FPS {
+	return &FPS{Position: clone(position), Yaw: yaw, Pitch: pitch, Speed: 3, LookSpeed: 0.005}
+}
+
+func (f *FPS) Update(c *Camera, m Motion, dt float32) {
+	f.Yaw -= m.LookX * f.LookSpeed
+	f.Pitch = clamp(f.Pitch-m.LookY*f.LookSpeed, -maxPitch, maxPitch)
+
+	sin := float32(math.Sin(float64(f.Yaw)))
+	cos := float32(math.Cos(float64(f.Yaw)))
+	forward := math3d.Vector3{-sin, 0, -cos}
+	right := math3d.Vector3{cos, 0, -sin}
+	step := f.Speed * dt
+	f.Position = f.Position.Added(forward.Scaled(m.Forward * step)).Added(right.Scaled(m.Right * step))
+
+	if !f.started {
+		f.position, f.yaw, f.pitch = clone(f.Position), f.Yaw, f.Pitch
+		f.started = true
+	}
+	t := follow(dt, f.Smoothing)
+	f.position = lerpVector(f.position, f.Position, t)
+	f.yaw = lerp(f.yaw, f.Yaw, t)
+	f.pitch = lerp(f.pitch, f.Pitch, t)
+
+	c.Position = clone(f.position)
+	c.Orientation = YawPitch(f.yaw, f.pitch)
+}
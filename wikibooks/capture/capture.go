@@ -0,0 +1,219 @@
+// Package capture saves rendered frames as PNG files: screenshots taken
+// with a key or at given frame numbers, and sequences of every frame.
+package capture
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"device"
+	"input"
+)
+
+// Action is the action which takes a screenshot.
+const Action = "screenshot"
+
+// Capturer saves frames read back from a device. A frame is read back
+// while the next one is drawn, so the render loop does not wait for the
+// GPU, and encoded on a background goroutine. Screenshots the encoder has
+// no room for are dropped and reported by Err rather than holding up the
+// render loop; sequences wait for the encoder so that no frame is lost.
+type Capturer struct {
+	// Actions, if not nil, takes a screenshot on the Action binding.
+	Actions *input.Actions
+	// Frames lists frame numbers which are captured automatically,
+	// counting from 0 at the first call of Frame.
+	Frames []int
+	// Pattern is the file name of screenshots, given the screenshot
+	// number, e.g. "screenshot%03d.png".
+	Pattern string
+	// Sequence, if not empty, captures every frame to this file name
+	// given the frame number, e.g. "cube%05d.png". Render with a fixed
+	// time step to get a smooth animation.
+	Sequence string
+
+	frame       int
+	screenshots int
+	next        bool
+
+	dev     device.Device
+	pending read
+
+	images chan encodeJob
+	done   sync.WaitGroup
+
+	mu  sync.Mutex
+	err error
+}
+
+// read is a frame being read back, which is to be saved as name.
+type read struct {
+	name          string
+	r             device.Readback
+	width, height int
+	wait          bool
+}
+
+type encodeJob struct {
+	name string
+	img  *image.RGBA
+}
+
+// New starts a capturer whose encoder holds up to queue frames.
+func New(queue int) *Capturer {
+	c := &Capturer{
+		Pattern: "screenshot%03d.png",
+		images:  make(chan encodeJob, queue),
+	}
+	c.done.Add(1)
+	go c.encode()
+	return c
+}
+
+// Screenshot captures the next frame.
+func (c *Capturer) Screenshot() {
+	c.next = true
+}
+
+// Update takes a screenshot at the next frame if the action was pressed.
+// Call it once per frame, after the input frame starts.
+func (c *Capturer) Update(in *input.Input) {
+	if c.Actions != nil && c.Actions.Pressed(in, Action) {
+		c.next = true
+	}
+}
+
+// Frame must be called once per frame after it is drawn and before it is
+// shown. It starts reading the frame back from d if it is to be captured,
+// and queues the frame read before for encoding.
+func (c *Capturer) Frame(d device.Device, width, height int) {
+	name := c.trigger()
+	c.frame++
+	c.finish()
+	if name == "" || width <= 0 || height <= 0 {
+		return
+	}
+	c.dev = d
+	c.pending = read{name, d.StartReadPixels(width, height), width, height, c.Sequence != ""}
+}
+
+// finish queues the pending frame for encoding.
+func (c *Capturer) finish() {
+	p := c.pending
+	if p.name == "" {
+		return
+	}
+	c.pending = read{}
+	img := image.NewRGBA(image.Rect(0, 0, p.width, p.height))
+	c.dev.FinishReadPixels(p.r, img)
+	// The alpha of the framebuffer is meaningless for a screenshot
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 255
+	}
+	if p.wait {
+		c.images <- encodeJob{p.name, img}
+		return
+	}
+	select {
+	case c.images <- encodeJob{p.name, img}:
+	default:
+		c.setErr(fmt.Errorf("capture: %s dropped, the encoder is behind", p.name))
+	}
+}
+
+// trigger returns the file name for the current frame, or "" if it is not
+// captured.
+func (c *Capturer) trigger() string {
+	for _, f := range c.Frames {
+		if f == c.frame {
+			c.next = true
+		}
+	}
+	if c.Sequence != "" {
+		c.next = false
+		return fmt.Sprintf(c.Sequence, c.frame)
+	}
+	if c.next {
+		c.next = false
+		name := fmt.Sprintf(c.Pattern, c.screenshots)
+		c.screenshots++
+		return name
+	}
+	return ""
+}
+
+func (c *Capturer) encode() {
+	defer c.done.Done()
+	for job := range c.images {
+		if err := save(job.name, job.img); err != nil {
+			c.setErr(err)
+		}
+	}
+}
+
+func save(name string, img image.Image) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (c *Capturer) setErr(err error) {
+	c.mu.Lock()
+	if c.err == nil {
+		c.err = err
+	}
+	c.mu.Unlock()
+}
+
+// Err returns the first error of a failed or dropped capture, or nil.
+func (c *Capturer) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Close saves the last frame read, waits until all images are written and
+// returns Err. The device of Frame must still be current.
+func (c *Capturer) Close() error {
+	c.finish()
+	close(c.images)
+	c.done.Wait()
+	return c.Err()
+}
+
+var (
+	frames   = flag.String("capture-frames", "", "save screenshots of these frames, e.g. 0,60,120")
+	sequence = flag.String("capture-sequence", "", "save every frame to this file name given the frame number, e.g. frame%05d.png")
+)
+
+// FromFlags returns a capturer with the frames and sequence given on the
+// command line which takes screenshots on the action of actions, after
+// flag.Parse.
+func FromFlags(actions *input.Actions) (*Capturer, error) {
+	c := New(8)
+	c.Actions = actions
+	c.Sequence = *sequence
+	if *frames != "" {
+		for _, f := range strings.Split(*frames, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(f))
+			if err != nil || n < 0 {
+				c.Close()
+				return nil, fmt.Errorf("capture: invalid frame %q", f)
+			}
+			c.Frames = append(c.Frames, n)
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,145 @@
+package capture
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"device"
+	"device/soft"
+)
+
+// run captures frames frames of a device cleared to red.
+func run(t *testing.T, c *Capturer, frames int) {
+	d := soft.New(4, 3)
+	d.ClearColor(1, 0, 0, 0)
+	d.Clear(device.ColorBuffer)
+	for i := 0; i < frames; i++ {
+		c.Frame(d, 4, 3)
+	}
+}
+
+// files returns the names of the files in dir.
+func files(t *testing.T, dir string) []string {
+	f, err := os.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	names, err := f.Readdirnames(-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return names
+}
+
+func TestFrames(t *testing.T) {
+	dir := t.TempDir()
+	c := New(4)
+	c.Frames = []int{1, 3}
+	c.Pattern = filepath.Join(dir, "shot%d.png")
+	run(t, c, 4)
+	c.Screenshot()
+	run(t, c, 1)
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if names := files(t, dir); len(names) != 3 {
+		t.Errorf("got files %v, want 3 screenshots", names)
+	}
+
+	file, err := os.Open(filepath.Join(dir, "shot2.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Opaque although the framebuffer alpha is 0
+	if img.Bounds() != image.Rect(0, 0, 4, 3) {
+		t.Errorf("screenshot is %v", img.Bounds())
+	}
+	if r, g, b, a := img.At(3, 2).RGBA(); r != 0xffff || g != 0 || b != 0 || a != 0xffff {
+		t.Errorf("got color %x %x %x %x, want opaque red", r, g, b, a)
+	}
+}
+
+// A sequence of more frames than the queue holds waits for the encoder
+// instead of dropping frames.
+func TestSequence(t *testing.T) {
+	dir := t.TempDir()
+	c := New(1)
+	c.Sequence = filepath.Join(dir, "frame%02d.png")
+	run(t, c, 20)
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if names := files(t, dir); len(names) != 20 {
+		t.Errorf("got %d files, want 20", len(names))
+	}
+	for _, name := range []string{"frame00.png", "frame19.png"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// A frame is read back while the next one is drawn.
+func TestReadLater(t *testing.T) {
+	dir := t.TempDir()
+	d := device.NewRecorder()
+	c := New(4)
+	c.Frames = []int{0}
+	c.Pattern = filepath.Join(dir, "shot%d.png")
+	c.Frame(d, 4, 3)
+	want := "StartReadPixels(4, 3, 1)\n"
+	if d.String() != want {
+		t.Errorf("first frame: got calls\n%swant\n%s", d, want)
+	}
+	c.Frame(d, 4, 3)
+	want += "FinishReadPixels(1, 4x3)\n"
+	if d.String() != want {
+		t.Errorf("second frame: got calls\n%swant\n%s", d, want)
+	}
+
+	// Close saves the frame still being read
+	c.Screenshot()
+	c.Frame(d, 4, 3)
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range d.Errors {
+		t.Error(err)
+	}
+	if names := files(t, dir); len(names) != 2 {
+		t.Errorf("got files %v, want 2 screenshots", names)
+	}
+}
+
+// Screenshots the encoder has no room for are dropped instead of waiting.
+func TestDropped(t *testing.T) {
+	// No encoder takes the frames from the queue
+	c := &Capturer{Pattern: "shot%d.png", Frames: []int{0, 1}, images: make(chan encodeJob, 1)}
+	run(t, c, 3)
+	if err := c.Err(); err == nil || !strings.Contains(err.Error(), "shot1.png dropped") {
+		t.Errorf("got error %v, want shot1.png dropped", err)
+	}
+	if len(c.images) != 1 {
+		t.Errorf("%d frames queued, want 1", len(c.images))
+	}
+}
+
+func TestSaveError(t *testing.T) {
+	c := New(1)
+	c.Pattern = filepath.Join(t.TempDir(), "missing", "shot%d.png")
+	c.Screenshot()
+	run(t, c, 1)
+	if err := c.Close(); err == nil {
+		t.Error("no error")
+	}
+}
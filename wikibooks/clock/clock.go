@@ -0,0 +1,172 @@
+// Package clock provides the time animations are computed from. Render
+// loops call Tick once per frame and read Now while drawing it, so every
+// draw of a frame sees the same time, and frames can be reproduced by
+// swapping the real clock for a fixed-step or scripted one.
+package clock
+
+import (
+	"flag"
+	"time"
+)
+
+type Clock interface {
+	// Now returns the time of the current frame in seconds.
+	Now() float64
+	// Tick advances the clock to the next frame.
+	Tick()
+}
+
+// Real follows the wall clock, starting from 0 when it is created.
+type Real struct {
+	start time.Time
+	now   float64
+}
+
+func NewReal() *Real {
+	return &Real{start: time.Now()}
+}
+
+func (c *Real) Now() float64 {
+	return c.now
+}
+
+func (c *Real) Tick() {
+	c.now = time.Since(c.start).Seconds()
+}
+
+// Fixed advances by Step seconds every frame, however long frames take.
+type Fixed struct {
+	Step float64
+	now  float64
+}
+
+// NewFixed returns a clock at start advancing by step.
+func NewFixed(start, step float64) *Fixed {
+	return &Fixed{Step: step, now: start}
+}
+
+func (c *Fixed) Now() float64 {
+	return c.now
+}
+
+func (c *Fixed) Tick() {
+	c.now += c.Step
+}
+
+// Scaled runs at Scale times the speed of another clock, e.g. 0.25 for
+// slow motion. Changing Scale does not make the time jump.
+type Scaled struct {
+	Clock Clock
+	Scale float64
+	last  float64
+	now   float64
+}
+
+func NewScaled(c Clock, scale float64) *Scaled {
+	return &Scaled{Clock: c, Scale: scale, last: c.Now(), now: c.Now()}
+}
+
+func (c *Scaled) Now() float64 {
+	return c.now
+}
+
+func (c *Scaled) Tick() {
+	c.Clock.Tick()
+	t := c.Clock.Now()
+	c.now += (t - c.last) * c.Scale
+	c.last = t
+}
+
+// Pausable follows another clock except while Paused, when its time
+// stands still. Frames go on being drawn, only the animation stops, and
+// resuming does not make the time jump.
+type Pausable struct {
+	Clock  Clock
+	Paused bool
+	last   float64
+	now    float64
+}
+
+func NewPausable(c Clock) *Pausable {
+	return &Pausable{Clock: c, last: c.Now(), now: c.Now()}
+}
+
+func (c *Pausable) Now() float64 {
+	return c.now
+}
+
+func (c *Pausable) Tick() {
+	c.Clock.Tick()
+	t := c.Clock.Now()
+	if !c.Paused {
+		c.now += t - c.last
+	}
+	c.last = t
+}
+
+// Base returns the clock under the Scaled and Pausable clocks around c,
+// whose time goes on while c is paused or slowed down.
+func Base(c Clock) Clock {
+	for {
+		switch w := c.(type) {
+		case *Scaled:
+			c = w.Clock
+		case *Pausable:
+			c = w.Clock
+		default:
+			return c
+		}
+	}
+}
+
+// Script returns given times, one per frame. After the last it stays at
+// the last.
+type Script struct {
+	Times []float64
+	frame int
+}
+
+func NewScript(times ...float64) *Script {
+	return &Script{Times: times}
+}
+
+func (c *Script) Now() float64 {
+	if len(c.Times) == 0 {
+		return 0
+	}
+	return c.Times[c.frame]
+}
+
+func (c *Script) Tick() {
+	if c.frame+1 < len(c.Times) {
+		c.frame++
+	}
+}
+
+// Done reports whether the clock is at the last time of the script.
+func (c *Script) Done() bool {
+	return c.frame+1 >= len(c.Times)
+}
+
+var (
+	step  = flag.Float64("timestep", 0, "advance the clock by this many seconds each frame instead of following real time")
+	speed = flag.Float64("speed", 1, "speed of the clock, e.g. 0.5 for slow motion or 0 to pause")
+)
+
+// FromFlags returns the clock chosen with -timestep and -speed, after
+// flag.Parse. A speed of 0 gives a paused Pausable.
+func FromFlags() Clock {
+	var c Clock = NewReal()
+	if *step > 0 {
+		c = NewFixed(0, *step)
+	}
+	switch {
+	case *speed == 0:
+		p := NewPausable(c)
+		p.Paused = true
+		c = p
+	case *speed != 1:
+		c = NewScaled(c, *speed)
+	}
+	return c
+}
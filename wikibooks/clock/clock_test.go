@@ -0,0 +1,123 @@
+package clock
+
+import (
+	"testing"
+)
+
+// ticks ticks c n times and returns Now after each.
+func ticks(c Clock, n int) []float64 {
+	times := make([]float64, n)
+	for i := range times {
+		c.Tick()
+		times[i] = c.Now()
+	}
+	return times
+}
+
+func equal(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFixed(t *testing.T) {
+	c := NewFixed(1, 0.25)
+	if c.Now() != 1 {
+		t.Errorf("starts at %v, want 1", c.Now())
+	}
+	if got, want := ticks(c, 3), []float64{1.25, 1.5, 1.75}; !equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestScaled(t *testing.T) {
+	c := NewScaled(NewFixed(10, 1), 0.5)
+	if c.Now() != 10 {
+		t.Errorf("starts at %v, want 10", c.Now())
+	}
+	got := ticks(c, 2)
+	// Paused, then faster, without jumps
+	c.Scale = 0
+	got = append(got, ticks(c, 2)...)
+	c.Scale = 2
+	got = append(got, ticks(c, 2)...)
+	if want := []float64{10.5, 11, 11, 11, 13, 15}; !equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestPausable(t *testing.T) {
+	base := NewFixed(10, 1)
+	c := NewPausable(base)
+	got := ticks(c, 2)
+	// Paused, then resumed without a jump
+	c.Paused = true
+	got = append(got, ticks(c, 2)...)
+	c.Paused = false
+	got = append(got, ticks(c, 2)...)
+	if want := []float64{11, 12, 12, 12, 13, 14}; !equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if base.Now() != 16 {
+		t.Errorf("base is at %v, want 16", base.Now())
+	}
+}
+
+func TestBase(t *testing.T) {
+	fixed := NewFixed(0, 1)
+	tests := []struct {
+		name string
+		c    Clock
+	}{
+		{"fixed", fixed},
+		{"scaled", NewScaled(fixed, 2)},
+		{"paused and scaled", NewPausable(NewScaled(fixed, 2))},
+	}
+	for _, test := range tests {
+		if b := Base(test.c); b != Clock(fixed) {
+			t.Errorf("%s: base is %v", test.name, b)
+		}
+	}
+}
+
+func TestScript(t *testing.T) {
+	c := NewScript(0, 0.5, 0.25)
+	if c.Now() != 0 || c.Done() {
+		t.Errorf("starts at %v, done %v", c.Now(), c.Done())
+	}
+	// Stays at the last time
+	if got, want := ticks(c, 4), []float64{0.5, 0.25, 0.25, 0.25}; !equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if !c.Done() {
+		t.Error("not done after the last time")
+	}
+
+	empty := NewScript()
+	if got := ticks(empty, 2); !equal(got, []float64{0, 0}) || !empty.Done() {
+		t.Errorf("empty script gives %v, done %v", got, empty.Done())
+	}
+}
+
+func TestReal(t *testing.T) {
+	c := NewReal()
+	if c.Now() != 0 {
+		t.Errorf("starts at %v, want 0", c.Now())
+	}
+	times := ticks(c, 3)
+	for i := 1; i < len(times); i++ {
+		if times[i] < times[i-1] {
+			t.Errorf("went back from %v to %v", times[i-1], times[i])
+		}
+	}
+	// Now only moves on Tick
+	if c.Now() != times[2] {
+		t.Errorf("moved to %v without a tick", c.Now())
+	}
+}
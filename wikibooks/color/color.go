@@ -0,0 +1,107 @@
+package color
+
+import (
+	imagecolor "image/color"
+)
+
+// Color is a linear RGBA color with straight (not premultiplied) alpha.
+// Blending, lighting and interpolation should be done on linear colors;
+// only values written to an 8 bit image or read from one are sRGB encoded.
+type Color struct {
+	R, G, B, A float32
+}
+
+var (
+	Black = Color{0, 0, 0, 1}
+	White = Color{1, 1, 1, 1}
+)
+
+// Linear returns a color from linear components.
+func Linear(r, g, b, a float32) Color {
+	return Color{r, g, b, a}
+}
+
+// SRGB returns a color from sRGB encoded components in [0, 1]. Alpha is
+// always linear.
+func SRGB(r, g, b, a float32) Color {
+	return Color{SRGBToLinear(r), SRGBToLinear(g), SRGBToLinear(b), a}
+}
+
+// SRGB8 returns a color from 8 bit sRGB components, as found in image files
+// and color pickers.
+func SRGB8(r, g, b, a uint8) Color {
+	return Color{DecodeSRGB8(r), DecodeSRGB8(g), DecodeSRGB8(b), float32(a) / 255}
+}
+
+// SRGB returns the sRGB encoded components in [0, 1].
+func (c Color) SRGB() (r, g, b, a float32) {
+	c = c.Clamped()
+	return LinearToSRGB(c.R), LinearToSRGB(c.G), LinearToSRGB(c.B), c.A
+}
+
+// SRGB8 returns the 8 bit sRGB encoded components.
+func (c Color) SRGB8() (r, g, b, a uint8) {
+	return EncodeSRGB8(c.R), EncodeSRGB8(c.G), EncodeSRGB8(c.B), uint8(clamp(c.A)*255 + 0.5)
+}
+
+// RGBA implements the image/color.Color interface. Like all colors of the
+// image packages the result is sRGB encoded and premultiplied.
+func (c Color) RGBA() (r, g, b, a uint32) {
+	sr, sg, sb, sa := c.SRGB()
+	return uint32(sr*sa*0xffff + 0.5), uint32(sg*sa*0xffff + 0.5), uint32(sb*sa*0xffff + 0.5), uint32(sa*0xffff + 0.5)
+}
+
+// Lerp mixes c and d in linear space, t = 0 returns c and t = 1 returns d.
+func (c Color) Lerp(d Color, t float32) Color {
+	return Color{
+		c.R + (d.R-c.R)*t,
+		c.G + (d.G-c.G)*t,
+		c.B + (d.B-c.B)*t,
+		c.A + (d.A-c.A)*t,
+	}
+}
+
+// Scaled multiplies the color components, but not alpha, by s.
+func (c Color) Scaled(s float32) Color {
+	return Color{c.R * s, c.G * s, c.B * s, c.A}
+}
+
+func (c Color) Premultiplied() Color {
+	return Color{c.R * c.A, c.G * c.A, c.B * c.A, c.A}
+}
+
+func (c Color) Clamped() Color {
+	return Color{clamp(c.R), clamp(c.G), clamp(c.B), clamp(c.A)}
+}
+
+// Slice returns the components in a form suitable for glUniform4fv or
+// vertex buffers.
+func (c Color) Slice() []float32 {
+	return []float32{c.R, c.G, c.B, c.A}
+}
+
+// Model converts any image/color.Color to a linear Color.
+var Model = imagecolor.ModelFunc(model)
+
+func model(c imagecolor.Color) imagecolor.Color {
+	if _, ok := c.(Color); ok {
+		return c
+	}
+	r, g, b, a := c.RGBA()
+	if a == 0 {
+		return Color{}
+	}
+	// Undo the premultiplication before decoding
+	fa := float32(a)
+	return SRGB(float32(r)/fa, float32(g)/fa, float32(b)/fa, fa/0xffff)
+}
+
+func clamp(v float32) float32 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
@@ -0,0 +1,85 @@
+package color
+
+import (
+	imagecolor "image/color"
+	"math"
+	"testing"
+)
+
+func near(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-6
+}
+
+// The reference values are computed in double precision from the sRGB
+// formulas.
+func TestTransfer(t *testing.T) {
+	tests := []struct {
+		srgb, linear float32
+	}{
+		{0, 0},
+		{0.04, 0.0030959752},
+		{0.5, 0.21404114},
+		{0.46135613, 0.18},
+		{0.7353570, 0.5},
+		{1, 1},
+	}
+	for _, test := range tests {
+		if got := SRGBToLinear(test.srgb); !near(got, test.linear) {
+			t.Errorf("SRGBToLinear(%v) = %v, want %v", test.srgb, got, test.linear)
+		}
+		if got := LinearToSRGB(test.linear); !near(got, test.srgb) {
+			t.Errorf("LinearToSRGB(%v) = %v, want %v", test.linear, got, test.srgb)
+		}
+	}
+}
+
+func TestSRGB8(t *testing.T) {
+	decode := []struct {
+		v      uint8
+		linear float32
+	}{
+		{0, 0}, {64, 0.051269458}, {128, 0.2158605}, {255, 1},
+	}
+	for _, test := range decode {
+		if got := DecodeSRGB8(test.v); !near(got, test.linear) {
+			t.Errorf("DecodeSRGB8(%d) = %v, want %v", test.v, got, test.linear)
+		}
+	}
+	encode := []struct {
+		linear float32
+		v      uint8
+	}{
+		{-1, 0}, {0.01, 25}, {0.18, 118}, {0.5, 188}, {2, 255},
+	}
+	for _, test := range encode {
+		if got := EncodeSRGB8(test.linear); got != test.v {
+			t.Errorf("EncodeSRGB8(%v) = %d, want %d", test.linear, got, test.v)
+		}
+	}
+	for i := 0; i < 256; i++ {
+		if got := EncodeSRGB8(DecodeSRGB8(uint8(i))); got != uint8(i) {
+			t.Errorf("%d encodes back to %d", i, got)
+		}
+	}
+}
+
+func TestLinearize(t *testing.T) {
+	srgb := []float32{0, 0.5, 1}
+	linear := Linearize(srgb)
+	if !near(linear[0], 0) || !near(linear[1], 0.21404114) || !near(linear[2], 1) {
+		t.Errorf("got %v", linear)
+	}
+	if srgb[1] != 0.5 {
+		t.Errorf("changed its argument to %v", srgb)
+	}
+}
+
+func TestModel(t *testing.T) {
+	c := Model.Convert(imagecolor.NRGBA{128, 64, 255, 255}).(Color)
+	if !near(c.R, 0.2158605) || !near(c.G, 0.051269458) || !near(c.B, 1) || c.A != 1 {
+		t.Errorf("got %v", c)
+	}
+	if r, g, b, a := c.SRGB8(); r != 128 || g != 64 || b != 255 || a != 255 {
+		t.Errorf("encodes back to %d %d %d %d", r, g, b, a)
+	}
+}
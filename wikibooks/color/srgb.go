@@ -0,0 +1,74 @@
+package color
+
+import (
+	"math"
+)
+
+// Conversion between the sRGB transfer function and linear light.
+// See http://en.wikipedia.org/wiki/SRGB for the formulas.
+
+var (
+	// decodeTable maps an sRGB encoded byte to its linear value.
+	decodeTable [256]float32
+	// encodeTable holds the linear values at which the encoded byte
+	// changes, so encodeTable[i] is the smallest linear value that
+	// rounds to i.
+	encodeTable [256]float32
+)
+
+func init() {
+	for i := range decodeTable {
+		decodeTable[i] = SRGBToLinear(float32(i) / 255)
+	}
+	for i := 1; i < 256; i++ {
+		encodeTable[i] = SRGBToLinear((float32(i) - 0.5) / 255)
+	}
+}
+
+// SRGBToLinear decodes an sRGB encoded value in [0, 1].
+func SRGBToLinear(v float32) float32 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return float32(math.Pow((float64(v)+0.055)/1.055, 2.4))
+}
+
+// LinearToSRGB encodes a linear value in [0, 1].
+func LinearToSRGB(v float32) float32 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return float32(1.055*math.Pow(float64(v), 1/2.4) - 0.055)
+}
+
+// DecodeSRGB8 returns the linear value of an 8 bit sRGB value.
+func DecodeSRGB8(v uint8) float32 {
+	return decodeTable[v]
+}
+
+// EncodeSRGB8 converts a linear value to the nearest 8 bit sRGB value.
+// Values outside of [0, 1] are clamped.
+func EncodeSRGB8(v float32) uint8 {
+	// Binary search for the last threshold not above v
+	lo, hi := 0, 255
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if encodeTable[mid] <= v {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return uint8(lo)
+}
+
+// Linearize returns the linear values of sRGB encoded components, e.g. the
+// RGB vertex colors of a mesh. v must not contain alpha values and is left
+// unchanged.
+func Linearize(v []float32) []float32 {
+	linear := make([]float32, len(v))
+	for i, x := range v {
+		linear[i] = SRGBToLinear(x)
+	}
+	return linear
+}
@@ -0,0 +1,201 @@
+package device
+
+import (
+	"fmt"
+)
+
+// maxAttribs is the number of vertex attributes every OpenGL 3.3
+// implementation has.
+const maxAttribs = 16
+
+type recordedBuffer struct {
+	size    int
+	data    interface{}
+	deleted bool
+}
+
+// attribPointer is the state of a vertex attribute array.
+type attribPointer struct {
+	enabled              bool
+	buffer               Buffer
+	size, stride, offset int
+}
+
+// errorf adds an error about the last call.
+func (r *Recorder) errorf(format string, args ...interface{}) {
+	call := r.Calls[len(r.Calls)-1]
+	r.Errors = append(r.Errors, fmt.Errorf("device: call %d %v: %s", len(r.Calls)-1, call, fmt.Sprintf(format, args...)))
+}
+
+// Err returns the first error, or nil.
+func (r *Recorder) Err() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	return r.Errors[0]
+}
+
+// IsEnabled reports whether c is enabled.
+func (r *Recorder) IsEnabled(c Capability) bool {
+	return r.enabled[c]
+}
+
+// Program returns the program in use.
+func (r *Recorder) Program() Program {
+	return r.current
+}
+
+// BufferSize returns the size in bytes of the data of b.
+func (r *Recorder) BufferSize(b Buffer) int {
+	if buf := r.buffers[b]; buf != nil {
+		return buf.size
+	}
+	return 0
+}
+
+func (r *Recorder) buffer(b Buffer) *recordedBuffer {
+	buf := r.buffers[b]
+	switch {
+	case buf == nil:
+		r.errorf("unknown buffer %d", b)
+		return nil
+	case buf.deleted:
+		r.errorf("buffer %d is deleted", b)
+		return nil
+	}
+	return buf
+}
+
+func (r *Recorder) program(p Program) *recordedProgram {
+	rp := r.programs[p]
+	switch {
+	case rp == nil:
+		r.errorf("unknown program %d", p)
+		return nil
+	case rp.deleted:
+		r.errorf("program %d is deleted", p)
+		return nil
+	}
+	return rp
+}
+
+func (r *Recorder) attrib(location int) *attribPointer {
+	a := r.attribs[location]
+	if a == nil {
+		a = &attribPointer{}
+		r.attribs[location] = a
+	}
+	return a
+}
+
+func (r *Recorder) checkTarget(target Target) bool {
+	if target != ArrayBuffer && target != ElementArrayBuffer {
+		r.errorf("invalid target %v", target)
+		return false
+	}
+	return true
+}
+
+func (r *Recorder) checkAttrib(location int) bool {
+	if location < 0 || location >= maxAttribs {
+		r.errorf("invalid attribute location %d", location)
+		return false
+	}
+	return true
+}
+
+// checkUniform checks a uniform of the program in use. Like OpenGL it
+// ignores location -1.
+func (r *Recorder) checkUniform(location int) {
+	if r.current == 0 {
+		r.errorf("no program in use")
+		return
+	}
+	if location < -1 || location >= len(r.programs[r.current].uniforms) {
+		r.errorf("invalid uniform location %d", location)
+	}
+}
+
+func (r *Recorder) checkDraw() bool {
+	if r.current == 0 {
+		r.errorf("no program in use")
+		return false
+	}
+	return true
+}
+
+// checkVertices checks that the enabled attribute arrays hold vertex max.
+func (r *Recorder) checkVertices(max int) {
+	for location := 0; location < maxAttribs; location++ {
+		a := r.attribs[location]
+		if a == nil || !a.enabled {
+			continue
+		}
+		if a.buffer == 0 {
+			r.errorf("attribute %d is enabled without an array", location)
+			continue
+		}
+		buf := r.buffers[a.buffer]
+		if buf.deleted {
+			r.errorf("attribute %d reads deleted buffer %d", location, a.buffer)
+			continue
+		}
+		stride := a.stride
+		if stride == 0 {
+			stride = a.size * 4
+		}
+		if end := a.offset + max*stride + a.size*4; end > buf.size {
+			r.errorf("attribute %d reads %d bytes from buffer %d of %d bytes", location, end, a.buffer, buf.size)
+		}
+	}
+}
+
+// dataSize returns the size of buffer data in bytes, -1 for unsupported
+// types.
+func dataSize(data interface{}) int {
+	switch v := data.(type) {
+	case []float32:
+		return len(v) * 4
+	case []uint8:
+		return len(v)
+	case []uint16:
+		return len(v) * 2
+	case []uint32:
+		return len(v) * 4
+	}
+	return -1
+}
+
+func bufferType(data interface{}) IndexType {
+	switch data.(type) {
+	case []uint8:
+		return UnsignedByte
+	case []uint16:
+		return UnsignedShort
+	case []uint32:
+		return UnsignedInt
+	}
+	return -1
+}
+
+// maxIndex returns the largest of count indices from first.
+func maxIndex(data interface{}, first, count int) (int, bool) {
+	max := -1
+	for i := first; i < first+count; i++ {
+		var v int
+		switch d := data.(type) {
+		case []uint8:
+			v = int(d[i])
+		case []uint16:
+			v = int(d[i])
+		case []uint32:
+			v = int(d[i])
+		default:
+			return 0, false
+		}
+		if v > max {
+			max = v
+		}
+	}
+	return max, max >= 0
+}
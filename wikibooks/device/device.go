@@ -0,0 +1,190 @@
+// Package device hides the OpenGL binding behind an interface, so render
+// code runs unchanged on the GPU, in software or against a recording fake.
+// The methods follow their OpenGL namesakes but only offer what the
+// tutorials need: float vertex attributes, triangle lists and 8 bit or
+// floating point RGBA textures.
+package device
+
+import (
+	"fmt"
+	"image"
+	"io/ioutil"
+	"os"
+	// For image loading
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "hdr"
+)
+
+type (
+	Buffer   uint32
+	Shader   uint32
+	Program  uint32
+	Texture  uint32
+	Readback uint32
+)
+
+type ShaderType int
+
+const (
+	VertexShader ShaderType = iota
+	FragmentShader
+)
+
+type Target int
+
+const (
+	ArrayBuffer Target = iota
+	ElementArrayBuffer
+)
+
+type Capability int
+
+const (
+	Blend Capability = iota
+	DepthTest
+	// FramebufferSRGB encodes the linear output of fragment shaders to
+	// sRGB when writing it.
+	FramebufferSRGB
+)
+
+type BlendFactor int
+
+const (
+	Zero BlendFactor = iota
+	One
+	SrcAlpha
+	OneMinusSrcAlpha
+)
+
+type IndexType int
+
+const (
+	UnsignedByte IndexType = iota
+	UnsignedShort
+	UnsignedInt
+)
+
+// Size returns the size of an index in bytes.
+func (t IndexType) Size() int {
+	switch t {
+	case UnsignedShort:
+		return 2
+	case UnsignedInt:
+		return 4
+	}
+	return 1
+}
+
+// TextureOptions control how CreateTexture uploads an image. A nil
+// *TextureOptions uploads the texels as linear RGBA.
+type TextureOptions struct {
+	// SRGB marks the texels as sRGB encoded, which is true for nearly all
+	// photos and painted textures. They are decoded to linear values when
+	// sampled, before filtering. It does not apply to *hdr.Image.
+	SRGB bool
+	// Float32 uploads an *hdr.Image with full instead of half precision.
+	Float32 bool
+}
+
+type ClearMask int
+
+const (
+	ColorBuffer ClearMask = 1 << iota
+	DepthBuffer
+)
+
+// Device is an OpenGL context. Handles of 0 mean none, locations of -1
+// that the program does not use the name.
+type Device interface {
+	CreateBuffer() Buffer
+	DeleteBuffer(b Buffer)
+	BindBuffer(target Target, b Buffer)
+	// BufferData copies data, a []float32, []uint8, []uint16 or []uint32,
+	// into the buffer bound to target.
+	BufferData(target Target, data interface{})
+
+	// CreateShader compiles source. The error holds the info log.
+	CreateShader(typ ShaderType, source string) (Shader, error)
+	DeleteShader(s Shader)
+	// CreateProgram links shaders. The error holds the info log.
+	CreateProgram(shaders ...Shader) (Program, error)
+	DeleteProgram(p Program)
+	UseProgram(p Program)
+	AttribLocation(p Program, name string) int
+	UniformLocation(p Program, name string) int
+	// Uniforms are set in the program in use.
+	Uniform1i(location int, v int)
+	Uniform1f(location int, v float32)
+	// UniformMatrix4 sets a mat4 from 16 values stored by columns.
+	UniformMatrix4(location int, m []float32)
+
+	EnableVertexAttribArray(location int)
+	DisableVertexAttribArray(location int)
+	// VertexAttribPointer reads the attribute as size floats from the
+	// buffer bound to ArrayBuffer. stride and offset count bytes; a stride
+	// of 0 means the values are packed.
+	VertexAttribPointer(location, size, stride, offset int)
+
+	// CreateTexture uploads img as an 8 bit RGBA 2D texture with linear
+	// filtering and repeating coordinates, or as an RGBA16F floating point
+	// texture if it is an *hdr.Image. opts may be nil.
+	CreateTexture(img image.Image, opts *TextureOptions) (Texture, error)
+	DeleteTexture(t Texture)
+	BindTexture(unit int, t Texture)
+
+	Enable(c Capability)
+	Disable(c Capability)
+	BlendFunc(src, dst BlendFactor)
+	ClearColor(r, g, b, a float32)
+	Clear(mask ClearMask)
+	Viewport(x, y, width, height int)
+	// ReadPixels reads the pixels drawn so far into img, from the lower
+	// left corner of the framebuffer, with the top row first.
+	ReadPixels(img *image.RGBA)
+	// StartReadPixels starts reading width by height pixels like
+	// ReadPixels without waiting for the GPU to draw them.
+	// FinishReadPixels copies them into img, waiting if they are not read
+	// yet, and frees r. Finishing a frame later usually does not wait.
+	StartReadPixels(width, height int) Readback
+	FinishReadPixels(r Readback, img *image.RGBA)
+
+	// DrawArrays draws the triangles of count vertices from first.
+	DrawArrays(first, count int)
+	// DrawElements draws the triangles of count indices, starting offset
+	// bytes into the buffer bound to ElementArrayBuffer.
+	DrawElements(count int, typ IndexType, offset int)
+}
+
+// LoadShader compiles the shader in the file name.
+func LoadShader(d Device, typ ShaderType, name string) (Shader, error) {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return 0, err
+	}
+	if len(b) == 0 {
+		return 0, fmt.Errorf("device: %s: no shader code", name)
+	}
+	s, err := d.CreateShader(typ, string(b))
+	if err != nil {
+		return 0, fmt.Errorf("device: %s: %v", name, err)
+	}
+	return s, nil
+}
+
+// OpenTexture decodes an image file and uploads it with CreateTexture.
+// Radiance .hdr and OpenEXR files become floating point textures.
+func OpenTexture(d Device, name string, opts *TextureOptions) (Texture, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return 0, err
+	}
+	return d.CreateTexture(img, opts)
+}
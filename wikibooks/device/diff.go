@@ -0,0 +1,87 @@
+package device
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expect compares the recorded calls with want, one call per line in the
+// form Call.String gives, and returns an error listing the difference.
+// Blank lines and surrounding space are ignored.
+func (r *Recorder) Expect(want string) error {
+	var got []string
+	for _, c := range r.Calls {
+		got = append(got, c.String())
+	}
+	if d := Diff(lines(want), got); d != "" {
+		return fmt.Errorf("device: calls differ (-want +got):\n%s", d)
+	}
+	return nil
+}
+
+// Filter returns the recorded calls with the given names.
+func (r *Recorder) Filter(names ...string) []Call {
+	var calls []Call
+	for _, c := range r.Calls {
+		for _, n := range names {
+			if c.Name == n {
+				calls = append(calls, c)
+				break
+			}
+		}
+	}
+	return calls
+}
+
+func lines(s string) []string {
+	var out []string
+	for _, l := range strings.Split(s, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// Diff returns a line diff of want and got, with lines only in want marked
+// "-", lines only in got "+" and common lines " ". It returns "" if they are
+// equal.
+func Diff(want, got []string) string {
+	// Longest common subsequence of the suffixes
+	n, m := len(want), len(got)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if want[i] == got[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	if lcs[0][0] == n && n == m {
+		return ""
+	}
+	s := ""
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && want[i] == got[j]:
+			s += "  " + want[i] + "\n"
+			i++
+			j++
+		case j < m && (i == n || lcs[i][j+1] > lcs[i+1][j]):
+			s += "+ " + got[j] + "\n"
+			j++
+		default:
+			s += "- " + want[i] + "\n"
+			i++
+		}
+	}
+	return s
+}
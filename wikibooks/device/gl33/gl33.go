@@ -0,0 +1,364 @@
+// Package gl33 implements device.Device with the OpenGL 3.3 binding of
+// github.com/chsc/gogl.
+package gl33
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"unsafe"
+
+	"device"
+	"hdr"
+
+	gl "github.com/chsc/gogl/gl33"
+)
+
+// Device draws with the OpenGL context current on the calling thread.
+type Device struct {
+	// readbacks holds the size of the pixels read into pixel buffers
+	readbacks map[device.Readback]image.Point
+}
+
+// New loads the OpenGL functions. It must be called after the context is
+// created.
+func New() (*Device, error) {
+	if err := gl.Init(); err != nil {
+		return nil, err
+	}
+	return &Device{readbacks: make(map[device.Readback]image.Point)}, nil
+}
+
+var (
+	shaderTypes  = []gl.Enum{gl.VERTEX_SHADER, gl.FRAGMENT_SHADER}
+	targets      = []gl.Enum{gl.ARRAY_BUFFER, gl.ELEMENT_ARRAY_BUFFER}
+	capabilities = []gl.Enum{gl.BLEND, gl.DEPTH_TEST, gl.FRAMEBUFFER_SRGB}
+	blendFactors = []gl.Enum{gl.ZERO, gl.ONE, gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA}
+	indexTypes   = []gl.Enum{gl.UNSIGNED_BYTE, gl.UNSIGNED_SHORT, gl.UNSIGNED_INT}
+)
+
+func (*Device) CreateBuffer() device.Buffer {
+	var b gl.Uint
+	gl.GenBuffers(1, &b)
+	return device.Buffer(b)
+}
+
+func (*Device) DeleteBuffer(b device.Buffer) {
+	buffer := gl.Uint(b)
+	gl.DeleteBuffers(1, &buffer)
+}
+
+func (*Device) BindBuffer(target device.Target, b device.Buffer) {
+	gl.BindBuffer(targets[target], gl.Uint(b))
+}
+
+func (*Device) BufferData(target device.Target, data interface{}) {
+	var size int
+	var p gl.Pointer
+	switch v := data.(type) {
+	case []float32:
+		size = len(v) * 4
+		if size > 0 {
+			p = gl.Pointer(&v[0])
+		}
+	case []uint8:
+		size = len(v)
+		if size > 0 {
+			p = gl.Pointer(&v[0])
+		}
+	case []uint16:
+		size = len(v) * 2
+		if size > 0 {
+			p = gl.Pointer(&v[0])
+		}
+	case []uint32:
+		size = len(v) * 4
+		if size > 0 {
+			p = gl.Pointer(&v[0])
+		}
+	default:
+		panic("gl33: unsupported buffer data")
+	}
+	gl.BufferData(targets[target], gl.Sizeiptr(size), p, gl.STATIC_DRAW)
+}
+
+func (*Device) CreateShader(typ device.ShaderType, source string) (device.Shader, error) {
+	shader := gl.CreateShader(shaderTypes[typ])
+	src := gl.GLStringArray(source)
+	defer gl.GLStringArrayFree(src)
+	gl.ShaderSource(shader, gl.Sizei(1), &src[0], nil)
+	gl.CompileShader(shader)
+
+	var ok, length gl.Int
+	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &ok)
+	if ok == 0 {
+		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &length)
+		log := gl.GLStringAlloc(gl.Sizei(length + 1))
+		defer gl.GLStringFree(log)
+		gl.GetShaderInfoLog(shader, gl.Sizei(length+1), nil, log)
+		gl.DeleteShader(shader)
+		return 0, errors.New(gl.GoString(log))
+	}
+	return device.Shader(shader), nil
+}
+
+func (*Device) DeleteShader(s device.Shader) {
+	gl.DeleteShader(gl.Uint(s))
+}
+
+func (*Device) CreateProgram(shaders ...device.Shader) (device.Program, error) {
+	program := gl.CreateProgram()
+	for _, s := range shaders {
+		gl.AttachShader(program, gl.Uint(s))
+	}
+	gl.LinkProgram(program)
+
+	var ok, length gl.Int
+	gl.GetProgramiv(program, gl.LINK_STATUS, &ok)
+	if ok == 0 {
+		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &length)
+		log := gl.GLStringAlloc(gl.Sizei(length + 1))
+		defer gl.GLStringFree(log)
+		gl.GetProgramInfoLog(program, gl.Sizei(length+1), nil, log)
+		gl.DeleteProgram(program)
+		return 0, errors.New(gl.GoString(log))
+	}
+	return device.Program(program), nil
+}
+
+func (*Device) DeleteProgram(p device.Program) {
+	gl.DeleteProgram(gl.Uint(p))
+}
+
+func (*Device) UseProgram(p device.Program) {
+	gl.UseProgram(gl.Uint(p))
+}
+
+func (*Device) AttribLocation(p device.Program, name string) int {
+	s := gl.GLString(name)
+	defer gl.GLStringFree(s)
+	return int(gl.GetAttribLocation(gl.Uint(p), s))
+}
+
+func (*Device) UniformLocation(p device.Program, name string) int {
+	s := gl.GLString(name)
+	defer gl.GLStringFree(s)
+	return int(gl.GetUniformLocation(gl.Uint(p), s))
+}
+
+func (*Device) Uniform1i(location int, v int) {
+	gl.Uniform1i(gl.Int(location), gl.Int(v))
+}
+
+func (*Device) Uniform1f(location int, v float32) {
+	gl.Uniform1f(gl.Int(location), gl.Float(v))
+}
+
+func (*Device) UniformMatrix4(location int, m []float32) {
+	gl.UniformMatrix4fv(gl.Int(location), 1, gl.FALSE, (*gl.Float)(&m[0]))
+}
+
+func (*Device) EnableVertexAttribArray(location int) {
+	gl.EnableVertexAttribArray(gl.Uint(location))
+}
+
+func (*Device) DisableVertexAttribArray(location int) {
+	gl.DisableVertexAttribArray(gl.Uint(location))
+}
+
+func (*Device) VertexAttribPointer(location, size, stride, offset int) {
+	gl.VertexAttribPointer(gl.Uint(location), gl.Int(size), gl.FLOAT, gl.FALSE, gl.Sizei(stride), bufferOffset(offset))
+}
+
+// bufferOffset returns a byte offset into the bound buffer in the form
+// OpenGL expects it.
+func bufferOffset(n int) gl.Pointer {
+	return gl.Pointer(uintptr(unsafe.Pointer(nil)) + uintptr(n))
+}
+
+func (*Device) CreateTexture(img image.Image, opts *device.TextureOptions) (device.Texture, error) {
+	if img.Bounds().Empty() {
+		return 0, errors.New("gl33: empty image")
+	}
+	if f, ok := img.(*hdr.Image); ok {
+		format := gl.Int(gl.RGBA16F)
+		if opts != nil && opts.Float32 {
+			format = gl.RGBA32F
+		}
+		return device.Texture(uploadFloat(f, format)), nil
+	}
+	format := gl.Int(gl.RGBA)
+	if opts != nil && opts.SRGB {
+		format = gl.SRGB8_ALPHA8
+	}
+	rgba, ok := img.(*image.RGBA)
+	if !ok || rgba.Stride != 4*rgba.Rect.Dx() {
+		b := img.Bounds()
+		rgba = image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(rgba, rgba.Rect, img, b.Min, draw.Src)
+	}
+	return device.Texture(upload(format, rgba.Rect.Dx(), rgba.Rect.Dy(), gl.UNSIGNED_BYTE, gl.Pointer(&rgba.Pix[0]))), nil
+}
+
+// uploadFloat uploads img as a floating point texture. The driver converts
+// the values to half floats for RGBA16F.
+func uploadFloat(img *hdr.Image, internalFormat gl.Int) gl.Uint {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	pix := img.Pix
+	if img.Stride != 4*w {
+		pix = make([]float32, 0, 4*w*h)
+		for y := 0; y < h; y++ {
+			pix = append(pix, img.Pix[y*img.Stride:y*img.Stride+4*w]...)
+		}
+	}
+	return upload(internalFormat, w, h, gl.FLOAT, gl.Pointer(&pix[0]))
+}
+
+// upload creates a texture with linear filtering from pixels. It uses the
+// active texture unit and leaves its binding and the unpack alignment as
+// they were.
+func upload(internalFormat gl.Int, w, h int, dataType gl.Enum, pixels gl.Pointer) gl.Uint {
+	var bound, alignment gl.Int
+	gl.GetIntegerv(gl.TEXTURE_BINDING_2D, &bound)
+	gl.GetIntegerv(gl.UNPACK_ALIGNMENT, &alignment)
+
+	var t gl.Uint
+	gl.GenTextures(1, &t)
+	gl.BindTexture(gl.TEXTURE_2D, t)
+
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, internalFormat, gl.Sizei(w), gl.Sizei(h), 0, gl.RGBA, dataType, pixels)
+
+	gl.PixelStorei(gl.UNPACK_ALIGNMENT, alignment)
+	gl.BindTexture(gl.TEXTURE_2D, gl.Uint(bound))
+	return t
+}
+
+func (*Device) DeleteTexture(t device.Texture) {
+	texture := gl.Uint(t)
+	gl.DeleteTextures(1, &texture)
+}
+
+func (*Device) BindTexture(unit int, t device.Texture) {
+	gl.ActiveTexture(gl.Enum(gl.TEXTURE0 + unit))
+	gl.BindTexture(gl.TEXTURE_2D, gl.Uint(t))
+}
+
+func (*Device) Enable(c device.Capability) {
+	gl.Enable(capabilities[c])
+}
+
+func (*Device) Disable(c device.Capability) {
+	gl.Disable(capabilities[c])
+}
+
+func (*Device) BlendFunc(src, dst device.BlendFactor) {
+	gl.BlendFunc(blendFactors[src], blendFactors[dst])
+}
+
+func (*Device) ClearColor(r, g, b, a float32) {
+	gl.ClearColor(gl.Float(r), gl.Float(g), gl.Float(b), gl.Float(a))
+}
+
+func (*Device) Clear(mask device.ClearMask) {
+	var bits gl.Bitfield
+	if mask&device.ColorBuffer != 0 {
+		bits |= gl.COLOR_BUFFER_BIT
+	}
+	if mask&device.DepthBuffer != 0 {
+		bits |= gl.DEPTH_BUFFER_BIT
+	}
+	gl.Clear(bits)
+}
+
+func (*Device) Viewport(x, y, width, height int) {
+	gl.Viewport(gl.Int(x), gl.Int(y), gl.Sizei(width), gl.Sizei(height))
+}
+
+// ReadPixels reads the back buffer, leaving the read buffer and pack
+// alignment as they were.
+func (*Device) ReadPixels(img *image.RGBA) {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	if w == 0 || h == 0 {
+		return
+	}
+	pixels := make([]byte, w*h*4)
+	readBack(w, h, gl.Pointer(&pixels[0]))
+	flipRows(img, pixels, w, h, w*4)
+}
+
+// StartReadPixels reads into a new pixel buffer object, which OpenGL fills
+// once the frame is drawn.
+func (d *Device) StartReadPixels(width, height int) device.Readback {
+	var pbo gl.Uint
+	var bound gl.Int
+	gl.GenBuffers(1, &pbo)
+	gl.GetIntegerv(gl.PIXEL_PACK_BUFFER_BINDING, &bound)
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, pbo)
+	gl.BufferData(gl.PIXEL_PACK_BUFFER, gl.Sizeiptr(width*height*4), nil, gl.STREAM_READ)
+	if width > 0 && height > 0 {
+		readBack(width, height, nil)
+	}
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, gl.Uint(bound))
+	r := device.Readback(pbo)
+	d.readbacks[r] = image.Pt(width, height)
+	return r
+}
+
+func (d *Device) FinishReadPixels(r device.Readback, img *image.RGBA) {
+	size, ok := d.readbacks[r]
+	if !ok {
+		return
+	}
+	delete(d.readbacks, r)
+	pbo := gl.Uint(r)
+	w, h := size.X, size.Y
+	if img.Rect.Dx() < w || img.Rect.Dy() < h {
+		w, h = img.Rect.Dx(), img.Rect.Dy()
+	}
+	if w > 0 && h > 0 {
+		var bound gl.Int
+		gl.GetIntegerv(gl.PIXEL_PACK_BUFFER_BINDING, &bound)
+		gl.BindBuffer(gl.PIXEL_PACK_BUFFER, pbo)
+		if ptr := gl.MapBuffer(gl.PIXEL_PACK_BUFFER, gl.READ_ONLY); ptr != nil {
+			n := size.X * size.Y * 4
+			pixels := (*[1 << 30]byte)(unsafe.Pointer(ptr))[:n:n]
+			flipRows(img, pixels, w, h, size.X*4)
+			gl.UnmapBuffer(gl.PIXEL_PACK_BUFFER)
+		}
+		gl.BindBuffer(gl.PIXEL_PACK_BUFFER, gl.Uint(bound))
+	}
+	gl.DeleteBuffers(1, &pbo)
+}
+
+// flipRows copies the lower left w by h pixels of OpenGL rows of stride
+// bytes, which come bottom row first, into img.
+func flipRows(img *image.RGBA, pixels []byte, w, h, stride int) {
+	for y := 0; y < h; y++ {
+		copy(img.Pix[y*img.Stride:y*img.Stride+w*4], pixels[(h-1-y)*stride:])
+	}
+}
+
+// readBack reads the back buffer into p, leaving the read buffer and pack
+// alignment as they were.
+func readBack(w, h int, p gl.Pointer) {
+	var read, alignment gl.Int
+	gl.GetIntegerv(gl.READ_BUFFER, &read)
+	gl.GetIntegerv(gl.PACK_ALIGNMENT, &alignment)
+	gl.ReadBuffer(gl.BACK)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.ReadPixels(0, 0, gl.Sizei(w), gl.Sizei(h), gl.RGBA, gl.UNSIGNED_BYTE, p)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, alignment)
+	gl.ReadBuffer(gl.Enum(read))
+}
+
+func (*Device) DrawArrays(first, count int) {
+	gl.DrawArrays(gl.TRIANGLES, gl.Int(first), gl.Sizei(count))
+}
+
+func (*Device) DrawElements(count int, typ device.IndexType, offset int) {
+	gl.DrawElements(gl.TRIANGLES, gl.Sizei(count), indexTypes[typ], bufferOffset(offset))
+}
@@ -0,0 +1,454 @@
+package device
+
+import (
+	"fmt"
+	"image"
+	"regexp"
+	"strings"
+)
+
+func (t ShaderType) String() string {
+	return enumName(int(t), "VertexShader", "FragmentShader")
+}
+
+func (t Target) String() string {
+	return enumName(int(t), "ArrayBuffer", "ElementArrayBuffer")
+}
+
+func (c Capability) String() string {
+	return enumName(int(c), "Blend", "DepthTest", "FramebufferSRGB")
+}
+
+func (f BlendFactor) String() string {
+	return enumName(int(f), "Zero", "One", "SrcAlpha", "OneMinusSrcAlpha")
+}
+
+func (t IndexType) String() string {
+	return enumName(int(t), "UnsignedByte", "UnsignedShort", "UnsignedInt")
+}
+
+func (m ClearMask) String() string {
+	var names []string
+	if m&ColorBuffer != 0 {
+		names = append(names, "ColorBuffer")
+	}
+	if m&DepthBuffer != 0 {
+		names = append(names, "DepthBuffer")
+	}
+	if len(names) == 0 {
+		return "0"
+	}
+	return strings.Join(names, "|")
+}
+
+func enumName(i int, names ...string) string {
+	if i < 0 || i >= len(names) {
+		return fmt.Sprint(i)
+	}
+	return names[i]
+}
+
+// Call is a Device method call recorded by a Recorder.
+type Call struct {
+	Name string
+	Args []interface{}
+}
+
+// String formats the call like Go code, with the length instead of the
+// contents of slices.
+func (c Call) String() string {
+	args := make([]string, len(c.Args))
+	for i, a := range c.Args {
+		switch v := a.(type) {
+		case string:
+			args[i] = fmt.Sprintf("%q", v)
+		case []float32:
+			args[i] = fmt.Sprintf("[]float32(%d)", len(v))
+		case []uint8:
+			args[i] = fmt.Sprintf("[]uint8(%d)", len(v))
+		case []uint16:
+			args[i] = fmt.Sprintf("[]uint16(%d)", len(v))
+		case []uint32:
+			args[i] = fmt.Sprintf("[]uint32(%d)", len(v))
+		case image.Rectangle:
+			args[i] = fmt.Sprintf("%dx%d", v.Dx(), v.Dy())
+		default:
+			args[i] = fmt.Sprint(v)
+		}
+	}
+	return c.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// Recorder is a Device which draws nothing and records the calls made to
+// it. Locations are given to the attributes and uniforms the shader sources
+// declare, in order, so names the shaders do not use get -1 as in OpenGL.
+//
+// It also tracks the state OpenGL would have and checks every call
+// against it, collecting misuse in Errors instead of panicking: drawing
+// without a program, invalid enums and offsets, locations of -1, unknown
+// or deleted objects, and draws reading past the end of a buffer. Expect compares the calls with the ones a test wants.
+type Recorder struct {
+	Calls  []Call
+	Errors []error
+
+	next     uint32
+	shaders  map[Shader]string
+	programs map[Program]*recordedProgram
+	buffers  map[Buffer]*recordedBuffer
+	textures map[Texture]bool
+	// readbacks holds the size of unfinished reads
+	readbacks map[Readback]image.Point
+	bound     [2]Buffer
+	current   Program
+	attribs   map[int]*attribPointer
+	enabled   map[Capability]bool
+}
+
+type recordedProgram struct {
+	attributes, uniforms []string
+	deleted              bool
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{
+		shaders:   make(map[Shader]string),
+		programs:  make(map[Program]*recordedProgram),
+		buffers:   make(map[Buffer]*recordedBuffer),
+		textures:  make(map[Texture]bool),
+		readbacks: make(map[Readback]image.Point),
+		attribs:   make(map[int]*attribPointer),
+		enabled:   make(map[Capability]bool),
+	}
+}
+
+// String lists the calls, one per line.
+func (r *Recorder) String() string {
+	s := ""
+	for _, c := range r.Calls {
+		s += c.String() + "\n"
+	}
+	return s
+}
+
+// Reset forgets the recorded calls and errors but keeps the state.
+func (r *Recorder) Reset() {
+	r.Calls = nil
+	r.Errors = nil
+}
+
+func (r *Recorder) record(name string, args ...interface{}) {
+	r.Calls = append(r.Calls, Call{name, args})
+}
+
+func (r *Recorder) handle() uint32 {
+	r.next++
+	return r.next
+}
+
+func (r *Recorder) CreateBuffer() Buffer {
+	b := Buffer(r.handle())
+	r.buffers[b] = &recordedBuffer{}
+	r.record("CreateBuffer", b)
+	return b
+}
+
+func (r *Recorder) DeleteBuffer(b Buffer) {
+	r.record("DeleteBuffer", b)
+	if r.buffer(b) != nil {
+		r.buffers[b].deleted = true
+		for t := range r.bound {
+			if r.bound[t] == b {
+				r.bound[t] = 0
+			}
+		}
+	}
+}
+
+func (r *Recorder) BindBuffer(target Target, b Buffer) {
+	r.record("BindBuffer", target, b)
+	if !r.checkTarget(target) {
+		return
+	}
+	if b == 0 || r.buffer(b) != nil {
+		r.bound[target] = b
+	}
+}
+
+func (r *Recorder) BufferData(target Target, data interface{}) {
+	r.record("BufferData", target, data)
+	if !r.checkTarget(target) {
+		return
+	}
+	b := r.bound[target]
+	if b == 0 {
+		r.errorf("no buffer bound to %v", target)
+		return
+	}
+	size := dataSize(data)
+	if size < 0 {
+		r.errorf("unsupported data %T", data)
+		return
+	}
+	r.buffers[b].size = size
+	r.buffers[b].data = data
+}
+
+func (r *Recorder) CreateShader(typ ShaderType, source string) (Shader, error) {
+	s := Shader(r.handle())
+	r.shaders[s] = source
+	r.record("CreateShader", typ, s)
+	return s, nil
+}
+
+func (r *Recorder) DeleteShader(s Shader) {
+	r.record("DeleteShader", s)
+	if _, ok := r.shaders[s]; !ok {
+		r.errorf("unknown shader %d", s)
+	}
+	delete(r.shaders, s)
+}
+
+var declaration = regexp.MustCompile(`(?m)^\s*(attribute|uniform)\s+\w+\s+(\w+)`)
+
+func (r *Recorder) CreateProgram(shaders ...Shader) (Program, error) {
+	p := Program(r.handle())
+	rp := &recordedProgram{}
+	args := []interface{}{p}
+	for _, s := range shaders {
+		args = append(args, s)
+	}
+	r.programs[p] = rp
+	r.record("CreateProgram", args...)
+	for _, s := range shaders {
+		source, ok := r.shaders[s]
+		if !ok {
+			r.errorf("unknown shader %d", s)
+		}
+		for _, m := range declaration.FindAllStringSubmatch(source, -1) {
+			if m[1] == "attribute" {
+				rp.attributes = append(rp.attributes, m[2])
+			} else {
+				rp.uniforms = append(rp.uniforms, m[2])
+			}
+		}
+	}
+	return p, nil
+}
+
+func (r *Recorder) DeleteProgram(p Program) {
+	r.record("DeleteProgram", p)
+	if rp := r.program(p); rp != nil {
+		rp.deleted = true
+		if r.current == p {
+			r.current = 0
+		}
+	}
+}
+
+func (r *Recorder) UseProgram(p Program) {
+	r.record("UseProgram", p)
+	if p == 0 || r.program(p) != nil {
+		r.current = p
+	}
+}
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *Recorder) AttribLocation(p Program, name string) int {
+	r.record("AttribLocation", p, name)
+	if rp := r.program(p); rp != nil {
+		return indexOf(rp.attributes, name)
+	}
+	return -1
+}
+
+func (r *Recorder) UniformLocation(p Program, name string) int {
+	r.record("UniformLocation", p, name)
+	if rp := r.program(p); rp != nil {
+		return indexOf(rp.uniforms, name)
+	}
+	return -1
+}
+
+func (r *Recorder) Uniform1i(location int, v int) {
+	r.record("Uniform1i", location, v)
+	r.checkUniform(location)
+}
+
+func (r *Recorder) Uniform1f(location int, v float32) {
+	r.record("Uniform1f", location, v)
+	r.checkUniform(location)
+}
+
+func (r *Recorder) UniformMatrix4(location int, m []float32) {
+	r.record("UniformMatrix4", location, m)
+	r.checkUniform(location)
+	if len(m) != 16 {
+		r.errorf("matrix has %d values instead of 16", len(m))
+	}
+}
+
+func (r *Recorder) EnableVertexAttribArray(location int) {
+	r.record("EnableVertexAttribArray", location)
+	if r.checkAttrib(location) {
+		r.attrib(location).enabled = true
+	}
+}
+
+func (r *Recorder) DisableVertexAttribArray(location int) {
+	r.record("DisableVertexAttribArray", location)
+	if r.checkAttrib(location) {
+		r.attrib(location).enabled = false
+	}
+}
+
+func (r *Recorder) VertexAttribPointer(location, size, stride, offset int) {
+	r.record("VertexAttribPointer", location, size, stride, offset)
+	if !r.checkAttrib(location) {
+		return
+	}
+	if r.bound[ArrayBuffer] == 0 {
+		r.errorf("no buffer bound to %v", ArrayBuffer)
+		return
+	}
+	if size < 1 || size > 4 || stride < 0 || offset < 0 {
+		r.errorf("invalid size, stride or offset")
+		return
+	}
+	a := r.attrib(location)
+	a.buffer, a.size, a.stride, a.offset = r.bound[ArrayBuffer], size, stride, offset
+}
+
+func (r *Recorder) CreateTexture(img image.Image, opts *TextureOptions) (Texture, error) {
+	t := Texture(r.handle())
+	r.record("CreateTexture", t, img.Bounds(), opts != nil && opts.SRGB)
+	if img.Bounds().Empty() {
+		r.errorf("empty image")
+	}
+	r.textures[t] = true
+	return t, nil
+}
+
+func (r *Recorder) DeleteTexture(t Texture) {
+	r.record("DeleteTexture", t)
+	if !r.textures[t] {
+		r.errorf("unknown texture %d", t)
+	}
+	delete(r.textures, t)
+}
+
+func (r *Recorder) BindTexture(unit int, t Texture) {
+	r.record("BindTexture", unit, t)
+	if t != 0 && !r.textures[t] {
+		r.errorf("unknown texture %d", t)
+	}
+}
+
+func (r *Recorder) Enable(c Capability) {
+	r.record("Enable", c)
+	r.enabled[c] = true
+}
+
+func (r *Recorder) Disable(c Capability) {
+	r.record("Disable", c)
+	r.enabled[c] = false
+}
+
+func (r *Recorder) BlendFunc(src, dst BlendFactor) { r.record("BlendFunc", src, dst) }
+
+func (r *Recorder) ClearColor(red, green, blue, alpha float32) {
+	r.record("ClearColor", red, green, blue, alpha)
+}
+
+func (r *Recorder) Clear(mask ClearMask) { r.record("Clear", mask) }
+
+func (r *Recorder) Viewport(x, y, width, height int) {
+	r.record("Viewport", x, y, width, height)
+	if width < 0 || height < 0 {
+		r.errorf("negative viewport size")
+	}
+}
+
+func (r *Recorder) ReadPixels(img *image.RGBA) {
+	r.record("ReadPixels", img.Bounds())
+	if img.Rect.Empty() {
+		r.errorf("empty image")
+	}
+}
+
+func (r *Recorder) StartReadPixels(width, height int) Readback {
+	rb := Readback(r.handle())
+	r.readbacks[rb] = image.Pt(width, height)
+	r.record("StartReadPixels", width, height, rb)
+	if width <= 0 || height <= 0 {
+		r.errorf("empty read")
+	}
+	return rb
+}
+
+func (r *Recorder) FinishReadPixels(rb Readback, img *image.RGBA) {
+	r.record("FinishReadPixels", rb, img.Bounds())
+	size, ok := r.readbacks[rb]
+	if !ok {
+		r.errorf("unknown or finished read %d", rb)
+		return
+	}
+	delete(r.readbacks, rb)
+	if img.Rect.Size() != size {
+		r.errorf("reads %dx%d pixels into a %dx%d image", size.X, size.Y, img.Rect.Dx(), img.Rect.Dy())
+	}
+}
+
+func (r *Recorder) DrawArrays(first, count int) {
+	r.record("DrawArrays", first, count)
+	if first < 0 || count < 0 {
+		r.errorf("negative first or count")
+		return
+	}
+	if r.checkDraw() && count > 0 {
+		r.checkVertices(first + count - 1)
+	}
+}
+
+func (r *Recorder) DrawElements(count int, typ IndexType, offset int) {
+	r.record("DrawElements", count, typ, offset)
+	if count < 0 || offset < 0 {
+		r.errorf("negative count or offset")
+		return
+	}
+	if typ < UnsignedByte || typ > UnsignedInt {
+		r.errorf("invalid index type %v", typ)
+		return
+	}
+	if offset%typ.Size() != 0 {
+		r.errorf("offset %d is not a multiple of the index size", offset)
+		return
+	}
+	if !r.checkDraw() {
+		return
+	}
+	b := r.bound[ElementArrayBuffer]
+	if b == 0 {
+		r.errorf("no buffer bound to %v", ElementArrayBuffer)
+		return
+	}
+	buf := r.buffers[b]
+	if end := offset + count*typ.Size(); end > buf.size {
+		r.errorf("reads %d bytes of indices from buffer %d of %d bytes", end, b, buf.size)
+		return
+	}
+	if bufferType(buf.data) != typ {
+		r.errorf("draws %v indices from buffer %d holding %T", typ, b, buf.data)
+		return
+	}
+	if max, ok := maxIndex(buf.data, offset/typ.Size(), count); ok {
+		r.checkVertices(max)
+	}
+}
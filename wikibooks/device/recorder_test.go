@@ -0,0 +1,161 @@
+package device
+
+import (
+	"image"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name      string
+		want, got []string
+		diff      string
+	}{
+		{"equal", []string{"a", "b"}, []string{"a", "b"}, ""},
+		{"empty", nil, nil, ""},
+		{"changed", []string{"a", "b", "c"}, []string{"a", "x", "c"}, "  a\n- b\n+ x\n  c\n"},
+		{"added", []string{"a", "c"}, []string{"a", "b", "c"}, "  a\n+ b\n  c\n"},
+		{"removed", []string{"a", "b", "c"}, []string{"a", "c"}, "  a\n- b\n  c\n"},
+		{"only want", []string{"a"}, nil, "- a\n"},
+		{"only got", nil, []string{"a"}, "+ a\n"},
+	}
+	for _, test := range tests {
+		if d := Diff(test.want, test.got); d != test.diff {
+			t.Errorf("%s: got diff\n%swant\n%s", test.name, d, test.diff)
+		}
+	}
+}
+
+func TestExpect(t *testing.T) {
+	r := NewRecorder()
+	r.ClearColor(1, 1, 1, 1)
+	r.Clear(ColorBuffer | DepthBuffer)
+	r.BufferData(ArrayBuffer, []float32{1, 2, 3})
+
+	// Blank lines and indentation do not matter
+	if err := r.Expect(`
+		ClearColor(1, 1, 1, 1)
+
+		Clear(ColorBuffer|DepthBuffer)
+		BufferData(ArrayBuffer, []float32(3))
+	`); err != nil {
+		t.Error(err)
+	}
+
+	err := r.Expect(`
+		ClearColor(1, 1, 1, 1)
+		Clear(ColorBuffer)
+		BufferData(ArrayBuffer, []float32(3))
+	`)
+	want := "device: calls differ (-want +got):\n" +
+		"  ClearColor(1, 1, 1, 1)\n" +
+		"- Clear(ColorBuffer)\n" +
+		"+ Clear(ColorBuffer|DepthBuffer)\n" +
+		"  BufferData(ArrayBuffer, []float32(3))\n"
+	if err == nil || err.Error() != want {
+		t.Errorf("got error\n%v\nwant\n%s", err, want)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	r := NewRecorder()
+	r.Enable(Blend)
+	r.Clear(ColorBuffer)
+	r.Enable(DepthTest)
+	calls := r.Filter("Enable")
+	if len(calls) != 2 || calls[0].String() != "Enable(Blend)" || calls[1].String() != "Enable(DepthTest)" {
+		t.Errorf("got %v", calls)
+	}
+}
+
+// triangle sets r up to draw a triangle of 3 vertices of 3 floats with 16
+// bit indices, and returns the vertex buffer.
+func triangle(r *Recorder) Buffer {
+	vs, _ := r.CreateShader(VertexShader, "attribute vec3 coord;\nuniform mat4 mvp;\n")
+	p, _ := r.CreateProgram(vs)
+	r.UseProgram(p)
+	vertices := r.CreateBuffer()
+	r.BindBuffer(ArrayBuffer, vertices)
+	r.BufferData(ArrayBuffer, make([]float32, 9))
+	location := r.AttribLocation(p, "coord")
+	r.EnableVertexAttribArray(location)
+	r.VertexAttribPointer(location, 3, 0, 0)
+	r.BindBuffer(ElementArrayBuffer, r.CreateBuffer())
+	r.BufferData(ElementArrayBuffer, []uint16{0, 1, 2})
+	return vertices
+}
+
+// The checks find the misuse OpenGL would silently ignore or crash on,
+// without panicking themselves.
+func TestChecks(t *testing.T) {
+	tests := []struct {
+		name   string
+		misuse func(r *Recorder, vertices Buffer)
+		err    string
+	}{
+		{"draw", func(r *Recorder, vertices Buffer) {
+			r.DrawArrays(0, 3)
+			r.DrawElements(3, UnsignedShort, 0)
+			r.DrawElements(1, UnsignedShort, 4)
+		}, ""},
+		{"vertices past the buffer", func(r *Recorder, vertices Buffer) {
+			r.DrawArrays(1, 3)
+		}, "attribute 0 reads 48 bytes from buffer 3 of 36 bytes"},
+		{"index past the vertices", func(r *Recorder, vertices Buffer) {
+			r.BufferData(ElementArrayBuffer, []uint16{0, 1, 3})
+			r.DrawElements(3, UnsignedShort, 0)
+		}, "attribute 0 reads 48 bytes"},
+		{"indices past the buffer", func(r *Recorder, vertices Buffer) {
+			r.DrawElements(3, UnsignedShort, 2)
+		}, "reads 8 bytes of indices from buffer 4 of 6 bytes"},
+		{"negative offset", func(r *Recorder, vertices Buffer) {
+			r.DrawElements(3, UnsignedShort, -2)
+		}, "negative count or offset"},
+		{"unaligned offset", func(r *Recorder, vertices Buffer) {
+			r.DrawElements(1, UnsignedShort, 1)
+		}, "offset 1 is not a multiple of the index size"},
+		{"index type", func(r *Recorder, vertices Buffer) {
+			r.DrawElements(3, UnsignedByte, 0)
+		}, "draws UnsignedByte indices from buffer 4 holding []uint16"},
+		{"invalid index type", func(r *Recorder, vertices Buffer) {
+			r.DrawElements(3, IndexType(3), 0)
+		}, "invalid index type 3"},
+		{"invalid target", func(r *Recorder, vertices Buffer) {
+			r.BindBuffer(Target(2), vertices)
+			r.BufferData(Target(-1), []float32{1})
+		}, "invalid target 2"},
+		{"deleted buffer", func(r *Recorder, vertices Buffer) {
+			r.DeleteBuffer(vertices)
+			r.DrawArrays(0, 3)
+		}, "attribute 0 reads deleted buffer 3"},
+		{"attribute -1", func(r *Recorder, vertices Buffer) {
+			r.EnableVertexAttribArray(-1)
+		}, "invalid attribute location -1"},
+		{"no program", func(r *Recorder, vertices Buffer) {
+			r.UseProgram(0)
+			r.DrawElements(3, UnsignedShort, 0)
+		}, "no program in use"},
+		{"finished read", func(r *Recorder, vertices Buffer) {
+			rb := r.StartReadPixels(2, 1)
+			img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+			r.FinishReadPixels(rb, img)
+			r.FinishReadPixels(rb, img)
+		}, "unknown or finished read 5"},
+	}
+	for _, test := range tests {
+		r := NewRecorder()
+		vertices := triangle(r)
+		if err := r.Err(); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		test.misuse(r, vertices)
+		err := r.Err()
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s: %v", test.name, err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+		}
+	}
+}
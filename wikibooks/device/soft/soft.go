@@ -0,0 +1,425 @@
+// Package soft implements device.Device with the software rasterizer, for
+// rendering without a GPU or a window. GLSL is not compiled: every program
+// is a Go mirror of its shaders, registered with AddProgram.
+package soft
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"device"
+	"hdr"
+	"raster"
+)
+
+// Uniforms is implemented by programs with uniforms. It returns pointers
+// to them by name: *float32 for floats, *[]float32 for matrices stored by
+// columns, *int for integers and **raster.Texture for samplers. Locations
+// number the names in sorted order.
+type Uniforms interface {
+	Uniforms() map[string]interface{}
+}
+
+// Device draws into an image. Like a GL context it is not safe for
+// concurrent use.
+type Device struct {
+	ctx *raster.Context
+
+	programs map[string]raster.Program
+	next     uint32
+
+	shaders  map[device.Shader]string
+	linked   map[device.Program]*program
+	buffers  map[device.Buffer]interface{}
+	textures map[device.Texture]*raster.Texture
+	// readbacks holds the pixels read by StartReadPixels
+	readbacks map[device.Readback]*image.RGBA
+
+	bound    [2]device.Buffer
+	current  *program
+	attribs  []*attrib
+	units    map[int]device.Texture
+	blend    bool
+	src, dst device.BlendFactor
+}
+
+type program struct {
+	raster.Program
+	names    []string
+	uniforms []interface{}
+	// samplers holds the texture unit of sampler uniforms
+	samplers map[int]int
+}
+
+type attrib struct {
+	enabled bool
+	buffer  device.Buffer
+	raster.Attrib
+}
+
+// New creates a device drawing into a width by height image.
+func New(width, height int) *Device {
+	return &Device{
+		ctx:       raster.New(width, height),
+		programs:  make(map[string]raster.Program),
+		shaders:   make(map[device.Shader]string),
+		linked:    make(map[device.Program]*program),
+		buffers:   make(map[device.Buffer]interface{}),
+		textures:  make(map[device.Texture]*raster.Texture),
+		readbacks: make(map[device.Readback]*image.RGBA),
+		attribs:   make([]*attrib, 16),
+		units:     make(map[int]device.Texture),
+		src:       device.One,
+		dst:       device.Zero,
+	}
+}
+
+// programKey identifies a program by the sources of its shaders, in any
+// order.
+func programKey(sources []string) string {
+	sorted := append([]string(nil), sources...)
+	for i, s := range sorted {
+		sorted[i] = strings.TrimSpace(s)
+	}
+	sort.Strings(sorted)
+	return strings.Join(sorted, "\x00")
+}
+
+// AddProgram makes p the program CreateProgram links from shaders with the
+// given sources. p should implement Uniforms if the shaders have uniforms.
+func (d *Device) AddProgram(p raster.Program, sources ...string) {
+	d.programs[programKey(sources)] = p
+}
+
+// LoadProgram is AddProgram with the sources read from the named files.
+func (d *Device) LoadProgram(p raster.Program, filenames ...string) error {
+	var sources []string
+	for _, name := range filenames {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, string(b))
+	}
+	d.AddProgram(p, sources...)
+	return nil
+}
+
+// Resize resizes the image and sets the viewport to all of it.
+func (d *Device) Resize(width, height int) {
+	d.ctx.Resize(width, height)
+}
+
+// Image returns the color buffer.
+func (d *Device) Image() *image.RGBA {
+	return d.ctx.Color
+}
+
+func (d *Device) handle() uint32 {
+	d.next++
+	return d.next
+}
+
+func (d *Device) CreateBuffer() device.Buffer {
+	b := device.Buffer(d.handle())
+	d.buffers[b] = nil
+	return b
+}
+
+func (d *Device) DeleteBuffer(b device.Buffer) {
+	delete(d.buffers, b)
+}
+
+func (d *Device) BindBuffer(target device.Target, b device.Buffer) {
+	d.bound[target] = b
+}
+
+func (d *Device) BufferData(target device.Target, data interface{}) {
+	switch v := data.(type) {
+	case []float32:
+		data = append([]float32(nil), v...)
+	case []uint8:
+		data = append([]uint8(nil), v...)
+	case []uint16:
+		data = append([]uint16(nil), v...)
+	case []uint32:
+		data = append([]uint32(nil), v...)
+	default:
+		panic("soft: unsupported buffer data")
+	}
+	d.buffers[d.bound[target]] = data
+}
+
+func (d *Device) CreateShader(typ device.ShaderType, source string) (device.Shader, error) {
+	s := device.Shader(d.handle())
+	d.shaders[s] = source
+	return s, nil
+}
+
+func (d *Device) DeleteShader(s device.Shader) {
+	delete(d.shaders, s)
+}
+
+func (d *Device) CreateProgram(shaders ...device.Shader) (device.Program, error) {
+	var sources []string
+	for _, s := range shaders {
+		sources = append(sources, d.shaders[s])
+	}
+	rp, ok := d.programs[programKey(sources)]
+	if !ok {
+		return 0, errors.New("soft: no Go program added for the shaders")
+	}
+	p := &program{Program: rp, samplers: make(map[int]int)}
+	if u, ok := rp.(Uniforms); ok {
+		pointers := u.Uniforms()
+		for name := range pointers {
+			p.names = append(p.names, name)
+		}
+		sort.Strings(p.names)
+		for _, name := range p.names {
+			p.uniforms = append(p.uniforms, pointers[name])
+		}
+	}
+	h := device.Program(d.handle())
+	d.linked[h] = p
+	return h, nil
+}
+
+func (d *Device) DeleteProgram(p device.Program) {
+	if d.current == d.linked[p] {
+		d.current = nil
+	}
+	delete(d.linked, p)
+}
+
+func (d *Device) UseProgram(p device.Program) {
+	d.current = d.linked[p]
+}
+
+func (d *Device) AttribLocation(p device.Program, name string) int {
+	if lp := d.linked[p]; lp != nil {
+		for i, n := range lp.Attributes() {
+			if n == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (d *Device) UniformLocation(p device.Program, name string) int {
+	if lp := d.linked[p]; lp != nil {
+		for i, n := range lp.names {
+			if n == name {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// uniform returns the pointer to a uniform of the program in use, nil for
+// location -1.
+func (d *Device) uniform(location int) interface{} {
+	if d.current == nil || location < 0 || location >= len(d.current.uniforms) {
+		return nil
+	}
+	return d.current.uniforms[location]
+}
+
+func (d *Device) Uniform1i(location int, v int) {
+	switch u := d.uniform(location).(type) {
+	case *int:
+		*u = v
+	case **raster.Texture:
+		d.current.samplers[location] = v
+	}
+}
+
+func (d *Device) Uniform1f(location int, v float32) {
+	if u, ok := d.uniform(location).(*float32); ok {
+		*u = v
+	}
+}
+
+func (d *Device) UniformMatrix4(location int, m []float32) {
+	if u, ok := d.uniform(location).(*[]float32); ok {
+		*u = append((*u)[:0], m...)
+	}
+}
+
+// attrib returns the attribute at location, or nil if there is none, like
+// for location -1, which OpenGL ignores.
+func (d *Device) attrib(location int) *attrib {
+	if location < 0 || location >= len(d.attribs) {
+		return nil
+	}
+	if d.attribs[location] == nil {
+		d.attribs[location] = &attrib{}
+	}
+	return d.attribs[location]
+}
+
+func (d *Device) EnableVertexAttribArray(location int) {
+	if a := d.attrib(location); a != nil {
+		a.enabled = true
+	}
+}
+
+func (d *Device) DisableVertexAttribArray(location int) {
+	if a := d.attrib(location); a != nil {
+		a.enabled = false
+	}
+}
+
+func (d *Device) VertexAttribPointer(location, size, stride, offset int) {
+	a := d.attrib(location)
+	if a == nil {
+		return
+	}
+	a.buffer = d.bound[device.ArrayBuffer]
+	a.Size, a.Stride, a.Offset = size, stride/4, offset/4
+}
+
+func (d *Device) CreateTexture(img image.Image, opts *device.TextureOptions) (device.Texture, error) {
+	if img.Bounds().Empty() {
+		return 0, errors.New("soft: empty image")
+	}
+	t := device.Texture(d.handle())
+	if f, ok := img.(*hdr.Image); ok {
+		d.textures[t] = floatTexture(f)
+	} else if opts != nil && opts.SRGB {
+		d.textures[t] = raster.NewSRGBTexture(img)
+	} else {
+		d.textures[t] = raster.NewTexture(img)
+	}
+	return t, nil
+}
+
+// floatTexture copies the values of img, which are not rounded to half
+// floats as they would be on the GPU.
+func floatTexture(img *hdr.Image) *raster.Texture {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	t := &raster.Texture{Width: w, Height: h, Pix: make([]float32, 0, 4*w*h)}
+	for y := 0; y < h; y++ {
+		t.Pix = append(t.Pix, img.Pix[y*img.Stride:y*img.Stride+4*w]...)
+	}
+	return t
+}
+
+func (d *Device) DeleteTexture(t device.Texture) {
+	delete(d.textures, t)
+}
+
+func (d *Device) BindTexture(unit int, t device.Texture) {
+	d.units[unit] = t
+}
+
+func (d *Device) setCapability(c device.Capability, on bool) {
+	switch c {
+	case device.Blend:
+		d.blend = on
+	case device.DepthTest:
+		d.ctx.DepthTest = on
+	case device.FramebufferSRGB:
+		d.ctx.SRGB = on
+	}
+}
+
+func (d *Device) Enable(c device.Capability) {
+	d.setCapability(c, true)
+}
+
+func (d *Device) Disable(c device.Capability) {
+	d.setCapability(c, false)
+}
+
+// BlendFunc supports blending by source alpha. Other factors draw without
+// blending.
+func (d *Device) BlendFunc(src, dst device.BlendFactor) {
+	d.src, d.dst = src, dst
+}
+
+func (d *Device) ClearColor(r, g, b, a float32) {
+	d.ctx.ClearColor = raster.Vec4{r, g, b, a}
+}
+
+func (d *Device) Viewport(x, y, width, height int) {
+	d.ctx.Viewport(x, y, width, height)
+}
+
+func (d *Device) ReadPixels(img *image.RGBA) {
+	// The framebuffer is stored top row first
+	from := image.Pt(0, d.ctx.Color.Rect.Dy()-img.Rect.Dy())
+	draw.Draw(img, img.Rect, d.ctx.Color, from, draw.Src)
+}
+
+// StartReadPixels reads the pixels at once, as drawing is already done.
+func (d *Device) StartReadPixels(width, height int) device.Readback {
+	r := device.Readback(d.handle())
+	d.readbacks[r] = image.NewRGBA(image.Rect(0, 0, width, height))
+	d.ReadPixels(d.readbacks[r])
+	return r
+}
+
+func (d *Device) FinishReadPixels(r device.Readback, img *image.RGBA) {
+	if pixels, ok := d.readbacks[r]; ok {
+		draw.Draw(img, img.Rect, pixels, image.Pt(0, pixels.Rect.Dy()-img.Rect.Dy()), draw.Src)
+		delete(d.readbacks, r)
+	}
+}
+
+func (d *Device) Clear(mask device.ClearMask) {
+	d.ctx.Clear(mask&device.ColorBuffer != 0, mask&device.DepthBuffer != 0)
+}
+
+// prepare returns the attribute arrays for a draw, after setting the state
+// and samplers of the program in use.
+func (d *Device) prepare() []*raster.Attrib {
+	d.ctx.Blend = d.blend && d.src == device.SrcAlpha && d.dst == device.OneMinusSrcAlpha
+	for location, unit := range d.current.samplers {
+		*d.current.uniforms[location].(**raster.Texture) = d.textures[d.units[unit]]
+	}
+	attribs := make([]*raster.Attrib, len(d.current.Attributes()))
+	for i := range attribs {
+		a := d.attribs[i]
+		if a == nil || !a.enabled {
+			continue
+		}
+		data, _ := d.buffers[a.buffer].([]float32)
+		attribs[i] = &raster.Attrib{Data: data, Size: a.Size, Stride: a.Stride, Offset: a.Offset}
+	}
+	return attribs
+}
+
+func (d *Device) DrawArrays(first, count int) {
+	if d.current == nil {
+		return
+	}
+	d.ctx.Draw(d.current, d.prepare(), first, count)
+}
+
+func (d *Device) DrawElements(count int, typ device.IndexType, offset int) {
+	if d.current == nil {
+		return
+	}
+	first := offset / typ.Size()
+	indices := make([]uint32, count)
+	switch v := d.buffers[d.bound[device.ElementArrayBuffer]].(type) {
+	case []uint8:
+		for i := range indices {
+			indices[i] = uint32(v[first+i])
+		}
+	case []uint16:
+		for i := range indices {
+			indices[i] = uint32(v[first+i])
+		}
+	case []uint32:
+		copy(indices, v[first:])
+	}
+	d.ctx.DrawIndexed(d.current, d.prepare(), indices)
+}
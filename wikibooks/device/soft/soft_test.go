@@ -0,0 +1,19 @@
+package soft
+
+import "testing"
+
+// Attributes the shaders optimized away have location -1, which the device
+// ignores like OpenGL.
+func TestMissingAttrib(t *testing.T) {
+	d := New(2, 2)
+	for _, location := range []int{-1, 16} {
+		d.EnableVertexAttribArray(location)
+		d.VertexAttribPointer(location, 4, 0, 0)
+		d.DisableVertexAttribArray(location)
+	}
+	for i, a := range d.attribs {
+		if a != nil {
+			t.Errorf("attribute %d set", i)
+		}
+	}
+}
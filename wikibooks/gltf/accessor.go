@@ -0,0 +1,234 @@
+package gltf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Component types
+const (
+	typeByte          = 5120
+	typeUnsignedByte  = 5121
+	typeShort         = 5122
+	typeUnsignedShort = 5123
+	typeUnsignedInt   = 5125
+	typeFloat         = 5126
+)
+
+func componentSize(t int) int {
+	switch t {
+	case typeByte, typeUnsignedByte:
+		return 1
+	case typeShort, typeUnsignedShort:
+		return 2
+	case typeUnsignedInt, typeFloat:
+		return 4
+	}
+	return 0
+}
+
+// shape returns the rows and columns of an accessor type.
+func shape(t string) (rows, cols int) {
+	switch t {
+	case "SCALAR":
+		return 1, 1
+	case "VEC2":
+		return 2, 1
+	case "VEC3":
+		return 3, 1
+	case "VEC4":
+		return 4, 1
+	case "MAT2":
+		return 2, 2
+	case "MAT3":
+		return 3, 3
+	case "MAT4":
+		return 4, 4
+	}
+	return 0, 0
+}
+
+// component decodes a single value. Normalized integers map to [0, 1] or
+// [-1, 1].
+func component(b []byte, t int, normalized bool) float64 {
+	switch t {
+	case typeByte:
+		v := float64(int8(b[0]))
+		if normalized {
+			return math.Max(v/127, -1)
+		}
+		return v
+	case typeUnsignedByte:
+		v := float64(b[0])
+		if normalized {
+			return v / 255
+		}
+		return v
+	case typeShort:
+		v := float64(int16(binary.LittleEndian.Uint16(b)))
+		if normalized {
+			return math.Max(v/32767, -1)
+		}
+		return v
+	case typeUnsignedShort:
+		v := float64(binary.LittleEndian.Uint16(b))
+		if normalized {
+			return v / 65535
+		}
+		return v
+	case typeUnsignedInt:
+		return float64(binary.LittleEndian.Uint32(b))
+	}
+	return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+}
+
+// index decodes an unsigned integer as used for indices.
+func index(b []byte, t int) (uint32, error) {
+	switch t {
+	case typeUnsignedByte:
+		return uint32(b[0]), nil
+	case typeUnsignedShort:
+		return uint32(binary.LittleEndian.Uint16(b)), nil
+	case typeUnsignedInt:
+		return binary.LittleEndian.Uint32(b), nil
+	}
+	return 0, fmt.Errorf("gltf: component type %d can not be used for indices", t)
+}
+
+// maxZeroElements bounds the count of accessors without a buffer view, whose
+// elements are zero and take no space in the file.
+const maxZeroElements = 1 << 24
+
+// accessor returns accessor i and its number of components per element.
+func (d *decoder) accessor(i int) (*accessorJSON, int, error) {
+	if i < 0 || i >= len(d.doc.Accessors) {
+		return nil, 0, fmt.Errorf("gltf: accessor %d out of range", i)
+	}
+	a := &d.doc.Accessors[i]
+	rows, cols := shape(a.Type)
+	if rows == 0 {
+		return nil, 0, fmt.Errorf("gltf: accessor %d has unknown type %q", i, a.Type)
+	}
+	if componentSize(a.ComponentType) == 0 {
+		return nil, 0, fmt.Errorf("gltf: accessor %d has unknown component type %d", i, a.ComponentType)
+	}
+	if a.Count < 0 || a.ByteOffset < 0 {
+		return nil, 0, fmt.Errorf("gltf: accessor %d has negative count or byte offset", i)
+	}
+	if s := a.Sparse; s != nil {
+		if s.Count < 0 || s.Count > a.Count || s.Indices.ByteOffset < 0 || s.Values.ByteOffset < 0 {
+			return nil, 0, fmt.Errorf("gltf: accessor %d has a bad sparse count or byte offset", i)
+		}
+	}
+	// The count sizes the slices read into, so it is checked against the
+	// data before read checks each element
+	if a.BufferView != nil {
+		data, _, err := d.bufferView(*a.BufferView)
+		if err != nil {
+			return nil, 0, err
+		}
+		if a.Count > len(data) {
+			return nil, 0, fmt.Errorf("gltf: accessor %d exceeds buffer view %d", i, *a.BufferView)
+		}
+	} else if a.Count > maxZeroElements {
+		return nil, 0, fmt.Errorf("gltf: accessor %d has %d elements without a buffer view", i, a.Count)
+	}
+	return a, rows * cols, nil
+}
+
+// read calls set for every component of accessor a, numbered element by
+// element. Elements without a buffer view are zero unless a sparse
+// substitution sets them.
+func (d *decoder) read(a *accessorJSON, set func(j int, v float64)) error {
+	rows, cols := shape(a.Type)
+	size := componentSize(a.ComponentType)
+	// Matrix columns start on 4 byte boundaries
+	colStride := rows * size
+	if cols > 1 {
+		colStride = (colStride + 3) &^ 3
+	}
+	elemSize := cols * colStride
+	n := rows * cols
+
+	readElement := func(data []byte, e int) {
+		for c := 0; c < cols; c++ {
+			for r := 0; r < rows; r++ {
+				set(e*n+c*rows+r, component(data[c*colStride+r*size:], a.ComponentType, a.Normalized))
+			}
+		}
+	}
+
+	if a.BufferView != nil {
+		data, stride, err := d.bufferView(*a.BufferView)
+		if err != nil {
+			return err
+		}
+		if stride == 0 {
+			stride = elemSize
+		}
+		if stride < elemSize {
+			return fmt.Errorf("gltf: buffer view %d has a byte stride of %d, less than the %d bytes of an element", *a.BufferView, stride, elemSize)
+		}
+		// Written not to overflow with counts and offsets from the file
+		if a.Count > 0 && (a.ByteOffset > len(data)-elemSize || a.Count-1 > (len(data)-elemSize-a.ByteOffset)/stride) {
+			return fmt.Errorf("gltf: accessor exceeds buffer view %d", *a.BufferView)
+		}
+		for e := 0; e < a.Count; e++ {
+			readElement(data[a.ByteOffset+e*stride:], e)
+		}
+	}
+
+	if s := a.Sparse; s != nil {
+		indices, _, err := d.bufferView(s.Indices.BufferView)
+		if err != nil {
+			return err
+		}
+		values, _, err := d.bufferView(s.Values.BufferView)
+		if err != nil {
+			return err
+		}
+		isize := componentSize(s.Indices.ComponentType)
+		if isize == 0 || s.Indices.ByteOffset > len(indices) || s.Count > (len(indices)-s.Indices.ByteOffset)/isize ||
+			s.Values.ByteOffset > len(values) || s.Count > (len(values)-s.Values.ByteOffset)/elemSize {
+			return fmt.Errorf("gltf: bad sparse accessor")
+		}
+		for k := 0; k < s.Count; k++ {
+			e, err := index(indices[s.Indices.ByteOffset+k*isize:], s.Indices.ComponentType)
+			if err != nil {
+				return err
+			}
+			if int(e) >= a.Count {
+				return fmt.Errorf("gltf: sparse index %d out of range", e)
+			}
+			readElement(values[s.Values.ByteOffset+k*elemSize:], int(e))
+		}
+	}
+	return nil
+}
+
+// readFloats returns the content of accessor i and its number of components
+// per element. Matrices are stored column by column as in the file.
+func (d *decoder) readFloats(i int) ([]float32, int, error) {
+	a, n, err := d.accessor(i)
+	if err != nil {
+		return nil, 0, err
+	}
+	out := make([]float32, a.Count*n)
+	err = d.read(a, func(j int, v float64) { out[j] = float32(v) })
+	return out, n, err
+}
+
+// readIndices returns the content of a scalar integer accessor.
+func (d *decoder) readIndices(i int) ([]uint32, error) {
+	a, n, err := d.accessor(i)
+	if err != nil {
+		return nil, err
+	}
+	if n != 1 || a.ComponentType == typeFloat || a.ComponentType == typeByte || a.ComponentType == typeShort {
+		return nil, fmt.Errorf("gltf: accessor %d does not hold indices", i)
+	}
+	out := make([]uint32, a.Count)
+	err = d.read(a, func(j int, v float64) { out[j] = uint32(v) })
+	return out, err
+}
@@ -0,0 +1,186 @@
+package gltf
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+type Animation struct {
+	Name     string
+	Channels []*Channel
+}
+
+// Channel animates one property of a node. Path is "translation",
+// "rotation", "scale" or "weights".
+type Channel struct {
+	Node    *Node
+	Path    string
+	Sampler *AnimationSampler
+}
+
+// AnimationSampler holds key frames. For "CUBICSPLINE" interpolation every
+// key has an in tangent, a value and an out tangent.
+type AnimationSampler struct {
+	Times  []float32
+	Values []float32
+	// Size is the number of components of a value.
+	Size int
+	// Interpolation is "LINEAR", "STEP" or "CUBICSPLINE".
+	Interpolation string
+}
+
+func (d *decoder) animation(m *Model, aj animationJSON) (*Animation, error) {
+	a := &Animation{Name: aj.Name}
+	samplers := make([]*AnimationSampler, len(aj.Samplers))
+	for i, sj := range aj.Samplers {
+		s := &AnimationSampler{Interpolation: sj.Interpolation}
+		if s.Interpolation == "" {
+			s.Interpolation = "LINEAR"
+		}
+		var err error
+		if s.Times, _, err = d.readFloats(sj.Input); err != nil {
+			return nil, err
+		}
+		if s.Values, _, err = d.readFloats(sj.Output); err != nil {
+			return nil, err
+		}
+		keys := len(s.Times)
+		if s.Interpolation == "CUBICSPLINE" {
+			keys *= 3
+		}
+		if keys == 0 || len(s.Values)%keys != 0 {
+			return nil, fmt.Errorf("sampler %d: %d values for %d keys", i, len(s.Values), len(s.Times))
+		}
+		s.Size = len(s.Values) / keys
+		samplers[i] = s
+	}
+	for i, cj := range aj.Channels {
+		if cj.Sampler < 0 || cj.Sampler >= len(samplers) {
+			return nil, fmt.Errorf("channel %d: sampler out of range", i)
+		}
+		c := &Channel{Path: cj.Target.Path, Sampler: samplers[cj.Sampler]}
+		if cj.Target.Node == nil {
+			// Targets defined by extensions
+			continue
+		}
+		if *cj.Target.Node < 0 || *cj.Target.Node >= len(m.Nodes) {
+			return nil, fmt.Errorf("channel %d: node out of range", i)
+		}
+		c.Node = m.Nodes[*cj.Target.Node]
+		a.Channels = append(a.Channels, c)
+	}
+	return a, nil
+}
+
+// Duration returns the time of the last key frame.
+func (a *Animation) Duration() float32 {
+	var d float32
+	for _, c := range a.Channels {
+		if t := c.Sampler.Times; len(t) > 0 && t[len(t)-1] > d {
+			d = t[len(t)-1]
+		}
+	}
+	return d
+}
+
+// Apply poses the animated nodes at time t. Morph target weights are not
+// applied.
+func (a *Animation) Apply(t float32) {
+	for _, c := range a.Channels {
+		n := c.Node
+		switch c.Path {
+		case "translation":
+			c.Sampler.Sample(t, n.Translation, false)
+		case "rotation":
+			c.Sampler.Sample(t, n.Rotation[:], true)
+		case "scale":
+			c.Sampler.Sample(t, n.Scale, false)
+		default:
+			continue
+		}
+		// Animated nodes are always given by TRS
+		n.Matrix = nil
+	}
+}
+
+// Sample stores the value at time t in out. Times before the first or after
+// the last key are clamped. If rotation is set the values are quaternions,
+// which are interpolated spherically.
+func (s *AnimationSampler) Sample(t float32, out []float32, rotation bool) {
+	n := s.Size
+	cubic := s.Interpolation == "CUBICSPLINE"
+	value := func(k int) []float32 {
+		if cubic {
+			return s.Values[(3*k+1)*n : (3*k+2)*n]
+		}
+		return s.Values[k*n : (k+1)*n]
+	}
+
+	// k is the last key at or before t
+	k := sort.Search(len(s.Times), func(i int) bool { return s.Times[i] > t }) - 1
+	if k < 0 {
+		copy(out, value(0))
+		return
+	}
+	if k >= len(s.Times)-1 || s.Interpolation == "STEP" {
+		copy(out, value(k))
+		return
+	}
+	dt := s.Times[k+1] - s.Times[k]
+	u := (t - s.Times[k]) / dt
+	v0, v1 := value(k), value(k+1)
+
+	switch {
+	case cubic:
+		b0 := s.Values[(3*k+2)*n : (3*k+3)*n]
+		a1 := s.Values[(3*k+3)*n : (3*k+4)*n]
+		u2, u3 := u*u, u*u*u
+		for i := 0; i < n && i < len(out); i++ {
+			out[i] = (2*u3-3*u2+1)*v0[i] + (u3-2*u2+u)*dt*b0[i] + (-2*u3+3*u2)*v1[i] + (u3-u2)*dt*a1[i]
+		}
+		if rotation {
+			normalize(out)
+		}
+	case rotation:
+		slerp(out, v0, v1, u)
+	default:
+		for i := 0; i < n && i < len(out); i++ {
+			out[i] = v0[i] + (v1[i]-v0[i])*u
+		}
+	}
+}
+
+// slerp interpolates the unit quaternions a and b along the shorter arc.
+func slerp(out, a, b []float32, u float32) {
+	dot := a[0]*b[0] + a[1]*b[1] + a[2]*b[2] + a[3]*b[3]
+	sign := float32(1)
+	if dot < 0 {
+		dot, sign = -dot, -1
+	}
+	wa, wb := 1-u, u
+	if dot < 0.9995 {
+		theta := math.Acos(float64(dot))
+		sin := math.Sin(theta)
+		wa = float32(math.Sin(float64(1-u)*theta) / sin)
+		wb = float32(math.Sin(float64(u)*theta) / sin)
+	}
+	for i := 0; i < 4; i++ {
+		out[i] = wa*a[i] + sign*wb*b[i]
+	}
+	normalize(out)
+}
+
+func normalize(q []float32) {
+	var l float32
+	for _, c := range q {
+		l += c * c
+	}
+	if l == 0 {
+		return
+	}
+	l = float32(1 / math.Sqrt(float64(l)))
+	for i := range q {
+		q[i] *= l
+	}
+}
@@ -0,0 +1,374 @@
+package gltf
+
+import (
+	"fmt"
+
+	"color"
+	"math3d"
+	"mesh"
+)
+
+// Primitive modes
+const (
+	modeTriangles     = 4
+	modeTriangleStrip = 5
+	modeTriangleFan   = 6
+)
+
+// Vertex attributes imported into meshes. Only the first set of texture
+// coordinates, colors and joints is used.
+var attributes = []struct {
+	name     string
+	semantic mesh.Semantic
+}{
+	{"POSITION", mesh.Position},
+	{"NORMAL", mesh.Normal},
+	{"TANGENT", mesh.Tangent},
+	{"TEXCOORD_0", mesh.TexCoord},
+	{"COLOR_0", mesh.Color},
+	{"JOINTS_0", mesh.Joints},
+	{"WEIGHTS_0", mesh.Weights},
+}
+
+// model builds the Model from the parsed document.
+func (d *decoder) model() (*Model, error) {
+	doc := &d.doc
+	m := &Model{}
+
+	for i, im := range doc.Images {
+		img, err := d.image(i, im)
+		if err != nil {
+			return nil, err
+		}
+		m.Images = append(m.Images, img)
+	}
+	for i, t := range doc.Textures {
+		tex := &Texture{Sampler: Sampler{WrapS: 10497, WrapT: 10497}} // GL_REPEAT
+		if t.Source != nil {
+			if *t.Source < 0 || *t.Source >= len(m.Images) {
+				return nil, fmt.Errorf("gltf: texture %d: image out of range", i)
+			}
+			tex.Image = m.Images[*t.Source]
+		}
+		if t.Sampler != nil {
+			if *t.Sampler < 0 || *t.Sampler >= len(doc.Samplers) {
+				return nil, fmt.Errorf("gltf: texture %d: sampler out of range", i)
+			}
+			s := doc.Samplers[*t.Sampler]
+			tex.Sampler.MagFilter, tex.Sampler.MinFilter = s.MagFilter, s.MinFilter
+			if s.WrapS != 0 {
+				tex.Sampler.WrapS = s.WrapS
+			}
+			if s.WrapT != 0 {
+				tex.Sampler.WrapT = s.WrapT
+			}
+		}
+		m.Textures = append(m.Textures, tex)
+	}
+	for i, mj := range doc.Materials {
+		mat, err := d.material(m, mj)
+		if err != nil {
+			return nil, fmt.Errorf("gltf: material %d: %s", i, err)
+		}
+		m.Materials = append(m.Materials, mat)
+	}
+	for i, mj := range doc.Meshes {
+		me := &Mesh{Name: mj.Name}
+		for p, pj := range mj.Primitives {
+			prim, err := d.primitive(pj)
+			if err != nil {
+				return nil, fmt.Errorf("gltf: mesh %d primitive %d: %s", i, p, err)
+			}
+			if prim == nil {
+				continue
+			}
+			if pj.Material != nil {
+				if *pj.Material < 0 || *pj.Material >= len(m.Materials) {
+					return nil, fmt.Errorf("gltf: mesh %d primitive %d: material out of range", i, p)
+				}
+				prim.Material = m.Materials[*pj.Material]
+			}
+			me.Primitives = append(me.Primitives, prim)
+		}
+		m.Meshes = append(m.Meshes, me)
+	}
+	for _, cj := range doc.Cameras {
+		c := &Camera{Name: cj.Name}
+		switch {
+		case cj.Perspective != nil:
+			p := cj.Perspective
+			c.Perspective = true
+			c.YFov, c.AspectRatio, c.ZNear = p.YFov, p.AspectRatio, p.ZNear
+			if p.ZFar != nil {
+				c.ZFar = *p.ZFar
+			}
+		case cj.Orthographic != nil:
+			o := cj.Orthographic
+			c.XMag, c.YMag, c.ZNear, c.ZFar = o.XMag, o.YMag, o.ZNear, o.ZFar
+		}
+		m.Cameras = append(m.Cameras, c)
+	}
+
+	// Nodes first, so skins and animations can point at them
+	for _, nj := range doc.Nodes {
+		n := &Node{Name: nj.Name, Translation: math3d.Vector3{0, 0, 0}, Rotation: [4]float32{0, 0, 0, 1}, Scale: math3d.Vector3{1, 1, 1}}
+		switch {
+		case len(nj.Matrix) == 16:
+			n.Matrix = columnMajor(nj.Matrix)
+		case len(nj.Matrix) != 0:
+			return nil, fmt.Errorf("gltf: node %q: matrix has %d values", nj.Name, len(nj.Matrix))
+		}
+		if len(nj.Translation) == 3 {
+			copy(n.Translation, nj.Translation)
+		}
+		if len(nj.Rotation) == 4 {
+			copy(n.Rotation[:], nj.Rotation)
+		}
+		if len(nj.Scale) == 3 {
+			copy(n.Scale, nj.Scale)
+		}
+		m.Nodes = append(m.Nodes, n)
+	}
+	for i, nj := range doc.Nodes {
+		n := m.Nodes[i]
+		for _, c := range nj.Children {
+			if c < 0 || c >= len(m.Nodes) || m.Nodes[c].Parent != nil || c == i {
+				return nil, fmt.Errorf("gltf: node %d: bad child %d", i, c)
+			}
+			m.Nodes[c].Parent = n
+			n.Children = append(n.Children, m.Nodes[c])
+		}
+		if nj.Mesh != nil {
+			if *nj.Mesh < 0 || *nj.Mesh >= len(m.Meshes) {
+				return nil, fmt.Errorf("gltf: node %d: mesh out of range", i)
+			}
+			n.Mesh = m.Meshes[*nj.Mesh]
+		}
+		if nj.Camera != nil {
+			if *nj.Camera < 0 || *nj.Camera >= len(m.Cameras) {
+				return nil, fmt.Errorf("gltf: node %d: camera out of range", i)
+			}
+			n.Camera = m.Cameras[*nj.Camera]
+		}
+	}
+	// A child list which loops back to an ancestor leaves the loop without
+	// a root
+	for i, n := range m.Nodes {
+		seen := 0
+		for p := n.Parent; p != nil; p = p.Parent {
+			if seen++; seen > len(m.Nodes) {
+				return nil, fmt.Errorf("gltf: node %d is part of a cycle", i)
+			}
+		}
+	}
+
+	for i, sj := range doc.Skins {
+		s, err := d.skin(m, sj)
+		if err != nil {
+			return nil, fmt.Errorf("gltf: skin %d: %s", i, err)
+		}
+		m.Skins = append(m.Skins, s)
+	}
+	for i, nj := range doc.Nodes {
+		if nj.Skin != nil {
+			if *nj.Skin < 0 || *nj.Skin >= len(m.Skins) {
+				return nil, fmt.Errorf("gltf: node %d: skin out of range", i)
+			}
+			m.Nodes[i].Skin = m.Skins[*nj.Skin]
+		}
+	}
+	for i, aj := range doc.Animations {
+		a, err := d.animation(m, aj)
+		if err != nil {
+			return nil, fmt.Errorf("gltf: animation %d: %s", i, err)
+		}
+		m.Animations = append(m.Animations, a)
+	}
+
+	for i, sj := range doc.Scenes {
+		s := &Scene{Name: sj.Name}
+		for _, n := range sj.Nodes {
+			if n < 0 || n >= len(m.Nodes) || m.Nodes[n].Parent != nil {
+				return nil, fmt.Errorf("gltf: scene %d: bad root node %d", i, n)
+			}
+			s.Nodes = append(s.Nodes, m.Nodes[n])
+		}
+		m.Scenes = append(m.Scenes, s)
+	}
+	if doc.Scene != nil {
+		if *doc.Scene < 0 || *doc.Scene >= len(m.Scenes) {
+			return nil, fmt.Errorf("gltf: scene %d out of range", *doc.Scene)
+		}
+		m.Scene = m.Scenes[*doc.Scene]
+	}
+	return m, nil
+}
+
+// columnMajor converts a glTF matrix to the row major layout of math3d.
+func columnMajor(v []float32) math3d.Matrix4 {
+	return math3d.Matrix4(v).Transposed()
+}
+
+func (d *decoder) material(m *Model, mj materialJSON) (*Material, error) {
+	mat := &Material{
+		Name:        mj.Name,
+		BaseColor:   color.White,
+		Metallic:    1,
+		Roughness:   1,
+		AlphaMode:   "OPAQUE",
+		AlphaCutoff: 0.5,
+		DoubleSided: mj.DoubleSided,
+	}
+	if mj.AlphaMode != "" {
+		mat.AlphaMode = mj.AlphaMode
+	}
+	if mj.AlphaCutoff != nil {
+		mat.AlphaCutoff = *mj.AlphaCutoff
+	}
+	if len(mj.EmissiveFactor) == 3 {
+		copy(mat.Emissive[:], mj.EmissiveFactor)
+	}
+	ref := func(t *textureInfoJSON) (*TextureRef, error) {
+		if t == nil {
+			return nil, nil
+		}
+		if t.Index < 0 || t.Index >= len(m.Textures) {
+			return nil, fmt.Errorf("texture %d out of range", t.Index)
+		}
+		r := &TextureRef{Texture: m.Textures[t.Index], TexCoord: t.TexCoord, Scale: 1}
+		if t.Scale != nil {
+			r.Scale = *t.Scale
+		}
+		if t.Strength != nil {
+			r.Scale = *t.Strength
+		}
+		return r, nil
+	}
+	var err error
+	if pbr := mj.PBRMetallicRoughness; pbr != nil {
+		if len(pbr.BaseColorFactor) == 4 {
+			f := pbr.BaseColorFactor
+			mat.BaseColor = color.Linear(f[0], f[1], f[2], f[3])
+		}
+		if pbr.MetallicFactor != nil {
+			mat.Metallic = *pbr.MetallicFactor
+		}
+		if pbr.RoughnessFactor != nil {
+			mat.Roughness = *pbr.RoughnessFactor
+		}
+		if mat.BaseColorTexture, err = ref(pbr.BaseColorTexture); err != nil {
+			return nil, err
+		}
+		if mat.MetallicRoughness, err = ref(pbr.MetallicRoughnessTexture); err != nil {
+			return nil, err
+		}
+	}
+	if mat.Normal, err = ref(mj.NormalTexture); err != nil {
+		return nil, err
+	}
+	if mat.Occlusion, err = ref(mj.OcclusionTexture); err != nil {
+		return nil, err
+	}
+	if mat.EmissiveTexture, err = ref(mj.EmissiveTexture); err != nil {
+		return nil, err
+	}
+	return mat, nil
+}
+
+// primitive converts a triangle primitive into a mesh. It returns nil for
+// points and lines.
+func (d *decoder) primitive(pj primitiveJSON) (*Primitive, error) {
+	mode := modeTriangles
+	if pj.Mode != nil {
+		mode = *pj.Mode
+	}
+	if mode < modeTriangles {
+		return nil, nil
+	}
+	if mode > modeTriangleFan {
+		return nil, fmt.Errorf("unknown mode %d", mode)
+	}
+	if _, ok := pj.Attributes["POSITION"]; !ok {
+		return nil, fmt.Errorf("no POSITION attribute")
+	}
+
+	m := mesh.New()
+	for _, attr := range attributes {
+		a, ok := pj.Attributes[attr.name]
+		if !ok {
+			continue
+		}
+		data, size, err := d.readFloats(a)
+		if err != nil {
+			return nil, err
+		}
+		m.Add(attr.semantic, size, data)
+	}
+
+	var indices []uint32
+	if pj.Indices != nil {
+		var err error
+		if indices, err = d.readIndices(*pj.Indices); err != nil {
+			return nil, err
+		}
+	} else {
+		indices = make([]uint32, m.VertexCount())
+		for i := range indices {
+			indices[i] = uint32(i)
+		}
+	}
+	switch mode {
+	case modeTriangles:
+		m.Indices = indices[:len(indices)/3*3]
+	case modeTriangleStrip:
+		for i := 0; i+2 < len(indices); i++ {
+			// Every other triangle is flipped to keep the winding
+			if i%2 == 0 {
+				m.Indices = append(m.Indices, indices[i], indices[i+1], indices[i+2])
+			} else {
+				m.Indices = append(m.Indices, indices[i+1], indices[i], indices[i+2])
+			}
+		}
+	case modeTriangleFan:
+		for i := 1; i+1 < len(indices); i++ {
+			m.Indices = append(m.Indices, indices[0], indices[i], indices[i+1])
+		}
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return &Primitive{Mesh: m}, nil
+}
+
+func (d *decoder) skin(m *Model, sj skinJSON) (*Skin, error) {
+	s := &Skin{Name: sj.Name}
+	for _, j := range sj.Joints {
+		if j < 0 || j >= len(m.Nodes) {
+			return nil, fmt.Errorf("joint %d out of range", j)
+		}
+		s.Joints = append(s.Joints, m.Nodes[j])
+	}
+	if sj.Skeleton != nil {
+		if *sj.Skeleton < 0 || *sj.Skeleton >= len(m.Nodes) {
+			return nil, fmt.Errorf("skeleton %d out of range", *sj.Skeleton)
+		}
+		s.Skeleton = m.Nodes[*sj.Skeleton]
+	}
+	if sj.InverseBindMatrices == nil {
+		for range s.Joints {
+			s.InverseBindMatrices = append(s.InverseBindMatrices, math3d.MakeIdentity())
+		}
+		return s, nil
+	}
+	data, n, err := d.readFloats(*sj.InverseBindMatrices)
+	if err != nil {
+		return nil, err
+	}
+	if n != 16 || len(data) < 16*len(s.Joints) {
+		return nil, fmt.Errorf("bad inverse bind matrices")
+	}
+	for i := range s.Joints {
+		s.InverseBindMatrices = append(s.InverseBindMatrices, columnMajor(data[16*i:16*i+16]))
+	}
+	return s, nil
+}
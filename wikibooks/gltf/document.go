@@ -0,0 +1,172 @@
+package gltf
+
+// JSON structure of a glTF 2.0 asset.
+// See https://github.com/KhronosGroup/glTF/tree/master/specification/2.0
+
+type document struct {
+	Asset struct {
+		Version    string `json:"version"`
+		MinVersion string `json:"minVersion"`
+	} `json:"asset"`
+	ExtensionsRequired []string         `json:"extensionsRequired"`
+	Scene              *int             `json:"scene"`
+	Scenes             []sceneJSON      `json:"scenes"`
+	Nodes              []nodeJSON       `json:"nodes"`
+	Meshes             []meshJSON       `json:"meshes"`
+	Accessors          []accessorJSON   `json:"accessors"`
+	BufferViews        []bufferViewJSON `json:"bufferViews"`
+	Buffers            []bufferJSON     `json:"buffers"`
+	Materials          []materialJSON   `json:"materials"`
+	Textures           []textureJSON    `json:"textures"`
+	Images             []imageJSON      `json:"images"`
+	Samplers           []samplerJSON    `json:"samplers"`
+	Cameras            []cameraJSON     `json:"cameras"`
+	Skins              []skinJSON       `json:"skins"`
+	Animations         []animationJSON  `json:"animations"`
+}
+
+type sceneJSON struct {
+	Name  string `json:"name"`
+	Nodes []int  `json:"nodes"`
+}
+
+type nodeJSON struct {
+	Name        string    `json:"name"`
+	Children    []int     `json:"children"`
+	Mesh        *int      `json:"mesh"`
+	Camera      *int      `json:"camera"`
+	Skin        *int      `json:"skin"`
+	Matrix      []float32 `json:"matrix"`
+	Translation []float32 `json:"translation"`
+	Rotation    []float32 `json:"rotation"`
+	Scale       []float32 `json:"scale"`
+}
+
+type meshJSON struct {
+	Name       string          `json:"name"`
+	Primitives []primitiveJSON `json:"primitives"`
+}
+
+type primitiveJSON struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices"`
+	Material   *int           `json:"material"`
+	Mode       *int           `json:"mode"`
+}
+
+type accessorJSON struct {
+	BufferView    *int   `json:"bufferView"`
+	ByteOffset    int    `json:"byteOffset"`
+	ComponentType int    `json:"componentType"`
+	Normalized    bool   `json:"normalized"`
+	Count         int    `json:"count"`
+	Type          string `json:"type"`
+	Sparse        *struct {
+		Count   int `json:"count"`
+		Indices struct {
+			BufferView    int `json:"bufferView"`
+			ByteOffset    int `json:"byteOffset"`
+			ComponentType int `json:"componentType"`
+		} `json:"indices"`
+		Values struct {
+			BufferView int `json:"bufferView"`
+			ByteOffset int `json:"byteOffset"`
+		} `json:"values"`
+	} `json:"sparse"`
+}
+
+type bufferViewJSON struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride"`
+}
+
+type bufferJSON struct {
+	URI        string `json:"uri"`
+	ByteLength int    `json:"byteLength"`
+}
+
+type textureInfoJSON struct {
+	Index    int      `json:"index"`
+	TexCoord int      `json:"texCoord"`
+	Scale    *float32 `json:"scale"`
+	Strength *float32 `json:"strength"`
+}
+
+type materialJSON struct {
+	Name                 string `json:"name"`
+	PBRMetallicRoughness *struct {
+		BaseColorFactor          []float32        `json:"baseColorFactor"`
+		BaseColorTexture         *textureInfoJSON `json:"baseColorTexture"`
+		MetallicFactor           *float32         `json:"metallicFactor"`
+		RoughnessFactor          *float32         `json:"roughnessFactor"`
+		MetallicRoughnessTexture *textureInfoJSON `json:"metallicRoughnessTexture"`
+	} `json:"pbrMetallicRoughness"`
+	NormalTexture    *textureInfoJSON `json:"normalTexture"`
+	OcclusionTexture *textureInfoJSON `json:"occlusionTexture"`
+	EmissiveTexture  *textureInfoJSON `json:"emissiveTexture"`
+	EmissiveFactor   []float32        `json:"emissiveFactor"`
+	AlphaMode        string           `json:"alphaMode"`
+	AlphaCutoff      *float32         `json:"alphaCutoff"`
+	DoubleSided      bool             `json:"doubleSided"`
+}
+
+type textureJSON struct {
+	Sampler *int `json:"sampler"`
+	Source  *int `json:"source"`
+}
+
+type imageJSON struct {
+	Name       string `json:"name"`
+	URI        string `json:"uri"`
+	MimeType   string `json:"mimeType"`
+	BufferView *int   `json:"bufferView"`
+}
+
+type samplerJSON struct {
+	MagFilter int `json:"magFilter"`
+	MinFilter int `json:"minFilter"`
+	WrapS     int `json:"wrapS"`
+	WrapT     int `json:"wrapT"`
+}
+
+type cameraJSON struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Perspective *struct {
+		AspectRatio float32  `json:"aspectRatio"`
+		YFov        float32  `json:"yfov"`
+		ZNear       float32  `json:"znear"`
+		ZFar        *float32 `json:"zfar"`
+	} `json:"perspective"`
+	Orthographic *struct {
+		XMag  float32 `json:"xmag"`
+		YMag  float32 `json:"ymag"`
+		ZNear float32 `json:"znear"`
+		ZFar  float32 `json:"zfar"`
+	} `json:"orthographic"`
+}
+
+type skinJSON struct {
+	Name                string `json:"name"`
+	InverseBindMatrices *int   `json:"inverseBindMatrices"`
+	Skeleton            *int   `json:"skeleton"`
+	Joints              []int  `json:"joints"`
+}
+
+type animationJSON struct {
+	Name     string `json:"name"`
+	Channels []struct {
+		Sampler int `json:"sampler"`
+		Target  struct {
+			Node *int   `json:"node"`
+			Path string `json:"path"`
+		} `json:"target"`
+	} `json:"channels"`
+	Samplers []struct {
+		Input         int    `json:"input"`
+		Output        int    `json:"output"`
+		Interpolation string `json:"interpolation"`
+	} `json:"samplers"`
+}
@@ -0,0 +1,229 @@
+// Package gltf reads glTF 2.0 assets, both .gltf files with external or
+// embedded buffers and binary .glb files.
+package gltf
+
+import (
+	"bytes"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/ioutil"
+
+	"color"
+	"math3d"
+	"mesh"
+)
+
+// Model is the content of a glTF asset. Objects refer to each other by
+// pointer; the slices keep the order of the file.
+type Model struct {
+	Scenes []*Scene
+	// Scene is the scene to show, nil if the file does not say.
+	Scene      *Scene
+	Nodes      []*Node
+	Meshes     []*Mesh
+	Materials  []*Material
+	Textures   []*Texture
+	Images     []*Image
+	Cameras    []*Camera
+	Skins      []*Skin
+	Animations []*Animation
+}
+
+type Scene struct {
+	Name  string
+	Nodes []*Node
+}
+
+// Node is an element of the scene hierarchy. Its local transform is either
+// Matrix or, if Matrix is nil, the product of Translation, Rotation and
+// Scale.
+type Node struct {
+	Name     string
+	Parent   *Node
+	Children []*Node
+	Mesh     *Mesh
+	Camera   *Camera
+	Skin     *Skin
+	Matrix   math3d.Matrix4
+
+	Translation math3d.Vector3
+	// Rotation is the unit quaternion x, y, z, w.
+	Rotation [4]float32
+	Scale    math3d.Vector3
+}
+
+// Local returns the transform from the node to its parent.
+func (n *Node) Local() math3d.Matrix4 {
+	if n.Matrix != nil {
+		return n.Matrix
+	}
+	t, r, s := n.Translation, n.Rotation, n.Scale
+	return math3d.MakeTranslationMatrix(t[0], t[1], t[2]).
+		Multiply(math3d.MakeQuaternionRotationMatrix(r[0], r[1], r[2], r[3])).
+		Multiply(math3d.MakeScaleMatrix(s[0], s[1], s[2]))
+}
+
+// World returns the transform from the node to the scene.
+func (n *Node) World() math3d.Matrix4 {
+	if n.Parent == nil {
+		return n.Local()
+	}
+	return n.Parent.World().Multiply(n.Local())
+}
+
+// Mesh is a glTF mesh, drawn as one draw call per primitive.
+type Mesh struct {
+	Name       string
+	Primitives []*Primitive
+}
+
+// Primitive is a triangle mesh with a single material. Points and lines
+// are not imported.
+type Primitive struct {
+	Mesh *mesh.Mesh
+	// Material is nil for the default material.
+	Material *Material
+}
+
+// Material is a PBR metallic-roughness material. Factors are linear and
+// multiply the texture values.
+type Material struct {
+	Name              string
+	BaseColor         color.Color
+	BaseColorTexture  *TextureRef
+	Metallic          float32
+	Roughness         float32
+	MetallicRoughness *TextureRef
+	Normal            *TextureRef
+	Occlusion         *TextureRef
+	Emissive          [3]float32
+	EmissiveTexture   *TextureRef
+	// AlphaMode is "OPAQUE", "MASK" or "BLEND".
+	AlphaMode   string
+	AlphaCutoff float32
+	DoubleSided bool
+}
+
+// TextureRef is a material's use of a texture.
+type TextureRef struct {
+	Texture *Texture
+	// TexCoord is the index of the TEXCOORD_n set, only 0 is imported.
+	TexCoord int
+	// Scale is the normal map scale, or the occlusion strength.
+	Scale float32
+}
+
+type Texture struct {
+	Image   *Image
+	Sampler Sampler
+}
+
+// Sampler holds OpenGL filter and wrap enums.
+type Sampler struct {
+	MagFilter, MinFilter int
+	WrapS, WrapT         int
+}
+
+// Image is an encoded PNG or JPEG image, either embedded in the asset or
+// stored next to it at Path.
+type Image struct {
+	Name     string
+	MimeType string
+	Data     []byte
+	Path     string
+}
+
+// Decode decodes the image.
+func (i *Image) Decode() (image.Image, error) {
+	data := i.Data
+	if data == nil {
+		var err error
+		if data, err = ioutil.ReadFile(i.Path); err != nil {
+			return nil, err
+		}
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+type Camera struct {
+	Name        string
+	Perspective bool
+	// YFov is the vertical field of view in radians. AspectRatio is 0 if
+	// the viewport decides.
+	YFov        float32
+	AspectRatio float32
+	// XMag and YMag are half the width and height of an orthographic view.
+	XMag, YMag  float32
+	ZNear, ZFar float32
+}
+
+// Projection returns the projection matrix for a viewport of the given
+// aspect ratio, used unless the camera fixes its own.
+func (c *Camera) Projection(aspect float32) math3d.Matrix4 {
+	if !c.Perspective {
+		n, f := c.ZNear, c.ZFar
+		return math3d.Matrix4{
+			1 / c.XMag, 0, 0, 0,
+			0, 1 / c.YMag, 0, 0,
+			0, 0, 2 / (n - f), (f + n) / (n - f),
+			0, 0, 0, 1,
+		}
+	}
+	if c.AspectRatio != 0 {
+		aspect = c.AspectRatio
+	}
+	if c.ZFar == 0 {
+		// Infinite far plane
+		m := math3d.MakePerspectiveMatrix(c.YFov/2, aspect, c.ZNear, 1)
+		m[10], m[11] = -1, -2*c.ZNear
+		return m
+	}
+	return math3d.MakePerspectiveMatrix(c.YFov/2, aspect, c.ZNear, c.ZFar)
+}
+
+// Skin binds mesh vertices to joint nodes.
+type Skin struct {
+	Name     string
+	Joints   []*Node
+	Skeleton *Node
+	// InverseBindMatrices take mesh space to the space of each joint in
+	// the bind pose.
+	InverseBindMatrices []math3d.Matrix4
+}
+
+// JointMatrices returns the current joint matrices for skinning a mesh of
+// the given node. The results take bind pose mesh space to the mesh node's
+// space.
+func (s *Skin) JointMatrices(node *Node) []math3d.Matrix4 {
+	inverse := invert(node.World())
+	out := make([]math3d.Matrix4, len(s.Joints))
+	for i, j := range s.Joints {
+		out[i] = inverse.Multiply(j.World()).Multiply(s.InverseBindMatrices[i])
+	}
+	return out
+}
+
+// invert inverts an affine transform.
+func invert(m math3d.Matrix4) math3d.Matrix4 {
+	a, b, c := m[0], m[1], m[2]
+	d, e, f := m[4], m[5], m[6]
+	g, h, i := m[8], m[9], m[10]
+	A, B, C := e*i-f*h, f*g-d*i, d*h-e*g
+	det := a*A + b*B + c*C
+	if det == 0 {
+		return math3d.MakeIdentity()
+	}
+	r := 1 / det
+	inv := math3d.Matrix4{
+		A * r, (c*h - b*i) * r, (b*f - c*e) * r, 0,
+		B * r, (a*i - c*g) * r, (c*d - a*f) * r, 0,
+		C * r, (b*g - a*h) * r, (a*e - b*d) * r, 0,
+		0, 0, 0, 1,
+	}
+	for row := 0; row < 3; row++ {
+		inv[4*row+3] = -(inv[4*row]*m[3] + inv[4*row+1]*m[7] + inv[4*row+2]*m[11])
+	}
+	return inv
+}
@@ -0,0 +1,209 @@
+package gltf
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	glbMagic     = 0x46546c67 // "glTF"
+	glbChunkJSON = 0x4e4f534a
+	glbChunkBIN  = 0x004e4942
+)
+
+// Load reads a .gltf or .glb file. External buffers and images are looked up
+// relative to the file.
+func Load(name string) (*Model, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f, filepath.Dir(name))
+}
+
+// Parse reads a glTF asset in JSON or binary form. URIs which are not data
+// URIs are resolved against dir.
+func Parse(r io.Reader, dir string) (*Model, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var bin []byte
+	if len(data) >= 4 && binary.LittleEndian.Uint32(data) == glbMagic {
+		if data, bin, err = parseGLB(data); err != nil {
+			return nil, err
+		}
+	}
+	d := &decoder{dir: dir, bin: bin}
+	if err := json.Unmarshal(data, &d.doc); err != nil {
+		return nil, fmt.Errorf("gltf: %s", err)
+	}
+	if !strings.HasPrefix(d.doc.Asset.Version, "2.") {
+		return nil, fmt.Errorf("gltf: unsupported version %q", d.doc.Asset.Version)
+	}
+	if len(d.doc.ExtensionsRequired) > 0 {
+		return nil, fmt.Errorf("gltf: required extensions %v are not supported", d.doc.ExtensionsRequired)
+	}
+	if err := d.loadBuffers(); err != nil {
+		return nil, err
+	}
+	return d.model()
+}
+
+// parseGLB splits a binary file into its JSON and BIN chunks.
+func parseGLB(data []byte) (jsonChunk, bin []byte, err error) {
+	if len(data) < 20 {
+		return nil, nil, errors.New("gltf: truncated glb header")
+	}
+	if v := binary.LittleEndian.Uint32(data[4:]); v != 2 {
+		return nil, nil, fmt.Errorf("gltf: unsupported glb version %d", v)
+	}
+	length := int(binary.LittleEndian.Uint32(data[8:]))
+	if length < 12 || length > len(data) {
+		return nil, nil, errors.New("gltf: truncated glb file")
+	}
+	data = data[12:length]
+	for len(data) >= 8 {
+		size := int(binary.LittleEndian.Uint32(data))
+		kind := binary.LittleEndian.Uint32(data[4:])
+		if size > len(data)-8 {
+			return nil, nil, errors.New("gltf: truncated glb chunk")
+		}
+		chunk := data[8 : 8+size]
+		switch {
+		case kind == glbChunkJSON && jsonChunk == nil:
+			jsonChunk = chunk
+		case kind == glbChunkBIN && bin == nil:
+			bin = chunk
+		}
+		// Unknown chunks are skipped
+		data = data[8+size:]
+	}
+	if jsonChunk == nil {
+		return nil, nil, errors.New("gltf: glb without JSON chunk")
+	}
+	return jsonChunk, bin, nil
+}
+
+type decoder struct {
+	doc     document
+	dir     string
+	bin     []byte
+	buffers [][]byte
+}
+
+func (d *decoder) loadBuffers() error {
+	d.buffers = make([][]byte, len(d.doc.Buffers))
+	for i, b := range d.doc.Buffers {
+		var data []byte
+		if b.URI == "" {
+			// The first buffer of a glb file has no URI and lives in the
+			// BIN chunk
+			if i != 0 || d.bin == nil {
+				return fmt.Errorf("gltf: buffer %d has no data", i)
+			}
+			data = d.bin
+		} else {
+			var err error
+			if data, err = d.readURI(b.URI); err != nil {
+				return err
+			}
+		}
+		if b.ByteLength < 0 {
+			return fmt.Errorf("gltf: buffer %d has negative byte length", i)
+		}
+		if len(data) < b.ByteLength {
+			return fmt.Errorf("gltf: buffer %d has %d bytes, want %d", i, len(data), b.ByteLength)
+		}
+		d.buffers[i] = data[:b.ByteLength]
+	}
+	return nil
+}
+
+// readURI returns the content of a data URI or a file relative to dir.
+func (d *decoder) readURI(uri string) ([]byte, error) {
+	if data, ok, err := decodeDataURI(uri); ok {
+		return data, err
+	}
+	return ioutil.ReadFile(d.path(uri))
+}
+
+func (d *decoder) path(uri string) string {
+	name, err := url.PathUnescape(uri)
+	if err != nil {
+		name = uri
+	}
+	return filepath.Join(d.dir, filepath.FromSlash(name))
+}
+
+// decodeDataURI decodes a base64 data URI. ok is false if uri is not a data
+// URI.
+func decodeDataURI(uri string) (data []byte, ok bool, err error) {
+	if !strings.HasPrefix(uri, "data:") {
+		return nil, false, nil
+	}
+	comma := strings.IndexByte(uri, ',')
+	if comma < 0 || !strings.HasSuffix(uri[:comma], ";base64") {
+		return nil, true, errors.New("gltf: data URI is not base64 encoded")
+	}
+	data, err = base64.StdEncoding.DecodeString(uri[comma+1:])
+	if err != nil {
+		return nil, true, fmt.Errorf("gltf: data URI: %s", err)
+	}
+	return data, true, nil
+}
+
+// bufferView returns the bytes of a buffer view.
+func (d *decoder) bufferView(i int) ([]byte, int, error) {
+	if i < 0 || i >= len(d.doc.BufferViews) {
+		return nil, 0, fmt.Errorf("gltf: buffer view %d out of range", i)
+	}
+	v := d.doc.BufferViews[i]
+	if v.Buffer < 0 || v.Buffer >= len(d.buffers) {
+		return nil, 0, fmt.Errorf("gltf: buffer %d out of range", v.Buffer)
+	}
+	b := d.buffers[v.Buffer]
+	if v.ByteOffset < 0 || v.ByteLength < 0 || v.ByteOffset > len(b) || v.ByteLength > len(b)-v.ByteOffset {
+		return nil, 0, fmt.Errorf("gltf: buffer view %d exceeds its buffer", i)
+	}
+	if v.ByteStride < 0 {
+		return nil, 0, fmt.Errorf("gltf: buffer view %d has negative byte stride", i)
+	}
+	return b[v.ByteOffset : v.ByteOffset+v.ByteLength], v.ByteStride, nil
+}
+
+func (d *decoder) image(i int, im imageJSON) (*Image, error) {
+	img := &Image{Name: im.Name, MimeType: im.MimeType}
+	switch {
+	case im.BufferView != nil:
+		data, _, err := d.bufferView(*im.BufferView)
+		if err != nil {
+			return nil, err
+		}
+		img.Data = data
+	case strings.HasPrefix(im.URI, "data:"):
+		data, _, err := decodeDataURI(im.URI)
+		if err != nil {
+			return nil, err
+		}
+		img.Data = data
+	case im.URI != "":
+		img.Path = d.path(im.URI)
+	default:
+		return nil, fmt.Errorf("gltf: image %d has no data", i)
+	}
+	if img.Name == "" && img.Path != "" {
+		img.Name = filepath.Base(img.Path)
+	}
+	return img, nil
+}
@@ -0,0 +1,118 @@
+package gltf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type object map[string]interface{}
+
+// triangle returns a document of one triangle: accessor 0 holds the
+// positions, accessor 1 the indices and accessor 2 normals which are zero
+// but for the sparse substitution of the first by the first position.
+func triangle() object {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, []float32{0, 0, 0, 1, 0, 0, 0, 1, 0})
+	binary.Write(&buf, binary.LittleEndian, []uint16{0, 1, 2, 0})
+	uri := "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+	return object{
+		"asset":   object{"version": "2.0"},
+		"buffers": []object{{"uri": uri, "byteLength": buf.Len()}},
+		"bufferViews": []object{
+			{"buffer": 0, "byteOffset": 0, "byteLength": 36},
+			{"buffer": 0, "byteOffset": 36, "byteLength": 6},
+		},
+		"accessors": []object{
+			{"bufferView": 0, "componentType": typeFloat, "count": 3, "type": "VEC3"},
+			{"bufferView": 1, "componentType": typeUnsignedShort, "count": 3, "type": "SCALAR"},
+			{"componentType": typeFloat, "count": 3, "type": "VEC3", "sparse": object{
+				"count":   1,
+				"indices": object{"bufferView": 1, "componentType": typeUnsignedShort},
+				"values":  object{"bufferView": 0},
+			}},
+		},
+		"meshes": []object{{"primitives": []object{{
+			"attributes": object{"POSITION": 0, "NORMAL": 2},
+			"indices":    1,
+		}}}},
+	}
+}
+
+func parse(t *testing.T, doc object) (*Model, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Parse(bytes.NewReader(data), "")
+}
+
+func TestParseTriangle(t *testing.T) {
+	m, err := parse(t, triangle())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Meshes) != 1 || len(m.Meshes[0].Primitives) != 1 {
+		t.Fatalf("got %d meshes, want 1 with 1 primitive", len(m.Meshes))
+	}
+	p := m.Meshes[0].Primitives[0].Mesh
+	if p.VertexCount() != 3 || len(p.Indices) != 3 {
+		t.Errorf("got %d vertices and %d indices, want 3 and 3", p.VertexCount(), len(p.Indices))
+	}
+}
+
+func accessor(doc object, i int) object {
+	return doc["accessors"].([]object)[i]
+}
+
+func bufferView(doc object, i int) object {
+	return doc["bufferViews"].([]object)[i]
+}
+
+func sparse(doc object) object {
+	return accessor(doc, 2)["sparse"].(object)
+}
+
+// Malformed sizes and offsets used to panic when slicing or allocating.
+func TestParseMalformed(t *testing.T) {
+	const huge = 1 << 62
+	tests := []struct {
+		name   string
+		change func(doc object)
+	}{
+		{"negative count", func(doc object) { accessor(doc, 0)["count"] = -1 }},
+		{"huge count", func(doc object) { accessor(doc, 0)["count"] = huge }},
+		{"count past the view", func(doc object) { accessor(doc, 0)["count"] = 4 }},
+		{"huge count without a view", func(doc object) { accessor(doc, 2)["count"] = huge }},
+		{"negative accessor offset", func(doc object) { accessor(doc, 0)["byteOffset"] = -4 }},
+		{"huge accessor offset", func(doc object) { accessor(doc, 0)["byteOffset"] = huge }},
+		{"negative stride", func(doc object) { bufferView(doc, 0)["byteStride"] = -12 }},
+		{"huge stride", func(doc object) { bufferView(doc, 0)["byteStride"] = huge }},
+		{"stride less than an element", func(doc object) { bufferView(doc, 0)["byteStride"] = 4 }},
+		{"negative view offset", func(doc object) { bufferView(doc, 0)["byteOffset"] = -1 }},
+		{"huge view length", func(doc object) { bufferView(doc, 1)["byteLength"] = huge }},
+		{"negative buffer length", func(doc object) { doc["buffers"].([]object)[0]["byteLength"] = -1 }},
+		{"negative sparse count", func(doc object) { sparse(doc)["count"] = -1 }},
+		{"sparse count past the count", func(doc object) { sparse(doc)["count"] = 4 }},
+		{"negative sparse index offset", func(doc object) { sparse(doc)["indices"].(object)["byteOffset"] = -2 }},
+		{"sparse indices past the view", func(doc object) {
+			sparse(doc)["count"] = 3
+			sparse(doc)["indices"].(object)["byteOffset"] = 4
+		}},
+		{"negative sparse value offset", func(doc object) { sparse(doc)["values"].(object)["byteOffset"] = -12 }},
+		{"huge sparse value offset", func(doc object) { sparse(doc)["values"].(object)["byteOffset"] = huge }},
+	}
+	for _, test := range tests {
+		doc := triangle()
+		test.change(doc)
+		_, err := parse(t, doc)
+		if err == nil {
+			t.Errorf("%s: no error", test.name)
+		} else if !strings.HasPrefix(err.Error(), "gltf: ") && !strings.Contains(err.Error(), ": gltf: ") {
+			t.Errorf("%s: error %q without the package prefix", test.name, err)
+		}
+	}
+}
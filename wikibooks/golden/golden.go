@@ -0,0 +1,116 @@
+// Package golden compares rendered frames with checked in golden images,
+// to catch rendering regressions. Test renders a scene with the software
+// device and checks its last frame, or saves it as the new golden image
+// when the test runs with -update.
+package golden
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"strings"
+)
+
+// Options set how far an image may be from its golden image.
+type Options struct {
+	// Threshold is the largest difference of a channel, from 0 to 255,
+	// which still counts as equal.
+	Threshold int
+	// MaxRatio is the share of pixels which may differ by more than
+	// Threshold, e.g. edges which are rasterized a little differently.
+	MaxRatio float64
+}
+
+var DefaultOptions = Options{Threshold: 3, MaxRatio: 0.002}
+
+type Result struct {
+	Differing int
+	Ratio     float64
+	// Diff shows the differing pixels in red on a faded copy of the image.
+	Diff *image.RGBA
+}
+
+// OK reports whether the result is within the tolerance of opts.
+func (r *Result) OK(opts Options) bool {
+	return r.Ratio <= opts.MaxRatio
+}
+
+// Compare compares got with want pixel by pixel.
+func Compare(want, got image.Image, opts Options) (*Result, error) {
+	wb, gb := want.Bounds(), got.Bounds()
+	if wb.Dx() != gb.Dx() || wb.Dy() != gb.Dy() {
+		return nil, fmt.Errorf("golden: image is %dx%d, golden image %dx%d", gb.Dx(), gb.Dy(), wb.Dx(), wb.Dy())
+	}
+	r := &Result{Diff: image.NewRGBA(image.Rect(0, 0, gb.Dx(), gb.Dy()))}
+	for y := 0; y < gb.Dy(); y++ {
+		for x := 0; x < gb.Dx(); x++ {
+			w := color.NRGBAModel.Convert(want.At(wb.Min.X+x, wb.Min.Y+y)).(color.NRGBA)
+			g := color.NRGBAModel.Convert(got.At(gb.Min.X+x, gb.Min.Y+y)).(color.NRGBA)
+			if differs(w, g, opts.Threshold) {
+				r.Differing++
+				r.Diff.SetRGBA(x, y, color.RGBA{255, 0, 0, 255})
+				continue
+			}
+			// Faded gray of the pixel, so the red marks stand out
+			gray := uint8(191 + (int(g.R)+int(g.G)+int(g.B))/12)
+			r.Diff.SetRGBA(x, y, color.RGBA{gray, gray, gray, 255})
+		}
+	}
+	if n := gb.Dx() * gb.Dy(); n > 0 {
+		r.Ratio = float64(r.Differing) / float64(n)
+	}
+	return r, nil
+}
+
+func differs(a, b color.NRGBA, threshold int) bool {
+	d := func(x, y uint8) bool {
+		v := int(x) - int(y)
+		return v > threshold || -v > threshold
+	}
+	return d(a.R, b.R) || d(a.G, b.G) || d(a.B, b.B) || d(a.A, b.A)
+}
+
+// Check compares got with the golden PNG file name. If the images differ
+// too much it writes the difference next to it, with .diff.png in place of
+// .png, and returns an error.
+func Check(name string, got image.Image, opts Options) error {
+	want, err := Load(name)
+	if err != nil {
+		return err
+	}
+	r, err := Compare(want, got, opts)
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	if r.OK(opts) {
+		return nil
+	}
+	diff := strings.TrimSuffix(name, ".png") + ".diff.png"
+	if err := Save(diff, r.Diff); err != nil {
+		return err
+	}
+	return fmt.Errorf("golden: %s: %d pixels (%.2f%%) differ, see %s", name, r.Differing, 100*r.Ratio, diff)
+}
+
+func Load(name string) (image.Image, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+func Save(name string, img image.Image) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
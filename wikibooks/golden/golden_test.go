@@ -0,0 +1,122 @@
+package golden
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// uniform returns a w by h image of c.
+func uniform(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+func TestCompare(t *testing.T) {
+	want := uniform(10, 10, color.RGBA{100, 100, 100, 255})
+	opts := Options{Threshold: 3, MaxRatio: 0.02}
+	tests := []struct {
+		name      string
+		change    func(img *image.RGBA)
+		differing int
+		ok        bool
+	}{
+		{"equal", func(img *image.RGBA) {}, 0, true},
+		{"within threshold", func(img *image.RGBA) {
+			img.SetRGBA(0, 0, color.RGBA{103, 97, 100, 255})
+		}, 0, true},
+		{"alpha", func(img *image.RGBA) {
+			img.SetRGBA(0, 0, color.RGBA{100, 100, 100, 251})
+		}, 1, true},
+		{"within ratio", func(img *image.RGBA) {
+			img.SetRGBA(0, 0, color.RGBA{104, 100, 100, 255})
+			img.SetRGBA(9, 9, color.RGBA{0, 0, 0, 255})
+		}, 2, true},
+		{"beyond ratio", func(img *image.RGBA) {
+			for x := 0; x < 3; x++ {
+				img.SetRGBA(x, 5, color.RGBA{255, 255, 255, 255})
+			}
+		}, 3, false},
+	}
+	for _, test := range tests {
+		got := uniform(10, 10, color.RGBA{100, 100, 100, 255})
+		test.change(got)
+		r, err := Compare(want, got, opts)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if r.Differing != test.differing || r.Ratio != float64(test.differing)/100 || r.OK(opts) != test.ok {
+			t.Errorf("%s: %d pixels (ratio %v) differ and OK is %v, want %d and %v", test.name, r.Differing, r.Ratio, r.OK(opts), test.differing, test.ok)
+		}
+	}
+}
+
+// The diff marks differing pixels red on a light gray copy of the image.
+func TestDiffImage(t *testing.T) {
+	want := uniform(2, 1, color.RGBA{0, 0, 0, 255})
+	// An image which does not start at the origin
+	got := uniform(2, 1, color.RGBA{0, 0, 0, 255})
+	got.Rect = image.Rect(5, 5, 7, 6)
+	got.SetRGBA(6, 5, color.RGBA{255, 255, 255, 255})
+	r, err := Compare(want, got, DefaultOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Diff.Rect != image.Rect(0, 0, 2, 1) {
+		t.Fatalf("diff is %v", r.Diff.Rect)
+	}
+	if c := r.Diff.RGBAAt(0, 0); c != (color.RGBA{191, 191, 191, 255}) {
+		t.Errorf("equal pixel is %v in the diff, want light gray", c)
+	}
+	if c := r.Diff.RGBAAt(1, 0); c != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("differing pixel is %v in the diff, want red", c)
+	}
+}
+
+func TestCompareSizes(t *testing.T) {
+	_, err := Compare(uniform(4, 3, color.RGBA{}), uniform(3, 4, color.RGBA{}), DefaultOptions)
+	if err == nil || !strings.Contains(err.Error(), "image is 3x4, golden image 4x3") {
+		t.Errorf("got error %v, want a size mismatch", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "golden.png")
+	img := uniform(10, 10, color.RGBA{100, 100, 100, 255})
+	if err := Save(name, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := Check(name, img, DefaultOptions); err != nil {
+		t.Error(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "golden.diff.png")); !os.IsNotExist(err) {
+		t.Errorf("diff written for an equal image: %v", err)
+	}
+
+	img.SetRGBA(3, 4, color.RGBA{255, 0, 0, 255})
+	err := Check(name, img, DefaultOptions)
+	if err == nil || !strings.Contains(err.Error(), "1 pixels (1.00%) differ") {
+		t.Errorf("got error %v, want 1 pixel differing", err)
+	}
+	diff, err := Load(filepath.Join(dir, "golden.diff.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, g, b, _ := diff.At(3, 4).RGBA(); r != 0xffff || g != 0 || b != 0 {
+		t.Errorf("differing pixel is %x %x %x in the diff, want red", r, g, b)
+	}
+
+	if err := Check(filepath.Join(dir, "missing.png"), img, DefaultOptions); err == nil {
+		t.Error("missing golden image: no error")
+	}
+	if err := Check(name, uniform(5, 5, color.RGBA{}), DefaultOptions); err == nil {
+		t.Error("image of another size: no error")
+	}
+}
@@ -0,0 +1,56 @@
+package golden
+
+import (
+	"flag"
+	"testing"
+
+	"app"
+	"clock"
+	"device/soft"
+	"input"
+	"raster"
+)
+
+// Update makes Test save the frames as the new golden images.
+var Update = flag.Bool("update", false, "write golden.png instead of comparing with it")
+
+// A Program adds the Go mirror of a shader program to a software device.
+type Program func(d *soft.Device) error
+
+// Files mirrors the shaders in the named files with p.
+func Files(p raster.Program, names ...string) Program {
+	return func(d *soft.Device) error { return d.LoadProgram(p, names...) }
+}
+
+// Sources mirrors the shaders with the given sources with p.
+func Sources(p raster.Program, sources ...string) Program {
+	return func(d *soft.Device) error {
+		d.AddProgram(p, sources...)
+		return nil
+	}
+}
+
+// Test runs s with the software device of width by height pixels for
+// frames frames of 1/60 second, and checks the last with golden.png in
+// the current directory, or saves it there with -update.
+func Test(t *testing.T, s app.Scene, width, height, frames int, programs ...Program) {
+	d := soft.New(width, height)
+	for _, p := range programs {
+		if err := p(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w := &app.Headless{Dev: d, Width: width, Height: height, Frames: frames}
+	if err := app.Loop(s.App(input.New(), input.DefaultActions()), w, app.Config{Clock: clock.NewFixed(0, 1.0/60)}); err != nil {
+		t.Fatal(err)
+	}
+	if *Update {
+		if err := Save("golden.png", d.Image()); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	if err := Check("golden.png", d.Image(), DefaultOptions); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,353 @@
+package hdr
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"io/ioutil"
+	"math"
+	"sort"
+)
+
+// Only single part scanline OpenEXR files are supported, stored either
+// uncompressed or with RLE, ZIPS or ZIP compression.
+// See http://www.openexr.com/openexrfilelayout.pdf for the format.
+
+const exrMagic = "\x76\x2f\x31\x01"
+
+func init() {
+	image.RegisterFormat("exr", exrMagic, DecodeEXR, DecodeEXRConfig)
+}
+
+const (
+	exrUint  = 0
+	exrHalf  = 1
+	exrFloat = 2
+)
+
+const (
+	exrNoCompression   = 0
+	exrRLECompression  = 1
+	exrZIPSCompression = 2
+	exrZIPCompression  = 3
+)
+
+type exrChannel struct {
+	name      string
+	pixelType int32
+}
+
+func (c exrChannel) size() int {
+	if c.pixelType == exrHalf {
+		return 2
+	}
+	return 4
+}
+
+type exrHeader struct {
+	channels    []exrChannel
+	compression byte
+	dataWindow  image.Rectangle
+}
+
+func readCString(r *bufio.Reader) (string, error) {
+	s, err := r.ReadString(0)
+	if err != nil {
+		return "", err
+	}
+	return s[:len(s)-1], nil
+}
+
+func readEXRHeader(r *bufio.Reader) (*exrHeader, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return nil, err
+	}
+	if string(magic[:]) != exrMagic {
+		return nil, errors.New("exr: not an OpenEXR file")
+	}
+	var version uint32
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return nil, err
+	}
+	if version&0xff != 2 {
+		return nil, fmt.Errorf("exr: unsupported version %d", version&0xff)
+	}
+	if version&0x1a00 != 0 {
+		return nil, errors.New("exr: tiled, deep and multi part files are not supported")
+	}
+
+	h := &exrHeader{}
+	haveWindow := false
+	for {
+		name, err := readCString(r)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			break
+		}
+		typ, err := readCString(r)
+		if err != nil {
+			return nil, err
+		}
+		var size int32
+		if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return nil, errors.New("exr: bad attribute size")
+		}
+		value := make([]byte, size)
+		if _, err := io.ReadFull(r, value); err != nil {
+			return nil, err
+		}
+		switch {
+		case name == "channels" && typ == "chlist":
+			if h.channels, err = parseChannelList(value); err != nil {
+				return nil, err
+			}
+		case name == "compression" && typ == "compression" && size == 1:
+			h.compression = value[0]
+		case name == "dataWindow" && typ == "box2i" && size == 16:
+			var box [4]int32
+			binary.Read(bytes.NewReader(value), binary.LittleEndian, &box)
+			h.dataWindow = image.Rect(int(box[0]), int(box[1]), int(box[2])+1, int(box[3])+1)
+			haveWindow = true
+		}
+	}
+	if len(h.channels) == 0 || !haveWindow {
+		return nil, errors.New("exr: missing channels or dataWindow attribute")
+	}
+	if h.dataWindow.Empty() {
+		return nil, errors.New("exr: empty data window")
+	}
+	if tooLarge(h.dataWindow.Dx(), h.dataWindow.Dy()) {
+		return nil, fmt.Errorf("exr: image of %dx%d pixels is too large", h.dataWindow.Dx(), h.dataWindow.Dy())
+	}
+	switch h.compression {
+	case exrNoCompression, exrRLECompression, exrZIPSCompression, exrZIPCompression:
+	default:
+		return nil, fmt.Errorf("exr: unsupported compression %d", h.compression)
+	}
+	return h, nil
+}
+
+func parseChannelList(b []byte) ([]exrChannel, error) {
+	var channels []exrChannel
+	for len(b) > 0 && b[0] != 0 {
+		i := bytes.IndexByte(b, 0)
+		if i < 0 || len(b) < i+1+16 {
+			return nil, errors.New("exr: bad channel list")
+		}
+		c := exrChannel{name: string(b[:i])}
+		b = b[i+1:]
+		c.pixelType = int32(binary.LittleEndian.Uint32(b))
+		xSampling := binary.LittleEndian.Uint32(b[8:])
+		ySampling := binary.LittleEndian.Uint32(b[12:])
+		b = b[16:]
+		if c.pixelType < exrUint || c.pixelType > exrFloat {
+			return nil, fmt.Errorf("exr: unknown pixel type %d", c.pixelType)
+		}
+		if xSampling != 1 || ySampling != 1 {
+			return nil, errors.New("exr: subsampled channels are not supported")
+		}
+		channels = append(channels, c)
+	}
+	// Channels are stored in alphabetical order
+	sort.Sort(byName(channels))
+	return channels, nil
+}
+
+type byName []exrChannel
+
+func (c byName) Len() int           { return len(c) }
+func (c byName) Less(i, j int) bool { return c[i].name < c[j].name }
+func (c byName) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+
+func DecodeEXRConfig(r io.Reader) (image.Config, error) {
+	h, err := readEXRHeader(bufio.NewReader(r))
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: color.RGBA64Model, Width: h.dataWindow.Dx(), Height: h.dataWindow.Dy()}, nil
+}
+
+// DecodeEXR reads an OpenEXR file. It returns an *Image. The R, G, B and A
+// channels are used, a single Y channel is treated as gray. Missing alpha
+// defaults to 1.
+func DecodeEXR(r io.Reader) (image.Image, error) {
+	br := bufio.NewReader(r)
+	h, err := readEXRHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	width, height := h.dataWindow.Dx(), h.dataWindow.Dy()
+	linesPerChunk := 1
+	if h.compression == exrZIPCompression {
+		linesPerChunk = 16
+	}
+	chunks := (height + linesPerChunk - 1) / linesPerChunk
+	// The offset table is not needed when reading the chunks in order
+	if _, err := io.CopyN(ioutil.Discard, br, int64(chunks)*8); err != nil {
+		return nil, err
+	}
+
+	lineSize := 0
+	for _, c := range h.channels {
+		lineSize += width * c.size()
+	}
+	img := NewImage(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		if i%4 == 3 {
+			img.Pix[i] = 1
+		}
+	}
+	for i := 0; i < chunks; i++ {
+		var head [2]int32
+		if err := binary.Read(br, binary.LittleEndian, &head); err != nil {
+			return nil, err
+		}
+		y0 := int(head[0]) - h.dataWindow.Min.Y
+		// Compression never doubles the size of a chunk
+		if head[1] < 0 || int(head[1]) > 2*linesPerChunk*lineSize+64 || y0 < 0 || y0 >= height || y0%linesPerChunk != 0 {
+			return nil, errors.New("exr: bad chunk header")
+		}
+		data := make([]byte, head[1])
+		if _, err := io.ReadFull(br, data); err != nil {
+			return nil, err
+		}
+		lines := height - y0
+		if lines > linesPerChunk {
+			lines = linesPerChunk
+		}
+		if data, err = decompressEXR(h.compression, data, lines*lineSize); err != nil {
+			return nil, err
+		}
+		for l := 0; l < lines; l++ {
+			storeEXRLine(img, h.channels, y0+l, data[l*lineSize:(l+1)*lineSize])
+		}
+	}
+	return img, nil
+}
+
+func storeEXRLine(img *Image, channels []exrChannel, y int, line []byte) {
+	width := img.Rect.Dx()
+	row := img.Pix[y*img.Stride : (y+1)*img.Stride]
+	gray := len(channels) == 1 && channels[0].name == "Y"
+	for _, c := range channels {
+		offset := -1
+		switch c.name {
+		case "R":
+			offset = 0
+		case "G":
+			offset = 1
+		case "B":
+			offset = 2
+		case "A":
+			offset = 3
+		}
+		for x := 0; x < width; x++ {
+			var v float32
+			switch c.pixelType {
+			case exrHalf:
+				v = HalfToFloat(binary.LittleEndian.Uint16(line[2*x:]))
+			case exrFloat:
+				v = math.Float32frombits(binary.LittleEndian.Uint32(line[4*x:]))
+			case exrUint:
+				v = float32(binary.LittleEndian.Uint32(line[4*x:]))
+			}
+			if gray {
+				row[4*x], row[4*x+1], row[4*x+2] = v, v, v
+			} else if offset >= 0 {
+				row[4*x+offset] = v
+			}
+		}
+		line = line[width*c.size():]
+	}
+}
+
+func decompressEXR(compression byte, data []byte, size int) ([]byte, error) {
+	// Chunks which would not get smaller are always stored uncompressed
+	if compression == exrNoCompression || len(data) == size {
+		if len(data) != size {
+			return nil, errors.New("exr: bad chunk size")
+		}
+		return data, nil
+	}
+	var tmp []byte
+	switch compression {
+	case exrRLECompression:
+		tmp = make([]byte, 0, size)
+		for len(data) >= 2 {
+			count := int(int8(data[0]))
+			if count < 0 {
+				if len(data) < 1-count {
+					return nil, errors.New("exr: bad RLE data")
+				}
+				tmp = append(tmp, data[1:1-count]...)
+				data = data[1-count:]
+			} else {
+				for i := 0; i <= count; i++ {
+					tmp = append(tmp, data[1])
+				}
+				data = data[2:]
+			}
+		}
+	case exrZIPSCompression, exrZIPCompression:
+		zr, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		tmp, err = ioutil.ReadAll(zr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(tmp) != size {
+		return nil, errors.New("exr: bad chunk size")
+	}
+	// Undo the delta predictor
+	for i := 1; i < len(tmp); i++ {
+		tmp[i] = byte(int(tmp[i-1]) + int(tmp[i]) - 128)
+	}
+	// Interleave the two halves of the buffer again
+	out := make([]byte, size)
+	half := (size + 1) / 2
+	for i := 0; i < size; i++ {
+		if i%2 == 0 {
+			out[i] = tmp[i/2]
+		} else {
+			out[i] = tmp[half+i/2]
+		}
+	}
+	return out, nil
+}
+
+// HalfToFloat converts an IEEE 754 half precision value to float32.
+func HalfToFloat(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h) & 0x3ff
+	switch {
+	case exp == 0 && mant == 0:
+		return math.Float32frombits(sign)
+	case exp == 0:
+		// Denormalized half, normalize it for float32
+		for mant&0x400 == 0 {
+			mant <<= 1
+			exp--
+		}
+		exp++
+		mant &= 0x3ff
+	case exp == 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	}
+	return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+}
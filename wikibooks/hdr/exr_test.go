@@ -0,0 +1,240 @@
+package hdr
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestHalfToFloat(t *testing.T) {
+	tests := []struct {
+		h uint16
+		f float64
+	}{
+		{0x0000, 0},
+		{0x3c00, 1},
+		{0xc000, -2},
+		{0x3555, 0.333251953125},
+		{0x7bff, 65504},
+		// The smallest normal and the denormals below it
+		{0x0400, 6.103515625e-05},
+		{0x03ff, 6.097555160522461e-05},
+		{0x0001, 5.960464477539063e-08},
+		{0x7c00, math.Inf(1)},
+		{0xfc00, math.Inf(-1)},
+	}
+	for _, test := range tests {
+		if got := HalfToFloat(test.h); float64(got) != test.f {
+			t.Errorf("HalfToFloat(%#04x) = %v, want %v", test.h, got, test.f)
+		}
+	}
+	if got := HalfToFloat(0x8000); got != 0 || !math.Signbit(float64(got)) {
+		t.Errorf("HalfToFloat(0x8000) = %v, want -0", got)
+	}
+	if got := HalfToFloat(0x7e00); !math.IsNaN(float64(got)) {
+		t.Errorf("HalfToFloat(0x7e00) = %v, want NaN", got)
+	}
+}
+
+// exrFile builds a scanline OpenEXR file of the given channels, sorted by
+// name, whose pixel values value returns.
+type exrFile struct {
+	channels    []exrChannel
+	compression byte
+	width       int
+	height      int
+	value       func(c, x, y int) float32
+}
+
+func (f exrFile) attribute(buf *bytes.Buffer, name, typ string, value []byte) {
+	buf.WriteString(name + "\x00" + typ + "\x00")
+	binary.Write(buf, binary.LittleEndian, int32(len(value)))
+	buf.Write(value)
+}
+
+// line returns the pixels of row y, channel by channel.
+func (f exrFile) line(y int) []byte {
+	var b []byte
+	for c, ch := range f.channels {
+		for x := 0; x < f.width; x++ {
+			v := f.value(c, x, y)
+			switch ch.pixelType {
+			case exrHalf:
+				b = append(b, 0, 0)
+				binary.LittleEndian.PutUint16(b[len(b)-2:], floatToHalf(v))
+			case exrFloat:
+				b = append(b, 0, 0, 0, 0)
+				binary.LittleEndian.PutUint32(b[len(b)-4:], math.Float32bits(v))
+			case exrUint:
+				b = append(b, 0, 0, 0, 0)
+				binary.LittleEndian.PutUint32(b[len(b)-4:], uint32(v))
+			}
+		}
+	}
+	return b
+}
+
+// header returns the magic number, version and header.
+func (f exrFile) header() *bytes.Buffer {
+	var buf bytes.Buffer
+	buf.WriteString(exrMagic)
+	binary.Write(&buf, binary.LittleEndian, uint32(2))
+	var chlist bytes.Buffer
+	for _, c := range f.channels {
+		chlist.WriteString(c.name + "\x00")
+		binary.Write(&chlist, binary.LittleEndian, []int32{c.pixelType, 0, 1, 1})
+	}
+	chlist.WriteByte(0)
+	f.attribute(&buf, "channels", "chlist", chlist.Bytes())
+	f.attribute(&buf, "compression", "compression", []byte{f.compression})
+	// The data window need not start at the origin
+	var box bytes.Buffer
+	binary.Write(&box, binary.LittleEndian, []int32{10, 20, int32(10 + f.width - 1), int32(20 + f.height - 1)})
+	f.attribute(&buf, "dataWindow", "box2i", box.Bytes())
+	buf.WriteByte(0)
+	return &buf
+}
+
+func (f exrFile) bytes() []byte {
+	buf := f.header()
+	lines := 1
+	if f.compression == exrZIPCompression {
+		lines = 16
+	}
+	chunks := (f.height + lines - 1) / lines
+	buf.Write(make([]byte, 8*chunks))
+	for y0 := 0; y0 < f.height; y0 += lines {
+		var data []byte
+		for y := y0; y < y0+lines && y < f.height; y++ {
+			data = append(data, f.line(y)...)
+		}
+		data = compressEXR(f.compression, data)
+		binary.Write(buf, binary.LittleEndian, []int32{int32(20 + y0), int32(len(data))})
+		buf.Write(data)
+	}
+	return buf.Bytes()
+}
+
+// compressEXR is the inverse of decompressEXR, which writes RLE data as
+// literal runs only.
+func compressEXR(compression byte, data []byte) []byte {
+	if compression == exrNoCompression {
+		return data
+	}
+	// Split the even and odd bytes and store differences
+	tmp := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i += 2 {
+		tmp = append(tmp, data[i])
+	}
+	for i := 1; i < len(data); i += 2 {
+		tmp = append(tmp, data[i])
+	}
+	for i := len(tmp) - 1; i > 0; i-- {
+		tmp[i] = byte(int(tmp[i]) - int(tmp[i-1]) + 128)
+	}
+	var out bytes.Buffer
+	if compression == exrRLECompression {
+		for len(tmp) > 0 {
+			n := len(tmp)
+			if n > 127 {
+				n = 127
+			}
+			out.WriteByte(byte(-n))
+			out.Write(tmp[:n])
+			tmp = tmp[n:]
+		}
+		return out.Bytes()
+	}
+	zw := zlib.NewWriter(&out)
+	zw.Write(tmp)
+	zw.Close()
+	return out.Bytes()
+}
+
+// floatToHalf rounds v towards zero to a normal half float or 0.
+func floatToHalf(v float32) uint16 {
+	bits := math.Float32bits(v)
+	exp := int(bits>>23&0xff) - 127 + 15
+	if exp <= 0 {
+		return uint16(bits >> 16 & 0x8000)
+	}
+	return uint16(bits>>16&0x8000 | uint32(exp)<<10 | bits>>13&0x3ff)
+}
+
+func TestDecodeEXR(t *testing.T) {
+	// Values a half float holds exactly
+	value := func(c, x, y int) float32 { return float32(c+1) + float32(x)/4 + float32(y)*8 }
+	rgb := []exrChannel{{"B", exrHalf}, {"G", exrFloat}, {"R", exrHalf}}
+	for _, compression := range []byte{exrNoCompression, exrRLECompression, exrZIPSCompression, exrZIPCompression} {
+		f := exrFile{channels: rgb, compression: compression, width: 5, height: 20, value: value}
+		img, format, err := image.Decode(bytes.NewReader(f.bytes()))
+		if err != nil {
+			t.Errorf("compression %d: %v", compression, err)
+			continue
+		}
+		if format != "exr" || img.Bounds() != image.Rect(0, 0, 5, 20) {
+			t.Errorf("compression %d: decoded %s image of %v", compression, format, img.Bounds())
+			continue
+		}
+		for y := 0; y < 20; y++ {
+			for x := 0; x < 5; x++ {
+				r, g, b, a := img.(*Image).RGBAAt(x, y)
+				// Missing alpha is opaque
+				if r != value(2, x, y) || g != value(1, x, y) || b != value(0, x, y) || a != 1 {
+					t.Fatalf("compression %d: pixel %d, %d is %v %v %v %v", compression, x, y, r, g, b, a)
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeEXRGray(t *testing.T) {
+	f := exrFile{channels: []exrChannel{{"Y", exrUint}}, width: 2, height: 1,
+		value: func(c, x, y int) float32 { return float32(x + 3) }}
+	img, err := DecodeEXR(bytes.NewReader(f.bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []float32{3, 3, 3, 1, 4, 4, 4, 1}
+	for i, v := range img.(*Image).Pix {
+		if v != want[i] {
+			t.Fatalf("got %v, want %v", img.(*Image).Pix, want)
+		}
+	}
+}
+
+func TestEXRErrors(t *testing.T) {
+	valid := exrFile{channels: []exrChannel{{"R", exrFloat}}, width: 2, height: 2,
+		value: func(c, x, y int) float32 { return 1 }}
+	tooLarge := valid
+	tooLarge.width, tooLarge.height = 100000, 100000
+	// A chunk claiming a gigabyte of data
+	huge := valid.bytes()
+	binary.LittleEndian.PutUint32(huge[len(huge)-12:], 1<<30)
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{"magic", []byte("\x76\x2f\x31\x02\x02\x00\x00\x00"), "not an OpenEXR file"},
+		{"version", []byte(exrMagic + "\x01\x00\x00\x00"), "unsupported version 1"},
+		{"tiled", []byte(exrMagic + "\x02\x02\x00\x00"), "tiled"},
+		{"too large", tooLarge.header().Bytes(), "too large"},
+		{"truncated", valid.bytes()[:len(valid.bytes())-1], "EOF"},
+		{"chunk size", huge, "bad chunk header"},
+	}
+	for _, test := range tests {
+		_, err := DecodeEXR(bytes.NewReader(test.data))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+		}
+	}
+	config, err := DecodeEXRConfig(bytes.NewReader(valid.bytes()))
+	if err != nil || config.Width != 2 || config.Height != 2 {
+		t.Errorf("config %+v, error %v", config, err)
+	}
+}
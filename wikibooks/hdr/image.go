@@ -0,0 +1,113 @@
+package hdr
+
+import (
+	"image"
+	"image/color"
+)
+
+// Image is a floating point RGBA image. Values are linear and not clamped,
+// so they can exceed 1.0 for bright light sources.
+type Image struct {
+	// Pix holds the pixels as R, G, B, A float32 values, row by row.
+	Pix    []float32
+	Stride int
+	Rect   image.Rectangle
+}
+
+// maxPixels bounds the size of decoded images, so that a corrupt header
+// cannot make a decoder allocate more than 1 GiB.
+const maxPixels = 1 << 26
+
+// tooLarge reports whether a w by h image exceeds maxPixels.
+func tooLarge(w, h int) bool {
+	return w > maxPixels || h > maxPixels || w*h > maxPixels
+}
+
+func NewImage(r image.Rectangle) *Image {
+	w, h := r.Dx(), r.Dy()
+	return &Image{Pix: make([]float32, 4*w*h), Stride: 4 * w, Rect: r}
+}
+
+func (p *Image) ColorModel() color.Model {
+	return color.RGBA64Model
+}
+
+func (p *Image) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// At clamps the pixel to [0, 1]. Use RGBAAt for the unclamped values.
+func (p *Image) At(x, y int) color.Color {
+	r, g, b, a := p.RGBAAt(x, y)
+	return color.RGBA64{clamp16(r * a), clamp16(g * a), clamp16(b * a), clamp16(a)}
+}
+
+func (p *Image) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*4
+}
+
+func (p *Image) RGBAAt(x, y int) (r, g, b, a float32) {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return 0, 0, 0, 0
+	}
+	i := p.PixOffset(x, y)
+	return p.Pix[i], p.Pix[i+1], p.Pix[i+2], p.Pix[i+3]
+}
+
+func (p *Image) SetRGBA(x, y int, r, g, b, a float32) {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return
+	}
+	i := p.PixOffset(x, y)
+	p.Pix[i] = r
+	p.Pix[i+1] = g
+	p.Pix[i+2] = b
+	p.Pix[i+3] = a
+}
+
+// FlipY mirrors the image vertically in place. OpenGL expects the first row
+// of texture data to be the bottom of the image.
+func (p *Image) FlipY() {
+	h := p.Rect.Dy()
+	row := make([]float32, p.Stride)
+	for y := 0; y < h/2; y++ {
+		top := p.Pix[y*p.Stride : (y+1)*p.Stride]
+		bottom := p.Pix[(h-1-y)*p.Stride : (h-y)*p.Stride]
+		copy(row, top)
+		copy(top, bottom)
+		copy(bottom, row)
+	}
+}
+
+// FromImage converts any image into a floating point image. 8 and 16 bit
+// images are mapped to [0, 1] without any gamma decoding.
+func FromImage(img image.Image) *Image {
+	if f, ok := img.(*Image); ok {
+		return f
+	}
+	b := img.Bounds()
+	f := NewImage(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := img.At(x, y).RGBA()
+			if a == 0 {
+				f.SetRGBA(x, y, 0, 0, 0, 0)
+				continue
+			}
+			// Undo the premultiplied alpha of the color package
+			fa := float32(a)
+			f.SetRGBA(x, y, float32(r)/fa, float32(g)/fa, float32(bl)/fa, fa/0xffff)
+		}
+	}
+	return f
+}
+
+func clamp16(v float32) uint16 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 1 {
+		return 0xffff
+	}
+	return uint16(v*0xffff + 0.5)
+}
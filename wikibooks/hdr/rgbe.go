@@ -0,0 +1,266 @@
+package hdr
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"strings"
+)
+
+// Radiance .hdr files store one shared exponent per pixel (RGBE).
+// See http://www.graphics.cornell.edu/~bjw/rgbe.html for the format.
+
+func init() {
+	image.RegisterFormat("hdr", "#?RADIANCE", DecodeRGBE, DecodeRGBEConfig)
+	image.RegisterFormat("hdr", "#?RGBE", DecodeRGBE, DecodeRGBEConfig)
+}
+
+type rgbeHeader struct {
+	width, height int
+	flipX, flipY  bool
+}
+
+func readRGBEHeader(r *bufio.Reader) (*rgbeHeader, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "#?") {
+		return nil, errors.New("hdr: missing #? signature")
+	}
+	// Header lines end with an empty line
+	for {
+		line, err = r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(line, "FORMAT=") && line != "FORMAT=32-bit_rle_rgbe" {
+			return nil, fmt.Errorf("hdr: unsupported format %q", line[len("FORMAT="):])
+		}
+	}
+	// Resolution string, e.g. "-Y 512 +X 768"
+	line, err = r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	var yAxis, xAxis string
+	h := &rgbeHeader{}
+	_, err = fmt.Sscanf(line, "%s %d %s %d", &yAxis, &h.height, &xAxis, &h.width)
+	if err != nil {
+		return nil, fmt.Errorf("hdr: bad resolution string %q", strings.TrimSpace(line))
+	}
+	if len(yAxis) != 2 || len(xAxis) != 2 || yAxis[1] != 'Y' || xAxis[1] != 'X' {
+		return nil, fmt.Errorf("hdr: unsupported orientation %q", strings.TrimSpace(line))
+	}
+	h.flipY = yAxis[0] == '+'
+	h.flipX = xAxis[0] == '-'
+	if h.width <= 0 || h.height <= 0 {
+		return nil, errors.New("hdr: invalid image size")
+	}
+	if tooLarge(h.width, h.height) {
+		return nil, fmt.Errorf("hdr: image of %dx%d pixels is too large", h.width, h.height)
+	}
+	return h, nil
+}
+
+func DecodeRGBEConfig(r io.Reader) (image.Config, error) {
+	h, err := readRGBEHeader(bufio.NewReader(r))
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: color.RGBA64Model, Width: h.width, Height: h.height}, nil
+}
+
+// DecodeRGBE reads a Radiance .hdr file. It returns an *Image.
+func DecodeRGBE(r io.Reader) (image.Image, error) {
+	br := bufio.NewReader(r)
+	h, err := readRGBEHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	img := NewImage(image.Rect(0, 0, h.width, h.height))
+	scanline := make([]byte, 4*h.width)
+	for y := 0; y < h.height; y++ {
+		if err := readScanline(br, scanline); err != nil {
+			return nil, err
+		}
+		dy := y
+		if h.flipY {
+			dy = h.height - 1 - y
+		}
+		for x := 0; x < h.width; x++ {
+			dx := x
+			if h.flipX {
+				dx = h.width - 1 - x
+			}
+			rf, gf, bf := rgbeToFloat(scanline[4*x], scanline[4*x+1], scanline[4*x+2], scanline[4*x+3])
+			img.SetRGBA(dx, dy, rf, gf, bf, 1)
+		}
+	}
+	return img, nil
+}
+
+// readScanline fills buf with one scanline of RGBE quadruples, handling
+// flat, old style run length encoded and new style run length encoded data.
+func readScanline(r *bufio.Reader, buf []byte) error {
+	width := len(buf) / 4
+	head := make([]byte, 4)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return err
+	}
+	if width < 8 || width > 0x7fff || head[0] != 2 || head[1] != 2 || head[2]&0x80 != 0 {
+		return readOldScanline(r, buf, head)
+	}
+	if int(head[2])<<8|int(head[3]) != width {
+		return errors.New("hdr: wrong scanline width")
+	}
+	// New RLE: each of the four channels is encoded separately
+	for c := 0; c < 4; c++ {
+		for x := 0; x < width; {
+			count, err := r.ReadByte()
+			if err != nil {
+				return err
+			}
+			if count > 128 {
+				n := int(count - 128)
+				if x+n > width {
+					return errors.New("hdr: bad scanline data")
+				}
+				v, err := r.ReadByte()
+				if err != nil {
+					return err
+				}
+				for ; n > 0; n-- {
+					buf[4*x+c] = v
+					x++
+				}
+			} else {
+				n := int(count)
+				if n == 0 || x+n > width {
+					return errors.New("hdr: bad scanline data")
+				}
+				for ; n > 0; n-- {
+					v, err := r.ReadByte()
+					if err != nil {
+						return err
+					}
+					buf[4*x+c] = v
+					x++
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func readOldScanline(r *bufio.Reader, buf []byte, first []byte) error {
+	width := len(buf) / 4
+	pixel := make([]byte, 4)
+	copy(pixel, first)
+	havePixel := true
+	shift := uint(0)
+	for x := 0; x < width; {
+		if !havePixel {
+			if _, err := io.ReadFull(r, pixel); err != nil {
+				return err
+			}
+		}
+		havePixel = false
+		if pixel[0] == 1 && pixel[1] == 1 && pixel[2] == 1 {
+			// Repeat the previous pixel
+			if x == 0 {
+				return errors.New("hdr: run without previous pixel")
+			}
+			n := int(pixel[3]) << shift
+			if x+n > width {
+				return errors.New("hdr: bad scanline data")
+			}
+			for ; n > 0; n-- {
+				copy(buf[4*x:4*x+4], buf[4*x-4:4*x])
+				x++
+			}
+			shift += 8
+			continue
+		}
+		copy(buf[4*x:4*x+4], pixel)
+		x++
+		shift = 0
+	}
+	return nil
+}
+
+func rgbeToFloat(r, g, b, e byte) (float32, float32, float32) {
+	if e == 0 {
+		return 0, 0, 0
+	}
+	f := float32(math.Ldexp(1, int(e)-(128+8)))
+	return (float32(r) + 0.5) * f, (float32(g) + 0.5) * f, (float32(b) + 0.5) * f
+}
+
+func floatToRGBE(r, g, b float32) (byte, byte, byte, byte) {
+	// RGBE cannot represent negative values
+	r, g, b = positive(r), positive(g), positive(b)
+	v := r
+	if g > v {
+		v = g
+	}
+	if b > v {
+		v = b
+	}
+	if v < 1e-32 {
+		return 0, 0, 0, 0
+	}
+	frac, exp := math.Frexp(float64(v))
+	f := float32(frac * 256 / float64(v))
+	return byte(r * f), byte(g * f), byte(b * f), byte(exp + 128)
+}
+
+func positive(v float32) float32 {
+	if v < 0 {
+		return 0
+	}
+	return v
+}
+
+// EncodeRGBE writes img as a Radiance .hdr file. The alpha channel is
+// dropped.
+func EncodeRGBE(w io.Writer, img *Image) error {
+	bw := bufio.NewWriter(w)
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	fmt.Fprintf(bw, "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y %d +X %d\n", height, width)
+	scanline := make([]byte, 4*width)
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, _ := img.RGBAAt(img.Rect.Min.X+x, y)
+			scanline[4*x], scanline[4*x+1], scanline[4*x+2], scanline[4*x+3] = floatToRGBE(r, g, b)
+		}
+		if width < 8 || width > 0x7fff {
+			bw.Write(scanline)
+			continue
+		}
+		// New style scanline made of literal runs only, so that no pixel
+		// can be mistaken for a run marker.
+		bw.Write([]byte{2, 2, byte(width >> 8), byte(width)})
+		for c := 0; c < 4; c++ {
+			for x := 0; x < width; x += 128 {
+				n := width - x
+				if n > 128 {
+					n = 128
+				}
+				bw.WriteByte(byte(n))
+				for i := x; i < x+n; i++ {
+					bw.WriteByte(scanline[4*i+c])
+				}
+			}
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,161 @@
+package hdr
+
+import (
+	"bytes"
+	"image"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestRGBEReference(t *testing.T) {
+	// The shared exponent 129 scales the mantissas by 2^(129-136)
+	r, g, b, e := floatToRGBE(1, 0.5, 0.25)
+	if r != 128 || g != 64 || b != 32 || e != 129 {
+		t.Errorf("floatToRGBE(1, 0.5, 0.25) = %d %d %d %d, want 128 64 32 129", r, g, b, e)
+	}
+	rf, gf, bf := rgbeToFloat(128, 64, 32, 129)
+	if rf != 128.5/128 || gf != 64.5/128 || bf != 32.5/128 {
+		t.Errorf("rgbeToFloat(128, 64, 32, 129) = %v %v %v", rf, gf, bf)
+	}
+	if r, g, b, e := floatToRGBE(-1, 0, 1e-40); r != 0 || g != 0 || b != 0 || e != 0 {
+		t.Errorf("black encodes to %d %d %d %d", r, g, b, e)
+	}
+	if rf, gf, bf := rgbeToFloat(200, 10, 10, 0); rf != 0 || gf != 0 || bf != 0 {
+		t.Errorf("exponent 0 decodes to %v %v %v", rf, gf, bf)
+	}
+}
+
+// testImage returns a w by h image of values from 0 up to 1000.
+func testImage(w, h int) *Image {
+	img := NewImage(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		if i%4 == 3 {
+			img.Pix[i] = 1
+		} else {
+			img.Pix[i] = float32(math.Pow(1.1, float64(i%73))) - 1
+		}
+	}
+	return img
+}
+
+// The 8 bit mantissas keep the largest channel of a pixel to 1 part in
+// 128; smaller ones lose as much in absolute terms.
+func TestRGBERoundTrip(t *testing.T) {
+	// Scanlines narrower than 8 pixels are written flat, others run
+	// length encoded
+	for _, w := range []int{3, 8, 300} {
+		img := testImage(w, 5)
+		var buf bytes.Buffer
+		if err := EncodeRGBE(&buf, img); err != nil {
+			t.Fatal(err)
+		}
+		decoded, format, err := image.Decode(&buf)
+		if err != nil {
+			t.Fatalf("width %d: %v", w, err)
+		}
+		if format != "hdr" || decoded.Bounds() != img.Rect {
+			t.Fatalf("width %d: decoded %s image of %v", w, format, decoded.Bounds())
+		}
+		got := decoded.(*Image)
+		for i := 0; i < len(img.Pix); i += 4 {
+			max := img.Pix[i]
+			for c := 1; c < 3; c++ {
+				if img.Pix[i+c] > max {
+					max = img.Pix[i+c]
+				}
+			}
+			for c := 0; c < 4; c++ {
+				if d := math.Abs(float64(got.Pix[i+c] - img.Pix[i+c])); d > float64(max)/128 {
+					t.Fatalf("width %d: value %d is %v, want %v", w, i+c, got.Pix[i+c], img.Pix[i+c])
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeRGBE(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []float32
+	}{
+		{"flat", "#?RGBE\n\n-Y 1 +X 2\n" + "\x80\x40\x20\x81" + "\x00\x00\x00\x00",
+			[]float32{128.5 / 128, 64.5 / 128, 32.5 / 128, 1, 0, 0, 0, 1}},
+		{"old run", "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y 1 +X 3\n" + "\x80\x40\x20\x81" + "\x01\x01\x01\x02",
+			[]float32{128.5 / 128, 64.5 / 128, 32.5 / 128, 1, 128.5 / 128, 64.5 / 128, 32.5 / 128, 1, 128.5 / 128, 64.5 / 128, 32.5 / 128, 1}},
+		// The first scanline is the bottom one
+		{"flipped", "#?RGBE\n\n+Y 2 +X 1\n" + "\x80\x40\x20\x81" + "\x00\x00\x00\x00",
+			[]float32{0, 0, 0, 1, 128.5 / 128, 64.5 / 128, 32.5 / 128, 1}},
+		{"mirrored", "#?RGBE\n\n-Y 1 -X 2\n" + "\x80\x40\x20\x81" + "\x00\x00\x00\x00",
+			[]float32{0, 0, 0, 1, 128.5 / 128, 64.5 / 128, 32.5 / 128, 1}},
+	}
+	for _, test := range tests {
+		img, err := DecodeRGBE(strings.NewReader(test.data))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		pix := img.(*Image).Pix
+		if len(pix) != len(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, pix, test.want)
+			continue
+		}
+		for i := range pix {
+			if pix[i] != test.want[i] {
+				t.Errorf("%s: got %v, want %v", test.name, pix, test.want)
+				break
+			}
+		}
+	}
+}
+
+// A new style scanline stores each channel as runs.
+func TestDecodeRGBERuns(t *testing.T) {
+	data := "#?RADIANCE\n\n-Y 1 +X 8\n\x02\x02\x00\x08" +
+		"\x88\x80" + // 8 times 128
+		"\x04\x01\x02\x03\x04\x84\x00" + // 1, 2, 3, 4, then 4 times 0
+		"\x88\x00" +
+		"\x88\x81"
+	img, err := DecodeRGBE(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for x := 0; x < 8; x++ {
+		r, g, b, _ := img.(*Image).RGBAAt(x, 0)
+		// A mantissa of 0 decodes to half a step like every other
+		want := float32(0.5) / 128
+		if x < 4 {
+			want = (float32(x+1) + 0.5) / 128
+		}
+		if r != 128.5/128 || g != want || b != 0.5/128 {
+			t.Errorf("pixel %d is %v %v %v, want %v %v %v", x, r, g, b, 128.5/128, want, 0.5/128)
+		}
+	}
+}
+
+func TestRGBEErrors(t *testing.T) {
+	tests := []struct {
+		name, data, err string
+	}{
+		{"signature", "RADIANCE\n\n-Y 1 +X 1\n\x80\x80\x80\x80", "missing #? signature"},
+		{"format", "#?RADIANCE\nFORMAT=32-bit_rle_xyze\n\n-Y 1 +X 1\n", "unsupported format"},
+		{"resolution", "#?RADIANCE\n\n1 1\n", "bad resolution string"},
+		{"orientation", "#?RADIANCE\n\n-X 1 +Y 1\n", "unsupported orientation"},
+		{"size", "#?RADIANCE\n\n-Y 0 +X 1\n", "invalid image size"},
+		{"too large", "#?RADIANCE\n\n-Y 100000 +X 100000\n", "too large"},
+		{"run without pixel", "#?RADIANCE\n\n-Y 1 +X 2\n\x01\x01\x01\x02", "run without previous pixel"},
+		{"long run", "#?RADIANCE\n\n-Y 1 +X 8\n\x02\x02\x00\x08\x89\x80", "bad scanline data"},
+		{"scanline width", "#?RADIANCE\n\n-Y 1 +X 8\n\x02\x02\x00\x09", "wrong scanline width"},
+		{"truncated", "#?RADIANCE\n\n-Y 2 +X 1\n\x80\x80\x80\x80", "EOF"},
+	}
+	for _, test := range tests {
+		_, err := DecodeRGBE(strings.NewReader(test.data))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+		}
+	}
+	if _, err := DecodeRGBEConfig(strings.NewReader("#?RADIANCE\n\n-Y 100000 +X 100000\n")); err == nil {
+		t.Error("config of a too large image: no error")
+	}
+}
@@ -0,0 +1,69 @@
+package hdr
+
+import (
+	"image"
+	"math"
+
+	"color"
+)
+
+// An Operator maps a linear HDR value to a linear value in [0, 1].
+type Operator func(v float32) float32
+
+// Reinhard is the simple global operator v / (1 + v).
+func Reinhard(v float32) float32 {
+	return v / (1 + v)
+}
+
+// ReinhardExtended maps white and everything above it to 1.
+func ReinhardExtended(white float32) Operator {
+	w2 := white * white
+	return func(v float32) float32 {
+		return v * (1 + v/w2) / (1 + v)
+	}
+}
+
+// ACES is Krzysztof Narkowicz' fit of the ACES filmic tone curve.
+func ACES(v float32) float32 {
+	const (
+		a = 2.51
+		b = 0.03
+		c = 2.43
+		d = 0.59
+		e = 0.14
+	)
+	return (v * (a*v + b)) / (v*(c*v+d) + e)
+}
+
+// ToneMap scales img by 2^exposure, applies op to each color channel and
+// returns an sRGB encoded 8 bit image ready for display or saving.
+func ToneMap(img *Image, op Operator, exposure float32) *image.RGBA {
+	scale := float32(math.Exp2(float64(exposure)))
+	b := img.Rect
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			i := img.PixOffset(b.Min.X+x, b.Min.Y+y)
+			o := out.PixOffset(x, y)
+			for c := 0; c < 3; c++ {
+				v := img.Pix[i+c] * scale
+				if v < 0 {
+					v = 0
+				}
+				out.Pix[o+c] = color.EncodeSRGB8(op(v))
+			}
+			out.Pix[o+3] = uint8(clamp01(img.Pix[i+3])*255 + 0.5)
+		}
+	}
+	return out
+}
+
+func clamp01(v float32) float32 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
@@ -0,0 +1,83 @@
+package hdr
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestOperators(t *testing.T) {
+	tests := []struct {
+		name string
+		op   Operator
+		v, w float32
+	}{
+		{"Reinhard", Reinhard, 0, 0},
+		{"Reinhard", Reinhard, 1, 0.5},
+		{"Reinhard", Reinhard, 3, 0.75},
+		{"ReinhardExtended", ReinhardExtended(4), 0, 0},
+		{"ReinhardExtended", ReinhardExtended(4), 1, 0.53125},
+		// The white point maps to 1
+		{"ReinhardExtended", ReinhardExtended(4), 4, 1},
+		{"ACES", ACES, 0, 0},
+		{"ACES", ACES, 1, 2.54 / 3.16},
+	}
+	for _, test := range tests {
+		if got := test.op(test.v); math.Abs(float64(got-test.w)) > 1e-6 {
+			t.Errorf("%s(%v) = %v, want %v", test.name, test.v, got, test.w)
+		}
+	}
+
+	// All of them rise from 0 and approach 1, or 2.51/2.43 for the ACES fit
+	for _, op := range []Operator{Reinhard, ReinhardExtended(1000), ACES} {
+		last := float32(0)
+		for v := float32(0.01); v < 100; v *= 1.1 {
+			got := op(v)
+			if got <= last || got > 2.51/2.43 {
+				t.Errorf("%v maps to %v after %v", v, got, last)
+				break
+			}
+			last = got
+		}
+		if last < 0.97 {
+			t.Errorf("100 maps to %v, want nearly 1", last)
+		}
+	}
+}
+
+func TestToneMap(t *testing.T) {
+	// An image not starting at the origin
+	img := NewImage(image.Rect(2, 3, 5, 4))
+	img.SetRGBA(2, 3, 1, 0.5, -1, 1)
+	img.SetRGBA(3, 3, 100, 0, 0, 0.5)
+	img.SetRGBA(4, 3, 0.25, 0.25, 0.25, 2)
+	identity := func(v float32) float32 {
+		if v > 1 {
+			return 1
+		}
+		return v
+	}
+
+	out := ToneMap(img, identity, 0)
+	if out.Rect != image.Rect(0, 0, 3, 1) {
+		t.Fatalf("tone mapped image is %v", out.Rect)
+	}
+	// sRGB encodes 0.5 to 188 and 0.25 to 137
+	want := []uint8{255, 188, 0, 255, 255, 0, 0, 128, 137, 137, 137, 255}
+	for i := range want {
+		if out.Pix[i] != want[i] {
+			t.Fatalf("exposure 0: got %v, want %v", out.Pix, want)
+		}
+	}
+
+	// Each stop of exposure doubles the values
+	out = ToneMap(img, identity, 1)
+	if out.Pix[1] != 255 || out.Pix[8] != 188 {
+		t.Errorf("exposure 1: got %v", out.Pix)
+	}
+	// Reinhard maps 1 at exposure -1 to 1/3, which sRGB encodes to 156
+	out = ToneMap(img, Reinhard, -1)
+	if out.Pix[0] != 156 {
+		t.Errorf("exposure -1 with Reinhard: got %v", out.Pix)
+	}
+}
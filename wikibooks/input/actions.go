@@ -0,0 +1,179 @@
+package input
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Actions maps names of actions to the keys which trigger them.
+type Actions struct {
+	keys map[string][]Key
+}
+
+func NewActions() *Actions {
+	return &Actions{keys: make(map[string][]Key)}
+}
+
+// DefaultActions returns the default bindings: quit on Escape, look
+// around by dragging with the left mouse button and pan with the right,
+// move with WASD or the arrow keys, E and Q going up and down, and take a
+// screenshot with F12.
+func DefaultActions() *Actions {
+	a := NewActions()
+	a.Bind("quit", Escape)
+	a.Bind("look", MouseLeft)
+	a.Bind("pan", MouseRight)
+	a.Bind("forward", 'W', Up)
+	a.Bind("back", 'S', Down)
+	a.Bind("left", 'A', Left)
+	a.Bind("right", 'D', Right)
+	a.Bind("up", 'E', PageUp)
+	a.Bind("down", 'Q', PageDown)
+	a.Bind("screenshot", F12)
+	return a
+}
+
+// Bind makes keys, and only them, trigger action.
+func (a *Actions) Bind(action string, keys ...Key) {
+	a.keys[action] = append([]Key(nil), keys...)
+}
+
+// Keys returns the keys bound to action.
+func (a *Actions) Keys(action string) []Key {
+	return a.keys[action]
+}
+
+// Held reports whether a key of action is held.
+func (a *Actions) Held(in *Input, action string) bool {
+	for _, k := range a.keys[action] {
+		if in.Held(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// Pressed reports whether a key of action was pressed since the last frame.
+func (a *Actions) Pressed(in *Input, action string) bool {
+	for _, k := range a.keys[action] {
+		if in.Pressed(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// Released reports whether a key of action was released since the last
+// frame.
+func (a *Actions) Released(in *Input, action string) bool {
+	for _, k := range a.keys[action] {
+		if in.Released(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// Read reads bindings, one action per line like
+//
+//	rotate_left = Left, A
+//
+// Bindings replace those of the same action. A key may only trigger one
+// action. Empty lines and lines starting with # are skipped. name is used
+// in errors.
+func (a *Actions) Read(r io.Reader, name string) error {
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		i := strings.Index(text, "=")
+		if i < 0 {
+			return fmt.Errorf("input: %s:%d: expected action = keys", name, line)
+		}
+		action := strings.TrimSpace(text[:i])
+		if action == "" {
+			return fmt.Errorf("input: %s:%d: missing action", name, line)
+		}
+		var keys []Key
+		for _, f := range strings.Split(text[i+1:], ",") {
+			k, err := parseKey(strings.TrimSpace(f))
+			if err != nil {
+				return fmt.Errorf("input: %s:%d: %v", name, line, err)
+			}
+			keys = append(keys, k)
+		}
+		a.Bind(action, keys...)
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	return a.conflicts(name)
+}
+
+// conflicts returns an error for the first key bound to two actions.
+func (a *Actions) conflicts(name string) error {
+	var names []string
+	for action := range a.keys {
+		names = append(names, action)
+	}
+	sort.Strings(names)
+	bound := make(map[Key]string)
+	for _, action := range names {
+		for _, k := range a.keys[action] {
+			if other, ok := bound[k]; ok && other != action {
+				return fmt.Errorf("input: %s: %v is bound to %s and %s", name, k, other, action)
+			}
+			bound[k] = action
+		}
+	}
+	return nil
+}
+
+// Load reads bindings from the named file.
+func (a *Actions) Load(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return a.Read(f, filename)
+}
+
+// String returns the bindings in the form Read reads.
+func (a *Actions) String() string {
+	var names []string
+	for name := range a.keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	s := ""
+	for _, name := range names {
+		var keys []string
+		for _, k := range a.keys[name] {
+			keys = append(keys, k.String())
+		}
+		s += name + " = " + strings.Join(keys, ", ") + "\n"
+	}
+	return s
+}
+
+var actionsFile = flag.String("actions", "", "file of key bindings like: quit = Escape, X")
+
+// ActionsFromFlags returns the default actions with the bindings of the
+// file given with -actions, after flag.Parse.
+func ActionsFromFlags() (*Actions, error) {
+	a := DefaultActions()
+	if *actionsFile != "" {
+		if err := a.Load(*actionsFile); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
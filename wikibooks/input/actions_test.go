@@ -0,0 +1,98 @@
+package input
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	a := DefaultActions()
+	text := `
+# Comments and empty lines are skipped
+  quit = Escape, X
+jump=space
+fire = mouseleft, f1
+look = MouseMiddle
+`
+	if err := a.Read(strings.NewReader(text), "bindings"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		action string
+		keys   []Key
+	}{
+		{"quit", []Key{Escape, 'X'}},
+		{"jump", []Key{Space}},
+		{"fire", []Key{MouseLeft, F1}},
+		// Replaced, which frees MouseLeft for fire
+		{"look", []Key{MouseMiddle}},
+		// Kept
+		{"down", []Key{'Q', PageDown}},
+	}
+	for _, test := range tests {
+		got := a.Keys(test.action)
+		if len(got) != len(test.keys) {
+			t.Errorf("%s: got keys %v, want %v", test.action, got, test.keys)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.keys[i] {
+				t.Errorf("%s: got keys %v, want %v", test.action, got, test.keys)
+				break
+			}
+		}
+	}
+
+	// String writes what Read reads
+	b := NewActions()
+	if err := b.Read(strings.NewReader(a.String()), "string"); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != a.String() {
+		t.Errorf("read back\n%s\nwant\n%s", b.String(), a.String())
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name, text, err string
+	}{
+		{"no equals", "quit Escape", "bindings:1: expected action = keys"},
+		{"no action", "\n = Escape", "bindings:2: missing action"},
+		{"unknown key", "quit = Escape, Esc", `bindings:1: unknown key "Esc"`},
+		{"no keys", "quit =", `bindings:1: unknown key ""`},
+		// Q also moves down by default
+		{"duplicate", "quit = Escape, Q", "bindings: Q is bound to down and quit"},
+		{"duplicate in file", "a = K\nb = L, k", "bindings: K is bound to a and b"},
+	}
+	for _, test := range tests {
+		err := DefaultActions().Read(strings.NewReader(test.text), "bindings")
+		if err == nil || !strings.HasPrefix(err.Error(), "input: ") || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+		}
+	}
+
+	// Binding the key elsewhere first resolves the conflict
+	if err := DefaultActions().Read(strings.NewReader("down = PageDown\nquit = Escape, Q"), "bindings"); err != nil {
+		t.Errorf("rebound: %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "actions.txt")
+	if err := os.WriteFile(name, []byte("screenshot = P\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	a := DefaultActions()
+	if err := a.Load(name); err != nil {
+		t.Fatal(err)
+	}
+	if keys := a.Keys("screenshot"); len(keys) != 1 || keys[0] != 'P' {
+		t.Errorf("screenshot bound to %v", keys)
+	}
+	if err := a.Load(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("missing file: no error")
+	}
+}
@@ -0,0 +1,317 @@
+// Package input turns keyboard and mouse events into the state of each
+// frame: which keys are held, and which were pressed or released since the
+// frame before. Actions name keys, so that applications ask whether
+// "quit" was pressed rather than Escape.
+package input
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Key is a key or a mouse button. Printable keys are their upper case
+// character, like in GLFW.
+type Key int
+
+const Space Key = ' '
+
+const (
+	Escape Key = 256 + iota
+	Enter
+	Tab
+	Backspace
+	Insert
+	Delete
+	Up
+	Down
+	Left
+	Right
+	PageUp
+	PageDown
+	Home
+	End
+	LeftShift
+	RightShift
+	LeftCtrl
+	RightCtrl
+	F1
+	F2
+	F3
+	F4
+	F5
+	F6
+	F7
+	F8
+	F9
+	F10
+	F11
+	F12
+
+	MouseLeft
+	MouseRight
+	MouseMiddle
+)
+
+var keyNames = map[Key]string{
+	Space:       "Space",
+	Escape:      "Escape",
+	Enter:       "Enter",
+	Tab:         "Tab",
+	Backspace:   "Backspace",
+	Insert:      "Insert",
+	Delete:      "Delete",
+	Up:          "Up",
+	Down:        "Down",
+	Left:        "Left",
+	Right:       "Right",
+	PageUp:      "PageUp",
+	PageDown:    "PageDown",
+	Home:        "Home",
+	End:         "End",
+	LeftShift:   "LeftShift",
+	RightShift:  "RightShift",
+	LeftCtrl:    "LeftCtrl",
+	RightCtrl:   "RightCtrl",
+	MouseLeft:   "MouseLeft",
+	MouseRight:  "MouseRight",
+	MouseMiddle: "MouseMiddle",
+}
+
+func init() {
+	for k := F1; k <= F12; k++ {
+		keyNames[k] = fmt.Sprintf("F%d", k-F1+1)
+	}
+}
+
+func (k Key) String() string {
+	if name, ok := keyNames[k]; ok {
+		return name
+	}
+	if k > ' ' && k < 128 {
+		return string(rune(k))
+	}
+	return fmt.Sprintf("Key(%d)", int(k))
+}
+
+// ParseKey parses the name of a key as String returns it. Letters may be
+// lower case.
+func ParseKey(s string) (Key, error) {
+	k, err := parseKey(s)
+	if err != nil {
+		return 0, errors.New("input: " + err.Error())
+	}
+	return k, nil
+}
+
+func parseKey(s string) (Key, error) {
+	if len(s) == 1 && s[0] > ' ' && s[0] < 128 {
+		return Key(strings.ToUpper(s)[0]), nil
+	}
+	for k, name := range keyNames {
+		if strings.EqualFold(name, s) {
+			return k, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown key %q", s)
+}
+
+type EventKind int
+
+const (
+	KeyDown EventKind = iota
+	KeyUp
+	// Move moves the mouse to X, Y.
+	Move
+	// Scroll turns the mouse wheel by Y.
+	Scroll
+)
+
+var eventNames = []string{"down", "up", "move", "scroll"}
+
+func (k EventKind) String() string {
+	if k >= 0 && int(k) < len(eventNames) {
+		return eventNames[k]
+	}
+	return fmt.Sprintf("EventKind(%d)", int(k))
+}
+
+type Event struct {
+	Kind EventKind
+	Key  Key
+	X, Y int
+}
+
+func (e Event) String() string {
+	switch e.Kind {
+	case KeyDown, KeyUp:
+		return fmt.Sprintf("%v %v", e.Kind, e.Key)
+	case Move:
+		return fmt.Sprintf("%v %d %d", e.Kind, e.X, e.Y)
+	}
+	return fmt.Sprintf("%v %d", e.Kind, e.Y)
+}
+
+// Source delivers events, such as those of a window.
+type Source interface {
+	// Events returns the events since the last call.
+	Events() []Event
+}
+
+// Input is the input state of a frame.
+type Input struct {
+	sources []Source
+
+	held     map[Key]bool
+	pressed  map[Key]bool
+	released map[Key]bool
+
+	moved  bool
+	x, y   int
+	dx, dy int
+	scroll int
+
+	frame  int
+	record io.Writer
+	// recordErr is the first error writing to record, after which
+	// recording stops.
+	recordErr error
+}
+
+func New(sources ...Source) *Input {
+	return &Input{
+		sources:  sources,
+		held:     make(map[Key]bool),
+		pressed:  make(map[Key]bool),
+		released: make(map[Key]bool),
+	}
+}
+
+func (in *Input) AddSource(s Source) {
+	in.sources = append(in.sources, s)
+}
+
+// Frame starts a frame, applying the events of the sources since the last
+// frame.
+func (in *Input) Frame() {
+	in.pressed = make(map[Key]bool)
+	in.released = make(map[Key]bool)
+	in.dx, in.dy, in.scroll = 0, 0, 0
+	for _, s := range in.sources {
+		for _, e := range s.Events() {
+			if in.record != nil && in.recordErr == nil {
+				if _, err := fmt.Fprintf(in.record, "%d %v\n", in.frame, e); err != nil {
+					in.recordErr = fmt.Errorf("input: recording: %v", err)
+				}
+			}
+			in.apply(e)
+		}
+	}
+	in.frame++
+}
+
+// Record writes the events of the following frames to w as a script, to
+// play them back with a Script.
+func (in *Input) Record(w io.Writer) {
+	in.record = w
+}
+
+// Close stops recording and closes the writer given to Record if it is an
+// io.Closer. It returns the first error writing or closing it.
+func (in *Input) Close() error {
+	err := in.recordErr
+	if c, ok := in.record.(io.Closer); ok {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("input: recording: %v", cerr)
+		}
+	}
+	in.record, in.recordErr = nil, nil
+	return err
+}
+
+func (in *Input) apply(e Event) {
+	switch e.Kind {
+	case KeyDown:
+		if !in.held[e.Key] {
+			in.pressed[e.Key] = true
+		}
+		in.held[e.Key] = true
+	case KeyUp:
+		if in.held[e.Key] {
+			in.released[e.Key] = true
+		}
+		delete(in.held, e.Key)
+	case Move:
+		// The first position is no movement
+		if in.moved {
+			in.dx += e.X - in.x
+			in.dy += e.Y - in.y
+		}
+		in.moved = true
+		in.x, in.y = e.X, e.Y
+	case Scroll:
+		in.scroll += e.Y
+	}
+}
+
+// Held reports whether k is down.
+func (in *Input) Held(k Key) bool {
+	return in.held[k]
+}
+
+// Pressed reports whether k went down since the last frame, even if it
+// went up again.
+func (in *Input) Pressed(k Key) bool {
+	return in.pressed[k]
+}
+
+// Released reports whether k went up since the last frame.
+func (in *Input) Released(k Key) bool {
+	return in.released[k]
+}
+
+// Mouse returns the position of the mouse in the window, with y counting
+// downwards.
+func (in *Input) Mouse() (x, y int) {
+	return in.x, in.y
+}
+
+// MouseDelta returns how far the mouse moved since the last frame.
+func (in *Input) MouseDelta() (dx, dy int) {
+	return in.dx, in.dy
+}
+
+// Scroll returns how far the mouse wheel turned since the last frame.
+func (in *Input) Scroll() int {
+	return in.scroll
+}
+
+var (
+	play   = flag.String("play", "", "play back the input script file")
+	record = flag.String("record", "", "record the input to a script file")
+)
+
+// FromFlags returns input playing the script given with -play and
+// recording to the file given with -record, after flag.Parse. Close the
+// input to close the file.
+func FromFlags() (*Input, error) {
+	in := New()
+	if *play != "" {
+		s, err := LoadScript(*play)
+		if err != nil {
+			return nil, err
+		}
+		in.AddSource(s)
+	}
+	if *record != "" {
+		f, err := os.Create(*record)
+		if err != nil {
+			return nil, err
+		}
+		in.Record(f)
+	}
+	return in, nil
+}
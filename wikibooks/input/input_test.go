@@ -0,0 +1,147 @@
+package input
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func script() *Script {
+	s := NewScript()
+	s.Add(0, Event{Kind: KeyDown, Key: Left})
+	s.Add(2, Event{Kind: Move, X: 100, Y: 200}, Event{Kind: Scroll, Y: -1})
+	s.Add(3, Event{Kind: KeyUp, Key: Left})
+	return s
+}
+
+// recording is a file which fails after its first write.
+type recording struct {
+	bytes.Buffer
+	writes int
+	closed bool
+}
+
+func (r *recording) Write(p []byte) (int, error) {
+	r.writes++
+	if r.writes > 1 {
+		return 0, errors.New("disk full")
+	}
+	return r.Buffer.Write(p)
+}
+
+func (r *recording) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestRecordRoundTrip(t *testing.T) {
+	in := New(script())
+	var buf bytes.Buffer
+	in.Record(&buf)
+	for i := 0; i < 4; i++ {
+		in.Frame()
+	}
+	if err := in.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := "0 down Left\n2 move 100 200\n2 scroll -1\n3 up Left\n"
+	if buf.String() != want {
+		t.Fatalf("recorded\n%s\nwant\n%s", buf.String(), want)
+	}
+	s, err := ReadScript(&buf, "recording")
+	if err != nil {
+		t.Fatal(err)
+	}
+	played, recorded := New(s), New(script())
+	for i := 0; i < 4; i++ {
+		played.Frame()
+		recorded.Frame()
+		if played.Held(Left) != recorded.Held(Left) || played.Scroll() != recorded.Scroll() {
+			t.Errorf("frame %d plays back differently", i)
+		}
+	}
+}
+
+func TestRecordError(t *testing.T) {
+	in := New(script())
+	r := &recording{}
+	in.Record(r)
+	for i := 0; i < 4; i++ {
+		in.Frame()
+	}
+	// Recording stops at the first error, which Close returns
+	if r.writes != 2 {
+		t.Errorf("%d writes, want 2", r.writes)
+	}
+	if err := in.Close(); err == nil {
+		t.Error("no error")
+	}
+	if !r.closed {
+		t.Error("recording not closed")
+	}
+	if err := in.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+// Pressed and Released last one frame, Held until the key goes up.
+func TestEdges(t *testing.T) {
+	s := NewScript()
+	s.Add(0, Event{Kind: KeyDown, Key: 'A'})
+	// Key repeat
+	s.Add(1, Event{Kind: KeyDown, Key: 'A'})
+	s.Add(3, Event{Kind: KeyUp, Key: 'A'})
+	// Tapped within a frame
+	s.Add(4, Event{Kind: KeyDown, Key: 'B'}, Event{Kind: KeyUp, Key: 'B'})
+	// Up without down, like after the window gained focus
+	s.Add(5, Event{Kind: KeyUp, Key: 'C'})
+	tests := []struct {
+		key                     Key
+		pressed, held, released bool
+	}{
+		{'A', true, true, false},
+		{'A', false, true, false},
+		{'A', false, true, false},
+		{'A', false, false, true},
+		{'B', true, false, true},
+		{'C', false, false, false},
+		{'B', false, false, false},
+	}
+	in := New(s)
+	for frame, test := range tests {
+		in.Frame()
+		if in.Pressed(test.key) != test.pressed || in.Held(test.key) != test.held || in.Released(test.key) != test.released {
+			t.Errorf("frame %d: %v pressed %v, held %v, released %v", frame, test.key, in.Pressed(test.key), in.Held(test.key), in.Released(test.key))
+		}
+	}
+}
+
+func TestActionEdges(t *testing.T) {
+	a := NewActions()
+	a.Bind("fire", 'F', MouseLeft)
+	s := NewScript()
+	s.Add(0, Event{Kind: KeyDown, Key: 'F'})
+	// Each key presses and releases it, even while the other is held
+	s.Add(1, Event{Kind: KeyDown, Key: MouseLeft})
+	s.Add(2, Event{Kind: KeyUp, Key: 'F'})
+	s.Add(3, Event{Kind: KeyUp, Key: MouseLeft})
+	tests := []struct {
+		pressed, held, released bool
+	}{
+		{true, true, false},
+		{true, true, false},
+		{false, true, true},
+		{false, false, true},
+		{false, false, false},
+	}
+	in := New(s)
+	for frame, test := range tests {
+		in.Frame()
+		if a.Pressed(in, "fire") != test.pressed || a.Held(in, "fire") != test.held || a.Released(in, "fire") != test.released {
+			t.Errorf("frame %d: pressed %v, held %v, released %v", frame, a.Pressed(in, "fire"), a.Held(in, "fire"), a.Released(in, "fire"))
+		}
+	}
+	if a.Pressed(in, "unbound") || a.Held(in, "unbound") {
+		t.Error("unbound action triggered")
+	}
+}
@@ -0,0 +1,134 @@
+package input
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Script is a source playing events back by frame, for tests and demos.
+// It is written one event per line, like
+//
+//	# frame event
+//	0 down Left
+//	30 up Left
+//	31 move 100 200
+//	31 scroll -1
+type Script struct {
+	frames map[int][]Event
+	last   int
+	frame  int
+}
+
+func NewScript() *Script {
+	return &Script{frames: make(map[int][]Event)}
+}
+
+// Add adds events to deliver at frame, counting from 0.
+func (s *Script) Add(frame int, events ...Event) {
+	s.frames[frame] = append(s.frames[frame], events...)
+	if frame > s.last {
+		s.last = frame
+	}
+}
+
+// Events returns the events of the next frame.
+func (s *Script) Events() []Event {
+	events := s.frames[s.frame]
+	s.frame++
+	return events
+}
+
+// Done reports whether all events were delivered.
+func (s *Script) Done() bool {
+	return s.frame > s.last
+}
+
+// ParseEvent parses an event in the form Event.String gives.
+func ParseEvent(text string) (Event, error) {
+	e, err := parseEvent(text)
+	if err != nil {
+		return e, errors.New("input: " + err.Error())
+	}
+	return e, nil
+}
+
+func parseEvent(text string) (Event, error) {
+	f := strings.Fields(text)
+	if len(f) == 0 {
+		return Event{}, errors.New("empty event")
+	}
+	var kind EventKind = -1
+	for i, name := range eventNames {
+		if f[0] == name {
+			kind = EventKind(i)
+		}
+	}
+	e := Event{Kind: kind}
+	var args int
+	switch kind {
+	case KeyDown, KeyUp:
+		args = 1
+	case Move:
+		args = 2
+	case Scroll:
+		args = 1
+	default:
+		return e, fmt.Errorf("unknown event %q", f[0])
+	}
+	if len(f)-1 != args {
+		return e, fmt.Errorf("%s takes %d arguments", f[0], args)
+	}
+	var err error
+	switch kind {
+	case KeyDown, KeyUp:
+		e.Key, err = parseKey(f[1])
+	case Move:
+		if e.X, err = strconv.Atoi(f[1]); err == nil {
+			e.Y, err = strconv.Atoi(f[2])
+		}
+	case Scroll:
+		e.Y, err = strconv.Atoi(f[1])
+	}
+	return e, err
+}
+
+// ReadScript reads a script. name is used in errors.
+func ReadScript(r io.Reader, name string) (*Script, error) {
+	s := NewScript()
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		i := strings.IndexAny(text, " \t")
+		if i < 0 {
+			return nil, fmt.Errorf("input: %s:%d: expected frame and event", name, line)
+		}
+		frame, err := strconv.Atoi(text[:i])
+		if err != nil || frame < 0 {
+			return nil, fmt.Errorf("input: %s:%d: bad frame %q", name, line, text[:i])
+		}
+		e, err := parseEvent(text[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("input: %s:%d: %v", name, line, err)
+		}
+		s.Add(frame, e)
+	}
+	return s, sc.Err()
+}
+
+// LoadScript reads the named script file.
+func LoadScript(filename string) (*Script, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadScript(f, filename)
+}
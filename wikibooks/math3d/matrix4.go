@@ -80,6 +80,16 @@ func MakeRotationMatrix(angle float32, vec Vector3) Matrix4 {
 	}
 }
 
+// Rotation given by the unit quaternion x, y, z, w
+func MakeQuaternionRotationMatrix(x, y, z, w float32) Matrix4 {
+	return Matrix4{
+		1 - 2*(y*y+z*z), 2 * (x*y - z*w), 2 * (x*z + y*w), 0,
+		2 * (x*y + z*w), 1 - 2*(x*x+z*z), 2 * (y*z - x*w), 0,
+		2 * (x*z - y*w), 2 * (y*z + x*w), 1 - 2*(x*x+y*y), 0,
+		0, 0, 0, 1,
+	}
+}
+
 /*
 func MakePerspectiveMatrix(fovy, aspect, zNear, zFar float32) Matrix4 {
 	f := 1 / float32(math.Tan(float64(fovy/2)))
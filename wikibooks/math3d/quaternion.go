@@ -0,0 +1,76 @@
+package math3d
+
+import (
+	"math"
+)
+
+// Quaternion is a rotation, as a unit quaternion.
+type Quaternion struct {
+	X, Y, Z, W float32
+}
+
+func MakeIdentityQuaternion() Quaternion {
+	return Quaternion{0, 0, 0, 1}
+}
+
+// Rotation by angle around the unit vector axis
+func MakeAxisAngleQuaternion(angle float32, axis Vector3) Quaternion {
+	s := float32(math.Sin(float64(angle / 2)))
+	c := float32(math.Cos(float64(angle / 2)))
+	return Quaternion{axis[0] * s, axis[1] * s, axis[2] * s, c}
+}
+
+// Multiply returns the rotation by q2 followed by q1.
+func (q1 Quaternion) Multiply(q2 Quaternion) Quaternion {
+	return Quaternion{
+		q1.W*q2.X + q1.X*q2.W + q1.Y*q2.Z - q1.Z*q2.Y,
+		q1.W*q2.Y - q1.X*q2.Z + q1.Y*q2.W + q1.Z*q2.X,
+		q1.W*q2.Z + q1.X*q2.Y - q1.Y*q2.X + q1.Z*q2.W,
+		q1.W*q2.W - q1.X*q2.X - q1.Y*q2.Y - q1.Z*q2.Z,
+	}
+}
+
+// Conjugate returns the inverse rotation.
+func (q Quaternion) Conjugate() Quaternion {
+	return Quaternion{-q.X, -q.Y, -q.Z, q.W}
+}
+
+func (q Quaternion) Normalized() Quaternion {
+	l := float32(math.Sqrt(float64(q.X*q.X + q.Y*q.Y + q.Z*q.Z + q.W*q.W)))
+	return Quaternion{q.X / l, q.Y / l, q.Z / l, q.W / l}
+}
+
+func (q Quaternion) Rotate(v Vector3) Vector3 {
+	p := q.Multiply(Quaternion{v[0], v[1], v[2], 0}).Multiply(q.Conjugate())
+	return Vector3{p.X, p.Y, p.Z}
+}
+
+func (q Quaternion) Matrix() Matrix4 {
+	return MakeQuaternionRotationMatrix(q.X, q.Y, q.Z, q.W)
+}
+
+// Slerp interpolates from q1 at t = 0 to q2 at t = 1 along the shortest
+// arc.
+func Slerp(q1, q2 Quaternion, t float32) Quaternion {
+	dot := q1.X*q2.X + q1.Y*q2.Y + q1.Z*q2.Z + q1.W*q2.W
+	if dot < 0 {
+		q2 = Quaternion{-q2.X, -q2.Y, -q2.Z, -q2.W}
+		dot = -dot
+	}
+	var a, b float32
+	if dot > 0.9995 {
+		// Nearly the same rotation: interpolate linearly
+		a, b = 1-t, t
+	} else {
+		theta := math.Acos(float64(dot))
+		sin := math.Sin(theta)
+		a = float32(math.Sin((1-float64(t))*theta) / sin)
+		b = float32(math.Sin(float64(t)*theta) / sin)
+	}
+	return Quaternion{
+		a*q1.X + b*q2.X,
+		a*q1.Y + b*q2.Y,
+		a*q1.Z + b*q2.Z,
+		a*q1.W + b*q2.W,
+	}.Normalized()
+}
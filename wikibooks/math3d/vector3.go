@@ -14,6 +14,10 @@ func (v Vector3) Sub(vec Vector3) *Vector3 {
 	return &Vector3{v[0] - vec[0], v[1] - vec[1], v[2] - vec[2]}
 }
 
+func (v Vector3) Added(vec Vector3) Vector3 {
+	return Vector3{v[0] + vec[0], v[1] + vec[1], v[2] + vec[2]}
+}
+
 func (v Vector3) Multiplied(vec Vector3) Vector3 {
 	return Vector3{v[0] * vec[0], v[1] * vec[1], v[2] * vec[2]}
 }
@@ -48,5 +52,5 @@ func (v Vector3) Normalized() Vector3 {
 }
 
 func (v Vector3) Cross(vec Vector3) Vector3 {
-	return Vector3{v[1]*vec[2] - v[2]*vec[1], v[2]*vec[0] - v[0]*vec[2], v[0] - vec[1] - v[1]*vec[0]}
+	return Vector3{v[1]*vec[2] - v[2]*vec[1], v[2]*vec[0] - v[0]*vec[2], v[0]*vec[1] - v[1]*vec[0]}
 }
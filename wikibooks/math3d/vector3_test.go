@@ -0,0 +1,43 @@
+package math3d
+
+import (
+	"testing"
+)
+
+func TestCross(t *testing.T) {
+	tests := []struct {
+		a, b, want Vector3
+	}{
+		{Vector3{1, 0, 0}, Vector3{0, 1, 0}, Vector3{0, 0, 1}},
+		{Vector3{0, 1, 0}, Vector3{0, 0, 1}, Vector3{1, 0, 0}},
+		{Vector3{0, 0, 1}, Vector3{1, 0, 0}, Vector3{0, 1, 0}},
+		{Vector3{1, 2, 3}, Vector3{4, 5, 6}, Vector3{-3, 6, -3}},
+	}
+	for _, test := range tests {
+		got := test.a.Cross(test.b)
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%v x %v = %v, want %v", test.a, test.b, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestLookAtAxes(t *testing.T) {
+	// The rows of the rotation are the axes of the camera, which must be
+	// unit length and perpendicular
+	m := MakeLookAtMatrix(Vector3{0, 2, 0}, Vector3{0, -2, -4}, Vector3{0, 1, 0})
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			dot := m[i]*m[j] + m[4+i]*m[4+j] + m[8+i]*m[8+j]
+			want := float32(0)
+			if i == j {
+				want = 1
+			}
+			if d := dot - want; d < -1e-6 || d > 1e-6 {
+				t.Errorf("axes %d and %d have dot product %v, want %v", i, j, dot, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package gpu
+
+import (
+	"device"
+	"mesh"
+)
+
+// Mesh is a mesh uploaded to the graphic card: one interleaved vertex
+// buffer and one index buffer.
+type Mesh struct {
+	Layout mesh.VertexLayout
+
+	d         device.Device
+	vbo       device.Buffer
+	ibo       device.Buffer
+	count     int
+	indexType device.IndexType
+	locations map[device.Program][]int
+	enabled   []int
+}
+
+// Upload copies m into new buffer objects of d. 16 bit indices are used
+// when the mesh has few enough vertices.
+func Upload(d device.Device, m *mesh.Mesh) *Mesh {
+	data, layout := m.Interleave()
+	g := &Mesh{
+		Layout:    layout,
+		d:         d,
+		count:     len(m.Indices),
+		locations: make(map[device.Program][]int),
+	}
+
+	g.vbo = d.CreateBuffer()
+	d.BindBuffer(device.ArrayBuffer, g.vbo)
+	d.BufferData(device.ArrayBuffer, data)
+	d.BindBuffer(device.ArrayBuffer, 0)
+
+	if g.count > 0 {
+		g.ibo = d.CreateBuffer()
+		d.BindBuffer(device.ElementArrayBuffer, g.ibo)
+		if indices, ok := m.Indices16(); ok {
+			g.indexType = device.UnsignedShort
+			d.BufferData(device.ElementArrayBuffer, indices)
+		} else {
+			g.indexType = device.UnsignedInt
+			d.BufferData(device.ElementArrayBuffer, m.Indices)
+		}
+		d.BindBuffer(device.ElementArrayBuffer, 0)
+	} else {
+		// Not indexed, draw the vertices in order
+		g.count = m.VertexCount()
+	}
+	return g
+}
+
+// attribLocations looks up the location of every vertex element in the
+// program, -1 if the shader does not use it.
+func (g *Mesh) attribLocations(program device.Program) []int {
+	if locations, ok := g.locations[program]; ok {
+		return locations
+	}
+	locations := make([]int, len(g.Layout.Elements))
+	for i, e := range g.Layout.Elements {
+		locations[i] = g.d.AttribLocation(program, e.Name)
+	}
+	g.locations[program] = locations
+	return locations
+}
+
+// Bind binds the buffers and sets up the attributes which program exposes.
+// Attributes the shader does not declare are skipped.
+func (g *Mesh) Bind(program device.Program) {
+	g.d.BindBuffer(device.ArrayBuffer, g.vbo)
+	for i, location := range g.attribLocations(program) {
+		if location == -1 {
+			continue
+		}
+		e := g.Layout.Elements[i]
+		g.d.EnableVertexAttribArray(location)
+		g.d.VertexAttribPointer(location, e.Size, g.Layout.Stride, e.Offset)
+		g.enabled = append(g.enabled, location)
+	}
+	if g.ibo != 0 {
+		g.d.BindBuffer(device.ElementArrayBuffer, g.ibo)
+	}
+}
+
+// Unbind disables the attributes enabled by Bind.
+func (g *Mesh) Unbind() {
+	for _, location := range g.enabled {
+		g.d.DisableVertexAttribArray(location)
+	}
+	g.enabled = g.enabled[:0]
+	g.d.BindBuffer(device.ArrayBuffer, 0)
+	g.d.BindBuffer(device.ElementArrayBuffer, 0)
+}
+
+// Draw binds the mesh for program, which must be in use, draws all
+// triangles and unbinds it again.
+func (g *Mesh) Draw(program device.Program) {
+	g.Bind(program)
+	if g.ibo != 0 {
+		g.d.DrawElements(g.count, g.indexType, 0)
+	} else {
+		g.d.DrawArrays(0, g.count)
+	}
+	g.Unbind()
+}
+
+func (g *Mesh) Delete() {
+	g.d.DeleteBuffer(g.vbo)
+	if g.ibo != 0 {
+		g.d.DeleteBuffer(g.ibo)
+	}
+}
@@ -0,0 +1,208 @@
+package halfedge
+
+import (
+	"errors"
+)
+
+// Report lists what keeps a triangle list from being an oriented manifold.
+type Report struct {
+	// BoundaryEdges counts edges with a single face. They are allowed.
+	BoundaryEdges int
+	// Degenerate lists triangles using a vertex twice.
+	Degenerate []int
+	// NonManifoldEdges are shared by more than two faces.
+	NonManifoldEdges [][2]uint32
+	// NonManifoldVertices join fans of faces which share no edge, like the
+	// tip of two cones.
+	NonManifoldVertices []uint32
+	// FlippedEdges are used twice in the same direction, by neighbouring
+	// faces with opposite winding.
+	FlippedEdges [][2]uint32
+}
+
+// Manifold reports whether every edge and vertex is surrounded by a single
+// sheet of faces.
+func (r *Report) Manifold() bool {
+	return len(r.Degenerate) == 0 && len(r.NonManifoldEdges) == 0 && len(r.NonManifoldVertices) == 0
+}
+
+// Oriented reports whether neighbouring faces agree on their winding.
+func (r *Report) Oriented() bool {
+	return len(r.FlippedEdges) == 0
+}
+
+// edgeUse is a triangle on an undirected edge, reversed if it runs from
+// the larger vertex to the smaller.
+type edgeUse struct {
+	t        int
+	reversed bool
+}
+
+// edgeUses collects the triangles on each undirected edge, and returns the
+// edges in the order they are first used.
+func edgeUses(indices []uint32) (map[uint64][]edgeUse, []uint64) {
+	uses := make(map[uint64][]edgeUse)
+	var order []uint64
+	for t := 0; t < len(indices)/3; t++ {
+		for k := 0; k < 3; k++ {
+			a, b := indices[3*t+k], indices[3*t+(k+1)%3]
+			if a == b {
+				continue
+			}
+			reversed := a > b
+			if reversed {
+				a, b = b, a
+			}
+			key := edgeKey(a, b)
+			if uses[key] == nil {
+				order = append(order, key)
+			}
+			uses[key] = append(uses[key], edgeUse{t, reversed})
+		}
+	}
+	return uses, order
+}
+
+// Check examines the triangles of indices, which refer to vertexCount
+// vertices.
+func Check(indices []uint32, vertexCount int) *Report {
+	r := &Report{}
+	nt := len(indices) / 3
+	for t := 0; t < nt; t++ {
+		a, b, c := indices[3*t], indices[3*t+1], indices[3*t+2]
+		if a == b || b == c || c == a {
+			r.Degenerate = append(r.Degenerate, t)
+		}
+	}
+	uses, order := edgeUses(indices)
+	for _, key := range order {
+		e := [2]uint32{uint32(key >> 32), uint32(key)}
+		u := uses[key]
+		switch {
+		case len(u) == 1:
+			r.BoundaryEdges++
+		case len(u) > 2:
+			r.NonManifoldEdges = append(r.NonManifoldEdges, e)
+		case u[0].reversed == u[1].reversed:
+			r.FlippedEdges = append(r.FlippedEdges, e)
+		}
+	}
+
+	// Faces around a vertex which are connected through its edges form a
+	// fan; union find counts the fans
+	around := make([][]int, vertexCount)
+	for t := 0; t < nt; t++ {
+		for k := 0; k < 3; k++ {
+			v := indices[3*t+k]
+			// Degenerate triangles are around their vertices once
+			if k > 0 && v == indices[3*t] || k == 2 && v == indices[3*t+1] {
+				continue
+			}
+			around[v] = append(around[v], t)
+		}
+	}
+	for v, faces := range around {
+		parent := make(map[int]int, len(faces))
+		var find func(int) int
+		find = func(t int) int {
+			if parent[t] != t {
+				parent[t] = find(parent[t])
+			}
+			return parent[t]
+		}
+		// Faces meeting at another vertex besides v share an edge of v
+		other := make(map[uint32]int)
+		for _, t := range faces {
+			parent[t] = t
+		}
+		for _, t := range faces {
+			for k := 0; k < 3; k++ {
+				o := indices[3*t+k]
+				if int(o) == v {
+					continue
+				}
+				if first, ok := other[o]; ok {
+					parent[find(t)] = find(first)
+				} else {
+					other[o] = t
+				}
+			}
+		}
+		fans := 0
+		for _, t := range faces {
+			if find(t) == t {
+				fans++
+			}
+		}
+		if fans > 1 {
+			r.NonManifoldVertices = append(r.NonManifoldVertices, uint32(v))
+		}
+	}
+	return r
+}
+
+// FixWinding flips triangles so that neighbouring ones agree on their
+// winding, and returns how many it flipped. In each connected part the
+// winding most triangles already have wins. Edges with more than two faces
+// do not connect. An error means a part is not orientable, like a Moebius
+// strip; the triangles are then left as they were.
+func FixWinding(indices []uint32) (int, error) {
+	nt := len(indices) / 3
+	uses, _ := edgeUses(indices)
+	flip := make([]bool, nt)
+	visited := make([]bool, nt)
+	var part []int
+	for seed := 0; seed < nt; seed++ {
+		if visited[seed] {
+			continue
+		}
+		visited[seed] = true
+		part = append(part[:0], seed)
+		for i := 0; i < len(part); i++ {
+			t := part[i]
+			for k := 0; k < 3; k++ {
+				a, b := indices[3*t+k], indices[3*t+(k+1)%3]
+				if a > b {
+					a, b = b, a
+				}
+				u := uses[edgeKey(a, b)]
+				if len(u) != 2 {
+					continue
+				}
+				self, other := u[0], u[1]
+				if self.t != t {
+					self, other = other, self
+				}
+				// Agreeing neighbours use the edge in opposite directions
+				want := flip[t] != (self.reversed == other.reversed)
+				if !visited[other.t] {
+					visited[other.t] = true
+					flip[other.t] = want
+					part = append(part, other.t)
+				} else if flip[other.t] != want && other.t != t {
+					return 0, errors.New("halfedge: mesh is not orientable")
+				}
+			}
+		}
+		flipped := 0
+		for _, t := range part {
+			if flip[t] {
+				flipped++
+			}
+		}
+		if 2*flipped > len(part) {
+			for _, t := range part {
+				flip[t] = !flip[t]
+			}
+		}
+	}
+
+	n := 0
+	for t, f := range flip {
+		if f {
+			indices[3*t+1], indices[3*t+2] = indices[3*t+2], indices[3*t+1]
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,87 @@
+package halfedge_test
+
+import (
+	"testing"
+
+	"mesh/halfedge"
+)
+
+func TestCheck(t *testing.T) {
+	flipped := box()
+	flipped[1], flipped[2] = flipped[2], flipped[1]
+	tests := []struct {
+		name                string
+		indices             []uint32
+		vertices            int
+		boundary            int
+		degenerate          []int
+		edges, verts, flips int
+		manifold, oriented  bool
+	}{
+		{"box", box(), 8, 0, nil, 0, 0, 0, true, true},
+		{"plane", plane(3), 9, 8, nil, 0, 0, 0, true, true},
+		{"flipped", flipped, 8, 0, nil, 0, 0, 3, true, false},
+		{"degenerate", []uint32{0, 1, 2, 3, 3, 4}, 5, 3, []int{1}, 0, 0, 0, false, true},
+		// Three triangles on edge 0-1
+		{"fin", []uint32{0, 1, 2, 1, 0, 3, 0, 1, 4}, 5, 6, nil, 1, 0, 0, false, true},
+		{"bowtie", []uint32{0, 1, 2, 0, 3, 4}, 5, 6, nil, 0, 1, 0, false, true},
+	}
+	for _, test := range tests {
+		r := halfedge.Check(test.indices, test.vertices)
+		if r.BoundaryEdges != test.boundary || len(r.Degenerate) != len(test.degenerate) ||
+			len(r.NonManifoldEdges) != test.edges || len(r.NonManifoldVertices) != test.verts || len(r.FlippedEdges) != test.flips {
+			t.Errorf("%s: got %+v", test.name, r)
+		}
+		for i, d := range test.degenerate {
+			if i < len(r.Degenerate) && r.Degenerate[i] != d {
+				t.Errorf("%s: degenerate triangles %v, want %v", test.name, r.Degenerate, test.degenerate)
+			}
+		}
+		if r.Manifold() != test.manifold || r.Oriented() != test.oriented {
+			t.Errorf("%s: manifold %v and oriented %v, want %v and %v", test.name, r.Manifold(), r.Oriented(), test.manifold, test.oriented)
+		}
+	}
+}
+
+func TestFixWinding(t *testing.T) {
+	// The minority of each part is flipped, whichever triangle comes first
+	for _, flip := range [][]int{{0}, {0, 1, 2, 3, 4}, {11}} {
+		indices := box()
+		for _, f := range flip {
+			indices[3*f+1], indices[3*f+2] = indices[3*f+2], indices[3*f+1]
+		}
+		n, err := halfedge.FixWinding(indices)
+		if err != nil || n != len(flip) {
+			t.Errorf("flipped %v: %d triangles flipped back, error %v", flip, n, err)
+		}
+		if r := halfedge.Check(indices, 8); !r.Oriented() {
+			t.Errorf("flipped %v: still flipped edges %v", flip, r.FlippedEdges)
+		}
+		build(t, indices, 8)
+	}
+
+	// Separate parts are oriented on their own
+	indices := []uint32{0, 1, 2, 1, 2, 3, 4, 6, 5}
+	if n, err := halfedge.FixWinding(indices); err != nil || n != 1 || !halfedge.Check(indices, 7).Oriented() {
+		t.Errorf("two parts: %d flipped, error %v, got %v", n, err, indices)
+	}
+}
+
+func TestFixWindingMoebius(t *testing.T) {
+	// A strip of three quads between the top vertices 0, 1, 2 and the
+	// bottom ones 3, 4, 5, whose last quad joins 2 to 3 and 5 to 0
+	quads := [][4]uint32{{0, 1, 4, 3}, {1, 2, 5, 4}, {2, 3, 0, 5}}
+	var indices []uint32
+	for _, q := range quads {
+		indices = append(indices, q[0], q[1], q[2], q[0], q[2], q[3])
+	}
+	before := append([]uint32(nil), indices...)
+	if _, err := halfedge.FixWinding(indices); err == nil {
+		t.Error("Moebius strip oriented")
+	}
+	for i := range indices {
+		if indices[i] != before[i] {
+			t.Fatalf("triangles changed to %v", indices)
+		}
+	}
+}
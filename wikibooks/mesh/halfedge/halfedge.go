@@ -0,0 +1,325 @@
+// Package halfedge answers topology questions about triangle meshes, like
+// which faces share an edge or where the borders are, and edits the
+// topology with edge flips, splits and collapses.
+//
+// Vertices are numbered like the indices the mesh was built from, so the
+// caller keeps positions and other attributes in its own arrays. Indices
+// describe topology: vertices duplicated on texture seams split the mesh
+// there, Welded numbers vertices by position instead.
+package halfedge
+
+import (
+	"fmt"
+
+	"mesh"
+)
+
+// HalfEdge is one side of an edge, running from Origin to the origin of
+// Next around Face. Borders are closed with half edges without a face,
+// which run around the hole.
+type HalfEdge struct {
+	Origin int
+	Twin   int
+	Next   int
+	Prev   int
+	// Face is -1 on borders.
+	Face int
+}
+
+type Vertex struct {
+	// HalfEdge is a half edge leaving the vertex, a border one if the
+	// vertex is on a border. It is -1 for isolated and removed vertices.
+	HalfEdge int
+}
+
+type Face struct {
+	// HalfEdge is one of the three half edges around the face, -1 if the
+	// face was removed.
+	HalfEdge int
+}
+
+// Mesh links triangles through their half edges. Removed elements stay in
+// the slices, marked with -1, so the numbers of the others do not change.
+type Mesh struct {
+	Vertices  []Vertex
+	Faces     []Face
+	HalfEdges []HalfEdge
+}
+
+// Build links the triangles of indices, which refer to vertexCount
+// vertices. The triangles must form an oriented manifold; Check tells what
+// is wrong with those which do not, and FixWinding repairs the orientation.
+func Build(indices []uint32, vertexCount int) (*Mesh, error) {
+	nt := len(indices) / 3
+	m := &Mesh{
+		Vertices:  make([]Vertex, vertexCount),
+		Faces:     make([]Face, nt),
+		HalfEdges: make([]HalfEdge, 3*nt),
+	}
+	for v := range m.Vertices {
+		m.Vertices[v].HalfEdge = -1
+	}
+	edges := make(map[uint64]int, 3*nt)
+	for t := 0; t < nt; t++ {
+		tri := indices[3*t : 3*t+3]
+		if tri[0] == tri[1] || tri[1] == tri[2] || tri[2] == tri[0] {
+			return nil, fmt.Errorf("halfedge: triangle %d is degenerate", t)
+		}
+		for k := 0; k < 3; k++ {
+			a, b := tri[k], tri[(k+1)%3]
+			if int(a) >= vertexCount {
+				return nil, fmt.Errorf("halfedge: index %d out of range", a)
+			}
+			h := 3*t + k
+			if _, ok := edges[edgeKey(a, b)]; ok {
+				return nil, fmt.Errorf("halfedge: edge %d-%d is used twice in the same direction", a, b)
+			}
+			edges[edgeKey(a, b)] = h
+			m.HalfEdges[h] = HalfEdge{Origin: int(a), Twin: -1, Next: 3*t + (k+1)%3, Prev: 3*t + (k+2)%3, Face: t}
+			m.Vertices[a].HalfEdge = h
+		}
+		m.Faces[t].HalfEdge = 3 * t
+	}
+
+	// Twins, and border half edges where there are none
+	borderFrom := make(map[int]int)
+	for h := 0; h < 3*nt; h++ {
+		a, b := m.HalfEdges[h].Origin, m.Target(h)
+		if t, ok := edges[edgeKey(uint32(b), uint32(a))]; ok {
+			m.HalfEdges[h].Twin = t
+			continue
+		}
+		g := len(m.HalfEdges)
+		m.HalfEdges = append(m.HalfEdges, HalfEdge{Origin: b, Twin: h, Next: -1, Prev: -1, Face: -1})
+		m.HalfEdges[h].Twin = g
+		if _, ok := borderFrom[b]; ok {
+			return nil, fmt.Errorf("halfedge: vertex %d joins several borders", b)
+		}
+		borderFrom[b] = g
+		m.Vertices[b].HalfEdge = g
+	}
+	for _, g := range borderFrom {
+		// The border continues from where g ends
+		next, ok := borderFrom[m.HalfEdges[m.HalfEdges[g].Twin].Origin]
+		if !ok {
+			return nil, fmt.Errorf("halfedge: border ends at vertex %d", m.HalfEdges[m.HalfEdges[g].Twin].Origin)
+		}
+		m.HalfEdges[g].Next = next
+		m.HalfEdges[next].Prev = g
+	}
+
+	// All faces around a vertex must form a single fan
+	count := make([]int, vertexCount)
+	for _, e := range m.HalfEdges {
+		count[e.Origin]++
+	}
+	for v := range m.Vertices {
+		if count[v] > 0 && len(m.Outgoing(v)) != count[v] {
+			return nil, fmt.Errorf("halfedge: vertex %d joins several fans of faces", v)
+		}
+	}
+	return m, nil
+}
+
+func edgeKey(a, b uint32) uint64 {
+	return uint64(a)<<32 | uint64(b)
+}
+
+// Target returns the vertex half edge h points to.
+func (m *Mesh) Target(h int) int {
+	return m.HalfEdges[m.HalfEdges[h].Next].Origin
+}
+
+// IsBoundary reports whether the edge of h has a face on one side only.
+func (m *Mesh) IsBoundary(h int) bool {
+	return m.HalfEdges[h].Face < 0 || m.HalfEdges[m.HalfEdges[h].Twin].Face < 0
+}
+
+// IsBoundaryVertex reports whether v is on a border. Isolated vertices are
+// not.
+func (m *Mesh) IsBoundaryVertex(v int) bool {
+	h := m.Vertices[v].HalfEdge
+	return h >= 0 && m.HalfEdges[h].Face < 0
+}
+
+// Outgoing returns the half edges leaving v, counter clockwise.
+func (m *Mesh) Outgoing(v int) []int {
+	start := m.Vertices[v].HalfEdge
+	if start < 0 {
+		return nil
+	}
+	var out []int
+	h := start
+	for {
+		out = append(out, h)
+		h = m.HalfEdges[m.HalfEdges[h].Prev].Twin
+		if h == start || len(out) > len(m.HalfEdges) {
+			return out
+		}
+	}
+}
+
+// Neighbours returns the vertices sharing an edge with v, counter clockwise.
+func (m *Mesh) Neighbours(v int) []int {
+	out := m.Outgoing(v)
+	for i, h := range out {
+		out[i] = m.Target(h)
+	}
+	return out
+}
+
+func (m *Mesh) Valence(v int) int {
+	return len(m.Outgoing(v))
+}
+
+// VertexFaces returns the faces around v, counter clockwise.
+func (m *Mesh) VertexFaces(v int) []int {
+	var faces []int
+	for _, h := range m.Outgoing(v) {
+		if f := m.HalfEdges[h].Face; f >= 0 {
+			faces = append(faces, f)
+		}
+	}
+	return faces
+}
+
+// FaceHalfEdges returns the half edges around face f.
+func (m *Mesh) FaceHalfEdges(f int) [3]int {
+	h := m.Faces[f].HalfEdge
+	next := m.HalfEdges[h].Next
+	return [3]int{h, next, m.HalfEdges[next].Next}
+}
+
+// FaceVertices returns the corners of face f, counter clockwise.
+func (m *Mesh) FaceVertices(f int) [3]int {
+	hs := m.FaceHalfEdges(f)
+	return [3]int{m.HalfEdges[hs[0]].Origin, m.HalfEdges[hs[1]].Origin, m.HalfEdges[hs[2]].Origin}
+}
+
+// FaceNeighbours returns the faces sharing an edge with f.
+func (m *Mesh) FaceNeighbours(f int) []int {
+	var faces []int
+	for _, h := range m.FaceHalfEdges(f) {
+		if o := m.HalfEdges[m.HalfEdges[h].Twin].Face; o >= 0 {
+			faces = append(faces, o)
+		}
+	}
+	return faces
+}
+
+// Find returns the half edge from a to b, or -1 if they share no edge.
+func (m *Mesh) Find(a, b int) int {
+	for _, h := range m.Outgoing(a) {
+		if m.Target(h) == b {
+			return h
+		}
+	}
+	return -1
+}
+
+// EdgeFaces returns the faces on either side of the edge between a and b.
+func (m *Mesh) EdgeFaces(a, b int) []int {
+	h := m.Find(a, b)
+	if h < 0 {
+		return nil
+	}
+	var faces []int
+	for _, e := range []int{h, m.HalfEdges[h].Twin} {
+		if f := m.HalfEdges[e].Face; f >= 0 {
+			faces = append(faces, f)
+		}
+	}
+	return faces
+}
+
+// BoundaryLoops returns the vertices around each hole, in the direction of
+// the border half edges.
+func (m *Mesh) BoundaryLoops() [][]int {
+	var loops [][]int
+	seen := make([]bool, len(m.HalfEdges))
+	for h, e := range m.HalfEdges {
+		if e.Origin < 0 || e.Face >= 0 || seen[h] {
+			continue
+		}
+		var loop []int
+		for g := h; !seen[g]; g = m.HalfEdges[g].Next {
+			seen[g] = true
+			loop = append(loop, m.HalfEdges[g].Origin)
+		}
+		loops = append(loops, loop)
+	}
+	return loops
+}
+
+// Indices returns the triangles of the remaining faces.
+func (m *Mesh) Indices() []uint32 {
+	var out []uint32
+	for f, face := range m.Faces {
+		if face.HalfEdge < 0 {
+			continue
+		}
+		for _, v := range m.FaceVertices(f) {
+			out = append(out, uint32(v))
+		}
+	}
+	return out
+}
+
+// Validate checks that all links agree with each other.
+func (m *Mesh) Validate() error {
+	for h, e := range m.HalfEdges {
+		if e.Origin < 0 {
+			continue
+		}
+		switch {
+		case m.HalfEdges[e.Twin].Twin != h:
+			return fmt.Errorf("halfedge: twin of half edge %d does not point back", h)
+		case m.HalfEdges[e.Next].Prev != h || m.HalfEdges[e.Prev].Next != h:
+			return fmt.Errorf("halfedge: half edge %d is not in a loop", h)
+		case m.HalfEdges[e.Twin].Origin != m.Target(h):
+			return fmt.Errorf("halfedge: twin of half edge %d starts at %d, not %d", h, m.HalfEdges[e.Twin].Origin, m.Target(h))
+		case m.HalfEdges[e.Next].Face != e.Face:
+			return fmt.Errorf("halfedge: half edge %d and the next have different faces", h)
+		case m.Vertices[e.Origin].HalfEdge < 0:
+			return fmt.Errorf("halfedge: half edge %d leaves removed vertex %d", h, e.Origin)
+		case e.Face < 0 && !m.IsBoundaryVertex(e.Origin):
+			return fmt.Errorf("halfedge: vertex %d does not start at its border", e.Origin)
+		}
+	}
+	for f, face := range m.Faces {
+		if face.HalfEdge < 0 {
+			continue
+		}
+		hs := m.FaceHalfEdges(f)
+		if m.HalfEdges[hs[2]].Next != hs[0] {
+			return fmt.Errorf("halfedge: face %d is not a triangle", f)
+		}
+		for _, h := range hs {
+			if m.HalfEdges[h].Face != f {
+				return fmt.Errorf("halfedge: half edge %d of face %d points to face %d", h, f, m.HalfEdges[h].Face)
+			}
+		}
+	}
+	for v, vertex := range m.Vertices {
+		if vertex.HalfEdge >= 0 && m.HalfEdges[vertex.HalfEdge].Origin != v {
+			return fmt.Errorf("halfedge: half edge of vertex %d leaves vertex %d", v, m.HalfEdges[vertex.HalfEdge].Origin)
+		}
+	}
+	return nil
+}
+
+// Welded returns the triangles of m with vertices numbered by position, so
+// that seams do not split the topology, and a vertex of m for each
+// position.
+func Welded(m *mesh.Mesh) (indices []uint32, vertices []int) {
+	ids, count := m.PositionIDs()
+	vertices = make([]int, count)
+	for v := len(ids) - 1; v >= 0; v-- {
+		vertices[ids[v]] = v
+	}
+	indices = make([]uint32, len(m.Indices))
+	for i, v := range m.Indices {
+		indices[i] = uint32(ids[v])
+	}
+	return indices, vertices
+}
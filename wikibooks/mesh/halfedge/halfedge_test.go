@@ -0,0 +1,204 @@
+package halfedge_test
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"mesh"
+	"mesh/halfedge"
+)
+
+// box returns the 12 triangles of a closed box whose 8 corners are shared
+// by the faces around them, counter clockwise seen from outside.
+func box() []uint32 {
+	var indices []uint32
+	quads := [][4]uint32{{4, 5, 7, 6}, {0, 2, 3, 1}, {1, 3, 7, 5}, {0, 4, 6, 2}, {2, 6, 7, 3}, {0, 1, 5, 4}}
+	for _, q := range quads {
+		indices = append(indices, q[0], q[1], q[2], q[0], q[2], q[3])
+	}
+	return indices
+}
+
+// plane returns the triangles of an open grid of n by n vertices, vertex
+// x, y being y*n + x, with all diagonals from the lower left.
+func plane(n int) []uint32 {
+	var indices []uint32
+	for y := 0; y < n-1; y++ {
+		for x := 0; x < n-1; x++ {
+			v := uint32(y*n + x)
+			indices = append(indices, v, v+1, v+uint32(n)+1, v, v+uint32(n)+1, v+uint32(n))
+		}
+	}
+	return indices
+}
+
+func build(t *testing.T, indices []uint32, vertexCount int) *halfedge.Mesh {
+	m, err := halfedge.Build(indices, vertexCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func sorted(s []int) []int {
+	s = append([]int(nil), s...)
+	sort.Ints(s)
+	return s
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name      string
+		indices   []uint32
+		vertices  int
+		halfEdges int
+		loops     int
+	}{
+		{"box", box(), 8, 36, 0},
+		// 24 half edges in faces and 8 around the border
+		{"plane", plane(3), 9, 32, 1},
+	}
+	for _, test := range tests {
+		m := build(t, test.indices, test.vertices)
+		if len(m.HalfEdges) != test.halfEdges || len(m.BoundaryLoops()) != test.loops {
+			t.Errorf("%s: %d half edges and %d border loops, want %d and %d", test.name, len(m.HalfEdges), len(m.BoundaryLoops()), test.halfEdges, test.loops)
+		}
+		got := m.Indices()
+		for i := range got {
+			if got[i] != test.indices[i] {
+				t.Errorf("%s: Indices() = %v, want %v", test.name, got, test.indices)
+				break
+			}
+		}
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		indices  []uint32
+		vertices int
+		err      string
+	}{
+		{"degenerate", []uint32{0, 1, 1}, 2, "degenerate"},
+		{"range", []uint32{0, 1, 2}, 2, "out of range"},
+		{"winding", []uint32{0, 1, 2, 0, 1, 3}, 4, "same direction"},
+		// Two triangles touching at vertex 0
+		{"bowtie", []uint32{0, 1, 2, 0, 3, 4}, 5, "several borders"},
+		// Two closed fans around vertex 0, the tips of two cones
+		{"cones", []uint32{
+			0, 1, 2, 0, 2, 3, 0, 3, 1, 1, 3, 2,
+			0, 4, 5, 0, 5, 6, 0, 6, 4, 4, 6, 5,
+		}, 7, "several fans"},
+	}
+	for _, test := range tests {
+		_, err := halfedge.Build(test.indices, test.vertices)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+		}
+	}
+}
+
+func TestAdjacency(t *testing.T) {
+	m := build(t, plane(3), 9)
+	tests := []struct {
+		v          int
+		neighbours []int
+		faces      int
+		boundary   bool
+	}{
+		{4, []int{0, 1, 3, 5, 7, 8}, 6, false},
+		{0, []int{1, 3, 4}, 2, true},
+		{2, []int{1, 5}, 1, true},
+		{8, []int{4, 5, 7}, 2, true},
+	}
+	for _, test := range tests {
+		if got := sorted(m.Neighbours(test.v)); !equal(got, test.neighbours) {
+			t.Errorf("vertex %d: neighbours %v, want %v", test.v, got, test.neighbours)
+		}
+		if m.Valence(test.v) != len(test.neighbours) || len(m.VertexFaces(test.v)) != test.faces || m.IsBoundaryVertex(test.v) != test.boundary {
+			t.Errorf("vertex %d: valence %d, %d faces and border %v", test.v, m.Valence(test.v), len(m.VertexFaces(test.v)), m.IsBoundaryVertex(test.v))
+		}
+	}
+
+	// Face 0 is 0, 1, 4 and face 1 is 0, 4, 3
+	if h := m.Find(0, 4); h < 0 || m.Target(h) != 4 || m.IsBoundary(h) {
+		t.Errorf("Find(0, 4) = %d", h)
+	}
+	if h := m.Find(0, 8); h != -1 {
+		t.Errorf("Find(0, 8) = %d, want -1", h)
+	}
+	if h := m.Find(1, 0); h < 0 || !m.IsBoundary(h) {
+		t.Errorf("edge 1-0 is not on the border")
+	}
+	if got := sorted(m.EdgeFaces(0, 4)); !equal(got, []int{0, 1}) {
+		t.Errorf("EdgeFaces(0, 4) = %v", got)
+	}
+	if got := m.EdgeFaces(1, 0); !equal(got, []int{0}) {
+		t.Errorf("EdgeFaces(1, 0) = %v", got)
+	}
+	if got := m.FaceVertices(1); got != [3]int{0, 4, 3} {
+		t.Errorf("FaceVertices(1) = %v", got)
+	}
+	// Face 3 is 1, 5, 4 in the lower right quad
+	if got := sorted(m.FaceNeighbours(3)); !equal(got, []int{0, 2, 6}) {
+		t.Errorf("FaceNeighbours(3) = %v", got)
+	}
+}
+
+func TestBoundaryLoops(t *testing.T) {
+	m := build(t, plane(3), 9)
+	loops := m.BoundaryLoops()
+	if len(loops) != 1 || !equal(sorted(loops[0]), []int{0, 1, 2, 3, 5, 6, 7, 8}) {
+		t.Fatalf("got loops %v", loops)
+	}
+	// Against the winding of the faces: clockwise seen from the front
+	loop := loops[0]
+	for i, v := range loop {
+		next := loop[(i+1)%len(loop)]
+		h := m.Find(v, next)
+		if h < 0 || m.HalfEdges[h].Face >= 0 {
+			t.Errorf("no border half edge from %d to %d", v, next)
+		}
+	}
+
+	// Two triangles with their own borders
+	m = build(t, []uint32{0, 1, 2, 3, 4, 5}, 6)
+	if loops := m.BoundaryLoops(); len(loops) != 2 || len(loops[0]) != 3 || len(loops[1]) != 3 {
+		t.Errorf("got loops %v", loops)
+	}
+}
+
+func TestWelded(t *testing.T) {
+	// Two triangles of a quad with the diagonal duplicated, like a seam
+	m := mesh.New()
+	m.Add(mesh.Position, 3, []float32{0, 0, 0, 1, 0, 0, 1, 1, 0, 0, 0, 0, 1, 1, 0, 0, 1, 0})
+	m.Indices = []uint32{0, 1, 2, 3, 4, 5}
+	if got := build(t, m.Indices, 6).BoundaryLoops(); len(got) != 2 {
+		t.Errorf("%d border loops before welding, want 2", len(got))
+	}
+	indices, vertices := halfedge.Welded(m)
+	if len(vertices) != 4 || vertices[0] != 0 || vertices[2] != 2 {
+		t.Errorf("welded vertices %v", vertices)
+	}
+	w := build(t, indices, len(vertices))
+	if got := w.BoundaryLoops(); len(got) != 1 || len(got[0]) != 4 {
+		t.Errorf("welded border loops %v, want one around the quad", got)
+	}
+}
@@ -0,0 +1,261 @@
+package halfedge
+
+import (
+	"errors"
+	"fmt"
+)
+
+func (m *Mesh) newHalfEdge(origin int) int {
+	m.HalfEdges = append(m.HalfEdges, HalfEdge{Origin: origin, Twin: -1, Next: -1, Prev: -1, Face: -1})
+	return len(m.HalfEdges) - 1
+}
+
+// setFace makes a, b and c the loop of half edges around face f.
+// valid reports whether h is a half edge which was not removed.
+func (m *Mesh) valid(h int) bool {
+	return h >= 0 && h < len(m.HalfEdges) && m.HalfEdges[h].Origin >= 0
+}
+
+func (m *Mesh) setFace(f, a, b, c int) {
+	hs := [3]int{a, b, c}
+	for k, h := range hs {
+		m.HalfEdges[h].Face = f
+		m.HalfEdges[h].Next = hs[(k+1)%3]
+		m.HalfEdges[h].Prev = hs[(k+2)%3]
+	}
+	m.Faces[f].HalfEdge = a
+}
+
+func (m *Mesh) setTwins(a, b int) {
+	m.HalfEdges[a].Twin = b
+	m.HalfEdges[b].Twin = a
+}
+
+// setOutgoing makes h the half edge of its origin, or a border half edge
+// of the origin if there is one.
+func (m *Mesh) setOutgoing(h int) {
+	v := m.HalfEdges[h].Origin
+	m.Vertices[v].HalfEdge = h
+	for _, o := range m.Outgoing(v) {
+		if m.HalfEdges[o].Face < 0 {
+			m.Vertices[v].HalfEdge = o
+			return
+		}
+	}
+}
+
+// FlipEdge replaces the edge of h, between two faces, with the other
+// diagonal of the quad they form. It fails on borders, if the diagonal is
+// already an edge, or if a vertex would be left with too few edges.
+func (m *Mesh) FlipEdge(h int) error {
+	if !m.valid(h) {
+		return fmt.Errorf("halfedge: no half edge %d", h)
+	}
+	t := m.HalfEdges[h].Twin
+	if m.IsBoundary(h) {
+		return errors.New("halfedge: can not flip a border edge")
+	}
+	// Faces a, b, c and b, a, d
+	h1, h2 := m.HalfEdges[h].Next, m.HalfEdges[h].Prev
+	t1, t2 := m.HalfEdges[t].Next, m.HalfEdges[t].Prev
+	a, b := m.HalfEdges[h].Origin, m.HalfEdges[t].Origin
+	c, d := m.HalfEdges[h2].Origin, m.HalfEdges[t2].Origin
+	if c == d || m.Find(c, d) >= 0 {
+		return errors.New("halfedge: flipped edge already exists")
+	}
+	for _, v := range []int{a, b} {
+		min := 4
+		if m.IsBoundaryVertex(v) {
+			min = 3
+		}
+		if m.Valence(v) < min {
+			return errors.New("halfedge: flip would leave a vertex with too few edges")
+		}
+	}
+
+	// Faces a, d, c and d, b, c
+	fh, ft := m.HalfEdges[h].Face, m.HalfEdges[t].Face
+	m.HalfEdges[h].Origin = d
+	m.HalfEdges[t].Origin = c
+	m.setFace(fh, t1, h, h2)
+	m.setFace(ft, t2, h1, t)
+	if m.Vertices[a].HalfEdge == h {
+		m.Vertices[a].HalfEdge = t1
+	}
+	if m.Vertices[b].HalfEdge == t {
+		m.Vertices[b].HalfEdge = h1
+	}
+	return nil
+}
+
+// SplitEdge inserts a new vertex in the middle of the edge of h, splitting
+// the faces on either side in two, and returns the vertex. Attributes of
+// the new vertex are up to the caller. It returns -1 if there is no half
+// edge h.
+func (m *Mesh) SplitEdge(h int) int {
+	if !m.valid(h) {
+		return -1
+	}
+	if m.HalfEdges[h].Face < 0 {
+		h = m.HalfEdges[h].Twin
+	}
+	t := m.HalfEdges[h].Twin
+	v := len(m.Vertices)
+	m.Vertices = append(m.Vertices, Vertex{HalfEdge: -1})
+
+	// h becomes a-v, t becomes b-v, their new twins v-b and v-a
+	vb, va := m.newHalfEdge(v), m.newHalfEdge(v)
+	m.setTwins(t, vb)
+	m.setTwins(h, va)
+
+	// Face a, b, c becomes a, v, c and v, b, c
+	h1, h2 := m.HalfEdges[h].Next, m.HalfEdges[h].Prev
+	c := m.HalfEdges[h2].Origin
+	cv, vc := m.newHalfEdge(c), m.newHalfEdge(v)
+	m.setTwins(cv, vc)
+	f := len(m.Faces)
+	m.Faces = append(m.Faces, Face{})
+	m.setFace(m.HalfEdges[h].Face, h, vc, h2)
+	m.setFace(f, vb, h1, cv)
+
+	if g := m.HalfEdges[t].Face; g >= 0 {
+		// Face b, a, d becomes b, v, d and v, a, d
+		t1, t2 := m.HalfEdges[t].Next, m.HalfEdges[t].Prev
+		d := m.HalfEdges[t2].Origin
+		dv, vd := m.newHalfEdge(d), m.newHalfEdge(v)
+		m.setTwins(dv, vd)
+		f := len(m.Faces)
+		m.Faces = append(m.Faces, Face{})
+		m.setFace(g, t, vd, t2)
+		m.setFace(f, va, t1, dv)
+		m.Vertices[v].HalfEdge = va
+	} else {
+		// The border runs b, v, a
+		next := m.HalfEdges[t].Next
+		m.HalfEdges[t].Next = va
+		m.HalfEdges[va].Prev = t
+		m.HalfEdges[va].Next = next
+		m.HalfEdges[next].Prev = va
+		m.Vertices[v].HalfEdge = va
+	}
+	return v
+}
+
+// CanCollapse reports whether CollapseEdge(h) keeps the mesh a manifold.
+// Removed half edges can not be collapsed.
+func (m *Mesh) CanCollapse(h int) bool {
+	if !m.valid(h) {
+		return false
+	}
+	t := m.HalfEdges[h].Twin
+	a, b := m.HalfEdges[h].Origin, m.HalfEdges[t].Origin
+	if !m.IsBoundary(h) && m.IsBoundaryVertex(a) && m.IsBoundaryVertex(b) {
+		// The mesh would pinch together at one vertex
+		return false
+	}
+
+	// The vertices opposite the edge must be the only common neighbours
+	var opposite []int
+	for _, e := range []int{h, t} {
+		if m.HalfEdges[e].Face < 0 {
+			continue
+		}
+		prev := m.HalfEdges[e].Prev
+		o := m.HalfEdges[prev].Origin
+		opposite = append(opposite, o)
+		// Its faces would fold onto each other, like those of a collapsed
+		// tetrahedron
+		if !m.IsBoundaryVertex(o) && m.Valence(o) <= 3 {
+			return false
+		}
+		// The two other edges of the face become one, which must not be
+		// left without faces
+		if m.HalfEdges[m.HalfEdges[prev].Twin].Face < 0 && m.HalfEdges[m.HalfEdges[m.HalfEdges[e].Next].Twin].Face < 0 {
+			return false
+		}
+	}
+	common := 0
+	nb := m.Neighbours(b)
+	for _, x := range m.Neighbours(a) {
+		for _, y := range nb {
+			if x == y {
+				common++
+			}
+		}
+	}
+	if common != len(opposite) {
+		return false
+	}
+
+	// Faces of a moved to b must not duplicate faces of b
+	for _, f := range m.VertexFaces(a) {
+		vs := m.FaceVertices(f)
+		for k, v := range vs {
+			if v != a {
+				continue
+			}
+			x, y := vs[(k+1)%3], vs[(k+2)%3]
+			if x == b || y == b {
+				break
+			}
+			if e := m.Find(b, x); e >= 0 && m.HalfEdges[e].Face >= 0 && m.Target(m.HalfEdges[e].Next) == y {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// CollapseEdge merges the origin of h into its target, removing the faces
+// on the edge. The origin is removed; its attributes are no longer used.
+func (m *Mesh) CollapseEdge(h int) error {
+	if !m.valid(h) {
+		return fmt.Errorf("halfedge: no half edge %d", h)
+	}
+	if !m.CanCollapse(h) {
+		return errors.New("halfedge: collapse would break the manifold")
+	}
+	t := m.HalfEdges[h].Twin
+	a, b := m.HalfEdges[h].Origin, m.HalfEdges[t].Origin
+	moved := m.Outgoing(a)
+
+	// remove takes e, from x to y, and its face out of the mesh
+	remove := func(e int) {
+		if m.HalfEdges[e].Face < 0 {
+			prev, next := m.HalfEdges[e].Prev, m.HalfEdges[e].Next
+			m.HalfEdges[prev].Next = next
+			m.HalfEdges[next].Prev = prev
+			m.HalfEdges[e].Origin = -1
+			return
+		}
+		// The face x, y, z: its edges y-z and z-x become one
+		e1, e2 := m.HalfEdges[e].Next, m.HalfEdges[e].Prev
+		o1, o2 := m.HalfEdges[e1].Twin, m.HalfEdges[e2].Twin
+		m.setTwins(o1, o2)
+		z := m.HalfEdges[e2].Origin
+		if m.Vertices[z].HalfEdge == e2 {
+			m.Vertices[z].HalfEdge = o1
+		}
+		m.Faces[m.HalfEdges[e].Face].HalfEdge = -1
+		for _, r := range []int{e, e1, e2} {
+			m.HalfEdges[r].Origin = -1
+		}
+	}
+	remove(h)
+	remove(t)
+	for _, e := range moved {
+		if m.HalfEdges[e].Origin == a {
+			m.HalfEdges[e].Origin = b
+		}
+	}
+	m.Vertices[a].HalfEdge = -1
+
+	// b keeps an outgoing half edge that is still there
+	for _, e := range moved {
+		if m.HalfEdges[e].Origin == b {
+			m.setOutgoing(e)
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package halfedge_test
+
+import (
+	"testing"
+
+	"mesh/halfedge"
+)
+
+// valid fails t unless the links of m agree and its triangles are an
+// oriented manifold with border edges.
+func valid(t *testing.T, name string, m *halfedge.Mesh, border int) {
+	t.Helper()
+	if err := m.Validate(); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	r := halfedge.Check(m.Indices(), len(m.Vertices))
+	if !r.Manifold() || !r.Oriented() || r.BoundaryEdges != border {
+		t.Fatalf("%s: got %+v, want %d border edges", name, r, border)
+	}
+}
+
+// faces counts the faces which were not removed.
+func faces(m *halfedge.Mesh) int {
+	return len(m.Indices()) / 3
+}
+
+func TestFlipEdge(t *testing.T) {
+	m := build(t, box(), 8)
+	// The diagonal of the quad 0, 2, 3, 1
+	h := m.Find(0, 3)
+	if err := m.FlipEdge(h); err != nil {
+		t.Fatal(err)
+	}
+	valid(t, "box", m, 0)
+	if m.Find(0, 3) >= 0 || m.Find(1, 2) < 0 || faces(m) != 12 {
+		t.Errorf("box: diagonal 0-3 not flipped to 1-2")
+	}
+	if err := m.FlipEdge(h); err != nil {
+		t.Fatal(err)
+	}
+	valid(t, "box flipped back", m, 0)
+	if m.Find(0, 3) < 0 {
+		t.Errorf("box: diagonal 0-3 not flipped back")
+	}
+
+	m = build(t, plane(3), 9)
+	if err := m.FlipEdge(m.Find(4, 8)); err != nil {
+		t.Fatal(err)
+	}
+	valid(t, "plane", m, 8)
+	if m.Find(5, 7) < 0 {
+		t.Errorf("plane: diagonal 4-8 not flipped to 5-7")
+	}
+}
+
+func TestFlipEdgeErrors(t *testing.T) {
+	m := build(t, plane(3), 9)
+	if err := m.FlipEdge(m.Find(0, 1)); err == nil {
+		t.Error("border edge flipped")
+	}
+	// The other diagonal of a tetrahedron is an edge already
+	tet := build(t, []uint32{0, 2, 1, 0, 1, 3, 1, 2, 3, 2, 0, 3}, 4)
+	if err := tet.FlipEdge(tet.Find(0, 1)); err == nil {
+		t.Error("tetrahedron edge flipped")
+	}
+	for _, h := range []int{-1, len(m.HalfEdges)} {
+		if err := m.FlipEdge(h); err == nil {
+			t.Errorf("half edge %d flipped", h)
+		}
+	}
+	valid(t, "plane", m, 8)
+	valid(t, "tetrahedron", tet, 0)
+}
+
+func TestSplitEdge(t *testing.T) {
+	m := build(t, box(), 8)
+	v := m.SplitEdge(m.Find(0, 3))
+	valid(t, "box", m, 0)
+	if v != 8 || faces(m) != 14 || m.Valence(v) != 4 || m.Find(0, 3) >= 0 || m.Find(0, v) < 0 || m.Find(v, 3) < 0 {
+		t.Errorf("box: split into vertex %d of valence %d, %d faces", v, m.Valence(v), faces(m))
+	}
+	// An edge of the new vertex
+	if w := m.SplitEdge(m.Find(v, 2)); w != 9 {
+		t.Errorf("box: second split made vertex %d", w)
+	}
+	valid(t, "box split twice", m, 0)
+
+	m = build(t, plane(3), 9)
+	// Either half edge of a border edge
+	for _, h := range []int{m.Find(0, 1), m.Find(7, 6)} {
+		v := m.SplitEdge(h)
+		if !m.IsBoundaryVertex(v) || m.Valence(v) != 3 {
+			t.Errorf("plane: border vertex %d has valence %d", v, m.Valence(v))
+		}
+	}
+	valid(t, "plane", m, 10)
+	if loops := m.BoundaryLoops(); len(loops) != 1 || len(loops[0]) != 10 {
+		t.Errorf("plane: border loops %v", loops)
+	}
+	if v := m.SplitEdge(-1); v != -1 {
+		t.Errorf("half edge -1 split into vertex %d", v)
+	}
+}
+
+func TestCollapseEdge(t *testing.T) {
+	m := build(t, box(), 8)
+	h := m.Find(0, 3)
+	if err := m.CollapseEdge(h); err != nil {
+		t.Fatal(err)
+	}
+	valid(t, "box", m, 0)
+	if faces(m) != 10 || m.Vertices[0].HalfEdge != -1 || m.Valence(3) != 6 {
+		t.Errorf("box: %d faces and vertex 3 of valence %d after collapsing 0 into 3", faces(m), m.Valence(3))
+	}
+	// The removed half edge can not be collapsed again
+	if m.CanCollapse(h) || m.CollapseEdge(h) == nil {
+		t.Error("removed half edge collapsed")
+	}
+	if m.CanCollapse(-1) || m.CanCollapse(len(m.HalfEdges)) {
+		t.Error("missing half edges collapsible")
+	}
+
+	m = build(t, plane(3), 9)
+	// A border edge removes one face and keeps the border
+	if err := m.CollapseEdge(m.Find(1, 0)); err != nil {
+		t.Fatal(err)
+	}
+	valid(t, "plane border", m, 7)
+	if faces(m) != 7 || !m.IsBoundaryVertex(0) {
+		t.Errorf("plane: %d faces after collapsing border edge 1-0", faces(m))
+	}
+	// The inner vertex onto the border removes two
+	if err := m.CollapseEdge(m.Find(4, 5)); err != nil {
+		t.Fatal(err)
+	}
+	valid(t, "plane inner", m, 7)
+	if faces(m) != 5 {
+		t.Errorf("plane: %d faces after collapsing 4 into 5", faces(m))
+	}
+}
+
+func TestCanCollapse(t *testing.T) {
+	m := build(t, plane(3), 9)
+	// Both ends on the border, but the edge inside: the plane would pinch
+	// at one vertex
+	if m.CanCollapse(m.Find(1, 5)) || !m.CanCollapse(m.Find(1, 4)) {
+		t.Errorf("plane: collapsing 1-5 allowed or 1-4 not")
+	}
+	tet := build(t, []uint32{0, 2, 1, 0, 1, 3, 1, 2, 3, 2, 0, 3}, 4)
+	for h := range tet.HalfEdges {
+		if tet.CanCollapse(h) {
+			t.Errorf("tetrahedron: half edge %d collapsible", h)
+		}
+	}
+}
+
+// Collapsing whatever edges can be collapsed keeps the mesh valid, down to
+// a tetrahedron for the box.
+func TestCollapseAll(t *testing.T) {
+	tests := []struct {
+		name     string
+		indices  []uint32
+		vertices int
+		closed   bool
+	}{
+		{"box", box(), 8, true},
+		{"plane", plane(4), 16, false},
+	}
+	for _, test := range tests {
+		m := build(t, test.indices, test.vertices)
+		for collapsed := true; collapsed; {
+			collapsed = false
+			for h := range m.HalfEdges {
+				if !m.CanCollapse(h) {
+					continue
+				}
+				if err := m.CollapseEdge(h); err != nil {
+					t.Fatalf("%s: %v", test.name, err)
+				}
+				if err := m.Validate(); err != nil {
+					t.Fatalf("%s: after collapsing half edge %d: %v", test.name, h, err)
+				}
+				r := halfedge.Check(m.Indices(), len(m.Vertices))
+				if !r.Manifold() || !r.Oriented() || (r.BoundaryEdges == 0) != test.closed {
+					t.Fatalf("%s: after collapsing half edge %d: %+v", test.name, h, r)
+				}
+				collapsed = true
+			}
+		}
+		if test.closed && faces(m) != 4 {
+			t.Errorf("%s: collapsed to %d faces, want a tetrahedron", test.name, faces(m))
+		}
+		if !test.closed && faces(m) != 1 {
+			t.Errorf("%s: collapsed to %d faces, want one triangle", test.name, faces(m))
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package mesh
+
+import (
+	"fmt"
+)
+
+// VertexElement describes where an attribute is stored inside an
+// interleaved vertex.
+type VertexElement struct {
+	Semantic Semantic
+	Name     string
+	Size     int
+	// Offset is the byte offset from the start of the vertex.
+	Offset int
+}
+
+// VertexLayout describes interleaved float vertices, ready to be passed to
+// glVertexAttribPointer.
+type VertexLayout struct {
+	Elements []VertexElement
+	// Stride is the size of one vertex in bytes.
+	Stride int
+}
+
+// Element returns the element with the given attribute name.
+func (l VertexLayout) Element(name string) (VertexElement, bool) {
+	for _, e := range l.Elements {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return VertexElement{}, false
+}
+
+func (l VertexLayout) String() string {
+	s := ""
+	for _, e := range l.Elements {
+		s += fmt.Sprintf("%s:%d@%d ", e.Name, e.Size, e.Offset)
+	}
+	return fmt.Sprintf("%sstride %d", s, l.Stride)
+}
+
+// Layout returns the interleaved layout of the mesh attributes, in the
+// order they were added.
+func (m *Mesh) Layout() VertexLayout {
+	var l VertexLayout
+	for _, a := range m.Attributes {
+		l.Elements = append(l.Elements, VertexElement{a.Semantic, a.Name, a.Size, l.Stride})
+		l.Stride += a.Size * 4
+	}
+	return l
+}
+
+// Interleave packs all attributes into a single array, one vertex after
+// the other, e.g. x y z r g b x y z r g b for positions and colors.
+func (m *Mesh) Interleave() ([]float32, VertexLayout) {
+	l := m.Layout()
+	n := m.VertexCount()
+	floats := l.Stride / 4
+	data := make([]float32, n*floats)
+	for i, a := range m.Attributes {
+		offset := l.Elements[i].Offset / 4
+		for v := 0; v < n; v++ {
+			copy(data[v*floats+offset:], a.Get(v))
+		}
+	}
+	return data, l
+}
+
+// Deinterleave splits interleaved vertex data back into attributes. The
+// returned mesh has no indices.
+func Deinterleave(data []float32, l VertexLayout) *Mesh {
+	m := New()
+	floats := l.Stride / 4
+	n := len(data) / floats
+	for _, e := range l.Elements {
+		values := make([]float32, 0, n*e.Size)
+		for v := 0; v < n; v++ {
+			start := v*floats + e.Offset/4
+			values = append(values, data[start:start+e.Size]...)
+		}
+		m.AddNamed(e.Semantic, e.Name, e.Size, values)
+	}
+	return m
+}
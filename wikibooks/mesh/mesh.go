@@ -0,0 +1,189 @@
+package mesh
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Semantic tells what a vertex attribute means.
+type Semantic int
+
+const (
+	Position Semantic = iota
+	Normal
+	Color
+	TexCoord
+	Tangent
+	// Joints and Weights bind a vertex to up to four skeleton joints.
+	Joints
+	Weights
+)
+
+var semanticNames = []string{"position", "normal", "color", "texcoord", "tangent", "joints", "weights"}
+
+func (s Semantic) String() string {
+	if s < 0 || int(s) >= len(semanticNames) {
+		return fmt.Sprintf("Semantic(%d)", int(s))
+	}
+	return semanticNames[s]
+}
+
+// DefaultName returns the shader attribute name used by the tutorials for
+// the semantic.
+func (s Semantic) DefaultName() string {
+	switch s {
+	case Position:
+		return "coord3d"
+	case Normal:
+		return "v_normal"
+	case Color:
+		return "v_color"
+	case TexCoord:
+		return "texcoord"
+	case Tangent:
+		return "v_tangent"
+	case Joints:
+		return "v_joints"
+	case Weights:
+		return "v_weights"
+	}
+	return s.String()
+}
+
+// Attribute is one vertex attribute stored as Size floats per vertex.
+type Attribute struct {
+	Semantic Semantic
+	// Name is the attribute name in the vertex shader.
+	Name string
+	Size int
+	Data []float32
+}
+
+func (a *Attribute) Count() int {
+	return len(a.Data) / a.Size
+}
+
+// Get returns the components of vertex i.
+func (a *Attribute) Get(i int) []float32 {
+	return a.Data[i*a.Size : (i+1)*a.Size]
+}
+
+// Mesh is an indexed triangle mesh. All attributes have the same number of
+// vertices; every three indices form a counter clockwise triangle.
+type Mesh struct {
+	Attributes []*Attribute
+	Indices    []uint32
+}
+
+func New() *Mesh {
+	return &Mesh{}
+}
+
+// Add adds or replaces the attribute of the given semantic, named after
+// Semantic.DefaultName.
+func (m *Mesh) Add(s Semantic, size int, data []float32) *Attribute {
+	return m.AddNamed(s, s.DefaultName(), size, data)
+}
+
+func (m *Mesh) AddNamed(s Semantic, name string, size int, data []float32) *Attribute {
+	a := &Attribute{Semantic: s, Name: name, Size: size, Data: data}
+	for i, old := range m.Attributes {
+		if old.Semantic == s {
+			m.Attributes[i] = a
+			return a
+		}
+	}
+	m.Attributes = append(m.Attributes, a)
+	return a
+}
+
+// Attribute returns the attribute of the given semantic or nil.
+func (m *Mesh) Attribute(s Semantic) *Attribute {
+	for _, a := range m.Attributes {
+		if a.Semantic == s {
+			return a
+		}
+	}
+	return nil
+}
+
+func (m *Mesh) Remove(s Semantic) {
+	for i, a := range m.Attributes {
+		if a.Semantic == s {
+			m.Attributes = append(m.Attributes[:i], m.Attributes[i+1:]...)
+			return
+		}
+	}
+}
+
+// Positions returns the xyz positions, or nil.
+func (m *Mesh) Positions() []float32 {
+	if a := m.Attribute(Position); a != nil {
+		return a.Data
+	}
+	return nil
+}
+
+func (m *Mesh) VertexCount() int {
+	if len(m.Attributes) == 0 {
+		return 0
+	}
+	return m.Attributes[0].Count()
+}
+
+func (m *Mesh) TriangleCount() int {
+	return len(m.Indices) / 3
+}
+
+// Triangle returns the vertex indices of triangle t.
+func (m *Mesh) Triangle(t int) (a, b, c uint32) {
+	return m.Indices[3*t], m.Indices[3*t+1], m.Indices[3*t+2]
+}
+
+// Validate checks that the attributes agree on the vertex count and all
+// indices are in range.
+func (m *Mesh) Validate() error {
+	if m.Attribute(Position) == nil {
+		return errors.New("mesh: no positions")
+	}
+	n := m.VertexCount()
+	for _, a := range m.Attributes {
+		if a.Size < 1 || a.Size > 4 {
+			return fmt.Errorf("mesh: %s has %d components", a.Name, a.Size)
+		}
+		if len(a.Data)%a.Size != 0 || a.Count() != n {
+			return fmt.Errorf("mesh: %s has %d values, want %d vertices of %d", a.Name, len(a.Data), n, a.Size)
+		}
+	}
+	if len(m.Indices)%3 != 0 {
+		return errors.New("mesh: index count is not a multiple of 3")
+	}
+	for _, i := range m.Indices {
+		if int(i) >= n {
+			return fmt.Errorf("mesh: index %d out of range", i)
+		}
+	}
+	return nil
+}
+
+// Clone returns a deep copy.
+func (m *Mesh) Clone() *Mesh {
+	c := &Mesh{Indices: append([]uint32(nil), m.Indices...)}
+	for _, a := range m.Attributes {
+		c.AddNamed(a.Semantic, a.Name, a.Size, append([]float32(nil), a.Data...))
+	}
+	return c
+}
+
+// Indices16 returns the indices as 16 bit values if every vertex can be
+// addressed with them, which halves the size of the index buffer.
+func (m *Mesh) Indices16() ([]uint16, bool) {
+	if m.VertexCount() > 1<<16 {
+		return nil, false
+	}
+	out := make([]uint16, len(m.Indices))
+	for i, v := range m.Indices {
+		out[i] = uint16(v)
+	}
+	return out, true
+}
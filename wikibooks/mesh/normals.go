@@ -0,0 +1,173 @@
+package mesh
+
+import (
+	"math"
+)
+
+type vec3 [3]float64
+
+func (a vec3) add(b vec3) vec3      { return vec3{a[0] + b[0], a[1] + b[1], a[2] + b[2]} }
+func (a vec3) sub(b vec3) vec3      { return vec3{a[0] - b[0], a[1] - b[1], a[2] - b[2]} }
+func (a vec3) scale(s float64) vec3 { return vec3{a[0] * s, a[1] * s, a[2] * s} }
+func (a vec3) dot(b vec3) float64   { return a[0]*b[0] + a[1]*b[1] + a[2]*b[2] }
+
+func (a vec3) cross(b vec3) vec3 {
+	return vec3{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+
+func (a vec3) normalized() vec3 {
+	if l := math.Sqrt(a.dot(a)); l > 0 {
+		return a.scale(1 / l)
+	}
+	return a
+}
+
+func (a *Attribute) vec3(i uint32) vec3 {
+	d := a.Data[int(i)*a.Size:]
+	return vec3{float64(d[0]), float64(d[1]), float64(d[2])}
+}
+
+// cornerAngle returns the interior angle of a triangle at p, between the
+// edges to q and r.
+func cornerAngle(p, q, r vec3) float64 {
+	a, b := q.sub(p).normalized(), r.sub(p).normalized()
+	return math.Acos(math.Max(-1, math.Min(1, a.dot(b))))
+}
+
+// FlatNormals gives every face its own normal. Vertices are split where
+// faces of different orientation meet.
+func (m *Mesh) FlatNormals() {
+	m.ComputeNormals(0)
+}
+
+// SmoothNormals averages the normals of all faces around each position.
+func (m *Mesh) SmoothNormals() {
+	m.ComputeNormals(math.Pi)
+}
+
+// ComputeNormals sets the Normal attribute from the triangles. At each
+// position, the normal of a face is averaged with the normals of the faces
+// whose normal differs by at most creaseAngle radians, weighted by the
+// angle of each face at that position. Steeper edges stay sharp; vertices
+// on them are split so every face keeps its own normal. Vertices within
+// the tolerance of PositionIDs are treated as one, so texture seams are
+// smoothed over. Vertices which no triangle uses are dropped.
+func (m *Mesh) ComputeNormals(creaseAngle float32) {
+	pos := m.Attribute(Position)
+	if pos == nil {
+		return
+	}
+	// Drop the old normals, so split vertices do not copy them
+	m.Remove(Normal)
+
+	nt := m.TriangleCount()
+	faceNormals := make([]vec3, nt)
+	// Angle of each corner, zero for degenerate triangles
+	angles := make([]float64, len(m.Indices))
+	for t := 0; t < nt; t++ {
+		a, b, c := m.Triangle(t)
+		pa, pb, pc := pos.vec3(a), pos.vec3(b), pos.vec3(c)
+		n := pb.sub(pa).cross(pc.sub(pa))
+		if n.dot(n) == 0 {
+			continue
+		}
+		faceNormals[t] = n.normalized()
+		angles[3*t] = cornerAngle(pa, pb, pc)
+		angles[3*t+1] = cornerAngle(pb, pc, pa)
+		angles[3*t+2] = cornerAngle(pc, pa, pb)
+	}
+
+	// Corners sharing a position
+	ids, count := m.PositionIDs()
+	byPosition := make([][]int, count)
+	for c, v := range m.Indices {
+		byPosition[ids[v]] = append(byPosition[ids[v]], c)
+	}
+
+	limit := math.Cos(float64(creaseAngle)) - 1e-6
+	cornerNormals := make([]vec3, len(m.Indices))
+	for _, corners := range byPosition {
+		for _, c := range corners {
+			fn := faceNormals[c/3]
+			var sum vec3
+			for _, o := range corners {
+				on := faceNormals[o/3]
+				if o == c || fn.dot(on) >= limit {
+					sum = sum.add(on.scale(angles[o]))
+				}
+			}
+			if sum.dot(sum) == 0 {
+				sum = fn
+			}
+			cornerNormals[c] = sum.normalized()
+		}
+	}
+
+	// One vertex per distinct normal at each original vertex
+	type key struct {
+		vertex uint32
+		normal [3]float32
+	}
+	vertices := make(map[key]uint32)
+	var origin []uint32
+	var normals []float32
+	indices := make([]uint32, len(m.Indices))
+	for c, v := range m.Indices {
+		n := cornerNormals[c]
+		k := key{v, [3]float32{float32(n[0]), float32(n[1]), float32(n[2])}}
+		i, ok := vertices[k]
+		if !ok {
+			i = uint32(len(origin))
+			vertices[k] = i
+			origin = append(origin, v)
+			normals = append(normals, k.normal[:]...)
+		}
+		indices[c] = i
+	}
+	final := m.split(origin, indices)
+	m.Add(Normal, 3, scatter(normals, 3, final))
+}
+
+// split rebuilds the vertices for corners which were given new vertex
+// numbers, where new vertex i copies old vertex origin[i]. The new vertices
+// keep the order of the old ones. The returned slice maps the given numbers
+// to the final ones.
+func (m *Mesh) split(origin, corners []uint32) []uint32 {
+	// Counting sort by origin
+	start := make([]uint32, m.VertexCount()+1)
+	for _, o := range origin {
+		start[o+1]++
+	}
+	for i := 1; i < len(start); i++ {
+		start[i] += start[i-1]
+	}
+	final := make([]uint32, len(origin))
+	order := make([]uint32, len(origin))
+	for i, o := range origin {
+		final[i] = start[o]
+		order[start[o]] = o
+		start[o]++
+	}
+	for _, a := range m.Attributes {
+		data := make([]float32, 0, len(origin)*a.Size)
+		for _, v := range order {
+			data = append(data, a.Get(int(v))...)
+		}
+		a.Data = data
+	}
+	m.Indices = make([]uint32, len(corners))
+	for c, v := range corners {
+		m.Indices[c] = final[v]
+	}
+	return final
+}
+
+// scatter moves per vertex values from the numbering given to split to the
+// final one.
+func scatter(data []float32, size int, final []uint32) []float32 {
+	out := make([]float32, len(data))
+	for i, f := range final {
+		copy(out[int(f)*size:int(f+1)*size], data[i*size:(i+1)*size])
+	}
+	return out
+}
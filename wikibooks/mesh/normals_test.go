@@ -0,0 +1,154 @@
+package mesh_test
+
+import (
+	"math"
+	"testing"
+
+	"mesh"
+	"mesh/shape"
+)
+
+// cube returns a unit cube from 0 to 1 whose 8 corners are shared by the
+// faces around them. Corner i is at x = i&1, y = i>>1&1, z = i>>2&1.
+func cube() *mesh.Mesh {
+	m := mesh.New()
+	var pos []float32
+	for i := 0; i < 8; i++ {
+		pos = append(pos, float32(i&1), float32(i>>1&1), float32(i>>2&1))
+	}
+	m.Add(mesh.Position, 3, pos)
+	// Counter clockwise seen from outside
+	quads := [][4]uint32{{4, 5, 7, 6}, {0, 2, 3, 1}, {1, 3, 7, 5}, {0, 4, 6, 2}, {2, 6, 7, 3}, {0, 1, 5, 4}}
+	for _, q := range quads {
+		m.Indices = append(m.Indices, q[0], q[1], q[2], q[0], q[2], q[3])
+	}
+	return m
+}
+
+func dot(a, b []float32) float64 {
+	return float64(a[0]*b[0] + a[1]*b[1] + a[2]*b[2])
+}
+
+func cross(a, b []float32) []float32 {
+	return []float32{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+
+func TestCubeNormals(t *testing.T) {
+	m := cube()
+	m.ComputeNormals(math.Pi / 3)
+	// Every edge is a crease, so each corner splits into one vertex per face
+	if n := m.VertexCount(); n != 24 {
+		t.Errorf("got %d vertices, want 24", n)
+	}
+	if n := m.TriangleCount(); n != 12 {
+		t.Errorf("got %d triangles, want 12", n)
+	}
+	pos, normals := m.Attribute(mesh.Position), m.Attribute(mesh.Normal)
+	for v := 0; v < m.VertexCount(); v++ {
+		p, n := pos.Get(v), normals.Get(v)
+		// Axis aligned and pointing away from the center
+		out := []float32{p[0] - 0.5, p[1] - 0.5, p[2] - 0.5}
+		if math.Abs(dot(n, n)-1) > 1e-6 || math.Abs(dot(n, out)-0.5) > 1e-6 {
+			t.Errorf("vertex %d at %v has normal %v", v, p, n)
+		}
+	}
+
+	m = cube()
+	m.SmoothNormals()
+	if n := m.VertexCount(); n != 8 {
+		t.Errorf("smooth: got %d vertices, want 8", n)
+	}
+	pos, normals = m.Attribute(mesh.Position), m.Attribute(mesh.Normal)
+	for v := 0; v < m.VertexCount(); v++ {
+		p, n := pos.Get(v), normals.Get(v)
+		out := []float32{p[0] - 0.5, p[1] - 0.5, p[2] - 0.5}
+		// Every corner has three faces with right angles, so the normal
+		// points along the diagonal
+		if math.Abs(dot(n, out)-math.Sqrt(3)/2) > 1e-6 {
+			t.Errorf("smooth: vertex %d at %v has normal %v", v, p, n)
+		}
+	}
+}
+
+func TestSphereNormals(t *testing.T) {
+	for _, m := range []*mesh.Mesh{shape.UVSphere(1, 32, 16), shape.Icosphere(1, 2)} {
+		m.Remove(mesh.Normal)
+		vertices := m.VertexCount()
+		m.SmoothNormals()
+		if n := m.VertexCount(); n != vertices {
+			t.Errorf("got %d vertices, want %d", n, vertices)
+		}
+		pos, normals := m.Attribute(mesh.Position), m.Attribute(mesh.Normal)
+		for v := 0; v < m.VertexCount(); v++ {
+			p, n := pos.Get(v), normals.Get(v)
+			if d := dot(p, n) / math.Sqrt(dot(p, p)); d < 0.999 {
+				t.Errorf("vertex %d at %v has normal %v, %v from radial", v, p, n, math.Acos(d))
+			}
+		}
+	}
+}
+
+// The tangent follows increasing u and w*cross(normal, tangent) increasing
+// v, even where the texture is mirrored.
+func TestTangentHandedness(t *testing.T) {
+	// Two quads facing +z sharing the edge at x = 1, the second mirrored
+	m := mesh.New()
+	m.Add(mesh.Position, 3, []float32{0, 0, 0, 1, 0, 0, 1, 1, 0, 0, 1, 0, 2, 0, 0, 2, 1, 0})
+	m.Add(mesh.Normal, 3, []float32{0, 0, 1, 0, 0, 1, 0, 0, 1, 0, 0, 1, 0, 0, 1, 0, 0, 1})
+	m.Add(mesh.TexCoord, 2, []float32{0, 0, 1, 0, 1, 1, 0, 1, 0, 0, 0, 1})
+	m.Indices = []uint32{0, 1, 2, 0, 2, 3, 1, 4, 5, 1, 5, 2}
+	if err := m.ComputeTangents(); err != nil {
+		t.Fatal(err)
+	}
+	// The vertices of the shared edge split in two
+	if n := m.VertexCount(); n != 8 {
+		t.Errorf("got %d vertices, want 8", n)
+	}
+	checkTangents(t, m)
+	tangents := m.Attribute(mesh.Tangent)
+	for tri, w := range []float32{1, 1, -1, -1} {
+		a, _, _ := m.Triangle(tri)
+		if got := tangents.Get(int(a))[3]; got != w {
+			t.Errorf("triangle %d has w %v, want %v", tri, got, w)
+		}
+	}
+
+	for _, m := range []*mesh.Mesh{shape.UVSphere(1, 16, 8), shape.Box(1, 1, 1, 1)} {
+		m.Remove(mesh.Tangent)
+		if err := m.ComputeTangents(); err != nil {
+			t.Fatal(err)
+		}
+		checkTangents(t, m)
+	}
+}
+
+// checkTangents checks the tangent frame of every corner against the
+// direction in which u and v grow across its triangle.
+func checkTangents(t *testing.T, m *mesh.Mesh) {
+	pos, uv, tangents := m.Attribute(mesh.Position), m.Attribute(mesh.TexCoord), m.Attribute(mesh.Tangent)
+	normals := m.Attribute(mesh.Normal)
+	for tri := 0; tri < m.TriangleCount(); tri++ {
+		a, b, c := m.Triangle(tri)
+		pa, pb, pc := pos.Get(int(a)), pos.Get(int(b)), pos.Get(int(c))
+		ta, tb, tc := uv.Get(int(a)), uv.Get(int(b)), uv.Get(int(c))
+		e1 := []float32{pb[0] - pa[0], pb[1] - pa[1], pb[2] - pa[2]}
+		e2 := []float32{pc[0] - pa[0], pc[1] - pa[1], pc[2] - pa[2]}
+		du1, dv1, du2, dv2 := tb[0]-ta[0], tb[1]-ta[1], tc[0]-ta[0], tc[1]-ta[1]
+		det := du1*dv2 - du2*dv1
+		if math.Abs(float64(det)) < 1e-9 {
+			continue
+		}
+		var dPdu, dPdv []float32
+		for k := 0; k < 3; k++ {
+			dPdu = append(dPdu, (e1[k]*dv2-e2[k]*dv1)/det)
+			dPdv = append(dPdv, (e2[k]*du1-e1[k]*du2)/det)
+		}
+		for _, v := range []uint32{a, b, c} {
+			tangent, n := tangents.Get(int(v)), normals.Get(int(v))
+			bitangent := cross(n, tangent)
+			if dot(tangent, dPdu) <= 0 || float64(tangent[3])*dot(bitangent, dPdv) <= 0 {
+				t.Errorf("triangle %d: vertex %d has tangent %v", tri, v, tangent)
+			}
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package optimize
+
+import (
+	"math"
+
+	"mesh"
+)
+
+// Scoring of Tom Forsyth's "Linear-Speed Vertex Cache Optimisation",
+// http://tomforsyth1000.github.io/papers/fast_vert_cache_opt.html
+const (
+	cacheDecayPower   = 1.5
+	lastTriangleScore = 0.75
+	valenceBoostScale = 2.0
+	valencePower      = 0.5
+)
+
+// Score tables, indexed by cache position and by remaining triangles
+var (
+	cacheScores   [CacheSize]float64
+	valenceScores [64]float64
+)
+
+func init() {
+	for i := range cacheScores {
+		if i < 3 {
+			// The vertices of the last triangle get a fixed score so that
+			// the next triangle does not simply reuse the same edge
+			cacheScores[i] = lastTriangleScore
+		} else {
+			cacheScores[i] = math.Pow(1-float64(i-3)/float64(CacheSize-3), cacheDecayPower)
+		}
+	}
+	for i := 1; i < len(valenceScores); i++ {
+		valenceScores[i] = valenceBoost(i)
+	}
+}
+
+// valenceBoost favours vertices with few triangles left, so they are
+// finished first.
+func valenceBoost(remaining int) float64 {
+	return valenceBoostScale * math.Pow(float64(remaining), -valencePower)
+}
+
+// vertexScore rates a vertex by its position in the LRU cache, -1 if not
+// cached, and the number of triangles still using it.
+func vertexScore(cachePosition, remaining int) float64 {
+	if remaining == 0 {
+		return -1
+	}
+	score := 0.0
+	if cachePosition >= 0 {
+		score = cacheScores[cachePosition]
+	}
+	if remaining < len(valenceScores) {
+		return score + valenceScores[remaining]
+	}
+	return score + valenceBoost(remaining)
+}
+
+// VertexCache reorders the triangles so that consecutive triangles share
+// vertices, which the GPU then transforms only once. The winding of each
+// triangle is kept.
+func VertexCache(m *mesh.Mesh) {
+	nv, nt := m.VertexCount(), m.TriangleCount()
+	if nt == 0 {
+		return
+	}
+
+	// Triangles of each vertex, as offsets into one array
+	remaining := make([]int, nv)
+	for _, v := range m.Indices {
+		remaining[v]++
+	}
+	offsets := make([]int, nv+1)
+	for v := 0; v < nv; v++ {
+		offsets[v+1] = offsets[v] + remaining[v]
+	}
+	triangles := make([]int, len(m.Indices))
+	fill := append([]int(nil), offsets[:nv]...)
+	for i, v := range m.Indices {
+		triangles[fill[v]] = i / 3
+		fill[v]++
+	}
+
+	cachePos := make([]int, nv)
+	vScore := make([]float64, nv)
+	for v := range cachePos {
+		cachePos[v] = -1
+		vScore[v] = vertexScore(-1, remaining[v])
+	}
+	tScore := make([]float64, nt)
+	for t := range tScore {
+		for k := 0; k < 3; k++ {
+			tScore[t] += vScore[m.Indices[3*t+k]]
+		}
+	}
+	emitted := make([]bool, nt)
+	out := make([]uint32, 0, len(m.Indices))
+	cache := make([]uint32, 0, CacheSize+3)
+	newCache := make([]uint32, 0, CacheSize+3)
+
+	best := -1
+	next := 0 // scan position for restarts
+	for len(out) < len(m.Indices) {
+		if best < 0 {
+			// Nothing in the cache connects further, take the best of the
+			// rest
+			bestScore := -1.0
+			for t := next; t < nt; t++ {
+				if !emitted[t] && tScore[t] > bestScore {
+					best, bestScore = t, tScore[t]
+				}
+			}
+			for next < nt && emitted[next] {
+				next++
+			}
+		}
+		t := best
+		emitted[t] = true
+		tri := m.Indices[3*t : 3*t+3]
+		out = append(out, tri...)
+
+		// Remove t from the triangle lists of its vertices
+		for _, v := range tri {
+			list := triangles[offsets[v] : offsets[v]+remaining[v]]
+			for i, o := range list {
+				if o == t {
+					list[i] = list[len(list)-1]
+					break
+				}
+			}
+			remaining[v]--
+		}
+
+		// Move the triangle's vertices to the front of the cache
+		newCache = append(newCache[:0], tri...)
+		for _, v := range cache {
+			if v != tri[0] && v != tri[1] && v != tri[2] {
+				newCache = append(newCache, v)
+			}
+		}
+		for i, v := range newCache {
+			if i < CacheSize {
+				cachePos[v] = i
+			} else {
+				cachePos[v] = -1
+			}
+		}
+		// Rescore every vertex that moved, including evicted ones
+		for _, v := range newCache {
+			old := vScore[v]
+			vScore[v] = vertexScore(cachePos[v], remaining[v])
+			for _, o := range triangles[offsets[v] : offsets[v]+remaining[v]] {
+				tScore[o] += vScore[v] - old
+			}
+		}
+		if len(newCache) > CacheSize {
+			newCache = newCache[:CacheSize]
+		}
+		cache, newCache = newCache, cache
+
+		// The next triangle is the best one touching the cache
+		best = -1
+		bestScore := -1.0
+		for _, v := range cache {
+			for _, o := range triangles[offsets[v] : offsets[v]+remaining[v]] {
+				if tScore[o] > bestScore {
+					best, bestScore = o, tScore[o]
+				}
+			}
+		}
+	}
+	m.Indices = out
+}
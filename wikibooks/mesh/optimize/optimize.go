@@ -0,0 +1,190 @@
+// Package optimize reorders and compacts indexed meshes so that the GPU
+// transforms and fetches fewer vertices.
+package optimize
+
+import (
+	"fmt"
+	"math"
+
+	"mesh"
+)
+
+// CacheSize is the number of entries of the simulated post transform
+// vertex cache. Most hardware has at least this many.
+const CacheSize = 32
+
+// Stats describe how well a mesh uses the vertex cache.
+type Stats struct {
+	Vertices, Triangles int
+	// ACMR is the average cache miss ratio, transformed vertices per
+	// triangle. It is 3 without any reuse and about 0.5 at best for
+	// regular grids.
+	ACMR float64
+	// ATVR is the average transformed vertex ratio, transformed vertices
+	// per vertex. 1 is ideal.
+	ATVR float64
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("%d vertices, %d triangles, ACMR %.3f, ATVR %.3f", s.Vertices, s.Triangles, s.ACMR, s.ATVR)
+}
+
+// Analyze simulates a FIFO vertex cache of the given size.
+func Analyze(m *mesh.Mesh, cacheSize int) Stats {
+	s := Stats{Vertices: m.VertexCount(), Triangles: m.TriangleCount()}
+	if s.Triangles == 0 || s.Vertices == 0 {
+		return s
+	}
+	// Time each vertex entered the cache, the cache holds the last
+	// cacheSize entries
+	entered := make([]int, s.Vertices)
+	for i := range entered {
+		entered[i] = -cacheSize - 1
+	}
+	misses := 0
+	for _, v := range m.Indices {
+		if misses-entered[v] > cacheSize {
+			entered[v] = misses
+			misses++
+		}
+	}
+	s.ACMR = float64(misses) / float64(s.Triangles)
+	s.ATVR = float64(misses) / float64(s.Vertices)
+	return s
+}
+
+// Optimize welds identical vertices, drops unused ones, and reorders
+// triangles and vertices. It returns the statistics before and after.
+func Optimize(m *mesh.Mesh) (before, after Stats) {
+	before = Analyze(m, CacheSize)
+	Weld(m, 0)
+	VertexCache(m)
+	VertexFetch(m)
+	after = Analyze(m, CacheSize)
+	return
+}
+
+// Weld merges vertices whose attributes all differ by at most tolerance,
+// and drops vertices no triangle uses. It returns the number of vertices
+// removed.
+func Weld(m *mesh.Mesh, tolerance float32) int {
+	n := m.VertexCount()
+	pos := m.Attribute(mesh.Position)
+	if pos == nil {
+		return 0
+	}
+	same := func(a, b int) bool {
+		for _, attr := range m.Attributes {
+			x, y := attr.Get(a), attr.Get(b)
+			for k := range x {
+				if float32(math.Abs(float64(x[k]-y[k]))) > tolerance {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	// Vertices are hashed by position cell; with a tolerance the
+	// neighbouring cells are searched too
+	cell := float64(tolerance) * 2
+	type key [3]int64
+	cellOf := func(v int) key {
+		p := pos.Get(v)
+		if cell == 0 {
+			return key{int64(math.Float32bits(p[0])), int64(math.Float32bits(p[1])), int64(math.Float32bits(p[2]))}
+		}
+		return key{int64(math.Floor(float64(p[0]) / cell)), int64(math.Floor(float64(p[1]) / cell)), int64(math.Floor(float64(p[2]) / cell))}
+	}
+	cells := make(map[key][]int)
+	remap := make([]uint32, n)
+	var kept []int
+	for v := 0; v < n; v++ {
+		k := cellOf(v)
+		found := -1
+		search := func(k key) {
+			for _, o := range cells[k] {
+				if found < 0 && same(v, o) {
+					found = o
+				}
+			}
+		}
+		if cell == 0 {
+			search(k)
+		} else {
+			for dx := int64(-1); dx <= 1; dx++ {
+				for dy := int64(-1); dy <= 1; dy++ {
+					for dz := int64(-1); dz <= 1; dz++ {
+						search(key{k[0] + dx, k[1] + dy, k[2] + dz})
+					}
+				}
+			}
+		}
+		if found >= 0 {
+			remap[v] = remap[found]
+			continue
+		}
+		remap[v] = uint32(len(kept))
+		kept = append(kept, v)
+		cells[k] = append(cells[k], v)
+	}
+	for i, v := range m.Indices {
+		m.Indices[i] = remap[v]
+	}
+	reorder(m, kept)
+	return n - len(kept) + StripUnused(m)
+}
+
+// StripUnused drops vertices no triangle uses and returns how many.
+func StripUnused(m *mesh.Mesh) int {
+	n := m.VertexCount()
+	used := make([]bool, n)
+	for _, v := range m.Indices {
+		used[v] = true
+	}
+	var kept []int
+	remap := make([]uint32, n)
+	for v := 0; v < n; v++ {
+		if used[v] {
+			remap[v] = uint32(len(kept))
+			kept = append(kept, v)
+		}
+	}
+	for i, v := range m.Indices {
+		m.Indices[i] = remap[v]
+	}
+	reorder(m, kept)
+	return n - len(kept)
+}
+
+// VertexFetch renumbers the vertices in the order the triangles first use
+// them, so that vertex fetches walk through memory. Unused vertices are
+// dropped.
+func VertexFetch(m *mesh.Mesh) {
+	n := m.VertexCount()
+	remap := make([]int, n)
+	for i := range remap {
+		remap[i] = -1
+	}
+	var order []int
+	for i, v := range m.Indices {
+		if remap[v] < 0 {
+			remap[v] = len(order)
+			order = append(order, int(v))
+		}
+		m.Indices[i] = uint32(remap[v])
+	}
+	reorder(m, order)
+}
+
+// reorder keeps the vertices listed in order, which the indices already
+// refer to by their new numbers.
+func reorder(m *mesh.Mesh, order []int) {
+	for _, a := range m.Attributes {
+		data := make([]float32, 0, len(order)*a.Size)
+		for _, v := range order {
+			data = append(data, a.Get(v)...)
+		}
+		a.Data = data
+	}
+}
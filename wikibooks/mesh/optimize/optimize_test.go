@@ -0,0 +1,114 @@
+package optimize
+
+import (
+	"math/rand"
+	"testing"
+
+	"mesh"
+	"mesh/shape"
+)
+
+// shuffled returns a grid of n by n quads with its triangles in random
+// order, as meshes come out of tools which ignore the cache.
+func shuffled(n int) *mesh.Mesh {
+	m := shape.Plane(1, 1, n, n)
+	r := rand.New(rand.NewSource(1))
+	for t := m.TriangleCount() - 1; t > 0; t-- {
+		u := r.Intn(t + 1)
+		for k := 0; k < 3; k++ {
+			m.Indices[3*t+k], m.Indices[3*u+k] = m.Indices[3*u+k], m.Indices[3*t+k]
+		}
+	}
+	return m
+}
+
+// triangles returns the corner positions of each triangle, starting at the
+// smallest corner so that rotating a triangle does not change it.
+func triangles(m *mesh.Mesh) map[[9]float32]int {
+	pos := m.Attribute(mesh.Position)
+	set := make(map[[9]float32]int)
+	for t := 0; t < m.TriangleCount(); t++ {
+		a, b, c := m.Triangle(t)
+		corners := [3]uint32{a, b, c}
+		first := 0
+		for k := 1; k < 3; k++ {
+			if less(pos.Get(int(corners[k])), pos.Get(int(corners[first]))) {
+				first = k
+			}
+		}
+		var key [9]float32
+		for k := 0; k < 3; k++ {
+			copy(key[3*k:], pos.Get(int(corners[(first+k)%3])))
+		}
+		set[key]++
+	}
+	return set
+}
+
+func less(p, q []float32) bool {
+	for k := range p {
+		if p[k] != q[k] {
+			return p[k] < q[k]
+		}
+	}
+	return false
+}
+
+func TestOptimize(t *testing.T) {
+	m := shuffled(64)
+	want := triangles(m)
+	before, after := Optimize(m)
+	if after.ACMR >= before.ACMR || after.ACMR > 0.8 {
+		t.Errorf("ACMR went from %.3f to %.3f", before.ACMR, after.ACMR)
+	}
+	if after.ATVR > 1.5 {
+		t.Errorf("ATVR is %.3f", after.ATVR)
+	}
+	if after.Vertices != 65*65 || after.Triangles != before.Triangles {
+		t.Errorf("got %v, want %d vertices and %d triangles", after, 65*65, before.Triangles)
+	}
+	if err := m.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	// The same triangles, turning the same way
+	got := triangles(m)
+	if len(got) != len(want) {
+		t.Fatalf("got %d distinct triangles, want %d", len(got), len(want))
+	}
+	for k, n := range want {
+		if got[k] != n {
+			t.Fatalf("triangle %v is there %d times, want %d", k, got[k], n)
+		}
+	}
+	// Vertices are in the order of first use
+	next := uint32(0)
+	for _, v := range m.Indices {
+		if v > next {
+			t.Fatalf("vertex %d used before %d", v, next)
+		}
+		if v == next {
+			next++
+		}
+	}
+}
+
+func BenchmarkVertexCache(b *testing.B) {
+	m := shuffled(256)
+	indices := append([]uint32(nil), m.Indices...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		copy(m.Indices, indices)
+		VertexCache(m)
+	}
+}
+
+func BenchmarkOptimize(b *testing.B) {
+	sphere := shape.UVSphere(1, 256, 128)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		m := sphere.Clone()
+		b.StartTimer()
+		Optimize(m)
+	}
+}
@@ -0,0 +1,167 @@
+package shape
+
+import (
+	"mesh"
+)
+
+// Patch is a bicubic Bézier patch. Control point (i, j) is at 4*j+i, with i
+// running along s and j along t; the surface faces dP/ds x dP/dt.
+type Patch [16][3]float32
+
+func bernstein(t float64) (b, d [4]float64) {
+	u := 1 - t
+	b = [4]float64{u * u * u, 3 * t * u * u, 3 * t * t * u, t * t * t}
+	d = [4]float64{-3 * u * u, 3*u*u - 6*t*u, 6*t*u - 3*t*t, 3 * t * t}
+	return
+}
+
+// eval returns the point and partial derivatives of the patch at s, t.
+func (p *Patch) eval(s, t float64) (pos, ds, dt vec3) {
+	bs, dbs := bernstein(s)
+	bt, dbt := bernstein(t)
+	for j := 0; j < 4; j++ {
+		for i := 0; i < 4; i++ {
+			c := p[4*j+i]
+			q := vec3{float64(c[0]), float64(c[1]), float64(c[2])}
+			pos = pos.add(q.scale(bs[i] * bt[j]))
+			ds = ds.add(q.scale(dbs[i] * bt[j]))
+			dt = dt.add(q.scale(bs[i] * dbt[j]))
+		}
+	}
+	return
+}
+
+func (p *Patch) surface(s, t float64) vertex {
+	pos, ds, dt := p.eval(s, t)
+	n := ds.cross(dt)
+	if n.length() < 1e-9 {
+		// Collapsed edge, like the top of the teapot's lid
+		_, ds, dt = p.eval(s+1e-3*(0.5-s), t+1e-3*(0.5-t))
+		n = ds.cross(dt)
+	}
+	return vertex{pos, n.normalized(), [2]float64{s, t}}
+}
+
+// Patches tessellates each patch into segments by segments quads. Every
+// patch gets the whole texture.
+func Patches(patches []Patch, segments int) *mesh.Mesh {
+	var b builder
+	for i := range patches {
+		b.grid(patches[i].surface, positive(segments), positive(segments))
+	}
+	return b.mesh()
+}
+
+// Outline of the parts of the Utah teapot which are surfaces of revolution,
+// as radius and height, going from top to bottom.
+var teapotProfiles = [][4][2]float32{
+	{{0, 3.15}, {0.8, 3.15}, {0, 2.85}, {0.2, 2.7}},                // lid knob
+	{{0.2, 2.7}, {0.4, 2.55}, {1.3, 2.55}, {1.3, 2.4}},             // lid
+	{{1.4, 2.4}, {1.3375, 2.53125}, {1.4375, 2.53125}, {1.5, 2.4}}, // rim
+	{{1.5, 2.4}, {1.75, 1.875}, {2, 1.35}, {2, 0.9}},               // upper body
+	{{2, 0.9}, {2, 0.45}, {1.5, 0.225}, {1.5, 0.15}},               // lower body
+	{{1.5, 0.15}, {1.5, 0.075}, {1.425, 0}, {0, 0}},                // bottom
+}
+
+// Center lines of the handle and the spout, in the xy plane, with the half
+// height and half width of the tube at each control point.
+var (
+	teapotHandle = []tubePoint{
+		{-1.6, 1.875, 0.15, 0.3}, {-2.3, 1.875, 0.15, 0.3}, {-2.7, 1.875, 0.15, 0.3}, {-2.7, 1.65, 0.15, 0.3},
+		{-2.7, 1.425, 0.15, 0.3}, {-2.5, 0.975, 0.15, 0.3}, {-2, 0.75, 0.15, 0.3},
+	}
+	teapotSpout = []tubePoint{
+		{1.7, 0.86, 0.41, 0.66}, {2.6, 0.86, 0.41, 0.66}, {2.3, 1.95, 0.25, 0.25}, {2.7, 2.25, 0.25, 0.25},
+		{2.95, 2.45, 0.28, 0.28}, {3.15, 2.45, 0.3, 0.3}, {3.3, 2.4, 0.3, 0.3},
+	}
+)
+
+type tubePoint struct {
+	x, y          float32
+	height, width float32
+}
+
+// Length of the tangents of a cubic Bézier quarter circle, as used by the
+// original teapot.
+const kappa = 0.56
+
+// revolve turns a profile around the y axis into four patches.
+func revolve(profile [4][2]float32) []Patch {
+	dirs := [][2]float32{{1, 0}, {0, 1}, {-1, 0}, {0, -1}}
+	var patches []Patch
+	for q := 0; q < 4; q++ {
+		d0, d1 := dirs[q], dirs[(q+1)%4]
+		// Quarter circle in the xz plane, clockwise seen from above, so
+		// that the patch faces outward for a downward profile
+		arc := [4][2]float32{
+			d0,
+			{d0[0] + kappa*d1[0], d0[1] + kappa*d1[1]},
+			{d1[0] + kappa*d0[0], d1[1] + kappa*d0[1]},
+			d1,
+		}
+		var p Patch
+		for j, rh := range profile {
+			for i, a := range arc {
+				p[4*j+i] = [3]float32{rh[0] * a[0], rh[1], rh[0] * a[1]}
+			}
+		}
+		patches = append(patches, p)
+	}
+	return patches
+}
+
+// tube sweeps an elliptic cross section along a piecewise cubic center
+// line, giving four patches per cubic segment.
+func tube(points []tubePoint) []Patch {
+	n := len(points)
+	// Frame at each control point: normal in the xy plane, binormal z
+	normals := make([][2]float32, n)
+	for j := range points {
+		a, b := points[j], points[j]
+		if j > 0 {
+			a = points[j-1]
+		}
+		if j < n-1 {
+			b = points[j+1]
+		}
+		t := vec3{float64(b.x - a.x), float64(b.y - a.y), 0}.normalized()
+		normals[j] = [2]float32{float32(-t[1]), float32(t[0])}
+	}
+	// Quarter ellipse from cos, sin to the next quarter, as offsets along
+	// the normal and the binormal
+	quarters := [][4][2]float32{
+		{{1, 0}, {1, kappa}, {kappa, 1}, {0, 1}},
+		{{0, 1}, {-kappa, 1}, {-1, kappa}, {-1, 0}},
+		{{-1, 0}, {-1, -kappa}, {-kappa, -1}, {0, -1}},
+		{{0, -1}, {kappa, -1}, {1, -kappa}, {1, 0}},
+	}
+	var patches []Patch
+	for seg := 0; seg+3 < n; seg += 3 {
+		for _, q := range quarters {
+			var p Patch
+			for j := 0; j < 4; j++ {
+				c, nm := points[seg+j], normals[seg+j]
+				for i, o := range q {
+					h, w := o[0]*c.height, o[1]*c.width
+					p[4*j+i] = [3]float32{c.x + h*nm[0], c.y + h*nm[1], w}
+				}
+			}
+			patches = append(patches, p)
+		}
+	}
+	return patches
+}
+
+// Teapot returns the Utah teapot standing on the xz plane, 3.15 units high
+// with the spout pointing to +x. Each of its 40 patches is divided into
+// segments by segments quads. Like the original, the handle and spout pass
+// into the body and the lid leaves a gap, so the mesh is not closed.
+func Teapot(segments int) *mesh.Mesh {
+	var patches []Patch
+	for _, profile := range teapotProfiles {
+		patches = append(patches, revolve(profile)...)
+	}
+	patches = append(patches, tube(teapotHandle)...)
+	patches = append(patches, tube(teapotSpout)...)
+	return Patches(patches, segments)
+}
@@ -0,0 +1,166 @@
+// Package shape generates meshes of common solids with normals, texture
+// coordinates and tangents.
+package shape
+
+import (
+	"math"
+
+	"mesh"
+)
+
+type vec3 [3]float64
+
+func (a vec3) add(b vec3) vec3      { return vec3{a[0] + b[0], a[1] + b[1], a[2] + b[2]} }
+func (a vec3) sub(b vec3) vec3      { return vec3{a[0] - b[0], a[1] - b[1], a[2] - b[2]} }
+func (a vec3) scale(s float64) vec3 { return vec3{a[0] * s, a[1] * s, a[2] * s} }
+func (a vec3) dot(b vec3) float64   { return a[0]*b[0] + a[1]*b[1] + a[2]*b[2] }
+func (a vec3) length() float64      { return math.Sqrt(a.dot(a)) }
+func (a vec3) cross(b vec3) vec3 {
+	return vec3{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+
+func (a vec3) normalized() vec3 {
+	if l := a.length(); l > 0 {
+		return a.scale(1 / l)
+	}
+	return a
+}
+
+// vertex is a point of a parametric surface. n is the outward unit normal.
+type vertex struct {
+	p, n vec3
+	uv   [2]float64
+}
+
+// surface maps s, t in [0, 1] to a vertex. Moving along s and then t turns
+// counter clockwise seen from outside.
+type surface func(s, t float64) vertex
+
+// builder collects vertices and triangles.
+type builder struct {
+	positions, normals, texCoords, tangents []float32
+	indices                                 []uint32
+}
+
+func (b *builder) add(v vertex, tangent vec3, w float64) uint32 {
+	i := uint32(len(b.positions) / 3)
+	b.positions = append(b.positions, float32(v.p[0]), float32(v.p[1]), float32(v.p[2]))
+	b.normals = append(b.normals, float32(v.n[0]), float32(v.n[1]), float32(v.n[2]))
+	b.texCoords = append(b.texCoords, float32(v.uv[0]), float32(v.uv[1]))
+	b.tangents = append(b.tangents, float32(tangent[0]), float32(tangent[1]), float32(tangent[2]), float32(w))
+	return i
+}
+
+func (b *builder) pos(i uint32) vec3 {
+	return vec3{float64(b.positions[3*i]), float64(b.positions[3*i+1]), float64(b.positions[3*i+2])}
+}
+
+// triangle adds a triangle unless it is degenerate, as happens at the poles
+// and tips of surfaces.
+func (b *builder) triangle(i, j, k uint32) {
+	p := b.pos(i)
+	if b.pos(j).sub(p).cross(b.pos(k).sub(p)).length() < 1e-12 {
+		return
+	}
+	b.indices = append(b.indices, i, j, k)
+}
+
+// grid tessellates f into segS by segT quads. The tangent points along
+// increasing u of the texture coordinates and is found by differentiating f
+// numerically.
+func (b *builder) grid(f surface, segS, segT int) {
+	base := uint32(len(b.positions) / 3)
+	for j := 0; j <= segT; j++ {
+		for i := 0; i <= segS; i++ {
+			s, t := float64(i)/float64(segS), float64(j)/float64(segT)
+			v := f(s, t)
+			tangent, w := frame(f, s, t, v.n)
+			b.add(v, tangent, w)
+		}
+	}
+	row := uint32(segS + 1)
+	for j := uint32(0); j < uint32(segT); j++ {
+		for i := uint32(0); i < uint32(segS); i++ {
+			a := base + j*row + i
+			b.triangle(a, a+1, a+row+1)
+			b.triangle(a, a+row+1, a+row)
+		}
+	}
+}
+
+// frame returns the tangent and handedness at s, t. Where the surface
+// collapses to a point the frame is taken from a nearby point instead.
+func frame(f surface, s, t float64, n vec3) (vec3, float64) {
+	const h = 1e-4
+	for _, nudge := range []float64{0, 1e-3, 1e-2} {
+		s0, t0 := s+nudge*(0.5-s), t+nudge*(0.5-t)
+		sa, sb := math.Max(s0-h, 0), math.Min(s0+h, 1)
+		ta, tb := math.Max(t0-h, 0), math.Min(t0+h, 1)
+		fs0, fs1 := f(sa, t0), f(sb, t0)
+		ft0, ft1 := f(s0, ta), f(s0, tb)
+		dPds, dPdt := fs1.p.sub(fs0.p), ft1.p.sub(ft0.p)
+		dUds, dVds := fs1.uv[0]-fs0.uv[0], fs1.uv[1]-fs0.uv[1]
+		dUdt, dVdt := ft1.uv[0]-ft0.uv[0], ft1.uv[1]-ft0.uv[1]
+		det := dUds*dVdt - dUdt*dVds
+		if det == 0 {
+			continue
+		}
+		tangent := dPds.scale(dVdt).sub(dPdt.scale(dVds)).scale(1 / det)
+		bitangent := dPdt.scale(dUds).sub(dPds.scale(dUdt)).scale(1 / det)
+		// Gram-Schmidt against the vertex normal
+		tangent = tangent.sub(n.scale(n.dot(tangent)))
+		if tangent.length() < 1e-9 {
+			continue
+		}
+		w := 1.0
+		if n.cross(tangent).dot(bitangent) < 0 {
+			w = -1
+		}
+		return tangent.normalized(), w
+	}
+	// No texture direction at all, any perpendicular will do
+	a := vec3{1, 0, 0}
+	if math.Abs(n[0]) > 0.9 {
+		a = vec3{0, 1, 0}
+	}
+	return a.sub(n.scale(n.dot(a))).normalized(), 1
+}
+
+// mesh returns the collected geometry, dropping vertices which no triangle
+// uses.
+func (b *builder) mesh() *mesh.Mesh {
+	n := len(b.positions) / 3
+	remap := make([]int, n)
+	for i := range remap {
+		remap[i] = -1
+	}
+	for _, i := range b.indices {
+		remap[i] = 0
+	}
+	used := 0
+	for i := range remap {
+		if remap[i] == 0 {
+			remap[i] = used
+			used++
+		}
+	}
+	compact := func(data []float32, size int) []float32 {
+		out := make([]float32, 0, used*size)
+		for i := 0; i < n; i++ {
+			if remap[i] >= 0 {
+				out = append(out, data[i*size:(i+1)*size]...)
+			}
+		}
+		return out
+	}
+	m := mesh.New()
+	m.Add(mesh.Position, 3, compact(b.positions, 3))
+	m.Add(mesh.Normal, 3, compact(b.normals, 3))
+	m.Add(mesh.TexCoord, 2, compact(b.texCoords, 2))
+	m.Add(mesh.Tangent, 4, compact(b.tangents, 4))
+	m.Indices = make([]uint32, len(b.indices))
+	for k, i := range b.indices {
+		m.Indices[k] = uint32(remap[i])
+	}
+	return m
+}
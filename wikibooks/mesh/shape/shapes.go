@@ -0,0 +1,284 @@
+package shape
+
+import (
+	"math"
+
+	"mesh"
+)
+
+// Plane returns a width by depth rectangle in the xz plane facing +y,
+// divided into segX by segZ quads.
+func Plane(width, depth float32, segX, segZ int) *mesh.Mesh {
+	var b builder
+	b.grid(face(vec3{}, vec3{float64(width), 0, 0}, vec3{0, 0, -float64(depth)}), positive(segX), positive(segZ))
+	return b.mesh()
+}
+
+// face returns a parallelogram centered at c spanned by u and v, facing
+// u x v.
+func face(c, u, v vec3) surface {
+	n := u.cross(v).normalized()
+	return func(s, t float64) vertex {
+		p := c.add(u.scale(s - 0.5)).add(v.scale(t - 0.5))
+		return vertex{p, n, [2]float64{s, t}}
+	}
+}
+
+// Box returns a box centered at the origin. Every face is divided into
+// segments by segments quads and has its own vertices, so that it shows
+// the whole texture.
+func Box(width, height, depth float32, segments int) *mesh.Mesh {
+	x, y, z := vec3{float64(width), 0, 0}, vec3{0, float64(height), 0}, vec3{0, 0, float64(depth)}
+	faces := []struct{ normal, u, v vec3 }{
+		{z, x, y},                     // front
+		{y, x, z.scale(-1)},           // top
+		{z.scale(-1), x.scale(-1), y}, // back
+		{y.scale(-1), x, z},           // bottom
+		{x.scale(-1), z, y},           // left
+		{x, z.scale(-1), y},           // right
+	}
+	var b builder
+	n := positive(segments)
+	for _, f := range faces {
+		b.grid(face(f.normal.scale(0.5), f.u, f.v), n, n)
+	}
+	return b.mesh()
+}
+
+// sphere returns the point of the unit sphere at longitude s and latitude t,
+// both in [0, 1] with t running from the south to the north pole.
+func sphere(s, t float64) vec3 {
+	phi, theta := 2*math.Pi*s, math.Pi*t
+	ring := math.Sin(theta)
+	return vec3{ring * math.Cos(phi), -math.Cos(theta), -ring * math.Sin(phi)}
+}
+
+// UVSphere returns a sphere made of slices around the y axis and stacks
+// from pole to pole. Texture coordinates are longitude and latitude.
+func UVSphere(radius float32, slices, stacks int) *mesh.Mesh {
+	r := float64(radius)
+	var b builder
+	b.grid(func(s, t float64) vertex {
+		n := sphere(s, t)
+		return vertex{n.scale(r), n, [2]float64{s, t}}
+	}, positive3(slices), positive(stacks))
+	return b.mesh()
+}
+
+// Icosphere returns a sphere made by splitting the faces of an icosahedron
+// subdivisions times. Its triangles are much more even than those of
+// UVSphere. Vertices on the texture seam and at the poles are duplicated.
+func Icosphere(radius float32, subdivisions int) *mesh.Mesh {
+	g := (1 + math.Sqrt(5)) / 2
+	points := []vec3{
+		{-1, g, 0}, {1, g, 0}, {-1, -g, 0}, {1, -g, 0},
+		{0, -1, g}, {0, 1, g}, {0, -1, -g}, {0, 1, -g},
+		{g, 0, -1}, {g, 0, 1}, {-g, 0, -1}, {-g, 0, 1},
+	}
+	tris := [][3]int{
+		{0, 11, 5}, {0, 5, 1}, {0, 1, 7}, {0, 7, 10}, {0, 10, 11},
+		{1, 5, 9}, {5, 11, 4}, {11, 10, 2}, {10, 7, 6}, {7, 1, 8},
+		{3, 9, 4}, {3, 4, 2}, {3, 2, 6}, {3, 6, 8}, {3, 8, 9},
+		{4, 9, 5}, {2, 4, 11}, {6, 2, 10}, {8, 6, 7}, {9, 8, 1},
+	}
+	// Turn the icosahedron so a vertex sits at each pole
+	a := math.Atan2(g, 1)
+	c, s := math.Cos(a), math.Sin(a)
+	for i, p := range points {
+		points[i] = vec3{p[0], c*p[1] - s*p[2], s*p[1] + c*p[2]}.normalized()
+	}
+	for k := 0; k < subdivisions; k++ {
+		midpoints := make(map[[2]int]int)
+		mid := func(i, j int) int {
+			if i > j {
+				i, j = j, i
+			}
+			m, ok := midpoints[[2]int{i, j}]
+			if !ok {
+				m = len(points)
+				points = append(points, points[i].add(points[j]).normalized())
+				midpoints[[2]int{i, j}] = m
+			}
+			return m
+		}
+		var next [][3]int
+		for _, t := range tris {
+			ab, bc, ca := mid(t[0], t[1]), mid(t[1], t[2]), mid(t[2], t[0])
+			next = append(next, [3]int{t[0], ab, ca}, [3]int{t[1], bc, ab}, [3]int{t[2], ca, bc}, [3]int{ab, bc, ca})
+		}
+		tris = next
+	}
+
+	r := float64(radius)
+	var b builder
+	vertices := make(map[[2]int]uint32)
+	for _, t := range tris {
+		var uvs [3][2]float64
+		for k, i := range t {
+			uvs[k] = sphereUV(points[i])
+		}
+		// Triangles crossing the seam get texture coordinates past 1
+		if math.Max(uvs[0][0], math.Max(uvs[1][0], uvs[2][0]))-math.Min(uvs[0][0], math.Min(uvs[1][0], uvs[2][0])) > 0.5 {
+			for k := range uvs {
+				if uvs[k][0] < 0.5 {
+					uvs[k][0]++
+				}
+			}
+		}
+		var corners [3]uint32
+		for k, i := range t {
+			p := points[i]
+			key := [2]int{i, int(uvs[k][0] * 1e6)}
+			pole := math.Abs(p[1]) > 1-1e-9
+			if pole {
+				// A pole takes the longitude of the opposite edge
+				uvs[k][0] = (uvs[(k+1)%3][0] + uvs[(k+2)%3][0]) / 2
+				key = [2]int{i, -1 - len(b.indices)}
+			}
+			v, ok := vertices[key]
+			if !ok {
+				phi := 2 * math.Pi * uvs[k][0]
+				tangent := vec3{-math.Sin(phi), 0, -math.Cos(phi)}
+				v = b.add(vertex{p.scale(r), p, uvs[k]}, tangent, 1)
+				vertices[key] = v
+			}
+			corners[k] = v
+		}
+		b.triangle(corners[0], corners[1], corners[2])
+	}
+	return b.mesh()
+}
+
+// sphereUV returns the texture coordinates of a point on the unit sphere,
+// matching sphere.
+func sphereUV(p vec3) [2]float64 {
+	u := math.Atan2(-p[2], p[0]) / (2 * math.Pi)
+	if u < 0 {
+		u++
+	}
+	return [2]float64{u, math.Acos(math.Max(-1, math.Min(1, -p[1]))) / math.Pi}
+}
+
+// Cylinder returns a cylinder of the given radius and height around the y
+// axis, centered at the origin, made of slices around and stacks along the
+// axis. If capped, discs close both ends.
+func Cylinder(radius, height float32, slices, stacks int, capped bool) *mesh.Mesh {
+	return cone(float64(radius), float64(radius), float64(height), positive3(slices), positive(stacks), capped)
+}
+
+// Cone returns a cone with its base of the given radius at -height/2 and
+// its tip at height/2. If capped, a disc closes the base.
+func Cone(radius, height float32, slices, stacks int, capped bool) *mesh.Mesh {
+	return cone(float64(radius), 0, float64(height), positive3(slices), positive(stacks), capped)
+}
+
+// cone builds a truncated cone with radius r0 at the bottom and r1 at the
+// top.
+func cone(r0, r1, h float64, slices, stacks int, capped bool) *mesh.Mesh {
+	var b builder
+	// The normal tilts up by the slope of the side
+	slope := vec3{h, r0 - r1, 0}.normalized()
+	b.grid(func(s, t float64) vertex {
+		phi := 2 * math.Pi * s
+		c, sn := math.Cos(phi), math.Sin(phi)
+		r := r0 + (r1-r0)*t
+		p := vec3{r * c, (t - 0.5) * h, -r * sn}
+		n := vec3{slope[0] * c, slope[1], -slope[0] * sn}
+		return vertex{p, n, [2]float64{s, t}}
+	}, slices, stacks)
+	if capped {
+		b.disc(r0, -h/2, slices, false)
+		if r1 > 0 {
+			b.disc(r1, h/2, slices, true)
+		}
+	}
+	return b.mesh()
+}
+
+// disc adds a disc at height y facing +y if up, else -y. Texture
+// coordinates map the disc's bounding square to [0, 1].
+func (b *builder) disc(r, y float64, slices int, up bool) {
+	n := vec3{0, -1, 0}
+	if up {
+		n = vec3{0, 1, 0}
+	}
+	b.grid(func(s, t float64) vertex {
+		// Going around counter clockwise seen from above, the rim must
+		// come first when facing up
+		if up {
+			t = 1 - t
+		}
+		phi := 2 * math.Pi * s
+		x, z := r*t*math.Cos(phi), -r*t*math.Sin(phi)
+		u, v := x/(2*r)+0.5, z/(2*r)+0.5
+		if up {
+			v = 0.5 - z/(2*r)
+		}
+		return vertex{vec3{x, y, z}, n, [2]float64{u, v}}
+	}, slices, 1)
+}
+
+// Torus returns a ring around the y axis. major is the distance from the
+// center to the middle of the tube, minor the tube's radius.
+func Torus(major, minor float32, majorSegments, minorSegments int) *mesh.Mesh {
+	R, r := float64(major), float64(minor)
+	var b builder
+	b.grid(func(s, t float64) vertex {
+		phi, theta := 2*math.Pi*s, 2*math.Pi*t
+		n := vec3{math.Cos(theta) * math.Cos(phi), math.Sin(theta), -math.Cos(theta) * math.Sin(phi)}
+		center := vec3{R * math.Cos(phi), 0, -R * math.Sin(phi)}
+		return vertex{center.add(n.scale(r)), n, [2]float64{s, t}}
+	}, positive3(majorSegments), positive3(minorSegments))
+	return b.mesh()
+}
+
+// Capsule returns a cylinder of the given height capped by hemispheres, so
+// its total height is height+2*radius. rings is the number of stacks of
+// each hemisphere.
+func Capsule(radius, height float32, slices, stacks, rings int) *mesh.Mesh {
+	r, h := float64(radius), float64(height)
+	stacks, rings = positive(stacks), positive(rings)
+	total := 2*rings + stacks
+	// t is split at the ends of the cylinder so the grid rows land there
+	t0, t1 := float64(rings)/float64(total), float64(rings+stacks)/float64(total)
+	// Texture v follows the length of the outline
+	length := math.Pi*r + h
+	var b builder
+	b.grid(func(s, t float64) vertex {
+		phi := 2 * math.Pi * s
+		var angle, y, arc float64
+		switch {
+		case t < t0:
+			angle = -math.Pi / 2 * (1 - t/t0)
+			y = -h / 2
+			arc = (angle + math.Pi/2) * r
+		case t <= t1:
+			y = -h/2 + h*(t-t0)/(t1-t0)
+			arc = math.Pi/2*r + y + h/2
+		default:
+			angle = math.Pi / 2 * (t - t1) / (1 - t1)
+			y = h / 2
+			arc = math.Pi/2*r + h + angle*r
+		}
+		ring := math.Cos(angle)
+		n := vec3{ring * math.Cos(phi), math.Sin(angle), -ring * math.Sin(phi)}
+		p := n.scale(r).add(vec3{0, y, 0})
+		return vertex{p, n, [2]float64{s, arc / length}}
+	}, positive3(slices), total)
+	return b.mesh()
+}
+
+func positive(n int) int {
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// positive3 limits segments around an axis to at least 3.
+func positive3(n int) int {
+	if n < 3 {
+		return 3
+	}
+	return n
+}
@@ -0,0 +1,78 @@
+package shape
+
+import (
+	"math"
+	"testing"
+
+	"mesh"
+	"mesh/halfedge"
+)
+
+// closed lists the solids with their volume. Curved ones are polygons, so
+// their volume is a little less.
+var closed = []struct {
+	name   string
+	m      *mesh.Mesh
+	volume float64
+}{
+	{"box", Box(1, 2, 3, 2), 6},
+	{"uv sphere", UVSphere(1, 32, 16), 4 * math.Pi / 3},
+	{"icosphere", Icosphere(1, 3), 4 * math.Pi / 3},
+	{"cylinder", Cylinder(1, 2, 32, 2, true), 2 * math.Pi},
+	{"cone", Cone(1, 2, 32, 2, true), 2 * math.Pi / 3},
+	{"torus", Torus(2, 0.5, 48, 24), 2 * math.Pi * math.Pi * 2 * 0.25},
+	{"capsule", Capsule(0.5, 1, 32, 2, 8), math.Pi*0.25 + 4*math.Pi/3*0.125},
+}
+
+func position(a *mesh.Attribute, i uint32) vec3 {
+	p := a.Get(int(i))
+	return vec3{float64(p[0]), float64(p[1]), float64(p[2])}
+}
+
+// The volume of a closed mesh is the sum of the tetrahedra between its
+// triangles and the origin, positive if they turn counter clockwise seen
+// from outside.
+func TestVolume(t *testing.T) {
+	for _, s := range closed {
+		pos := s.m.Attribute(mesh.Position)
+		v := 0.0
+		for tri := 0; tri < s.m.TriangleCount(); tri++ {
+			a, b, c := s.m.Triangle(tri)
+			v += position(pos, a).dot(position(pos, b).cross(position(pos, c))) / 6
+		}
+		if v > s.volume*1.0001 || v < s.volume*0.97 {
+			t.Errorf("%s: volume %v, want %v", s.name, v, s.volume)
+		}
+	}
+}
+
+// TestWinding checks that every triangle turns like the normals of its
+// vertices.
+func TestWinding(t *testing.T) {
+	for _, s := range closed {
+		pos, normals := s.m.Attribute(mesh.Position), s.m.Attribute(mesh.Normal)
+		for tri := 0; tri < s.m.TriangleCount(); tri++ {
+			a, b, c := s.m.Triangle(tri)
+			p := position(pos, a)
+			n := position(pos, b).sub(p).cross(position(pos, c).sub(p))
+			for _, v := range []uint32{a, b, c} {
+				if n.dot(position(normals, v)) <= 0 {
+					t.Errorf("%s: triangle %d winds against the normal of vertex %d", s.name, tri, v)
+				}
+			}
+		}
+	}
+}
+
+// The seams of texture coordinates and normals split vertices; welded by
+// position every edge must have two faces running along it both ways.
+func TestWatertight(t *testing.T) {
+	for _, s := range closed {
+		indices, vertices := halfedge.Welded(s.m)
+		r := halfedge.Check(indices, len(vertices))
+		if r.BoundaryEdges != 0 || !r.Manifold() || !r.Oriented() {
+			t.Errorf("%s: %d open edges, %d degenerate triangles, %d non manifold edges and %d vertices, %d flipped edges",
+				s.name, r.BoundaryEdges, len(r.Degenerate), len(r.NonManifoldEdges), len(r.NonManifoldVertices), len(r.FlippedEdges))
+		}
+	}
+}
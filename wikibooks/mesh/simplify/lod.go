@@ -0,0 +1,60 @@
+package simplify
+
+import (
+	"math3d"
+	"mesh"
+)
+
+// Level is one level of detail.
+type Level struct {
+	Mesh *mesh.Mesh
+	// Error is how far the surface may be from the original mesh, in mesh
+	// units.
+	Error float32
+}
+
+// Chain holds levels of detail, from the original mesh at 0 to the
+// coarsest.
+type Chain struct {
+	Levels []Level
+}
+
+// NewChain builds up to levels levels of detail, each with ratio times the
+// triangles of the one before. Every level is simplified from m itself, so
+// errors do not add up. The chain ends early once a level can not be
+// simplified further.
+func NewChain(m *mesh.Mesh, levels int, ratio float32, opts Options) *Chain {
+	c := &Chain{Levels: []Level{{Mesh: m}}}
+	target := float32(m.TriangleCount())
+	for len(c.Levels) < levels {
+		target *= ratio
+		opts.TargetTriangles = int(target)
+		lod, err := Simplify(m, opts)
+		prev := c.Levels[len(c.Levels)-1].Mesh
+		if lod.TriangleCount() >= prev.TriangleCount() {
+			break
+		}
+		c.Levels = append(c.Levels, Level{lod, err})
+	}
+	return c
+}
+
+// PixelError returns the size in pixels of an error at the given distance
+// from the camera, for a projection made by math3d.MakePerspectiveMatrix and
+// a viewport height pixels tall.
+func PixelError(err float32, projection math3d.Matrix4, distance float32, height int) float32 {
+	// projection[5] is the cotangent of the field of view, which maps a
+	// length at distance 1 to half the viewport
+	return err * projection[5] / distance * float32(height) / 2
+}
+
+// Select returns the coarsest level whose error stays below maxPixels on
+// screen, when the mesh is drawn at the given distance from the camera.
+func (c *Chain) Select(projection math3d.Matrix4, distance float32, height int, maxPixels float32) int {
+	for i := len(c.Levels) - 1; i > 0; i-- {
+		if PixelError(c.Levels[i].Error, projection, distance, height) <= maxPixels {
+			return i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,67 @@
+package simplify
+
+import (
+	"math"
+	"testing"
+
+	"math3d"
+	"mesh/shape"
+)
+
+func TestNewChain(t *testing.T) {
+	sphere := shape.Icosphere(1, 3)
+	c := NewChain(sphere, 5, 0.25, Options{})
+	if len(c.Levels) != 5 || c.Levels[0].Mesh != sphere || c.Levels[0].Error != 0 {
+		t.Fatalf("got %d levels starting with %d triangles and error %v", len(c.Levels), c.Levels[0].Mesh.TriangleCount(), c.Levels[0].Error)
+	}
+	// 1280, 320, 80, 20 and 5 triangles, with growing errors
+	target := 1280
+	for i := 1; i < len(c.Levels); i++ {
+		target /= 4
+		l, prev := c.Levels[i], c.Levels[i-1]
+		if n := l.Mesh.TriangleCount(); n > target || n >= prev.Mesh.TriangleCount() {
+			t.Errorf("level %d: %d triangles, want at most %d", i, n, target)
+		}
+		if l.Error < prev.Error || l.Error <= 0 {
+			t.Errorf("level %d: error %v after %v", i, l.Error, prev.Error)
+		}
+	}
+
+	// An icosahedron can not be simplified without moving its surface,
+	// which ends the chain
+	ico := shape.Icosphere(1, 0)
+	if c := NewChain(ico, 4, 0.5, Options{MaxError: 1e-6}); len(c.Levels) != 1 {
+		t.Errorf("icosahedron: %d levels", len(c.Levels))
+	}
+}
+
+func TestSelect(t *testing.T) {
+	// A field of view whose cotangent is 1, so at distance d a length l
+	// covers l/d of half the viewport
+	projection := math3d.MakePerspectiveMatrix(math.Pi/4, 1, 0.1, 100)
+	if got := PixelError(0.01, projection, 10, 600); math.Abs(float64(got)-0.3) > 1e-6 {
+		t.Errorf("PixelError(0.01) at distance 10 = %v, want 0.3", got)
+	}
+
+	c := &Chain{Levels: []Level{{Error: 0}, {Error: 0.01}, {Error: 0.1}, {Error: 1}}}
+	tests := []struct {
+		distance float32
+		level    int
+	}{
+		// 3 pixels for level 1
+		{1, 0},
+		// 0.3, 3 and 30 pixels for levels 1 to 3
+		{10, 1},
+		{100, 2},
+		{1000, 3},
+	}
+	for _, test := range tests {
+		if got := c.Select(projection, test.distance, 600, 1); got != test.level {
+			t.Errorf("distance %v: level %d, want %d", test.distance, got, test.level)
+		}
+	}
+	// At the limit the coarser level is fine
+	if got := c.Select(projection, 10, 600, 3); got != 2 {
+		t.Errorf("3 pixels at distance 10: level %d, want 2", got)
+	}
+}
@@ -0,0 +1,75 @@
+package simplify
+
+import (
+	"math"
+)
+
+type vec3 [3]float64
+
+func (a vec3) sub(b vec3) vec3      { return vec3{a[0] - b[0], a[1] - b[1], a[2] - b[2]} }
+func (a vec3) scale(s float64) vec3 { return vec3{a[0] * s, a[1] * s, a[2] * s} }
+func (a vec3) dot(b vec3) float64   { return a[0]*b[0] + a[1]*b[1] + a[2]*b[2] }
+func (a vec3) length() float64      { return math.Sqrt(a.dot(a)) }
+
+func (a vec3) cross(b vec3) vec3 {
+	return vec3{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+
+// quadric measures the weighted sum of squared distances to a set of
+// planes, as the symmetric matrix
+//
+//	a2 ab ac ad
+//	ab b2 bc bd
+//	ac bc c2 cd
+//	ad bd cd d2
+//
+// of Garland and Heckbert, "Surface Simplification Using Quadric Error
+// Metrics", 1997.
+type quadric struct {
+	a2, ab, ac, ad float64
+	b2, bc, bd     float64
+	c2, cd         float64
+	d2             float64
+	// weight is the total weight of the planes
+	weight float64
+}
+
+// planeQuadric returns the quadric of the plane through p with unit normal
+// n.
+func planeQuadric(n, p vec3, weight float64) quadric {
+	a, b, c := n[0], n[1], n[2]
+	d := -n.dot(p)
+	w := weight
+	return quadric{
+		a * a * w, a * b * w, a * c * w, a * d * w,
+		b * b * w, b * c * w, b * d * w,
+		c * c * w, c * d * w,
+		d * d * w,
+		w,
+	}
+}
+
+func (q *quadric) add(o quadric) {
+	q.a2 += o.a2
+	q.ab += o.ab
+	q.ac += o.ac
+	q.ad += o.ad
+	q.b2 += o.b2
+	q.bc += o.bc
+	q.bd += o.bd
+	q.c2 += o.c2
+	q.cd += o.cd
+	q.d2 += o.d2
+	q.weight += o.weight
+}
+
+// eval returns the weighted sum of squared distances of p to the planes.
+func (q *quadric) eval(p vec3) float64 {
+	x, y, z := p[0], p[1], p[2]
+	e := q.a2*x*x + 2*q.ab*x*y + 2*q.ac*x*z + 2*q.ad*x +
+		q.b2*y*y + 2*q.bc*y*z + 2*q.bd*y +
+		q.c2*z*z + 2*q.cd*z +
+		q.d2
+	// Rounding can make it slightly negative
+	return math.Max(e, 0)
+}
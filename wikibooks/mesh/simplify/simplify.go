@@ -0,0 +1,388 @@
+// Package simplify reduces the triangle count of meshes by collapsing
+// edges in the order of their quadric error, and builds level of detail
+// chains from the results.
+package simplify
+
+import (
+	"container/heap"
+	"math"
+
+	"mesh"
+	"mesh/optimize"
+)
+
+type Options struct {
+	// TargetTriangles stops the simplification once the mesh has at most
+	// this many triangles.
+	TargetTriangles int
+	// MaxError stops it before the surface would move further than this,
+	// in mesh units. 0 means no limit.
+	MaxError float32
+	// LockBorder keeps the vertices on open borders in place. Otherwise
+	// they only move along the border.
+	LockBorder bool
+	// AttributeWeight converts differences of the other attributes, like
+	// texture coordinates and normals, into distances. 0 ignores them.
+	AttributeWeight float32
+}
+
+// Simplify returns a simplified copy of m and the error reached, in mesh
+// units.
+//
+// Edges are collapsed by moving one vertex onto the other, so the result
+// uses a subset of the original vertices and their attributes are kept
+// exactly. Vertices sharing a position, as on texture seams, move together,
+// and only along the seam.
+func Simplify(m *mesh.Mesh, opts Options) (*mesh.Mesh, float32) {
+	s := newSimplifier(m, opts)
+	e := s.run()
+	out := m.Clone()
+	out.Indices = out.Indices[:0]
+	for t, tri := range s.tris {
+		if s.alive[t] {
+			out.Indices = append(out.Indices, tri[0], tri[1], tri[2])
+		}
+	}
+	optimize.StripUnused(out)
+	return out, float32(math.Sqrt(e))
+}
+
+type simplifier struct {
+	m    *mesh.Mesh
+	opts Options
+
+	// Vertices with equal positions share a position number
+	posOf  []int
+	points []vec3
+
+	tris      [][3]uint32
+	alive     []bool
+	triangles int
+
+	// Per position: triangles using it, which may include dead ones
+	adj      [][]int
+	quadrics []quadric
+	locked   []bool
+	border   []bool
+	removed  []bool
+}
+
+func newSimplifier(m *mesh.Mesh, opts Options) *simplifier {
+	s := &simplifier{m: m, opts: opts}
+	s.weld(m)
+	np := len(s.points)
+	s.adj = make([][]int, np)
+	s.quadrics = make([]quadric, np)
+	s.locked = make([]bool, np)
+	s.border = make([]bool, np)
+	s.removed = make([]bool, np)
+
+	edges := make(map[[2]int][]int)
+	for t := 0; t < m.TriangleCount(); t++ {
+		a, b, c := m.Triangle(t)
+		s.tris = append(s.tris, [3]uint32{a, b, c})
+		s.alive = append(s.alive, true)
+		s.triangles++
+		p := [3]int{s.posOf[a], s.posOf[b], s.posOf[c]}
+		if p[0] == p[1] || p[1] == p[2] || p[2] == p[0] {
+			s.alive[t] = false
+			s.triangles--
+			continue
+		}
+		n := s.normal(t)
+		area := n.length() / 2
+		for k := 0; k < 3; k++ {
+			s.adj[p[k]] = append(s.adj[p[k]], t)
+			if area > 0 {
+				q := planeQuadric(n.scale(1/(2*area)), s.points[p[k]], area)
+				s.quadrics[p[k]].add(q)
+			}
+			e := [2]int{p[k], p[(k+1)%3]}
+			if e[0] > e[1] {
+				e[0], e[1] = e[1], e[0]
+			}
+			edges[e] = append(edges[e], t)
+		}
+	}
+	for e, ts := range edges {
+		switch {
+		case len(ts) == 1:
+			s.border[e[0]], s.border[e[1]] = true, true
+			if opts.LockBorder {
+				s.locked[e[0]], s.locked[e[1]] = true, true
+				continue
+			}
+			// A plane through the border, perpendicular to the face,
+			// keeps the border in place
+			a, b := s.points[e[0]], s.points[e[1]]
+			edge := b.sub(a)
+			n := s.normal(ts[0])
+			if side := edge.cross(n); side.length() > 0 {
+				w := edge.dot(edge)
+				q := planeQuadric(side.scale(1/side.length()), a, w)
+				s.quadrics[e[0]].add(q)
+				s.quadrics[e[1]].add(q)
+			}
+		case len(ts) > 2:
+			// Non manifold edges stay
+			s.locked[e[0]], s.locked[e[1]] = true, true
+		}
+	}
+	return s
+}
+
+// weld numbers the positions, see mesh.Mesh.PositionIDs.
+func (s *simplifier) weld(m *mesh.Mesh) {
+	pos := m.Attribute(mesh.Position)
+	var count int
+	s.posOf, count = m.PositionIDs()
+	s.points = make([]vec3, count)
+	for v, id := range s.posOf {
+		d := pos.Get(v)
+		s.points[id] = vec3{float64(d[0]), float64(d[1]), float64(d[2])}
+	}
+}
+
+// normal returns the cross product of the edges of triangle t, twice the
+// area in length.
+func (s *simplifier) normal(t int) vec3 {
+	tri := s.tris[t]
+	a, b, c := s.points[s.posOf[tri[0]]], s.points[s.posOf[tri[1]]], s.points[s.posOf[tri[2]]]
+	return b.sub(a).cross(c.sub(a))
+}
+
+// has reports whether alive triangle t uses position p.
+func (s *simplifier) has(t, p int) bool {
+	tri := s.tris[t]
+	return s.posOf[tri[0]] == p || s.posOf[tri[1]] == p || s.posOf[tri[2]] == p
+}
+
+// neighbours returns the positions sharing a triangle with p.
+func (s *simplifier) neighbours(p int) map[int]bool {
+	n := make(map[int]bool)
+	for _, t := range s.adj[p] {
+		if !s.alive[t] {
+			continue
+		}
+		for _, v := range s.tris[t] {
+			if q := s.posOf[v]; q != p {
+				n[q] = true
+			}
+		}
+	}
+	return n
+}
+
+// collapse describes moving position u onto v. Each vertex at u is
+// replaced by the vertex at v in wedges.
+type collapse struct {
+	u, v   int
+	cost   float64
+	wedges map[uint32]uint32
+}
+
+// evaluate returns the collapse of u onto v, or false if it would damage
+// the mesh.
+func (s *simplifier) evaluate(u, v int) (collapse, bool) {
+	c := collapse{u: u, v: v}
+	if s.removed[u] || s.removed[v] || s.locked[u] {
+		return c, false
+	}
+	var shared []int
+	for _, t := range s.adj[u] {
+		if s.alive[t] && s.has(t, v) {
+			shared = append(shared, t)
+		}
+	}
+	if len(shared) == 0 {
+		return c, false
+	}
+	if s.border[u] {
+		if len(shared) != 1 {
+			return c, false
+		}
+	} else if len(shared) != 2 {
+		return c, false
+	}
+	// The link condition: u and v may only have the neighbours of the
+	// triangles on the edge in common, or the mesh pinches
+	nu, nv := s.neighbours(u), s.neighbours(v)
+	common := 0
+	for p := range nu {
+		if nv[p] {
+			common++
+		}
+	}
+	if common != len(shared) {
+		return c, false
+	}
+
+	// Vertices at u take the vertex at v they share a triangle with
+	c.wedges = make(map[uint32]uint32)
+	for _, t := range shared {
+		var wu, wv uint32
+		for _, w := range s.tris[t] {
+			switch s.posOf[w] {
+			case u:
+				wu = w
+			case v:
+				wv = w
+			}
+		}
+		if old, ok := c.wedges[wu]; ok && old != wv {
+			// Across a seam which does not run along the edge
+			return c, false
+		}
+		c.wedges[wu] = wv
+	}
+	pv := s.points[v]
+	for _, t := range s.adj[u] {
+		if !s.alive[t] {
+			continue
+		}
+		tri := s.tris[t]
+		for _, w := range tri {
+			if s.posOf[w] == u {
+				if _, ok := c.wedges[w]; !ok {
+					return c, false
+				}
+			}
+		}
+		if s.has(t, v) {
+			continue
+		}
+		// Triangles must not flip over
+		before := s.normal(t)
+		var p [3]vec3
+		for k, w := range tri {
+			p[k] = s.points[s.posOf[w]]
+			if s.posOf[w] == u {
+				p[k] = pv
+			}
+		}
+		after := p[1].sub(p[0]).cross(p[2].sub(p[0]))
+		if after.dot(before) <= 1e-3*after.length()*before.length() {
+			return c, false
+		}
+	}
+
+	q := s.quadrics[u]
+	q.add(s.quadrics[v])
+	if q.weight > 0 {
+		c.cost = q.eval(pv) / q.weight
+	}
+	if aw := float64(s.opts.AttributeWeight); aw > 0 {
+		worst := 0.0
+		for wu, wv := range c.wedges {
+			worst = math.Max(worst, s.attributeDistance(wu, wv))
+		}
+		c.cost += aw * aw * worst
+	}
+	return c, true
+}
+
+// attributeDistance returns the squared distance between the attributes of
+// two vertices, other than their position.
+func (s *simplifier) attributeDistance(a, b uint32) float64 {
+	d := 0.0
+	for _, attr := range s.m.Attributes {
+		if attr.Semantic == mesh.Position {
+			continue
+		}
+		x, y := attr.Get(int(a)), attr.Get(int(b))
+		for k := range x {
+			d += float64(x[k]-y[k]) * float64(x[k]-y[k])
+		}
+	}
+	return d
+}
+
+func (s *simplifier) apply(c collapse) {
+	for _, t := range s.adj[c.u] {
+		if !s.alive[t] {
+			continue
+		}
+		if s.has(t, c.v) {
+			s.alive[t] = false
+			s.triangles--
+			continue
+		}
+		for k, w := range s.tris[t] {
+			if s.posOf[w] == c.u {
+				s.tris[t][k] = c.wedges[w]
+			}
+		}
+		s.adj[c.v] = append(s.adj[c.v], t)
+	}
+	s.quadrics[c.v].add(s.quadrics[c.u])
+	s.adj[c.u] = nil
+	s.removed[c.u] = true
+
+	// Drop dead triangles from the list of v
+	live := s.adj[c.v][:0]
+	for _, t := range s.adj[c.v] {
+		if s.alive[t] {
+			live = append(live, t)
+		}
+	}
+	s.adj[c.v] = live
+}
+
+// run collapses edges and returns the largest squared error reached.
+func (s *simplifier) run() float64 {
+	var queue collapseQueue
+	push := func(u, v int) {
+		if c, ok := s.evaluate(u, v); ok {
+			// Evaluated again when popped
+			c.wedges = nil
+			heap.Push(&queue, c)
+		}
+	}
+	for u := range s.points {
+		for v := range s.neighbours(u) {
+			push(u, v)
+		}
+	}
+	limit := math.Inf(1)
+	if s.opts.MaxError > 0 {
+		limit = float64(s.opts.MaxError) * float64(s.opts.MaxError)
+	}
+
+	reached := 0.0
+	for queue.Len() > 0 && s.triangles > s.opts.TargetTriangles {
+		old := heap.Pop(&queue).(collapse)
+		c, ok := s.evaluate(old.u, old.v)
+		if !ok {
+			continue
+		}
+		if c.cost > old.cost*(1+1e-9)+1e-30 {
+			// The neighbourhood changed since it was queued
+			heap.Push(&queue, c)
+			continue
+		}
+		if c.cost > limit {
+			break
+		}
+		s.apply(c)
+		reached = math.Max(reached, c.cost)
+		for n := range s.neighbours(c.v) {
+			push(c.v, n)
+			push(n, c.v)
+		}
+	}
+	return reached
+}
+
+type collapseQueue []collapse
+
+func (q collapseQueue) Len() int            { return len(q) }
+func (q collapseQueue) Less(i, j int) bool  { return q[i].cost < q[j].cost }
+func (q collapseQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *collapseQueue) Push(x interface{}) { *q = append(*q, x.(collapse)) }
+
+func (q *collapseQueue) Pop() interface{} {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return c
+}
@@ -0,0 +1,157 @@
+package simplify
+
+import (
+	"fmt"
+	"testing"
+
+	"mesh"
+	"mesh/halfedge"
+	"mesh/shape"
+)
+
+// closed fails t unless m is still a closed oriented manifold.
+func closed(t *testing.T, name string, m *mesh.Mesh) {
+	t.Helper()
+	indices, vertices := halfedge.Welded(m)
+	r := halfedge.Check(indices, len(vertices))
+	if r.BoundaryEdges != 0 || !r.Manifold() || !r.Oriented() {
+		t.Errorf("%s: simplified to %+v", name, r)
+	}
+}
+
+// vertexSet returns the vertices of m, all attributes together, as keys.
+func vertexSet(m *mesh.Mesh) map[string]bool {
+	set := make(map[string]bool)
+	for v := 0; v < m.VertexCount(); v++ {
+		set[vertexKey(m, v)] = true
+	}
+	return set
+}
+
+func vertexKey(m *mesh.Mesh, v int) string {
+	var key []float32
+	for _, a := range m.Attributes {
+		key = append(key, a.Get(v)...)
+	}
+	return fmt.Sprint(key)
+}
+
+func TestSimplifyTarget(t *testing.T) {
+	sphere := shape.Icosphere(1, 3)
+	last := float32(0)
+	for _, target := range []int{1000, 320, 80, 20} {
+		m, err := Simplify(sphere, Options{TargetTriangles: target})
+		if n := m.TriangleCount(); n > target || n < target*3/4 {
+			t.Errorf("target %d: %d triangles", target, n)
+		}
+		closed(t, "sphere", m)
+		if err < last {
+			t.Errorf("target %d: error %v below %v of the larger target", target, err, last)
+		}
+		last = err
+		// Collapses keep the vertices which stay exactly
+		original := vertexSet(sphere)
+		for v := 0; v < m.VertexCount(); v++ {
+			if !original[vertexKey(m, v)] {
+				t.Fatalf("target %d: vertex %d is new", target, v)
+			}
+		}
+	}
+	if sphere.TriangleCount() != 1280 {
+		t.Errorf("the original mesh changed to %d triangles", sphere.TriangleCount())
+	}
+}
+
+func TestMaxError(t *testing.T) {
+	sphere := shape.Icosphere(1, 3)
+	unlimited, _ := Simplify(sphere, Options{})
+	m, err := Simplify(sphere, Options{MaxError: 0.01})
+	if err > 0.01 {
+		t.Errorf("error %v beyond the limit", err)
+	}
+	if m.TriangleCount() <= unlimited.TriangleCount() || m.TriangleCount() >= sphere.TriangleCount() {
+		t.Errorf("%d triangles within the limit, %d without", m.TriangleCount(), unlimited.TriangleCount())
+	}
+}
+
+// onBorder reports whether a vertex of the 2 by 2 plane is on its border.
+func onBorder(p []float32) bool {
+	return p[0] == -1 || p[0] == 1 || p[2] == -1 || p[2] == 1
+}
+
+func TestBorder(t *testing.T) {
+	plane := shape.Plane(2, 2, 8, 8)
+	border := 0
+	for v := 0; v < plane.VertexCount(); v++ {
+		if onBorder(plane.Attribute(mesh.Position).Get(v)) {
+			border++
+		}
+	}
+
+	// Locked, all 32 border vertices stay where they are
+	m, err := Simplify(plane, Options{LockBorder: true})
+	pos := m.Attribute(mesh.Position)
+	n := 0
+	for v := 0; v < m.VertexCount(); v++ {
+		if onBorder(pos.Get(v)) {
+			n++
+		}
+	}
+	if n != border || err != 0 {
+		t.Errorf("locked: %d of %d border vertices left, error %v", n, border, err)
+	}
+	if m.TriangleCount() >= plane.TriangleCount() {
+		t.Errorf("locked: the inside was not simplified")
+	}
+
+	// Otherwise they move along the border, which keeps its corners, down
+	// to two triangles for free
+	m, err = Simplify(plane, Options{MaxError: 1e-3})
+	pos = m.Attribute(mesh.Position)
+	corners := 0
+	for v := 0; v < m.VertexCount(); v++ {
+		p := pos.Get(v)
+		if !onBorder(p) || p[1] != 0 {
+			t.Errorf("vertex %d moved off the border to %v", v, p)
+		}
+		if (p[0] == -1 || p[0] == 1) && (p[2] == -1 || p[2] == 1) {
+			corners++
+		}
+	}
+	if corners != 4 || m.TriangleCount() != 2 || err > 1e-6 {
+		t.Errorf("%d corners and %d triangles left, error %v", corners, m.TriangleCount(), err)
+	}
+}
+
+// Faces of a box have their own normals, which simplification keeps apart.
+func TestAttributes(t *testing.T) {
+	box := shape.Box(1, 1, 1, 4)
+	for _, weight := range []float32{0, 1} {
+		m, err := Simplify(box, Options{AttributeWeight: weight})
+		// Texture coordinates differ across the faces, so weighting them
+		// makes collapses inside the faces cost
+		if weight == 0 && (m.TriangleCount() != 12 || err > 1e-6) {
+			t.Errorf("weight %v: %d triangles, error %v", weight, m.TriangleCount(), err)
+		}
+		closed(t, "box", m)
+		normals := m.Attribute(mesh.Normal)
+		for tri := 0; tri < m.TriangleCount(); tri++ {
+			a, b, c := m.Triangle(tri)
+			na, nb, nc := normals.Get(int(a)), normals.Get(int(b)), normals.Get(int(c))
+			for k := range na {
+				if na[k] != nb[k] || na[k] != nc[k] {
+					t.Fatalf("weight %v: triangle %d mixes normals %v, %v and %v", weight, tri, na, nb, nc)
+				}
+			}
+		}
+	}
+
+	// Weighted attributes add to the cost of collapsing across a seam of
+	// texture coordinates
+	sphere := shape.UVSphere(1, 16, 8)
+	_, plain := Simplify(sphere, Options{TargetTriangles: 100})
+	_, weighted := Simplify(sphere, Options{TargetTriangles: 100, AttributeWeight: 10})
+	if weighted < plain {
+		t.Errorf("error %v with attributes weighted, %v without", weighted, plain)
+	}
+}
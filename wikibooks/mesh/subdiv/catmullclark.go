@@ -0,0 +1,158 @@
+package subdiv
+
+import (
+	"mesh"
+)
+
+// CatmullClark subdivides the polygons faces, which index the vertices of
+// m, with the rules of Catmull and Clark. Every level turns an n-sided
+// polygon into n quads; the result has two triangles per quad. Polygons
+// makes quads out of the triangles of m.
+func CatmullClark(m *mesh.Mesh, faces [][]uint32, opts Options) *mesh.Mesh {
+	c := newCage(m, faces, opts.Creases)
+	for i := 0; i < opts.Levels; i++ {
+		c = c.catmullClark()
+	}
+	if opts.Limit {
+		c.catmullClarkLimit()
+	}
+	return c.mesh(m.Attribute(mesh.Normal) != nil, m.Attribute(mesh.Tangent) != nil)
+}
+
+func (c *cage) catmullClark() *cage {
+	t := c.topology()
+	n, nf := len(c.points), len(c.faces)
+	next := &cage{
+		points:    make([]vec3, n+nf+len(t.order)),
+		attrs:     c.attrs,
+		sharpness: make(map[edge]float64),
+	}
+
+	// Face points are the centroids
+	attrGroups := make([][]int, nf)
+	for f, face := range c.faces {
+		var sum vec3
+		for _, v := range face {
+			sum = sum.add(c.points[v.p])
+			attrGroups[f] = append(attrGroups[f], v.a)
+		}
+		next.points[n+f] = sum.scale(1 / float64(len(face)))
+	}
+	faceAttrs := c.attributePoints(attrGroups)
+
+	// Edge points average the ends and the face points on either side
+	for i, e := range t.order {
+		info := t.edges[e]
+		info.point = n + nf + i
+		smooth := c.points[e[0]].add(c.points[e[1]])
+		for _, f := range info.faces {
+			smooth = smooth.add(next.points[n+f])
+		}
+		smooth = smooth.scale(1 / float64(2+len(info.faces)))
+		next.points[info.point] = c.edgeRule(t, e, smooth)
+	}
+	ae := &attributeEdges{ids: make(map[[2]int]int)}
+	for _, face := range c.faces {
+		for k, v := range face {
+			ae.add(v.a, face[(k+1)%len(face)].a)
+		}
+	}
+	edgeAttrs := c.attributePoints(ae.groups)
+
+	// Vertex points: (F + 2R + (n-3)P) / n with the averages F of the
+	// face points around and R of the edge midpoints
+	for p, P := range c.points {
+		edges := t.vertexEdges[p]
+		if len(edges) == 0 {
+			next.points[p] = P
+			continue
+		}
+		var F, R vec3
+		for _, f := range t.vertexFaces[p] {
+			F = F.add(next.points[n+f])
+		}
+		F = F.scale(1 / float64(len(t.vertexFaces[p])))
+		for _, e := range edges {
+			R = R.add(c.points[e[0]].add(c.points[e[1]]).scale(0.5))
+		}
+		valence := float64(len(edges))
+		R = R.scale(1 / valence)
+		smooth := F.add(R.scale(2)).add(P.scale(valence - 3)).scale(1 / valence)
+		next.points[p] = c.vertexRule(t, p, smooth, 1.0/8)
+	}
+
+	// Each corner gets a quad of itself, the edge points on either side
+	// and the face point
+	for f, face := range c.faces {
+		center := corner{n + f, faceAttrs[f]}
+		for k, v := range face {
+			prev, after := face[(k+len(face)-1)%len(face)], face[(k+1)%len(face)]
+			e1, e2 := makeEdge(v.p, after.p), makeEdge(prev.p, v.p)
+			next.faces = append(next.faces, []corner{
+				v,
+				{t.edges[e1].point, edgeAttrs[ae.get(v.a, after.a)]},
+				center,
+				{t.edges[e2].point, edgeAttrs[ae.get(prev.a, v.a)]},
+			})
+		}
+	}
+	for e, s := range c.sharpness {
+		info := t.edges[e]
+		if info == nil {
+			continue
+		}
+		if s = childSharpness(s); s > 0 {
+			next.sharpness[makeEdge(e[0], info.point)] = s
+			next.sharpness[makeEdge(info.point, e[1])] = s
+		}
+	}
+	return next
+}
+
+// catmullClarkLimit moves the points of a quad mesh onto the limit
+// surface, with the weights of Halstead et al., "Efficient, Fair
+// Interpolation using Catmull-Clark Surfaces", 1993: n² for the point, 4
+// for each edge neighbour and 1 for each diagonal neighbour. Creases get
+// the limit of their cubic B-spline.
+func (c *cage) catmullClarkLimit() {
+	t := c.topology()
+	limit := make([]vec3, len(c.points))
+	for p, P := range c.points {
+		edges := t.vertexEdges[p]
+		valence := float64(len(edges))
+		if valence == 0 {
+			limit[p] = P
+			continue
+		}
+		sum := P.scale(valence * valence)
+		for _, e := range edges {
+			sum = sum.add(c.points[e.other(p)].scale(4))
+		}
+		for _, f := range t.vertexFaces[p] {
+			face := c.faces[f]
+			for k, v := range face {
+				if v.p == p {
+					sum = sum.add(c.points[face[(k+len(face)/2)%len(face)].p])
+				}
+			}
+		}
+		smooth := sum.scale(1 / (valence * (valence + 5)))
+		limit[p] = c.limitRule(t, p, smooth)
+	}
+	c.points = limit
+}
+
+// limitRule is vertexRule for the limit surface. Edges which are still
+// sharp at all count as sharp.
+func (c *cage) limitRule(t *topology, p int, smooth vec3) vec3 {
+	sharp, _ := c.sharpEdges(t, p)
+	switch len(sharp) {
+	case 0, 1:
+		return smooth
+	case 2:
+		return c.points[p].scale(4.0 / 6).
+			add(c.points[sharp[0].other(p)].scale(1.0 / 6)).
+			add(c.points[sharp[1].other(p)].scale(1.0 / 6))
+	}
+	return c.points[p]
+}
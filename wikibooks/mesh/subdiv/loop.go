@@ -0,0 +1,124 @@
+package subdiv
+
+import (
+	"math"
+
+	"mesh"
+)
+
+// Loop subdivides the triangles of m with the rules of Charles Loop,
+// "Smooth Subdivision Surfaces Based on Triangles", 1987. Every level
+// splits each triangle into 4.
+func Loop(m *mesh.Mesh, opts Options) *mesh.Mesh {
+	faces := make([][]uint32, m.TriangleCount())
+	for t := range faces {
+		faces[t] = m.Indices[3*t : 3*t+3]
+	}
+	c := newCage(m, faces, opts.Creases)
+	for i := 0; i < opts.Levels; i++ {
+		c = c.loop()
+	}
+	if opts.Limit {
+		c.loopLimit()
+	}
+	return c.mesh(m.Attribute(mesh.Normal) != nil, m.Attribute(mesh.Tangent) != nil)
+}
+
+// loopBeta is the weight of each of n neighbours of a smooth vertex.
+func loopBeta(n int) float64 {
+	x := 3.0/8 + math.Cos(2*math.Pi/float64(n))/4
+	return (5.0/8 - x*x) / float64(n)
+}
+
+func (c *cage) loop() *cage {
+	t := c.topology()
+	n := len(c.points)
+	next := &cage{
+		points:    make([]vec3, n+len(t.order)),
+		attrs:     c.attrs,
+		sharpness: make(map[edge]float64),
+	}
+
+	// Edge points: 3/8 of each end and 1/8 of each opposite corner
+	for i, e := range t.order {
+		info := t.edges[e]
+		info.point = n + i
+		smooth := c.points[e[0]].add(c.points[e[1]]).scale(3.0 / 8)
+		for _, f := range info.faces {
+			for _, v := range c.faces[f] {
+				if v.p != e[0] && v.p != e[1] {
+					smooth = smooth.add(c.points[v.p].scale(1.0 / 8))
+				}
+			}
+		}
+		next.points[info.point] = c.edgeRule(t, e, smooth)
+	}
+	ae := &attributeEdges{ids: make(map[[2]int]int)}
+	for _, face := range c.faces {
+		for k, v := range face {
+			ae.add(v.a, face[(k+1)%3].a)
+		}
+	}
+	edgeAttrs := c.attributePoints(ae.groups)
+
+	for p, P := range c.points {
+		edges := t.vertexEdges[p]
+		if len(edges) == 0 {
+			next.points[p] = P
+			continue
+		}
+		beta := loopBeta(len(edges))
+		smooth := P.scale(1 - float64(len(edges))*beta)
+		for _, e := range edges {
+			smooth = smooth.add(c.points[e.other(p)].scale(beta))
+		}
+		next.points[p] = c.vertexRule(t, p, smooth, 1.0/8)
+	}
+
+	for _, face := range c.faces {
+		var mid [3]corner
+		for k, v := range face {
+			w := face[(k+1)%3]
+			mid[k] = corner{t.edges[makeEdge(v.p, w.p)].point, edgeAttrs[ae.get(v.a, w.a)]}
+		}
+		next.faces = append(next.faces,
+			[]corner{face[0], mid[0], mid[2]},
+			[]corner{mid[0], face[1], mid[1]},
+			[]corner{mid[2], mid[1], face[2]},
+			[]corner{mid[0], mid[1], mid[2]},
+		)
+	}
+	for e, s := range c.sharpness {
+		info := t.edges[e]
+		if info == nil {
+			continue
+		}
+		if s = childSharpness(s); s > 0 {
+			next.sharpness[makeEdge(e[0], info.point)] = s
+			next.sharpness[makeEdge(info.point, e[1])] = s
+		}
+	}
+	return next
+}
+
+// loopLimit moves the points onto the limit surface, where a smooth vertex
+// with n neighbours gets the weight 1/(3/(8 beta) + n) for each of them.
+func (c *cage) loopLimit() {
+	t := c.topology()
+	limit := make([]vec3, len(c.points))
+	for p, P := range c.points {
+		edges := t.vertexEdges[p]
+		if len(edges) == 0 {
+			limit[p] = P
+			continue
+		}
+		valence := float64(len(edges))
+		chi := 1 / (3/(8*loopBeta(len(edges))) + valence)
+		smooth := P.scale(1 - valence*chi)
+		for _, e := range edges {
+			smooth = smooth.add(c.points[e.other(p)].scale(chi))
+		}
+		limit[p] = c.limitRule(t, p, smooth)
+	}
+	c.points = limit
+}
@@ -0,0 +1,332 @@
+// Package subdiv smooths coarse meshes by subdivision: Loop subdivision for
+// triangle meshes and Catmull-Clark subdivision for quad meshes.
+//
+// Positions are smoothed across texture seams, as vertices with the same
+// position are treated as one. Texture coordinates and colors are
+// interpolated linearly on each face, so seams stay where they are. Normals
+// and tangents are computed again for the result; joints and weights are
+// dropped.
+package subdiv
+
+import (
+	"math"
+
+	"mesh"
+)
+
+type Options struct {
+	// Levels is the number of times the mesh is subdivided. Each level
+	// multiplies the number of faces by 4.
+	Levels int
+	// Creases gives edges, by the vertices at their ends in either order,
+	// a sharpness: the number of levels they stay sharp before smoothing.
+	// Fractional values blend between the two. Borders are always sharp.
+	Creases map[[2]uint32]float32
+	// Limit moves the vertices of the result onto the limit surface, the
+	// shape subdividing forever would give.
+	Limit bool
+}
+
+type vec3 [3]float64
+
+func (a vec3) add(b vec3) vec3      { return vec3{a[0] + b[0], a[1] + b[1], a[2] + b[2]} }
+func (a vec3) scale(s float64) vec3 { return vec3{a[0] * s, a[1] * s, a[2] * s} }
+
+func lerp(a, b vec3, t float64) vec3 {
+	return a.scale(1 - t).add(b.scale(t))
+}
+
+// corner is a face corner: p numbers the position, a the vertex the other
+// attributes come from.
+type corner struct {
+	p, a int
+}
+
+// edge connects two positions, the smaller first.
+type edge [2]int
+
+func makeEdge(p, q int) edge {
+	if p > q {
+		p, q = q, p
+	}
+	return edge{p, q}
+}
+
+// cage is the polygon mesh of one subdivision level.
+type cage struct {
+	points []vec3
+	// attrs are interpolated, indexed by corner.a
+	attrs []*mesh.Attribute
+	faces [][]corner
+	// sharpness of the edges which have any
+	sharpness map[edge]float64
+}
+
+// edgeInfo lists the faces on an edge and the number of its new point.
+type edgeInfo struct {
+	faces []int
+	point int
+}
+
+// topology collects the edges of the cage, in the order they are first
+// used, and the edges and faces around each position.
+type topology struct {
+	edges       map[edge]*edgeInfo
+	order       []edge
+	vertexEdges [][]edge
+	vertexFaces [][]int
+}
+
+func (c *cage) topology() *topology {
+	t := &topology{
+		edges:       make(map[edge]*edgeInfo),
+		vertexEdges: make([][]edge, len(c.points)),
+		vertexFaces: make([][]int, len(c.points)),
+	}
+	for f, face := range c.faces {
+		for k, v := range face {
+			t.vertexFaces[v.p] = append(t.vertexFaces[v.p], f)
+			e := makeEdge(v.p, face[(k+1)%len(face)].p)
+			info := t.edges[e]
+			if info == nil {
+				info = &edgeInfo{}
+				t.edges[e] = info
+				t.order = append(t.order, e)
+				t.vertexEdges[e[0]] = append(t.vertexEdges[e[0]], e)
+				t.vertexEdges[e[1]] = append(t.vertexEdges[e[1]], e)
+			}
+			info.faces = append(info.faces, f)
+		}
+	}
+	return t
+}
+
+// edgeSharpness returns the sharpness of e, infinite on borders.
+func (c *cage) edgeSharpness(t *topology, e edge) float64 {
+	if len(t.edges[e].faces) != 2 {
+		return math.Inf(1)
+	}
+	return c.sharpness[e]
+}
+
+// other returns the end of e which is not p.
+func (e edge) other(p int) int {
+	if e[0] == p {
+		return e[1]
+	}
+	return e[0]
+}
+
+// sharpEdges returns the sharp edges around p and their total sharpness.
+// Corners of a single face count as meeting more than two sharp edges, so
+// they stay in place.
+func (c *cage) sharpEdges(t *topology, p int) ([]edge, float64) {
+	var sharp []edge
+	total := 0.0
+	for _, e := range t.vertexEdges[p] {
+		if s := c.edgeSharpness(t, e); s > 0 {
+			sharp = append(sharp, e)
+			total += s
+		}
+	}
+	if len(t.vertexFaces[p]) == 1 {
+		sharp = append(sharp, sharp...)
+	}
+	return sharp, total
+}
+
+// vertexRule smooths position p with the smooth point given, following
+// the sharp edges around it: a vertex on one sharp edge is smooth, on two
+// it moves along the crease with the given weight of its neighbours, on
+// more it stays. Semi sharp edges blend the results.
+func (c *cage) vertexRule(t *topology, p int, smooth vec3, creaseWeight float64) vec3 {
+	sharp, total := c.sharpEdges(t, p)
+	if len(sharp) < 2 {
+		return smooth
+	}
+	P := c.points[p]
+	rule := P
+	if len(sharp) == 2 {
+		rule = P.scale(1 - 2*creaseWeight).
+			add(c.points[sharp[0].other(p)].scale(creaseWeight)).
+			add(c.points[sharp[1].other(p)].scale(creaseWeight))
+	}
+	if s := total / float64(len(sharp)); s < 1 {
+		return lerp(smooth, rule, s)
+	}
+	return rule
+}
+
+// edgeRule blends the smooth point of e with its midpoint by sharpness.
+func (c *cage) edgeRule(t *topology, e edge, smooth vec3) vec3 {
+	mid := c.points[e[0]].add(c.points[e[1]]).scale(0.5)
+	s := c.edgeSharpness(t, e)
+	if s >= 1 {
+		return mid
+	}
+	return lerp(smooth, mid, s)
+}
+
+// childSharpness returns the sharpness of the halves of an edge at the
+// next level.
+func childSharpness(s float64) float64 {
+	return math.Max(s-1, 0)
+}
+
+// attributePoints adds the averages of the attribute vertices of each
+// group and returns their numbers. Without attributes all corners use
+// vertex 0.
+func (c *cage) attributePoints(groups [][]int) []int {
+	out := make([]int, len(groups))
+	for _, a := range c.attrs {
+		first := a.Count()
+		for i, g := range groups {
+			out[i] = first + i
+			sum := make([]float32, a.Size)
+			for _, v := range g {
+				for k, x := range a.Get(v) {
+					sum[k] += x
+				}
+			}
+			for k := range sum {
+				sum[k] /= float32(len(g))
+			}
+			a.Data = append(a.Data, sum...)
+		}
+	}
+	return out
+}
+
+// attributeEdges numbers the midpoints of attribute vertex pairs along the
+// edges of the faces.
+type attributeEdges struct {
+	ids    map[[2]int]int
+	groups [][]int
+}
+
+func (ae *attributeEdges) add(a, b int) {
+	if a > b {
+		a, b = b, a
+	}
+	if _, ok := ae.ids[[2]int{a, b}]; !ok {
+		ae.ids[[2]int{a, b}] = len(ae.groups)
+		ae.groups = append(ae.groups, []int{a, b})
+	}
+}
+
+func (ae *attributeEdges) get(a, b int) int {
+	if a > b {
+		a, b = b, a
+	}
+	return ae.ids[[2]int{a, b}]
+}
+
+// newCage welds the positions of m and keeps the attributes to be
+// interpolated.
+func newCage(m *mesh.Mesh, faces [][]uint32, creases map[[2]uint32]float32) *cage {
+	c := &cage{sharpness: make(map[edge]float64)}
+	ids, count := m.PositionIDs()
+	pos := m.Attribute(mesh.Position)
+	c.points = make([]vec3, count)
+	for v, id := range ids {
+		d := pos.Get(v)
+		c.points[id] = vec3{float64(d[0]), float64(d[1]), float64(d[2])}
+	}
+	for _, a := range m.Attributes {
+		switch a.Semantic {
+		case mesh.Position, mesh.Normal, mesh.Tangent, mesh.Joints, mesh.Weights:
+			continue
+		}
+		c.attrs = append(c.attrs, &mesh.Attribute{Semantic: a.Semantic, Name: a.Name, Size: a.Size, Data: append([]float32(nil), a.Data...)})
+	}
+	for _, face := range faces {
+		corners := make([]corner, len(face))
+		for k, v := range face {
+			corners[k] = corner{ids[v], 0}
+			if len(c.attrs) > 0 {
+				corners[k].a = int(v)
+			}
+		}
+		c.faces = append(c.faces, corners)
+	}
+	for e, s := range creases {
+		if s > 0 {
+			c.sharpness[makeEdge(ids[e[0]], ids[e[1]])] = float64(s)
+		}
+	}
+	return c
+}
+
+// mesh triangulates the faces of the cage into a mesh with a vertex for
+// each pair of position and attribute vertex.
+func (c *cage) mesh(normals, tangents bool) *mesh.Mesh {
+	m := mesh.New()
+	vertices := make(map[corner]uint32)
+	var positions []float32
+	var order []int
+	index := func(v corner) uint32 {
+		i, ok := vertices[v]
+		if !ok {
+			i = uint32(len(order))
+			vertices[v] = i
+			order = append(order, v.a)
+			p := c.points[v.p]
+			positions = append(positions, float32(p[0]), float32(p[1]), float32(p[2]))
+		}
+		return i
+	}
+	for _, face := range c.faces {
+		for k := 2; k < len(face); k++ {
+			m.Indices = append(m.Indices, index(face[0]), index(face[k-1]), index(face[k]))
+		}
+	}
+	m.Add(mesh.Position, 3, positions)
+	for _, a := range c.attrs {
+		data := make([]float32, 0, len(order)*a.Size)
+		for _, v := range order {
+			data = append(data, a.Get(v)...)
+		}
+		m.AddNamed(a.Semantic, a.Name, a.Size, data)
+	}
+	if normals || tangents {
+		m.SmoothNormals()
+	}
+	if tangents {
+		m.ComputeTangents()
+	}
+	return m
+}
+
+// Polygons pairs consecutive triangles of m which share an edge into quads,
+// as the shapes package and most exporters write quads. Other triangles
+// stay triangles.
+func Polygons(m *mesh.Mesh) [][]uint32 {
+	var faces [][]uint32
+	nt := m.TriangleCount()
+	for t := 0; t < nt; t++ {
+		t1 := m.Indices[3*t : 3*t+3]
+		if t+1 < nt {
+			if quad := joinTriangles(t1, m.Indices[3*t+3:3*t+6]); quad != nil {
+				faces = append(faces, quad)
+				t++
+				continue
+			}
+		}
+		faces = append(faces, []uint32{t1[0], t1[1], t1[2]})
+	}
+	return faces
+}
+
+// joinTriangles returns the quad of two triangles with a common edge, or
+// nil.
+func joinTriangles(t1, t2 []uint32) []uint32 {
+	for k := 0; k < 3; k++ {
+		x, y, z := t1[k], t1[(k+1)%3], t1[(k+2)%3]
+		for j := 0; j < 3; j++ {
+			if t2[j] == y && t2[(j+1)%3] == x {
+				return []uint32{y, z, x, t2[(j+2)%3]}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package subdiv
+
+import (
+	"math"
+	"testing"
+
+	"mesh"
+)
+
+// cubeQuads are the faces of a cube whose corner i is at x = i&1,
+// y = i>>1&1, z = i>>2&1 scaled to -1 and 1, counter clockwise seen from
+// outside. The face at z = 1 comes first.
+var cubeQuads = [][]uint32{{4, 5, 7, 6}, {0, 2, 3, 1}, {1, 3, 7, 5}, {0, 4, 6, 2}, {2, 6, 7, 3}, {0, 1, 5, 4}}
+
+// cube returns the 8 corners of the cube as two triangles per face.
+func cube() *mesh.Mesh {
+	m := mesh.New()
+	var pos []float32
+	for i := 0; i < 8; i++ {
+		pos = append(pos, float32(i&1*2-1), float32(i>>1&1*2-1), float32(i>>2&1*2-1))
+	}
+	m.Add(mesh.Position, 3, pos)
+	for _, q := range cubeQuads {
+		m.Indices = append(m.Indices, q[0], q[1], q[2], q[0], q[2], q[3])
+	}
+	return m
+}
+
+func TestCatmullClarkCube(t *testing.T) {
+	m := CatmullClark(cube(), cubeQuads, Options{Levels: 1})
+	// 8 corners, 12 edge points and 6 face points
+	if v, tris := m.VertexCount(), m.TriangleCount(); v != 26 || tris != 48 {
+		t.Errorf("got %d vertices and %d triangles, want 26 and 48", v, tris)
+	}
+	// Only corners have no zero coordinate. A corner moves to
+	// (n-3)/n p + F/n + 2E/n with n = 3: the average of its face points,
+	// 1/3, plus twice that of its edge midpoints, 4/3, over 3
+	pos := m.Attribute(mesh.Position)
+	for v := 0; v < m.VertexCount(); v++ {
+		p := pos.Get(v)
+		if p[0] != 0 && p[1] != 0 && p[2] != 0 && !near(abs(p), []float32{5.0 / 9, 5.0 / 9, 5.0 / 9}) {
+			t.Errorf("corner at %v, want ±5/9", p)
+		}
+	}
+}
+
+func TestLoopCube(t *testing.T) {
+	m := Loop(cube(), Options{Levels: 2})
+	// 8 + 18 vertices and 48 triangles after the first level, 26 + 72
+	// and 192 after the second
+	if v, tris := m.VertexCount(), m.TriangleCount(); v != 98 || tris != 192 {
+		t.Errorf("got %d vertices and %d triangles, want 98 and 192", v, tris)
+	}
+}
+
+// With the edges around the top sharp, its corners follow the crease rule
+// 3/4 p + 1/8 of the two crease neighbours and the top stays flat.
+func TestCreases(t *testing.T) {
+	creases := make(map[[2]uint32]float32)
+	top := cubeQuads[0]
+	for k := range top {
+		creases[[2]uint32{top[k], top[(k+1)%4]}] = 2
+	}
+	m := CatmullClark(cube(), cubeQuads, Options{Levels: 1, Creases: creases})
+	pos := m.Attribute(mesh.Position)
+	corners := 0
+	for v := 0; v < m.VertexCount(); v++ {
+		p := pos.Get(v)
+		if p[2] > 0.99 && near(abs(p), []float32{0.75, 0.75, 1}) {
+			corners++
+		}
+	}
+	if corners != 4 {
+		t.Errorf("found %d corners at (±0.75, ±0.75, 1), want 4", corners)
+	}
+	for v := 0; v < m.VertexCount(); v++ {
+		if p := pos.Get(v); p[2] > 0.9 && p[2] != 1 {
+			t.Errorf("vertex at %v, want the top at z = 1", p)
+		}
+	}
+}
+
+func abs(p []float32) []float32 {
+	a := make([]float32, len(p))
+	for k, x := range p {
+		a[k] = float32(math.Abs(float64(x)))
+	}
+	return a
+}
+
+func near(p, q []float32) bool {
+	for k := range p {
+		if math.Abs(float64(p[k]-q[k])) > 1e-6 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+package mesh
+
+import (
+	"errors"
+	"math"
+)
+
+// ComputeTangents sets the Tangent attribute, four components per vertex:
+// the direction of increasing texture u, perpendicular to the normal, and
+// in w the sign of the bitangent, so that
+//
+//	bitangent = w * cross(normal, tangent)
+//
+// The method follows MikkTSpace, the convention of most bakers: face
+// tangents are projected on the vertex normal and averaged weighted by the
+// corner angle, only between faces whose texture mapping has the same
+// orientation. A vertex shared by mirrored faces is split in two. Vertices
+// which no triangle uses are dropped.
+func (m *Mesh) ComputeTangents() error {
+	pos, nrm, uv := m.Attribute(Position), m.Attribute(Normal), m.Attribute(TexCoord)
+	if pos == nil || nrm == nil || uv == nil || uv.Size < 2 {
+		return errors.New("mesh: tangents need positions, normals and texture coordinates")
+	}
+	m.Remove(Tangent)
+
+	type group struct {
+		vertex uint32
+		// flipped is set if the texture is mirrored on the faces
+		flipped bool
+	}
+	sums := make(map[group]vec3)
+	cornerGroups := make([]group, len(m.Indices))
+	degenerate := make([]bool, len(m.Indices))
+	for t := 0; t < m.TriangleCount(); t++ {
+		a, b, c := m.Triangle(t)
+		corners := [3]uint32{a, b, c}
+		p := [3]vec3{pos.vec3(a), pos.vec3(b), pos.vec3(c)}
+		ta, tb, tc := uv.Get(int(a)), uv.Get(int(b)), uv.Get(int(c))
+		e1, e2 := p[1].sub(p[0]), p[2].sub(p[0])
+		du1, dv1 := float64(tb[0]-ta[0]), float64(tb[1]-ta[1])
+		du2, dv2 := float64(tc[0]-ta[0]), float64(tc[1]-ta[1])
+		det := du1*dv2 - du2*dv1
+		flipped := det < 0
+		// Scaled by |det|, which normalizing removes
+		tangent := e1.scale(dv2).sub(e2.scale(dv1))
+		if flipped {
+			tangent = tangent.scale(-1)
+		}
+		for k, v := range corners {
+			cornerGroups[3*t+k] = group{v, flipped}
+			if math.Abs(det) < 1e-20 {
+				degenerate[3*t+k] = true
+				continue
+			}
+			n := nrm.vec3(v).normalized()
+			tk := tangent.sub(n.scale(n.dot(tangent)))
+			if tk.dot(tk) == 0 {
+				continue
+			}
+			angle := cornerAngle(p[k], p[(k+1)%3], p[(k+2)%3])
+			g := cornerGroups[3*t+k]
+			sums[g] = sums[g].add(tk.normalized().scale(angle))
+		}
+	}
+
+	groups := make(map[group]uint32)
+	var origin []uint32
+	var tangents []float32
+	indices := make([]uint32, len(m.Indices))
+	for c, g := range cornerGroups {
+		if degenerate[c] {
+			// Faces without a texture mapping join their neighbours
+			if _, ok := sums[g]; !ok {
+				g.flipped = !g.flipped
+				if _, ok := sums[g]; !ok {
+					g.flipped = false
+				}
+			}
+		}
+		i, ok := groups[g]
+		if !ok {
+			i = uint32(len(origin))
+			groups[g] = i
+			origin = append(origin, g.vertex)
+			n := nrm.vec3(g.vertex).normalized()
+			t := sums[g]
+			t = t.sub(n.scale(n.dot(t))).normalized()
+			if t.dot(t) == 0 {
+				t = perpendicular(n)
+			}
+			w := float32(1)
+			if g.flipped {
+				w = -1
+			}
+			tangents = append(tangents, float32(t[0]), float32(t[1]), float32(t[2]), w)
+		}
+		indices[c] = i
+	}
+	final := m.split(origin, indices)
+	m.Add(Tangent, 4, scatter(tangents, 4, final))
+	return nil
+}
+
+// perpendicular returns some unit vector perpendicular to n.
+func perpendicular(n vec3) vec3 {
+	a := vec3{1, 0, 0}
+	if math.Abs(n[0]) > 0.9 {
+		a = vec3{0, 1, 0}
+	}
+	return a.sub(n.scale(n.dot(a))).normalized()
+}
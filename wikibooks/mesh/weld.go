@@ -0,0 +1,60 @@
+package mesh
+
+import (
+	"math"
+)
+
+// PositionIDs numbers the distinct positions of m and returns the number of
+// each vertex and how many there are. Positions closer than a millionth of
+// the size of the mesh count as one, so that seams computed on both sides
+// with different rounding still hold together.
+func (m *Mesh) PositionIDs() (ids []int, count int) {
+	pos := m.Attribute(Position)
+	n := pos.Count()
+	var lo, hi vec3
+	for v := 0; v < n; v++ {
+		p := pos.vec3(uint32(v))
+		for k := 0; k < 3; k++ {
+			if v == 0 || p[k] < lo[k] {
+				lo[k] = p[k]
+			}
+			if v == 0 || p[k] > hi[k] {
+				hi[k] = p[k]
+			}
+		}
+	}
+	d := hi.sub(lo)
+	tolerance := math.Sqrt(d.dot(d)) * 1e-6
+	if tolerance == 0 {
+		tolerance = math.SmallestNonzeroFloat64
+	}
+
+	type key [3]int64
+	cells := make(map[key][]int)
+	var points []vec3
+	ids = make([]int, n)
+	for v := 0; v < n; v++ {
+		p := pos.vec3(uint32(v))
+		k := key{int64(math.Floor(p[0] / tolerance)), int64(math.Floor(p[1] / tolerance)), int64(math.Floor(p[2] / tolerance))}
+		id := -1
+		for dx := int64(-1); dx <= 1 && id < 0; dx++ {
+			for dy := int64(-1); dy <= 1 && id < 0; dy++ {
+				for dz := int64(-1); dz <= 1 && id < 0; dz++ {
+					for _, o := range cells[key{k[0] + dx, k[1] + dy, k[2] + dz}] {
+						if e := points[o].sub(p); e.dot(e) <= tolerance*tolerance {
+							id = o
+							break
+						}
+					}
+				}
+			}
+		}
+		if id < 0 {
+			id = len(points)
+			points = append(points, p)
+			cells[k] = append(cells[k], id)
+		}
+		ids[v] = id
+	}
+	return ids, len(points)
+}
@@ -0,0 +1,192 @@
+package obj
+
+import (
+	"math"
+
+	"mesh"
+)
+
+// Part is the geometry of a file using one material.
+type Part struct {
+	MaterialName string
+	// Material is nil if the material is not defined in any library.
+	Material *Material
+	Mesh     *mesh.Mesh
+}
+
+type vertexKey struct {
+	index Index
+	// smoothing keeps vertices of different smoothing groups apart, so
+	// normals computed later do not blend across hard edges.
+	smoothing int
+}
+
+// Meshes triangulates the faces and returns one indexed mesh per material,
+// in the order the materials are first used. Face vertices which share
+// position, texture coordinate and normal become a single mesh vertex.
+func (f *File) Meshes() []Part {
+	useSmoothing := false
+	for _, face := range f.Faces {
+		if face.Smoothing != 0 {
+			useSmoothing = true
+			break
+		}
+	}
+
+	type builder struct {
+		part     Part
+		vertices map[vertexKey]uint32
+		keys     []vertexKey
+	}
+	var builders []*builder
+	byMaterial := make(map[string]*builder)
+	for fi, face := range f.Faces {
+		b := byMaterial[face.Material]
+		if b == nil {
+			b = &builder{part: Part{MaterialName: face.Material, Material: f.Materials[face.Material], Mesh: mesh.New()}, vertices: make(map[vertexKey]uint32)}
+			byMaterial[face.Material] = b
+			builders = append(builders, b)
+		}
+		var corners []uint32
+		for _, v := range face.Vertices {
+			key := vertexKey{index: v}
+			if v.N < 0 && useSmoothing {
+				key.smoothing = face.Smoothing
+				if face.Smoothing == 0 {
+					// Smoothing off: the face gets its own vertices
+					key.smoothing = -1 - fi
+				}
+			}
+			i, ok := b.vertices[key]
+			if !ok {
+				i = uint32(len(b.keys))
+				b.vertices[key] = i
+				b.keys = append(b.keys, key)
+			}
+			corners = append(corners, i)
+		}
+		for _, t := range f.triangulate(face) {
+			b.part.Mesh.Indices = append(b.part.Mesh.Indices, corners[t[0]], corners[t[1]], corners[t[2]])
+		}
+	}
+
+	parts := make([]Part, len(builders))
+	for i, b := range builders {
+		var positions, texCoords, normals []float32
+		hasTexCoords, hasNormals := false, false
+		for _, k := range b.keys {
+			hasTexCoords = hasTexCoords || k.index.T >= 0
+			hasNormals = hasNormals || k.index.N >= 0
+		}
+		for _, k := range b.keys {
+			positions = append(positions, f.Positions[3*k.index.V:3*k.index.V+3]...)
+			if hasTexCoords {
+				if k.index.T >= 0 {
+					texCoords = append(texCoords, f.TexCoords[2*k.index.T:2*k.index.T+2]...)
+				} else {
+					texCoords = append(texCoords, 0, 0)
+				}
+			}
+			if hasNormals {
+				if k.index.N >= 0 {
+					normals = append(normals, f.Normals[3*k.index.N:3*k.index.N+3]...)
+				} else {
+					normals = append(normals, 0, 0, 0)
+				}
+			}
+		}
+		m := b.part.Mesh
+		m.Add(mesh.Position, 3, positions)
+		if hasNormals {
+			m.Add(mesh.Normal, 3, normals)
+		}
+		if hasTexCoords {
+			m.Add(mesh.TexCoord, 2, texCoords)
+		}
+		parts[i] = b.part
+	}
+	return parts
+}
+
+// triangulate splits a face into triangles of corner numbers. Convex and
+// concave planar polygons are handled by ear clipping.
+func (f *File) triangulate(face Face) [][3]int {
+	n := len(face.Vertices)
+	if n == 3 {
+		return [][3]int{{0, 1, 2}}
+	}
+	pos := func(i int) [3]float64 {
+		v := face.Vertices[i].V
+		return [3]float64{float64(f.Positions[3*v]), float64(f.Positions[3*v+1]), float64(f.Positions[3*v+2])}
+	}
+
+	// Newell's method gives a robust polygon normal; project onto the
+	// plane where the normal has its largest component.
+	var normal [3]float64
+	for i := 0; i < n; i++ {
+		a, b := pos(i), pos((i+1)%n)
+		normal[0] += (a[1] - b[1]) * (a[2] + b[2])
+		normal[1] += (a[2] - b[2]) * (a[0] + b[0])
+		normal[2] += (a[0] - b[0]) * (a[1] + b[1])
+	}
+	axis := 2
+	if math.Abs(normal[0]) > math.Abs(normal[1]) && math.Abs(normal[0]) > math.Abs(normal[2]) {
+		axis = 0
+	} else if math.Abs(normal[1]) > math.Abs(normal[2]) {
+		axis = 1
+	}
+	u, v := (axis+1)%3, (axis+2)%3
+	sign := 1.0
+	if normal[axis] < 0 {
+		sign = -1
+	}
+	p := make([][2]float64, n)
+	for i := range p {
+		q := pos(i)
+		p[i] = [2]float64{q[u], q[v]}
+	}
+	cross := func(a, b, c int) float64 {
+		return sign * ((p[b][0]-p[a][0])*(p[c][1]-p[a][1]) - (p[b][1]-p[a][1])*(p[c][0]-p[a][0]))
+	}
+
+	remaining := make([]int, n)
+	for i := range remaining {
+		remaining[i] = i
+	}
+	var tris [][3]int
+	for len(remaining) > 3 {
+		m := len(remaining)
+		found := false
+		for i := 0; i < m; i++ {
+			a, b, c := remaining[(i+m-1)%m], remaining[i], remaining[(i+1)%m]
+			if cross(a, b, c) <= 0 {
+				// Reflex or degenerate corner
+				continue
+			}
+			ear := true
+			for _, o := range remaining {
+				if o == a || o == b || o == c {
+					continue
+				}
+				if cross(a, b, o) >= 0 && cross(b, c, o) >= 0 && cross(c, a, o) >= 0 {
+					ear = false
+					break
+				}
+			}
+			if ear {
+				tris = append(tris, [3]int{a, b, c})
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			// Self intersecting or degenerate polygon, fall back to a fan
+			for i := 1; i+1 < len(remaining); i++ {
+				tris = append(tris, [3]int{remaining[0], remaining[i], remaining[i+1]})
+			}
+			return tris
+		}
+	}
+	return append(tris, [3]int{remaining[0], remaining[1], remaining[2]})
+}
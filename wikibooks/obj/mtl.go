@@ -0,0 +1,117 @@
+package obj
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Material holds the commonly used statements of a MTL material.
+type Material struct {
+	Name     string
+	Ambient  [3]float32 // Ka
+	Diffuse  [3]float32 // Kd
+	Specular [3]float32 // Ks
+	// Shininess is the specular exponent Ns.
+	Shininess float32
+	// Dissolve is the opacity d, 1 is opaque.
+	Dissolve float32
+	// DiffuseMap is the file name of the map_Kd texture.
+	DiffuseMap string
+}
+
+func newMaterial(name string) *Material {
+	return &Material{Name: name, Diffuse: [3]float32{0.8, 0.8, 0.8}, Dissolve: 1}
+}
+
+// ParseMTL reads a material library.
+func ParseMTL(r io.Reader) (map[string]*Material, error) {
+	materials := make(map[string]*Material)
+	var m *Material
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		fail := func(format string, args ...interface{}) error {
+			return &parseError{lineNum, "mtl: " + fmt.Sprintf(format, args...)}
+		}
+		if fields[0] == "newmtl" {
+			if len(fields) < 2 {
+				return nil, fail("material without name")
+			}
+			m = newMaterial(strings.Join(fields[1:], " "))
+			materials[m.Name] = m
+			continue
+		}
+		if m == nil {
+			return nil, fail("%s before newmtl", fields[0])
+		}
+		args := fields[1:]
+		var err error
+		switch fields[0] {
+		case "Ka":
+			err = parseColor(args, &m.Ambient)
+		case "Kd":
+			err = parseColor(args, &m.Diffuse)
+		case "Ks":
+			err = parseColor(args, &m.Specular)
+		case "Ns":
+			err = parseScalar(args, &m.Shininess)
+		case "d":
+			err = parseScalar(args, &m.Dissolve)
+		case "Tr":
+			// Transparency is the inverse of dissolve
+			var tr float32
+			if err = parseScalar(args, &tr); err == nil {
+				m.Dissolve = 1 - tr
+			}
+		case "map_Kd":
+			if len(args) == 0 {
+				return nil, fail("map_Kd without file")
+			}
+			// Options like -s or -o come before the file name
+			m.DiffuseMap = args[len(args)-1]
+		}
+		if err != nil {
+			return nil, fail("%s", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return materials, nil
+}
+
+func parseColor(args []string, c *[3]float32) error {
+	if len(args) > 0 && (args[0] == "spectral" || args[0] == "xyz") {
+		return fmt.Errorf("%s colors are not supported", args[0])
+	}
+	v, err := parseFloats(args, 1, 3)
+	if err != nil {
+		return err
+	}
+	// A single value means gray
+	for len(v) < 3 {
+		v = append(v, v[0])
+	}
+	copy(c[:], v)
+	return nil
+}
+
+func parseScalar(args []string, s *float32) error {
+	v, err := parseFloats(args, 1, 1)
+	if err != nil {
+		return err
+	}
+	*s = v[0]
+	return nil
+}
@@ -0,0 +1,218 @@
+package obj
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Index points into the position, texture coordinate and normal lists of
+// a file. Indices are zero based, -1 means absent.
+type Index struct {
+	V, T, N int
+}
+
+type Face struct {
+	Vertices []Index
+	Material string
+	Group    string
+	Object   string
+	// Smoothing is the smoothing group, 0 if smoothing is off.
+	Smoothing int
+}
+
+// File is the raw content of a Wavefront OBJ file.
+// See http://www.martinreddy.net/gfx/3d/OBJ.spec for the format.
+type File struct {
+	Positions []float32 // x y z
+	TexCoords []float32 // u v
+	Normals   []float32 // x y z
+	Faces     []Face
+	// MaterialLibs lists the mtllib files in the order they appear.
+	MaterialLibs []string
+	Materials    map[string]*Material
+}
+
+type parseError struct {
+	line int
+	msg  string
+}
+
+func (e *parseError) Error() string {
+	return fmt.Sprintf("obj: line %d: %s", e.line, e.msg)
+}
+
+// Parse reads an OBJ file. Material libraries are not loaded, see Load.
+func Parse(r io.Reader) (*File, error) {
+	f := &File{Materials: make(map[string]*Material)}
+	var material, group, object string
+	smoothing := 0
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	lineNum := 0
+	var pending string
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		// A trailing backslash continues the statement on the next line
+		if strings.HasSuffix(line, "\\") {
+			pending += line[:len(line)-1] + " "
+			continue
+		}
+		line = pending + line
+		pending = ""
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		fail := func(format string, args ...interface{}) error {
+			return &parseError{lineNum, fmt.Sprintf(format, args...)}
+		}
+		args := fields[1:]
+		switch fields[0] {
+		case "v":
+			// An optional w or vertex color is ignored
+			v, err := parseFloats(args, 3, 3)
+			if err != nil {
+				return nil, fail("%s", err)
+			}
+			f.Positions = append(f.Positions, v[:3]...)
+		case "vt":
+			v, err := parseFloats(args, 1, 2)
+			if err != nil {
+				return nil, fail("%s", err)
+			}
+			if len(v) == 1 {
+				v = append(v, 0)
+			}
+			f.TexCoords = append(f.TexCoords, v[:2]...)
+		case "vn":
+			v, err := parseFloats(args, 3, 3)
+			if err != nil {
+				return nil, fail("%s", err)
+			}
+			f.Normals = append(f.Normals, v[:3]...)
+		case "f":
+			if len(args) < 3 {
+				return nil, fail("face with %d vertices", len(args))
+			}
+			face := Face{Material: material, Group: group, Object: object, Smoothing: smoothing}
+			for _, a := range args {
+				index, err := f.parseIndex(a)
+				if err != nil {
+					return nil, fail("%s", err)
+				}
+				face.Vertices = append(face.Vertices, index)
+			}
+			f.Faces = append(f.Faces, face)
+		case "g":
+			group = strings.Join(args, " ")
+		case "o":
+			object = strings.Join(args, " ")
+		case "s":
+			smoothing = 0
+			if len(args) > 0 && args[0] != "off" {
+				s, err := strconv.Atoi(args[0])
+				if err != nil {
+					return nil, fail("bad smoothing group %q", args[0])
+				}
+				smoothing = s
+			}
+		case "usemtl":
+			material = strings.Join(args, " ")
+		case "mtllib":
+			f.MaterialLibs = append(f.MaterialLibs, args...)
+		}
+		// Other statements, like lines, points and free form geometry,
+		// are ignored.
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func parseFloats(args []string, min, max int) ([]float32, error) {
+	if len(args) < min {
+		return nil, fmt.Errorf("want %d values, got %d", min, len(args))
+	}
+	if len(args) > max {
+		args = args[:max]
+	}
+	v := make([]float32, len(args), max)
+	for i, a := range args {
+		f, err := strconv.ParseFloat(a, 32)
+		if err != nil {
+			return nil, fmt.Errorf("bad number %q", a)
+		}
+		v[i] = float32(f)
+	}
+	return v, nil
+}
+
+// parseIndex parses v, v/t, v//n or v/t/n. Negative indices count back
+// from the last element read so far.
+func (f *File) parseIndex(s string) (Index, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) > 3 || parts[0] == "" {
+		return Index{}, fmt.Errorf("bad face vertex %q", s)
+	}
+	index := Index{-1, -1, -1}
+	counts := []int{len(f.Positions) / 3, len(f.TexCoords) / 2, len(f.Normals) / 3}
+	targets := []*int{&index.V, &index.T, &index.N}
+	for i, p := range parts {
+		if p == "" {
+			continue
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil || n == 0 {
+			return Index{}, fmt.Errorf("bad face vertex %q", s)
+		}
+		if n < 0 {
+			n = counts[i] + n
+		} else {
+			n--
+		}
+		if n < 0 || n >= counts[i] {
+			return Index{}, fmt.Errorf("index out of range in %q", s)
+		}
+		*targets[i] = n
+	}
+	return index, nil
+}
+
+// Load reads an OBJ file and the material libraries it references, which
+// are looked up relative to the OBJ file.
+func Load(name string) (*File, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	f, err := Parse(file)
+	if err != nil {
+		return nil, err
+	}
+	for _, lib := range f.MaterialLibs {
+		mtl, err := os.Open(filepath.Join(filepath.Dir(name), lib))
+		if err != nil {
+			return nil, err
+		}
+		materials, err := ParseMTL(mtl)
+		mtl.Close()
+		if err != nil {
+			return nil, err
+		}
+		for name, m := range materials {
+			f.Materials[name] = m
+		}
+	}
+	return f, nil
+}
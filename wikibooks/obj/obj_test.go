@@ -0,0 +1,246 @@
+package obj
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mesh"
+)
+
+var update = flag.Bool("update", false, "write the golden files of testdata instead of comparing with them")
+
+// dump writes the materials and parts of f as text.
+func dump(f *File) string {
+	var b bytes.Buffer
+	for _, part := range f.Meshes() {
+		fmt.Fprintf(&b, "part %q\n", part.MaterialName)
+		if m := part.Material; m != nil {
+			fmt.Fprintf(&b, "\tmaterial Ka %v Kd %v Ks %v Ns %v d %v map_Kd %q\n",
+				m.Ambient, m.Diffuse, m.Specular, m.Shininess, m.Dissolve, m.DiffuseMap)
+		}
+		m := part.Mesh
+		fmt.Fprintf(&b, "\t%d vertices, %d triangles\n", m.VertexCount(), m.TriangleCount())
+		for _, a := range m.Attributes {
+			fmt.Fprintf(&b, "\t%v", a.Semantic)
+			for i := 0; i < a.Count(); i++ {
+				fmt.Fprintf(&b, " %v", a.Get(i))
+			}
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "\tindices %v\n", m.Indices)
+	}
+	return b.String()
+}
+
+// TestGolden loads the samples of testdata and compares them with their
+// .golden dumps, or writes those with -update.
+func TestGolden(t *testing.T) {
+	names, err := filepath.Glob("testdata/*.obj")
+	if err != nil || len(names) == 0 {
+		t.Fatalf("no samples: %v", err)
+	}
+	for _, name := range names {
+		f, err := Load(name)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		got := dump(f)
+		golden := strings.TrimSuffix(name, ".obj") + ".golden"
+		if *update {
+			if err := ioutil.WriteFile(golden, []byte(got), 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if got != string(want) {
+			t.Errorf("%s: got\n%s\nwant\n%s", name, got, want)
+		}
+	}
+}
+
+func TestCube(t *testing.T) {
+	f, err := Load("testdata/cube.obj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := f.Meshes()
+	if len(parts) != 2 || parts[0].MaterialName != "red" || parts[1].MaterialName != "blue" {
+		t.Fatalf("got %d parts, want red and blue", len(parts))
+	}
+	for _, p := range parts {
+		if p.Material == nil {
+			t.Fatalf("material %q not loaded", p.MaterialName)
+		}
+		checkWinding(t, p.Mesh)
+	}
+	// 4 sides and 2 caps with 4 corners each, none shared as the normals
+	// differ
+	if n, tris := parts[0].Mesh.VertexCount(), parts[0].Mesh.TriangleCount(); n != 16 || tris != 8 {
+		t.Errorf("red part has %d vertices and %d triangles, want 16 and 8", n, tris)
+	}
+	if n, tris := parts[1].Mesh.VertexCount(), parts[1].Mesh.TriangleCount(); n != 8 || tris != 4 {
+		t.Errorf("blue part has %d vertices and %d triangles, want 8 and 4", n, tris)
+	}
+	if d := parts[1].Material.Dissolve; d != 0.75 {
+		t.Errorf("blue has dissolve %v, want 0.75", d)
+	}
+	if m := parts[0].Material.DiffuseMap; m != "red.png" {
+		t.Errorf("red has map_Kd %q, want red.png", m)
+	}
+}
+
+// checkWinding checks that the triangles turn like the normals of their
+// vertices.
+func checkWinding(t *testing.T, m *mesh.Mesh) {
+	pos, normals := m.Attribute(mesh.Position), m.Attribute(mesh.Normal)
+	for tri := 0; tri < m.TriangleCount(); tri++ {
+		a, b, c := m.Triangle(tri)
+		n := triangleNormal(pos.Get(int(a)), pos.Get(int(b)), pos.Get(int(c)))
+		vn := normals.Get(int(a))
+		if n[0]*float64(vn[0])+n[1]*float64(vn[1])+n[2]*float64(vn[2]) <= 0 {
+			t.Errorf("triangle %d winds against its normal %v", tri, vn)
+		}
+	}
+}
+
+func triangleNormal(a, b, c []float32) [3]float64 {
+	var e1, e2 [3]float64
+	for k := 0; k < 3; k++ {
+		e1[k] = float64(b[k] - a[k])
+		e2[k] = float64(c[k] - a[k])
+	}
+	return [3]float64{
+		e1[1]*e2[2] - e1[2]*e2[1],
+		e1[2]*e2[0] - e1[0]*e2[2],
+		e1[0]*e2[1] - e1[1]*e2[0],
+	}
+}
+
+// The L shaped polygon has a reflex corner, which a fan from the first
+// corner would cut across.
+func TestTriangulateConcave(t *testing.T) {
+	f, err := Load("testdata/concave.obj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tris := f.triangulate(f.Faces[0])
+	if len(tris) != 4 {
+		t.Fatalf("got %d triangles, want 4", len(tris))
+	}
+	area := 0.0
+	for _, tri := range tris {
+		p := func(i int) []float32 {
+			v := f.Faces[0].Vertices[tri[i]].V
+			return f.Positions[3*v : 3*v+3]
+		}
+		n := triangleNormal(p(0), p(1), p(2))
+		if n[2] <= 0 {
+			t.Errorf("triangle %v is clockwise or degenerate", tri)
+		}
+		area += n[2] / 2
+	}
+	if math.Abs(area-3) > 1e-9 {
+		t.Errorf("triangles cover %v, want the area 3 of the polygon", area)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"v 1 2\n",
+		"v 1 2 x\n",
+		"v 0 0 0\nv 1 0 0\nf 1 2\n",
+		"v 0 0 0\nf 1 1 2\n",
+		"v 0 0 0\nf 1 1 0\n",
+		"v 0 0 0\nf 1/1 1 1\n",
+		"v 0 0 0\nf 1/ 1 -2\n",
+		"s x\n",
+	}
+	for _, text := range tests {
+		if _, err := Parse(strings.NewReader(text)); err == nil {
+			t.Errorf("%q: no error", text)
+		} else if !strings.HasPrefix(err.Error(), "obj: line ") {
+			t.Errorf("%q: error %q without a line", text, err)
+		}
+	}
+}
+
+// FuzzParse checks that whatever parses turns into meshes whose indices
+// point at their vertices, two less triangles than corners a face.
+func FuzzParse(f *testing.F) {
+	names, _ := filepath.Glob("testdata/*.obj")
+	for _, name := range names {
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte("v 0 0 0\nv 1 0 0\nv 1 1 0\nv 0 1 0\nf 1 2 3 4\nf -1 -2 -3\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		file, err := Parse(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		want := 0
+		for _, face := range file.Faces {
+			want += len(face.Vertices) - 2
+		}
+		got := 0
+		for _, p := range file.Meshes() {
+			n := p.Mesh.VertexCount()
+			for _, i := range p.Mesh.Indices {
+				if int(i) >= n {
+					t.Fatalf("index %d of %d vertices", i, n)
+				}
+			}
+			got += p.Mesh.TriangleCount()
+		}
+		if got != want {
+			t.Fatalf("got %d triangles, want %d", got, want)
+		}
+	})
+}
+
+// FuzzTriangulate checks that ear clipping cuts any polygon, even self
+// intersecting or degenerate, into two less triangles than corners, each
+// of three different corners.
+func FuzzTriangulate(f *testing.F) {
+	f.Add([]byte{0, 0, 2, 0, 2, 1, 1, 1, 1, 2, 0, 2})
+	f.Add([]byte{0, 0, 1, 1, 1, 0, 0, 1})
+	f.Add([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		n := len(data) / 2
+		if n < 3 || n > 64 {
+			return
+		}
+		file := &File{}
+		face := Face{}
+		for i := 0; i < n; i++ {
+			file.Positions = append(file.Positions, float32(data[2*i]), float32(data[2*i+1]), 0)
+			face.Vertices = append(face.Vertices, Index{i, -1, -1})
+		}
+		tris := file.triangulate(face)
+		if len(tris) != n-2 {
+			t.Fatalf("got %d triangles for %d corners", len(tris), n)
+		}
+		for _, tri := range tris {
+			for k, c := range tri {
+				if c < 0 || c >= n || c == tri[(k+1)%3] {
+					t.Fatalf("bad triangle %v", tri)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,137 @@
+// Package pace paces frames and keeps statistics of their durations.
+package pace
+
+import (
+	"flag"
+	"fmt"
+	"time"
+)
+
+type Mode int
+
+const (
+	// VSync leaves pacing to the window, which waits for the vertical
+	// blank when swapping buffers.
+	VSync Mode = iota
+	// Cap waits so that frames come at most Rate times a second.
+	Cap
+	// Uncapped draws frames as fast as possible.
+	Uncapped
+)
+
+func (m Mode) String() string {
+	switch m {
+	case VSync:
+		return "vsync"
+	case Cap:
+		return "cap"
+	case Uncapped:
+		return "uncapped"
+	}
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+// ParseMode parses the name of a mode as String returns it.
+func ParseMode(s string) (Mode, error) {
+	for m := VSync; m <= Uncapped; m++ {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("pace: unknown mode %q", s)
+}
+
+// Set sets m from its name, so that a Mode is a flag.Value.
+func (m *Mode) Set(s string) error {
+	v, err := ParseMode(s)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// Pacer paces the frames of a render loop, which calls Wait once a frame.
+type Pacer struct {
+	Mode Mode
+	// Rate is the frame rate in frames a second for Cap.
+	Rate float64
+	// Now returns the time in seconds and Sleep sleeps, by the wall clock
+	// if nil. Replace both to pace by a fake clock.
+	Now   func() float64
+	Sleep func(seconds float64)
+	// Stats, if not nil, gets the duration of every frame.
+	Stats *Stats
+
+	started bool
+	last    float64
+	// next is when the next frame is due for Cap. It advances by whole
+	// frames rather than from the time Wait returns, so the rate does not
+	// drift by the time spent waking up.
+	next float64
+}
+
+var start = time.Now()
+
+func (p *Pacer) now() float64 {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Since(start).Seconds()
+}
+
+func (p *Pacer) sleep(d float64) {
+	if p.Sleep != nil {
+		p.Sleep(d)
+		return
+	}
+	time.Sleep(time.Duration(d * float64(time.Second)))
+}
+
+// Wait waits until the next frame is due and adds the duration of the
+// frame which ended to Stats.
+func (p *Pacer) Wait() {
+	now := p.now()
+	if p.Mode == Cap && p.Rate > 0 && p.started {
+		period := 1 / p.Rate
+		p.next += period
+		if now < p.next {
+			p.sleep(p.next - now)
+			now = p.now()
+		} else if now > p.next+period {
+			// More than a frame late: start again from now instead of
+			// rushing frames to catch up
+			p.next = now
+		}
+	} else {
+		p.next = now
+	}
+	if p.started && p.Stats != nil {
+		p.Stats.Add(now - p.last)
+	}
+	p.started = true
+	p.last = now
+}
+
+// Time returns the time the last Wait returned.
+func (p *Pacer) Time() float64 {
+	return p.last
+}
+
+var (
+	mode Mode
+	rate = flag.Float64("fps", 60, "frame rate of -pacing cap")
+
+	// Show asks to show frame statistics.
+	Show = flag.Bool("stats", false, "show frame time statistics")
+)
+
+func init() {
+	flag.Var(&mode, "pacing", "frame pacing: vsync, cap or uncapped")
+}
+
+// FromFlags returns the pacer chosen with -pacing and -fps, after
+// flag.Parse.
+func FromFlags() *Pacer {
+	return &Pacer{Mode: mode, Rate: *rate}
+}
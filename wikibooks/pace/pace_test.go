@@ -0,0 +1,136 @@
+package pace
+
+import (
+	"math"
+	"testing"
+)
+
+// clock is a fake clock which only moves when work is done or the pacer
+// sleeps.
+type clock struct {
+	t     float64
+	slept int
+}
+
+func (c *clock) pacer(mode Mode, rate float64) *Pacer {
+	return &Pacer{
+		Mode:  mode,
+		Rate:  rate,
+		Now:   func() float64 { return c.t },
+		Sleep: func(d float64) { c.t += d; c.slept++ },
+		Stats: NewStats(16),
+	}
+}
+
+// run does each piece of work in a frame of its own and returns the
+// durations of the frames.
+func run(p *Pacer, c *clock, work []float64) []float64 {
+	for _, w := range work {
+		c.t += w
+		p.Wait()
+	}
+	return p.Stats.Times()
+}
+
+func near(got, want []float64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCap(t *testing.T) {
+	tests := []struct {
+		name string
+		work []float64
+		want []float64
+	}{
+		{"on time", []float64{0.005, 0.005, 0.005, 0.005}, []float64{0.02, 0.02, 0.02}},
+		// Less than a frame late: the next frame is shorter to keep the
+		// rate
+		{"late", []float64{0.005, 0.005, 0.03, 0.005, 0.005}, []float64{0.02, 0.03, 0.01, 0.02}},
+		// More than a frame late: start again from there
+		{"very late", []float64{0.005, 0.005, 0.05, 0.005, 0.005}, []float64{0.02, 0.05, 0.02, 0.02}},
+	}
+	for _, test := range tests {
+		c := &clock{}
+		p := c.pacer(Cap, 50)
+		if got := run(p, c, test.work); !near(got, test.want) {
+			t.Errorf("%s: frames took %v, want %v", test.name, got, test.want)
+		}
+		if p.Time() != c.t {
+			t.Errorf("%s: Time is %v, want %v", test.name, p.Time(), c.t)
+		}
+	}
+}
+
+func TestNoCap(t *testing.T) {
+	for _, mode := range []Mode{VSync, Uncapped} {
+		c := &clock{}
+		// The rate only matters for Cap
+		p := c.pacer(mode, 1000)
+		work := []float64{0.001, 0.002, 0.003}
+		if got := run(p, c, work); !near(got, work[1:]) {
+			t.Errorf("%v: frames took %v, want %v", mode, got, work[1:])
+		}
+		if c.slept != 0 {
+			t.Errorf("%v: slept %d times", mode, c.slept)
+		}
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for m := VSync; m <= Uncapped; m++ {
+		if got, err := ParseMode(m.String()); err != nil || got != m {
+			t.Errorf("%v: got %v, %v", m, got, err)
+		}
+	}
+	if _, err := ParseMode("fast"); err == nil {
+		t.Error("fast: no error")
+	}
+}
+
+func TestStats(t *testing.T) {
+	s := NewStats(4)
+	for _, d := range []float64{0.5, 0.001, 0.004, 0.002, 0.003} {
+		s.Add(d)
+	}
+	// The first is gone
+	if got, want := s.Times(), []float64{0.001, 0.004, 0.002, 0.003}; !near(got, want) {
+		t.Errorf("Times is %v, want %v", got, want)
+	}
+	if s.Len() != 4 || s.Frames() != 5 {
+		t.Errorf("Len %d and Frames %d, want 4 and 5", s.Len(), s.Frames())
+	}
+	if got := s.Average(); math.Abs(got-0.0025) > 1e-12 {
+		t.Errorf("Average is %v, want 0.0025", got)
+	}
+	if got := s.Percentile(50); got != 0.002 {
+		t.Errorf("median is %v, want 0.002", got)
+	}
+	if got := s.Percentile(100); got != 0.004 {
+		t.Errorf("p100 is %v, want 0.004", got)
+	}
+	if got := s.Worst(); got != 0.004 {
+		t.Errorf("Worst is %v, want 0.004", got)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	s := NewStats(8)
+	for _, d := range []float64{-0.001, 0, 0.0015, 0.0025, 0.0026, 1, math.Inf(1)} {
+		s.Add(d)
+	}
+	want := []int{2, 1, 4}
+	got := s.Histogram(0.001, 3)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
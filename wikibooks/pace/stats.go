@@ -0,0 +1,157 @@
+package pace
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Stats keeps the durations of the last frames, in seconds.
+type Stats struct {
+	times []float64
+	next  int
+	n     int
+	total int
+}
+
+// NewStats returns statistics over the last size frames.
+func NewStats(size int) *Stats {
+	return &Stats{times: make([]float64, size)}
+}
+
+// Add adds the duration of a frame.
+func (s *Stats) Add(d float64) {
+	if len(s.times) == 0 {
+		return
+	}
+	s.times[s.next] = d
+	s.next = (s.next + 1) % len(s.times)
+	if s.n < len(s.times) {
+		s.n++
+	}
+	s.total++
+}
+
+// Len returns the number of frames the statistics are over.
+func (s *Stats) Len() int {
+	return s.n
+}
+
+// Frames returns the number of frames added since the start.
+func (s *Stats) Frames() int {
+	return s.total
+}
+
+// Times returns the durations kept, oldest first.
+func (s *Stats) Times() []float64 {
+	if s.n == 0 {
+		return nil
+	}
+	times := make([]float64, 0, s.n)
+	first := (s.next - s.n + len(s.times)) % len(s.times)
+	for i := 0; i < s.n; i++ {
+		times = append(times, s.times[(first+i)%len(s.times)])
+	}
+	return times
+}
+
+func (s *Stats) Average() float64 {
+	if s.n == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, t := range s.Times() {
+		sum += t
+	}
+	return sum / float64(s.n)
+}
+
+// Percentile returns the duration p percent of the frames are at most, by
+// the nearest rank.
+func (s *Stats) Percentile(p float64) float64 {
+	if s.n == 0 {
+		return 0
+	}
+	sorted := s.Times()
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p/100*float64(s.n))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= s.n {
+		rank = s.n - 1
+	}
+	return sorted[rank]
+}
+
+// Worst returns the longest duration.
+func (s *Stats) Worst() float64 {
+	worst := 0.0
+	for _, t := range s.Times() {
+		if t > worst {
+			worst = t
+		}
+	}
+	return worst
+}
+
+// Histogram counts the frames in n buckets of width seconds. The last
+// bucket also counts the frames which are longer, the first those which
+// are negative, as a clock stepping back gives.
+func (s *Stats) Histogram(width float64, n int) []int {
+	counts := make([]int, n)
+	if n == 0 {
+		return counts
+	}
+	for _, t := range s.Times() {
+		i := n - 1
+		if t < width*float64(n) {
+			i = int(t / width)
+		}
+		if i < 0 {
+			i = 0
+		}
+		counts[i]++
+	}
+	return counts
+}
+
+// String summarizes the statistics in milliseconds.
+func (s *Stats) String() string {
+	avg := s.Average()
+	fps := 0.0
+	if avg > 0 {
+		fps = 1 / avg
+	}
+	return fmt.Sprintf("%.1f fps, avg %.2f ms, p50 %.2f ms, p99 %.2f ms, worst %.2f ms",
+		fps, 1000*avg, 1000*s.Percentile(50), 1000*s.Percentile(99), 1000*s.Worst())
+}
+
+// HistogramString draws the histogram with n buckets of width seconds as
+// lines of bars, the longest max characters long.
+func (s *Stats) HistogramString(width float64, n, max int) string {
+	counts := s.Histogram(width, n)
+	most := 0
+	for _, c := range counts {
+		if c > most {
+			most = c
+		}
+	}
+	str := ""
+	for i, c := range counts {
+		bar := 0
+		if most > 0 {
+			bar = c * max / most
+		}
+		label := fmt.Sprintf("%5.1f ms", 1000*float64(i)*width)
+		if i == n-1 {
+			label = fmt.Sprintf("%5.1f+ms", 1000*float64(i)*width)
+		}
+		str += fmt.Sprintf("%s %5d ", label, c)
+		for j := 0; j < bar; j++ {
+			str += "#"
+		}
+		str += "\n"
+	}
+	return str
+}
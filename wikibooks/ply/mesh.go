@@ -0,0 +1,173 @@
+package ply
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"mesh"
+)
+
+// Property names of the attributes, in the order they are looked for.
+// Semantics without a common name are written as name_0, name_1, ...
+var attributeNames = []struct {
+	semantic mesh.Semantic
+	names    [][]string
+}{
+	{mesh.Position, [][]string{{"x", "y", "z"}}},
+	{mesh.Normal, [][]string{{"nx", "ny", "nz"}}},
+	{mesh.Color, [][]string{
+		{"red", "green", "blue", "alpha"},
+		{"r", "g", "b", "a"},
+		{"diffuse_red", "diffuse_green", "diffuse_blue", "diffuse_alpha"},
+	}},
+	{mesh.TexCoord, [][]string{
+		{"s", "t"},
+		{"u", "v"},
+		{"texture_u", "texture_v"},
+		{"texture_s", "texture_t"},
+	}},
+	{mesh.Tangent, nil},
+	{mesh.Joints, nil},
+	{mesh.Weights, nil},
+}
+
+func genericNames(s mesh.Semantic) []string {
+	names := make([]string, 4)
+	for i := range names {
+		names[i] = fmt.Sprintf("%s_%d", s, i)
+	}
+	return names
+}
+
+// Mesh returns the vertex and face elements as a mesh. Integer colors are
+// scaled to 0-1 and polygons are split into triangles. Properties which do
+// not map to a mesh attribute, like the confidence values of scanners, are
+// only available from the vertex element.
+func (f *File) Mesh() (*mesh.Mesh, error) {
+	vertices := f.Element("vertex")
+	if vertices == nil {
+		return nil, errors.New("ply: no vertex element")
+	}
+	m := mesh.New()
+	for _, a := range attributeNames {
+		names := a.names
+		if names == nil {
+			names = [][]string{genericNames(a.semantic)}
+		}
+		for _, set := range names {
+			var props []*Property
+			for _, name := range set {
+				p := vertices.Property(name)
+				if p == nil || p.IsList() {
+					break
+				}
+				props = append(props, p)
+			}
+			if len(props) < 2 {
+				continue
+			}
+			data := make([]float32, 0, vertices.Count*len(props))
+			for i := 0; i < vertices.Count; i++ {
+				for _, p := range props {
+					v := p.Values[i]
+					if a.semantic == mesh.Color {
+						v /= colorScale(p.Type)
+					}
+					data = append(data, float32(v))
+				}
+			}
+			m.Add(a.semantic, len(props), data)
+			break
+		}
+	}
+	if m.Attribute(mesh.Position) == nil || m.Attribute(mesh.Position).Size != 3 {
+		return nil, errors.New("ply: vertices without x, y and z")
+	}
+
+	if faces := f.Element("face"); faces != nil {
+		p := faces.Property("vertex_indices")
+		if p == nil {
+			p = faces.Property("vertex_index")
+		}
+		if p == nil || !p.IsList() {
+			return nil, errors.New("ply: faces without vertex_indices")
+		}
+		for _, list := range p.Lists {
+			for _, v := range list {
+				// NaN is not equal to its floor either
+				if v < 0 || v != math.Floor(v) || v >= float64(vertices.Count) {
+					return nil, fmt.Errorf("ply: vertex index %v out of range", v)
+				}
+			}
+			for k := 2; k < len(list); k++ {
+				m.Indices = append(m.Indices, uint32(list[0]), uint32(list[k-1]), uint32(list[k]))
+			}
+		}
+	}
+	return m, nil
+}
+
+// colorScale returns the value of full intensity for colors of type t.
+func colorScale(t Type) float64 {
+	switch t {
+	case Uint8:
+		return 255
+	case Uint16:
+		return 65535
+	}
+	return 1
+}
+
+// FromMesh returns a file holding m as vertex and face elements. Positions,
+// normals and texture coordinates are written as floats, colors as bytes.
+func FromMesh(m *mesh.Mesh, format Format) *File {
+	n := m.VertexCount()
+	vertices := &Element{Name: "vertex", Count: n}
+	for _, a := range m.Attributes {
+		var names []string
+		for _, known := range attributeNames {
+			if known.semantic == a.Semantic {
+				if known.names != nil {
+					names = known.names[0]
+				} else {
+					names = genericNames(a.Semantic)
+				}
+			}
+		}
+		for k := 0; k < a.Size && k < len(names); k++ {
+			p := &Property{Name: names[k], Type: Float32, Values: make([]float64, n)}
+			for i := range p.Values {
+				v := float64(a.Data[i*a.Size+k])
+				if a.Semantic == mesh.Color {
+					p.Type = Uint8
+					v = clamp(v, 0, 1) * 255
+				}
+				p.Values[i] = v
+			}
+			vertices.Properties = append(vertices.Properties, p)
+		}
+	}
+
+	faces := &Element{Name: "face", Count: m.TriangleCount()}
+	indices := &Property{Name: "vertex_indices", Type: Int32, CountType: Uint8, Lists: make([][]float64, faces.Count)}
+	values := make([]float64, len(m.Indices))
+	for i, v := range m.Indices {
+		values[i] = float64(v)
+	}
+	for t := range indices.Lists {
+		indices.Lists[t] = values[3*t : 3*t+3]
+	}
+	faces.Properties = []*Property{indices}
+	return &File{Format: format, Elements: []*Element{vertices, faces}}
+}
+
+func clamp(v, lo, hi float64) float64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
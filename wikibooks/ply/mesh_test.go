@@ -0,0 +1,83 @@
+package ply
+
+import (
+	"bytes"
+	"testing"
+
+	"mesh"
+)
+
+// quad returns two triangles with positions, normals, texture coordinates
+// and colors.
+func quad() *mesh.Mesh {
+	m := mesh.New()
+	m.Add(mesh.Position, 3, []float32{0, 0, 0, 1, 0, 0, 1, 1, 0, 0, 1, -0.5})
+	m.Add(mesh.Normal, 3, []float32{0, 0, 1, 0, 0, 1, 0, 0, 1, 0, 0, 1})
+	m.Add(mesh.TexCoord, 2, []float32{0, 0, 1, 0, 1, 1, 0, 0.75})
+	m.Add(mesh.Color, 3, []float32{0, 0.25, 0.5, 1, 0.2, 0.75, 0.5, 0.5, 0.5, 1, 1, 1})
+	m.Indices = []uint32{0, 1, 2, 0, 2, 3}
+	return m
+}
+
+func TestMeshRoundTrip(t *testing.T) {
+	for _, format := range []Format{ASCII, BinaryLittleEndian, BinaryBigEndian} {
+		in := quad()
+		var buf bytes.Buffer
+		if err := FromMesh(in, format).Write(&buf); err != nil {
+			t.Fatalf("%v: %v", format, err)
+		}
+		f, err := Parse(&buf)
+		if err != nil {
+			t.Fatalf("%v: %v", format, err)
+		}
+		if f.Format != format {
+			t.Errorf("%v: read format %v", format, f.Format)
+		}
+		out, err := f.Mesh()
+		if err != nil {
+			t.Fatalf("%v: %v", format, err)
+		}
+
+		for _, s := range []mesh.Semantic{mesh.Position, mesh.Normal, mesh.TexCoord} {
+			a, b := in.Attribute(s), out.Attribute(s)
+			if b == nil || b.Size != a.Size || !equal(a.Data, b.Data) {
+				t.Errorf("%v: %v is %v, want %v", format, s, b, a)
+			}
+		}
+		// Colors are written as bytes, v*255 rounded to the nearest
+		want := []float32{0, 64, 128, 255, 51, 191, 128, 128, 128, 255, 255, 255}
+		for i := range want {
+			want[i] = float32(float64(want[i]) / 255)
+		}
+		if c := out.Attribute(mesh.Color); c == nil || c.Size != 3 || !equal(c.Data, want) {
+			t.Errorf("%v: colors are %v, want %v", format, c, want)
+		}
+		if !equalIndices(out.Indices, in.Indices) {
+			t.Errorf("%v: indices are %v, want %v", format, out.Indices, in.Indices)
+		}
+	}
+}
+
+func equal(a, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalIndices(a, b []uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,362 @@
+// Package ply reads and writes Stanford PLY files, in ASCII and binary.
+// See http://paulbourke.net/dataformats/ply/ for the format.
+package ply
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type Format int
+
+const (
+	ASCII Format = iota
+	BinaryLittleEndian
+	BinaryBigEndian
+)
+
+var formatNames = []string{"ascii", "binary_little_endian", "binary_big_endian"}
+
+func (f Format) String() string {
+	if f < 0 || int(f) >= len(formatNames) {
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+	return formatNames[f]
+}
+
+// Type is the type of a property value or list count.
+type Type int
+
+const (
+	None Type = iota
+	Int8
+	Uint8
+	Int16
+	Uint16
+	Int32
+	Uint32
+	Float32
+	Float64
+)
+
+var typeNames = []string{"", "char", "uchar", "short", "ushort", "int", "uint", "float", "double"}
+
+// Alternative names used by some writers
+var typeAliases = map[string]Type{
+	"int8": Int8, "uint8": Uint8, "int16": Int16, "uint16": Uint16,
+	"int32": Int32, "uint32": Uint32, "float32": Float32, "float64": Float64,
+}
+
+var typeSizes = []int{0, 1, 1, 2, 2, 4, 4, 4, 8}
+
+func (t Type) String() string {
+	if t < 0 || int(t) >= len(typeNames) {
+		return fmt.Sprintf("Type(%d)", int(t))
+	}
+	return typeNames[t]
+}
+
+func parseType(s string) (Type, bool) {
+	for i, name := range typeNames {
+		if i > 0 && name == s {
+			return Type(i), true
+		}
+	}
+	t, ok := typeAliases[s]
+	return t, ok
+}
+
+// Property is one column of an element. Values of every type are kept as
+// float64, which holds all of them exactly.
+type Property struct {
+	Name string
+	Type Type
+	// CountType is the type of the length of list properties, None for
+	// scalar properties.
+	CountType Type
+	// Values holds one value per element of scalar properties.
+	Values []float64
+	// Lists holds one list per element of list properties.
+	Lists [][]float64
+}
+
+func (p *Property) IsList() bool {
+	return p.CountType != None
+}
+
+type Element struct {
+	Name       string
+	Count      int
+	Properties []*Property
+}
+
+// Property returns the property with the given name or nil.
+func (e *Element) Property(name string) *Property {
+	for _, p := range e.Properties {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+type File struct {
+	Format   Format
+	Comments []string
+	Elements []*Element
+}
+
+// Element returns the element with the given name or nil.
+func (f *File) Element(name string) *Element {
+	for _, e := range f.Elements {
+		if e.Name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+// Parse reads a PLY file.
+func Parse(r io.Reader) (*File, error) {
+	br := bufio.NewReader(r)
+	f, err := readHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range f.Elements {
+		n := e.Count
+		if n > preallocate {
+			n = preallocate
+		}
+		for _, p := range e.Properties {
+			if p.IsList() {
+				p.Lists = make([][]float64, 0, n)
+			} else {
+				p.Values = make([]float64, 0, n)
+			}
+		}
+	}
+	if f.Format == ASCII {
+		err = f.readASCII(br)
+	} else {
+		err = f.readBinary(br)
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = errors.New("ply: unexpected end of file")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Load reads the PLY file of the given name.
+func Load(name string) (*File, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+func readHeader(r *bufio.Reader) (*File, error) {
+	line, err := r.ReadString('\n')
+	if err != nil || strings.TrimSpace(line) != "ply" {
+		return nil, errors.New("ply: missing ply signature")
+	}
+	f := &File{}
+	hasFormat := false
+	var e *Element
+	for {
+		line, err = r.ReadString('\n')
+		if err != nil {
+			return nil, errors.New("ply: unexpected end of header")
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "format":
+			if len(fields) != 3 || fields[2] != "1.0" {
+				return nil, fmt.Errorf("ply: bad format line %q", strings.TrimSpace(line))
+			}
+			found := false
+			for i, name := range formatNames {
+				if name == fields[1] {
+					f.Format, found = Format(i), true
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("ply: unknown format %q", fields[1])
+			}
+			hasFormat = true
+		case "comment", "obj_info":
+			f.Comments = append(f.Comments, strings.TrimSpace(strings.TrimSpace(line)[len(fields[0]):]))
+		case "element":
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("ply: bad element line %q", strings.TrimSpace(line))
+			}
+			n, err := strconv.Atoi(fields[2])
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("ply: bad count for element %s", fields[1])
+			}
+			e = &Element{Name: fields[1], Count: n}
+			f.Elements = append(f.Elements, e)
+		case "property":
+			if e == nil {
+				return nil, errors.New("ply: property before element")
+			}
+			p := &Property{}
+			ok := false
+			switch {
+			case len(fields) == 3:
+				p.Type, ok = parseType(fields[1])
+				p.Name = fields[2]
+			case len(fields) == 5 && fields[1] == "list":
+				var countOK bool
+				p.CountType, countOK = parseType(fields[2])
+				p.Type, ok = parseType(fields[3])
+				ok = ok && countOK && p.CountType != Float32 && p.CountType != Float64
+				p.Name = fields[4]
+			}
+			if !ok {
+				return nil, fmt.Errorf("ply: bad property line %q", strings.TrimSpace(line))
+			}
+			e.Properties = append(e.Properties, p)
+		case "end_header":
+			if !hasFormat {
+				return nil, errors.New("ply: missing format")
+			}
+			return f, nil
+		default:
+			return nil, fmt.Errorf("ply: unknown header line %q", strings.TrimSpace(line))
+		}
+	}
+}
+
+func (f *File) readASCII(r *bufio.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanWords)
+	return f.readBody(func(t Type) (float64, error) {
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return 0, err
+			}
+			return 0, io.EOF
+		}
+		word := scanner.Text()
+		v, err := strconv.ParseFloat(word, 64)
+		if err != nil {
+			return 0, fmt.Errorf("ply: bad %s value %q", t, word)
+		}
+		return v, nil
+	})
+}
+
+func (f *File) readBinary(r *bufio.Reader) error {
+	var order binary.ByteOrder = binary.LittleEndian
+	if f.Format == BinaryBigEndian {
+		order = binary.BigEndian
+	}
+	var buf [8]byte
+	return f.readBody(func(t Type) (float64, error) {
+		b := buf[:typeSizes[t]]
+		if _, err := io.ReadFull(r, b); err != nil {
+			return 0, err
+		}
+		return decodeValue(t, b, order), nil
+	})
+}
+
+// preallocate bounds the space reserved for counts read from the file,
+// which may claim more than it holds. Slices grow past it as values are
+// read, so that memory is bounded by the size of the input.
+const preallocate = 1 << 16
+
+// maxCount returns the largest list length of count type t.
+func maxCount(t Type) float64 {
+	switch t {
+	case Int8:
+		return math.MaxInt8
+	case Uint8:
+		return math.MaxUint8
+	case Int16:
+		return math.MaxInt16
+	case Uint16:
+		return math.MaxUint16
+	case Int32:
+		return math.MaxInt32
+	}
+	return math.MaxUint32
+}
+
+// readBody fills the properties with the values returned by next, element
+// by element.
+func (f *File) readBody(next func(Type) (float64, error)) error {
+	for _, e := range f.Elements {
+		for i := 0; i < e.Count; i++ {
+			for _, p := range e.Properties {
+				if !p.IsList() {
+					v, err := next(p.Type)
+					if err != nil {
+						return err
+					}
+					p.Values = append(p.Values, v)
+					continue
+				}
+				n, err := next(p.CountType)
+				if err != nil {
+					return err
+				}
+				if n < 0 || n != math.Floor(n) || n > maxCount(p.CountType) {
+					return fmt.Errorf("ply: bad list length %v", n)
+				}
+				size := int(n)
+				if size > preallocate {
+					size = preallocate
+				}
+				list := make([]float64, 0, size)
+				for k := 0; k < int(n); k++ {
+					v, err := next(p.Type)
+					if err != nil {
+						return err
+					}
+					list = append(list, v)
+				}
+				p.Lists = append(p.Lists, list)
+			}
+		}
+	}
+	return nil
+}
+
+func decodeValue(t Type, b []byte, order binary.ByteOrder) float64 {
+	switch t {
+	case Int8:
+		return float64(int8(b[0]))
+	case Uint8:
+		return float64(b[0])
+	case Int16:
+		return float64(int16(order.Uint16(b)))
+	case Uint16:
+		return float64(order.Uint16(b))
+	case Int32:
+		return float64(int32(order.Uint32(b)))
+	case Uint32:
+		return float64(order.Uint32(b))
+	case Float32:
+		return float64(math.Float32frombits(order.Uint32(b)))
+	case Float64:
+		return math.Float64frombits(order.Uint64(b))
+	}
+	return 0
+}
@@ -0,0 +1,62 @@
+package ply
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+const header = `ply
+format ascii 1.0
+element vertex 3
+property float x
+property float y
+property float z
+element face 1
+property list uchar int vertex_indices
+end_header
+0 0 0
+1 0 0
+0 1 0
+`
+
+// Lengths read from the file used to size allocations before any value
+// was read.
+func TestParseBadLengths(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"huge ascii list", header + "1e18 0 0 0\n"},
+		{"list longer than its type", header + "300 0 0 0\n"},
+		{"fractional list", header + "2.5 0 0 0\n"},
+		{"huge element", "ply\nformat ascii 1.0\nelement vertex 1000000000000\nproperty float x\nend_header\n0\n"},
+	}
+	for _, test := range tests {
+		if _, err := Parse(strings.NewReader(test.data)); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+
+	// A binary list claiming 4 billion values, followed by 3
+	var buf bytes.Buffer
+	buf.WriteString("ply\nformat binary_little_endian 1.0\nelement face 1\nproperty list uint int vertex_indices\nend_header\n")
+	binary.Write(&buf, binary.LittleEndian, []uint32{0xffffffff, 0, 1, 2})
+	if _, err := Parse(&buf); err == nil {
+		t.Error("huge binary list: no error")
+	}
+}
+
+func TestMeshBadIndices(t *testing.T) {
+	for _, face := range []string{"3 NaN 0 1", "3 0.5 0 1", "3 -1 0 1", "3 3 0 1", "3 Inf 0 1"} {
+		f, err := Parse(strings.NewReader(header + face + "\n"))
+		if err != nil {
+			t.Errorf("%s: %v", face, err)
+			continue
+		}
+		if _, err := f.Mesh(); err == nil {
+			t.Errorf("%s: no error", face)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package ply
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+)
+
+// Write writes f in its format. Values are converted to the type of their
+// property.
+func (f *File) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "ply\nformat %s 1.0\n", f.Format)
+	for _, c := range f.Comments {
+		fmt.Fprintf(bw, "comment %s\n", c)
+	}
+	for _, e := range f.Elements {
+		fmt.Fprintf(bw, "element %s %d\n", e.Name, e.Count)
+		for _, p := range e.Properties {
+			if p.IsList() {
+				fmt.Fprintf(bw, "property list %s %s %s\n", p.CountType, p.Type, p.Name)
+			} else {
+				fmt.Fprintf(bw, "property %s %s\n", p.Type, p.Name)
+			}
+		}
+	}
+	bw.WriteString("end_header\n")
+
+	var put func(t Type, v float64)
+	var endRow func()
+	if f.Format == ASCII {
+		var sep string
+		put = func(t Type, v float64) {
+			bw.WriteString(sep)
+			bw.WriteString(formatValue(t, v))
+			sep = " "
+		}
+		endRow = func() {
+			bw.WriteByte('\n')
+			sep = ""
+		}
+	} else {
+		var order binary.ByteOrder = binary.LittleEndian
+		if f.Format == BinaryBigEndian {
+			order = binary.BigEndian
+		}
+		var buf [8]byte
+		put = func(t Type, v float64) {
+			b := buf[:typeSizes[t]]
+			encodeValue(t, v, b, order)
+			bw.Write(b)
+		}
+		endRow = func() {}
+	}
+	for _, e := range f.Elements {
+		for i := 0; i < e.Count; i++ {
+			for _, p := range e.Properties {
+				if !p.IsList() {
+					put(p.Type, p.Values[i])
+					continue
+				}
+				put(p.CountType, float64(len(p.Lists[i])))
+				for _, v := range p.Lists[i] {
+					put(p.Type, v)
+				}
+			}
+			endRow()
+		}
+	}
+	return bw.Flush()
+}
+
+// Save writes f to the file of the given name.
+func (f *File) Save(name string) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := f.Write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func formatValue(t Type, v float64) string {
+	switch t {
+	case Float32:
+		return strconv.FormatFloat(v, 'g', -1, 32)
+	case Float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return strconv.FormatInt(int64(math.Floor(v+0.5)), 10)
+}
+
+func encodeValue(t Type, v float64, b []byte, order binary.ByteOrder) {
+	i := int64(math.Floor(v + 0.5))
+	switch t {
+	case Int8, Uint8:
+		b[0] = byte(i)
+	case Int16, Uint16:
+		order.PutUint16(b, uint16(i))
+	case Int32, Uint32:
+		order.PutUint32(b, uint32(i))
+	case Float32:
+		order.PutUint32(b, math.Float32bits(float32(v)))
+	case Float64:
+		order.PutUint64(b, math.Float64bits(v))
+	}
+}
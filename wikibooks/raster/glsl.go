@@ -0,0 +1,25 @@
+package raster
+
+import (
+	"math"
+)
+
+// MulMat4 returns m * v for a matrix m stored by columns, as GLSL sees a
+// mat4 uniform.
+func MulMat4(m []float32, v Vec4) Vec4 {
+	var out Vec4
+	for r := 0; r < 4; r++ {
+		out[r] = m[r]*v[0] + m[4+r]*v[1] + m[8+r]*v[2] + m[12+r]*v[3]
+	}
+	return out
+}
+
+// Floor is floor in GLSL.
+func Floor(x float32) float32 {
+	return float32(math.Floor(float64(x)))
+}
+
+// Mod is mod in GLSL, x - y * floor(x / y).
+func Mod(x, y float32) float32 {
+	return x - y*Floor(x/y)
+}
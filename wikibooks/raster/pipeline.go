@@ -0,0 +1,245 @@
+package raster
+
+import (
+	"math"
+
+	"color"
+)
+
+// vertex is a shaded vertex in clip space.
+type vertex struct {
+	pos      Vec4
+	varyings []float32
+}
+
+type drawCall struct {
+	c        *Context
+	p        Program
+	attribs  []*Attrib
+	in       []Vec4
+	varyings int
+}
+
+func (c *Context) newDraw(p Program, attribs []*Attrib) *drawCall {
+	d := &drawCall{c: c, p: p, varyings: p.Varyings()}
+	d.attribs = make([]*Attrib, len(p.Attributes()))
+	copy(d.attribs, attribs)
+	d.in = make([]Vec4, len(d.attribs))
+	return d
+}
+
+func (d *drawCall) vertex(i int) *vertex {
+	for k, a := range d.attribs {
+		d.in[k] = a.fetch(i)
+	}
+	v := &vertex{varyings: make([]float32, d.varyings)}
+	v.pos = d.p.Vertex(d.in, v.varyings)
+	return v
+}
+
+// lerpVertex interpolates between a and b in clip space.
+func lerpVertex(a, b *vertex, t float32) *vertex {
+	v := &vertex{varyings: make([]float32, len(a.varyings))}
+	for k := range v.pos {
+		v.pos[k] = a.pos[k] + (b.pos[k]-a.pos[k])*t
+	}
+	for k := range v.varyings {
+		v.varyings[k] = a.varyings[k] + (b.varyings[k]-a.varyings[k])*t
+	}
+	return v
+}
+
+// clipPlanes give the signed distance of a clip space position to the near
+// and far planes and to w = 0, positive inside.
+var clipPlanes = []func(p Vec4) float32{
+	func(p Vec4) float32 { return p[3] - 1e-5 },
+	func(p Vec4) float32 { return p[2] + p[3] },
+	func(p Vec4) float32 { return p[3] - p[2] },
+}
+
+// clip cuts a polygon against the planes. Triangles only partly outside x
+// and y are left to the rasterizer.
+func clip(poly []*vertex) []*vertex {
+	for _, plane := range clipPlanes {
+		if len(poly) == 0 {
+			return nil
+		}
+		var out []*vertex
+		for k, a := range poly {
+			b := poly[(k+1)%len(poly)]
+			da, db := plane(a.pos), plane(b.pos)
+			if da >= 0 {
+				out = append(out, a)
+			}
+			if (da >= 0) != (db >= 0) {
+				out = append(out, lerpVertex(a, b, da/(da-db)))
+			}
+		}
+		poly = out
+	}
+	return poly
+}
+
+func (d *drawCall) triangle(a, b, c *vertex) {
+	inside := true
+	for _, plane := range clipPlanes {
+		if plane(a.pos) < 0 || plane(b.pos) < 0 || plane(c.pos) < 0 {
+			inside = false
+			break
+		}
+	}
+	if inside {
+		d.rasterize(d.window(a), d.window(b), d.window(c))
+		return
+	}
+	poly := clip([]*vertex{a, b, c})
+	for k := 2; k < len(poly); k++ {
+		d.rasterize(d.window(poly[0]), d.window(poly[k-1]), d.window(poly[k]))
+	}
+}
+
+// windowVertex is a vertex after the perspective division and the viewport
+// transform. Its varyings are divided by w.
+type windowVertex struct {
+	x, y, z, invW float32
+	varyings      []float32
+}
+
+func (d *drawCall) window(v *vertex) *windowVertex {
+	vp := d.c.viewport
+	invW := 1 / v.pos[3]
+	w := &windowVertex{
+		x:        float32(vp.Min.X) + (v.pos[0]*invW+1)*float32(vp.Dx())/2,
+		y:        float32(vp.Min.Y) + (v.pos[1]*invW+1)*float32(vp.Dy())/2,
+		z:        (v.pos[2]*invW + 1) / 2,
+		invW:     invW,
+		varyings: make([]float32, len(v.varyings)),
+	}
+	for k, x := range v.varyings {
+		w.varyings[k] = x * invW
+	}
+	return w
+}
+
+func edgeFunction(a, b *windowVertex, x, y float32) float32 {
+	return (b.x-a.x)*(y-a.y) - (b.y-a.y)*(x-a.x)
+}
+
+// topLeft reports whether pixels centered exactly on the edge from a to b,
+// of a counterclockwise triangle, belong to it.
+func topLeft(a, b *windowVertex) bool {
+	return b.y < a.y || (a.y == b.y && b.x < a.x)
+}
+
+func (d *drawCall) rasterize(a, b, c *windowVertex) {
+	area := edgeFunction(a, b, c.x, c.y)
+	if area == 0 || math.IsNaN(float64(area)) {
+		return
+	}
+	if area < 0 {
+		b, c = c, b
+		area = -area
+	}
+
+	c0 := d.c
+	bounds := c0.viewport.Intersect(c0.Color.Rect)
+	minX := maxInt(bounds.Min.X, int(math.Floor(float64(min3(a.x, b.x, c.x)))))
+	maxX := minInt(bounds.Max.X-1, int(math.Ceil(float64(max3(a.x, b.x, c.x)))))
+	minY := maxInt(bounds.Min.Y, int(math.Floor(float64(min3(a.y, b.y, c.y)))))
+	maxY := minInt(bounds.Max.Y-1, int(math.Ceil(float64(max3(a.y, b.y, c.y)))))
+
+	tl0, tl1, tl2 := topLeft(b, c), topLeft(c, a), topLeft(a, b)
+	varyings := make([]float32, len(a.varyings))
+	height := c0.Color.Rect.Dy()
+	for y := minY; y <= maxY; y++ {
+		py := float32(y) + 0.5
+		for x := minX; x <= maxX; x++ {
+			px := float32(x) + 0.5
+			w0 := edgeFunction(b, c, px, py)
+			w1 := edgeFunction(c, a, px, py)
+			w2 := edgeFunction(a, b, px, py)
+			if w0 < 0 || w1 < 0 || w2 < 0 ||
+				(w0 == 0 && !tl0) || (w1 == 0 && !tl1) || (w2 == 0 && !tl2) {
+				continue
+			}
+			w0, w1, w2 = w0/area, w1/area, w2/area
+
+			z := w0*a.z + w1*b.z + w2*c.z
+			i := (height-1-y)*c0.Color.Rect.Dx() + x
+			if c0.DepthTest {
+				if !(z < c0.depth[i]) {
+					continue
+				}
+				c0.depth[i] = z
+			}
+
+			invW := w0*a.invW + w1*b.invW + w2*c.invW
+			for k := range varyings {
+				varyings[k] = (w0*a.varyings[k] + w1*b.varyings[k] + w2*c.varyings[k]) / invW
+			}
+			c0.write(i, d.p.Fragment(varyings, Vec4{px, py, z, invW}))
+		}
+	}
+}
+
+// write stores v at pixel i, blending if enabled.
+func (c *Context) write(i int, v Vec4) {
+	pix := c.Color.Pix[4*i : 4*i+4]
+	if c.Blend {
+		alpha := clamp(v[3])
+		for k := range v {
+			dst := float32(pix[k]) / 255
+			if c.SRGB && k < 3 {
+				dst = color.DecodeSRGB8(pix[k])
+			}
+			v[k] = clamp(v[k])*alpha + dst*(1-alpha)
+		}
+	}
+	rgba := c.encode(v)
+	copy(pix, rgba[:])
+}
+
+// encode converts v to 8 bits, encoding the color to sRGB if enabled.
+func (c *Context) encode(v Vec4) [4]uint8 {
+	var rgba [4]uint8
+	for k := range v {
+		if c.SRGB && k < 3 {
+			rgba[k] = color.EncodeSRGB8(clamp(v[k]))
+		} else {
+			rgba[k] = toByte(v[k])
+		}
+	}
+	return rgba
+}
+
+func clamp(v float32) float32 {
+	if !(v > 0) {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+func min3(a, b, c float32) float32 {
+	return float32(math.Min(float64(a), math.Min(float64(b), float64(c))))
+}
+
+func max3(a, b, c float32) float32 {
+	return float32(math.Max(float64(a), math.Max(float64(b), float64(c))))
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,161 @@
+// Package raster renders triangles in software, for machines without a
+// GPU. It implements the parts of OpenGL the tutorials use: vertex arrays,
+// indexed and non-indexed triangle draws, the depth test, alpha blending
+// and linearly filtered 2D textures. Shaders are Go functions which mirror
+// the GLSL ones.
+package raster
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+type Vec4 [4]float32
+
+// Program is a vertex and a fragment shader written in Go. Uniforms are
+// fields of the implementation.
+type Program interface {
+	// Attributes names the inputs of Vertex by location.
+	Attributes() []string
+	// Varyings is the number of values Vertex writes to out. They are
+	// interpolated across the triangle and passed to Fragment.
+	Varyings() int
+	// Vertex returns gl_Position. Attributes without an array read as
+	// (0, 0, 0, 1), missing components of others as 0 and w as 1.
+	Vertex(in []Vec4, out []float32) Vec4
+	// Fragment returns gl_FragColor, given gl_FragCoord.
+	Fragment(in []float32, coord Vec4) Vec4
+}
+
+// Attrib feeds a vertex attribute from an array, like glVertexAttribPointer
+// with float data. Stride and Offset count floats; a Stride of 0 means the
+// values are packed.
+type Attrib struct {
+	Data   []float32
+	Size   int
+	Stride int
+	Offset int
+}
+
+func (a *Attrib) fetch(i int) Vec4 {
+	v := Vec4{0, 0, 0, 1}
+	if a == nil || a.Data == nil {
+		return v
+	}
+	stride := a.Stride
+	if stride == 0 {
+		stride = a.Size
+	}
+	base := a.Offset + i*stride
+	for k := 0; k < a.Size && k < 4; k++ {
+		if base+k < len(a.Data) {
+			v[k] = a.Data[base+k]
+		}
+	}
+	return v
+}
+
+// Context holds a framebuffer and the state used when drawing into it.
+type Context struct {
+	// Color is the color buffer. Row 0 is the top row, unlike in OpenGL
+	// where y counts upwards.
+	Color *image.RGBA
+	depth []float32
+
+	viewport image.Rectangle
+
+	// DepthTest keeps fragments nearer than the depth buffer, like
+	// glDepthFunc(GL_LESS), and writes their depth.
+	DepthTest bool
+	// Blend mixes fragments with the color buffer by their alpha, like
+	// glBlendFunc(GL_SRC_ALPHA, GL_ONE_MINUS_SRC_ALPHA).
+	Blend bool
+	// SRGB encodes the linear colors of fragments to sRGB, like
+	// GL_FRAMEBUFFER_SRGB. Blending happens on linear values.
+	SRGB bool
+
+	ClearColor Vec4
+	ClearDepth float32
+}
+
+// New creates a context with a width by height framebuffer.
+func New(width, height int) *Context {
+	c := &Context{ClearDepth: 1}
+	c.Resize(width, height)
+	return c
+}
+
+// Resize reallocates the framebuffer and sets the viewport to all of it.
+func (c *Context) Resize(width, height int) {
+	c.Color = image.NewRGBA(image.Rect(0, 0, width, height))
+	c.depth = make([]float32, width*height)
+	c.viewport = image.Rect(0, 0, width, height)
+}
+
+// Viewport maps normalized device coordinates to the given window
+// rectangle, with y counting upwards as in glViewport.
+func (c *Context) Viewport(x, y, width, height int) {
+	c.viewport = image.Rect(x, y, x+width, y+height)
+}
+
+// Clear clears the color buffer, the depth buffer or both. Like glClear
+// with GL_FRAMEBUFFER_SRGB enabled, it encodes the clear color to sRGB if
+// SRGB is set.
+func (c *Context) Clear(color, depth bool) {
+	if color {
+		rgba := c.encode(c.ClearColor)
+		pix := c.Color.Pix
+		for i := 0; i < len(pix); i += 4 {
+			copy(pix[i:i+4], rgba[:])
+		}
+	}
+	if depth {
+		for i := range c.depth {
+			c.depth[i] = c.ClearDepth
+		}
+	}
+}
+
+// At returns the color of the pixel at x, y with y counting upwards, as
+// glReadPixels would.
+func (c *Context) At(x, y int) color.RGBA {
+	return c.Color.RGBAAt(x, c.Color.Rect.Dy()-1-y)
+}
+
+func toByte(v float32) uint8 {
+	if !(v > 0) {
+		return 0
+	}
+	if v >= 1 {
+		return 255
+	}
+	return uint8(math.Floor(float64(v)*255 + 0.5))
+}
+
+// Draw draws the triangles of count vertices starting at first, like
+// glDrawArrays(GL_TRIANGLES, ...). attribs are indexed by location.
+func (c *Context) Draw(p Program, attribs []*Attrib, first, count int) {
+	d := c.newDraw(p, attribs)
+	for i := first; i+2 < first+count; i += 3 {
+		d.triangle(d.vertex(i), d.vertex(i+1), d.vertex(i+2))
+	}
+}
+
+// DrawIndexed draws the triangles of indices, like glDrawElements(
+// GL_TRIANGLES, ...). Each vertex is shaded once.
+func (c *Context) DrawIndexed(p Program, attribs []*Attrib, indices []uint32) {
+	d := c.newDraw(p, attribs)
+	cache := make(map[uint32]*vertex)
+	get := func(i uint32) *vertex {
+		v := cache[i]
+		if v == nil {
+			v = d.vertex(int(i))
+			cache[i] = v
+		}
+		return v
+	}
+	for i := 0; i+2 < len(indices); i += 3 {
+		d.triangle(get(indices[i]), get(indices[i+1]), get(indices[i+2]))
+	}
+}
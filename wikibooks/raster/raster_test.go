@@ -0,0 +1,203 @@
+package raster
+
+import (
+	"image/color"
+	"testing"
+
+	srgb "color"
+)
+
+// flat passes the position and color attributes through and counts the
+// vertices shaded and the fragments written per pixel.
+type flat struct {
+	vertices  int
+	fragments map[[2]float32]int
+}
+
+func (p *flat) Attributes() []string { return []string{"position", "color"} }
+
+func (p *flat) Varyings() int { return 4 }
+
+func (p *flat) Vertex(in []Vec4, out []float32) Vec4 {
+	p.vertices++
+	copy(out, in[1][:])
+	return in[0]
+}
+
+func (p *flat) Fragment(in []float32, coord Vec4) Vec4 {
+	if p.fragments == nil {
+		p.fragments = make(map[[2]float32]int)
+	}
+	p.fragments[[2]float32{coord[0], coord[1]}]++
+	return Vec4{in[0], in[1], in[2], in[3]}
+}
+
+// fill draws triangles of clip space positions in one color.
+func fill(c *Context, p Program, rgba Vec4, positions ...Vec4) {
+	var pos, col []float32
+	for _, v := range positions {
+		pos = append(pos, v[:]...)
+		col = append(col, rgba[:]...)
+	}
+	c.Draw(p, []*Attrib{{Data: pos, Size: 4}, {Data: col, Size: 4}}, 0, len(positions))
+}
+
+// screen returns a triangle covering the whole viewport at depth z.
+func screen(z float32) []Vec4 {
+	return []Vec4{{-1, -1, z, 1}, {3, -1, z, 1}, {-1, 3, z, 1}}
+}
+
+var (
+	red   = Vec4{1, 0, 0, 1}
+	green = Vec4{0, 1, 0, 1}
+	blue  = Vec4{0, 0, 1, 1}
+)
+
+// all reports whether every pixel of c is want.
+func all(c *Context, want color.RGBA) bool {
+	for y := 0; y < c.Color.Rect.Dy(); y++ {
+		for x := 0; x < c.Color.Rect.Dx(); x++ {
+			if c.At(x, y) != want {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestDepthTest(t *testing.T) {
+	c := New(4, 4)
+	c.DepthTest = true
+	c.Clear(true, true)
+	p := &flat{}
+	fill(c, p, red, screen(0.5)...)
+	fill(c, p, green, screen(-0.5)...)
+	// Farther, and as far as the nearest
+	fill(c, p, blue, screen(0.8)...)
+	fill(c, p, blue, screen(-0.5)...)
+	if !all(c, color.RGBA{0, 255, 0, 255}) {
+		t.Errorf("got %v, want the nearest triangle in green", c.At(0, 0))
+	}
+
+	c.DepthTest = false
+	fill(c, p, blue, screen(0.8)...)
+	if !all(c, color.RGBA{0, 0, 255, 255}) {
+		t.Errorf("without the depth test got %v, want the last triangle in blue", c.At(0, 0))
+	}
+}
+
+// Triangles sharing edges through pixel centers write each pixel once,
+// whatever their winding.
+func TestFillRule(t *testing.T) {
+	c := New(4, 4)
+	p := &flat{}
+	// Window x = 2.5 runs through pixel centers
+	left, right := float32(0.25), float32(1)
+	fill(c, p, red,
+		// Split along the diagonal through the pixel centers
+		Vec4{-1, -1, 0, 1}, Vec4{left, -1, 0, 1}, Vec4{left, left, 0, 1},
+		Vec4{-1, -1, 0, 1}, Vec4{left, left, 0, 1}, Vec4{-1, left, 0, 1},
+		// Clockwise
+		Vec4{left, -1, 0, 1}, Vec4{right, 1, 0, 1}, Vec4{right, -1, 0, 1},
+		Vec4{left, -1, 0, 1}, Vec4{left, 1, 0, 1}, Vec4{right, 1, 0, 1},
+		Vec4{-1, left, 0, 1}, Vec4{left, left, 0, 1}, Vec4{left, 1, 0, 1},
+		Vec4{-1, left, 0, 1}, Vec4{left, 1, 0, 1}, Vec4{-1, 1, 0, 1},
+	)
+	if len(p.fragments) != 16 {
+		t.Errorf("%d pixels written, want 16", len(p.fragments))
+	}
+	for pixel, n := range p.fragments {
+		if n != 1 {
+			t.Errorf("pixel %v written %d times", pixel, n)
+		}
+	}
+}
+
+func TestNearClip(t *testing.T) {
+	c := New(6, 6)
+	p := &flat{}
+	// The top vertex is in front of the near plane, which cuts the
+	// triangle at y = -1/3, window y = 2
+	fill(c, p, red, Vec4{-1, -1, 0, 1}, Vec4{1, -1, 0, 1}, Vec4{0, 1, -3, 1})
+	for y := 0; y < 6; y++ {
+		drawn := c.At(3, y) == color.RGBA{255, 0, 0, 255}
+		if drawn != (y < 2) {
+			t.Errorf("pixel 3, %d drawn is %v", y, drawn)
+		}
+	}
+
+	// Nothing behind the camera shows
+	c.Clear(true, true)
+	fill(c, p, red, Vec4{-1, -1, 0, -1}, Vec4{1, -1, 0, -1}, Vec4{0, 1, 0, -1})
+	if !all(c, color.RGBA{}) {
+		t.Error("triangle behind the camera drawn")
+	}
+}
+
+func TestBlend(t *testing.T) {
+	c := New(2, 2)
+	c.ClearColor = blue
+	c.Clear(true, false)
+	c.Blend = true
+	fill(c, &flat{}, Vec4{1, 0, 0, 0.25}, screen(0)...)
+	// Alpha is blended like the colors: 0.25*0.25 + 1*0.75
+	if got, want := c.At(0, 0), (color.RGBA{64, 0, 191, 207}); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// With sRGB output blending happens on linear values
+	c.SRGB = true
+	c.Clear(true, false)
+	fill(c, &flat{}, Vec4{1, 0, 0, 0.25}, screen(0)...)
+	want := color.RGBA{srgb.EncodeSRGB8(0.25), 0, srgb.EncodeSRGB8(0.75), 207}
+	if got := c.At(0, 0); got != want {
+		t.Errorf("sRGB: got %v, want %v", got, want)
+	}
+}
+
+func TestClear(t *testing.T) {
+	c := New(2, 2)
+	c.ClearColor = Vec4{0.5, 0.5, 0.5, 0.5}
+	c.Clear(true, false)
+	if !all(c, color.RGBA{128, 128, 128, 128}) {
+		t.Errorf("got %v", c.At(0, 0))
+	}
+	// Like the fragments, but not alpha
+	c.SRGB = true
+	c.Clear(true, false)
+	if !all(c, color.RGBA{188, 188, 188, 128}) {
+		t.Errorf("sRGB: got %v", c.At(0, 0))
+	}
+}
+
+func TestDrawIndexed(t *testing.T) {
+	c := New(4, 4)
+	p := &flat{}
+	pos := []float32{-1, -1, 0, 1, 1, -1, 0, 1, 1, 1, 0, 1, -1, 1, 0, 1}
+	col := make([]float32, 16)
+	for i := range col {
+		col[i] = 1
+	}
+	c.DrawIndexed(p, []*Attrib{{Data: pos, Size: 4}, {Data: col, Size: 4}}, []uint32{0, 1, 2, 0, 2, 3})
+	if p.vertices != 4 {
+		t.Errorf("%d vertices shaded, want each of 4 once", p.vertices)
+	}
+	if !all(c, color.RGBA{255, 255, 255, 255}) {
+		t.Error("quad does not cover the viewport")
+	}
+}
+
+func TestViewport(t *testing.T) {
+	c := New(4, 4)
+	// The lower left quarter, y counting upwards
+	c.Viewport(0, 0, 2, 2)
+	fill(c, &flat{}, red, screen(0)...)
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			drawn := c.At(x, y) == color.RGBA{255, 0, 0, 255}
+			if drawn != (x < 2 && y < 2) {
+				t.Errorf("pixel %d, %d drawn is %v", x, y, drawn)
+			}
+		}
+	}
+}
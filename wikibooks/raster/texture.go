@@ -0,0 +1,99 @@
+package raster
+
+import (
+	"image"
+	"image/draw"
+	"math"
+
+	"color"
+)
+
+type Filter int
+
+const (
+	Linear Filter = iota
+	Nearest
+)
+
+type Wrap int
+
+const (
+	Repeat Wrap = iota
+	ClampToEdge
+)
+
+// Texture is a 2D RGBA texture. Texel row 0 is the first row uploaded, the
+// top row of an image, which texture coordinate t = 0 samples as in
+// OpenGL.
+type Texture struct {
+	Width, Height int
+	// Pix holds 4 values per texel, row by row.
+	Pix    []float32
+	Filter Filter
+	WrapS  Wrap
+	WrapT  Wrap
+}
+
+// NewTexture converts img as the gl33 device uploads it: to 8 bit RGBA
+// with linear filtering and repeating coordinates.
+func NewTexture(img image.Image) *Texture {
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Rect, img, b.Min, draw.Src)
+	t := &Texture{Width: b.Dx(), Height: b.Dy(), Pix: make([]float32, len(rgba.Pix))}
+	for i, x := range rgba.Pix {
+		t.Pix[i] = float32(x) / 255
+	}
+	return t
+}
+
+// NewSRGBTexture is NewTexture for sRGB encoded images: the color values,
+// but not alpha, are decoded to linear ones like an SRGB8_ALPHA8 texture.
+func NewSRGBTexture(img image.Image) *Texture {
+	t := NewTexture(img)
+	for i := range t.Pix {
+		if i%4 != 3 {
+			t.Pix[i] = color.SRGBToLinear(t.Pix[i])
+		}
+	}
+	return t
+}
+
+func wrap(i, n int, mode Wrap) int {
+	if mode == ClampToEdge {
+		return minInt(maxInt(i, 0), n-1)
+	}
+	i %= n
+	if i < 0 {
+		i += n
+	}
+	return i
+}
+
+func (t *Texture) texel(x, y int) Vec4 {
+	i := 4 * (wrap(y, t.Height, t.WrapT)*t.Width + wrap(x, t.Width, t.WrapS))
+	return Vec4{t.Pix[i], t.Pix[i+1], t.Pix[i+2], t.Pix[i+3]}
+}
+
+// Sample returns the filtered color at s, t, like texture2D in GLSL.
+func (t *Texture) Sample(s, v float32) Vec4 {
+	if t == nil || t.Width == 0 || t.Height == 0 {
+		return Vec4{0, 0, 0, 1}
+	}
+	x, y := s*float32(t.Width), v*float32(t.Height)
+	if t.Filter == Nearest {
+		return t.texel(int(math.Floor(float64(x))), int(math.Floor(float64(y))))
+	}
+	x0, y0 := math.Floor(float64(x-0.5)), math.Floor(float64(y-0.5))
+	fx, fy := x-0.5-float32(x0), y-0.5-float32(y0)
+	ix, iy := int(x0), int(y0)
+	a, b := t.texel(ix, iy), t.texel(ix+1, iy)
+	c, d := t.texel(ix, iy+1), t.texel(ix+1, iy+1)
+	var out Vec4
+	for k := range out {
+		top := a[k] + (b[k]-a[k])*fx
+		bottom := c[k] + (d[k]-c[k])*fx
+		out[k] = top + (bottom-top)*fy
+	}
+	return out
+}
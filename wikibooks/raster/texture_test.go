@@ -0,0 +1,71 @@
+package raster
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// checker returns a 2x2 texture of black, red, green and blue texels, row
+// by row from the top.
+func checker() *Texture {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.SetRGBA(1, 0, color.RGBA{255, 0, 0, 255})
+	img.SetRGBA(0, 1, color.RGBA{0, 255, 0, 255})
+	img.SetRGBA(1, 1, color.RGBA{0, 0, 255, 255})
+	img.Pix[3] = 255
+	return NewTexture(img)
+}
+
+func TestSample(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		wrap   Wrap
+		s, t   float32
+		want   Vec4
+	}{
+		{"nearest", Nearest, Repeat, 0.75, 0.25, red},
+		{"nearest", Nearest, Repeat, 0.3, 0.9, green},
+		{"nearest repeat", Nearest, Repeat, 1.75, -0.25, blue},
+		{"nearest clamp", Nearest, ClampToEdge, 1.75, -0.25, red},
+		// Texel centers sample a single texel
+		{"linear center", Linear, Repeat, 0.75, 0.75, blue},
+		{"linear", Linear, Repeat, 0.5, 0.5, Vec4{0.25, 0.25, 0.25, 1}},
+		{"linear", Linear, ClampToEdge, 0.5, 0.25, Vec4{0.5, 0, 0, 1}},
+		// At the edge repeating mixes in the opposite side
+		{"linear repeat", Linear, Repeat, 0, 0.25, Vec4{0.5, 0, 0, 1}},
+		{"linear clamp", Linear, ClampToEdge, 0, 0.25, Vec4{0, 0, 0, 1}},
+		{"linear clamp", Linear, ClampToEdge, -3, 5, green},
+	}
+	for _, test := range tests {
+		tex := checker()
+		tex.Filter, tex.WrapS, tex.WrapT = test.filter, test.wrap, test.wrap
+		got := tex.Sample(test.s, test.t)
+		for k := range got {
+			if math.Abs(float64(got[k]-test.want[k])) > 1e-6 {
+				t.Errorf("%s: Sample(%v, %v) = %v, want %v", test.name, test.s, test.t, got, test.want)
+				break
+			}
+		}
+	}
+
+	var missing *Texture
+	if got := missing.Sample(0.5, 0.5); got != (Vec4{0, 0, 0, 1}) {
+		t.Errorf("nil texture: got %v, want opaque black", got)
+	}
+}
+
+func TestNewSRGBTexture(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	img.SetRGBA(0, 0, color.RGBA{188, 255, 0, 128})
+	tex := NewSRGBTexture(img)
+	// Within the rounding of 188
+	want := Vec4{0.5, 1, 0, 128.0 / 255}
+	for k := range want {
+		if math.Abs(float64(tex.Pix[k]-want[k])) > 0.005 {
+			t.Fatalf("got %v, want %v", tex.Pix, want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Package stl reads and writes STL files, as exported by CAD programs, in
+// ASCII and binary.
+//
+// STL stores unconnected triangles with a facet normal each. The normals
+// written by other programs are often missing or wrong, so they are always
+// computed again from the vertices, which STL orders counter clockwise.
+package stl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"mesh"
+)
+
+const (
+	headerSize = 80
+	facetSize  = 50
+)
+
+// Parse reads an ASCII or binary STL file. Each facet gets three vertices
+// of its own, with positions and the facet normal; optimize.Weld merges
+// them into a connected mesh. The solids of ASCII files with several are
+// joined.
+func Parse(r io.Reader) (*mesh.Mesh, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var positions []float32
+	if isBinary(data) {
+		positions, err = parseBinary(data)
+	} else {
+		positions, err = parseASCII(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return facets(positions), nil
+}
+
+// Load reads the STL file of the given name.
+func Load(name string) (*mesh.Mesh, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+// isBinary tells the formats apart. Binary files may also start with
+// "solid", so their size decides.
+func isBinary(data []byte) bool {
+	if len(data) >= headerSize+4 {
+		n := binary.LittleEndian.Uint32(data[headerSize:])
+		if uint64(len(data)) == headerSize+4+uint64(n)*facetSize {
+			return true
+		}
+	}
+	return !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("solid"))
+}
+
+func parseBinary(data []byte) ([]float32, error) {
+	if len(data) < headerSize+4 {
+		return nil, errors.New("stl: file too short")
+	}
+	n := int(binary.LittleEndian.Uint32(data[headerSize:]))
+	data = data[headerSize+4:]
+	if len(data) < n*facetSize {
+		return nil, errors.New("stl: unexpected end of file")
+	}
+	positions := make([]float32, 0, n*9)
+	for i := 0; i < n; i++ {
+		// The stored normal and the attribute byte count are skipped
+		facet := data[i*facetSize+12 : i*facetSize+48]
+		for k := 0; k < 9; k++ {
+			positions = append(positions, math.Float32frombits(binary.LittleEndian.Uint32(facet[4*k:])))
+		}
+	}
+	return positions, nil
+}
+
+func parseASCII(data []byte) ([]float32, error) {
+	var positions []float32
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	lineNum := 0
+	vertices := -1 // in the current facet, -1 outside of facets
+	for scanner.Scan() {
+		lineNum++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		fail := func(format string, args ...interface{}) error {
+			return fmt.Errorf("stl: line %d: %s", lineNum, fmt.Sprintf(format, args...))
+		}
+		switch fields[0] {
+		case "solid", "endsolid", "outer", "endloop":
+		case "facet":
+			vertices = 0
+		case "vertex":
+			if vertices < 0 || vertices == 3 {
+				return nil, fail("vertex outside of a triangle facet")
+			}
+			if len(fields) != 4 {
+				return nil, fail("vertex needs 3 coordinates")
+			}
+			for _, s := range fields[1:] {
+				v, err := strconv.ParseFloat(s, 32)
+				if err != nil {
+					return nil, fail("bad coordinate %q", s)
+				}
+				positions = append(positions, float32(v))
+			}
+			vertices++
+		case "endfacet":
+			if vertices != 3 {
+				return nil, fail("facet with %d vertices", vertices)
+			}
+			vertices = -1
+		default:
+			return nil, fail("unknown keyword %q", fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if vertices >= 0 {
+		return nil, errors.New("stl: unexpected end of file")
+	}
+	return positions, nil
+}
+
+// facets builds a mesh of unconnected triangles with facet normals.
+func facets(positions []float32) *mesh.Mesh {
+	m := mesh.New()
+	n := len(positions) / 3
+	normals := make([]float32, 0, len(positions))
+	for t := 0; t < n/3; t++ {
+		normal := facetNormal(positions[9*t:])
+		normals = append(normals, normal[:]...)
+		normals = append(normals, normal[:]...)
+		normals = append(normals, normal[:]...)
+	}
+	m.Add(mesh.Position, 3, positions)
+	m.Add(mesh.Normal, 3, normals)
+	m.Indices = make([]uint32, n)
+	for i := range m.Indices {
+		m.Indices[i] = uint32(i)
+	}
+	return m
+}
+
+// facetNormal returns the unit normal of the triangle of the first 9
+// values, or 0 if it has no area.
+func facetNormal(p []float32) [3]float32 {
+	var e1, e2 [3]float64
+	for k := 0; k < 3; k++ {
+		e1[k] = float64(p[3+k] - p[k])
+		e2[k] = float64(p[6+k] - p[k])
+	}
+	n := [3]float64{
+		e1[1]*e2[2] - e1[2]*e2[1],
+		e1[2]*e2[0] - e1[0]*e2[2],
+		e1[0]*e2[1] - e1[1]*e2[0],
+	}
+	l := math.Sqrt(n[0]*n[0] + n[1]*n[1] + n[2]*n[2])
+	if l == 0 {
+		return [3]float32{}
+	}
+	return [3]float32{float32(n[0] / l), float32(n[1] / l), float32(n[2] / l)}
+}
@@ -0,0 +1,62 @@
+package stl
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"mesh"
+)
+
+// tetrahedron returns a closed mesh with counter clockwise triangles seen
+// from outside.
+func tetrahedron() *mesh.Mesh {
+	m := mesh.New()
+	m.Add(mesh.Position, 3, []float32{0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0, 1.5})
+	m.Indices = []uint32{0, 2, 1, 0, 1, 3, 0, 3, 2, 1, 2, 3}
+	return m
+}
+
+func TestRoundTrip(t *testing.T) {
+	encoders := []struct {
+		name   string
+		encode func(w io.Writer, m *mesh.Mesh, name string) error
+	}{
+		{"binary", Encode},
+		{"ascii", EncodeASCII},
+	}
+	for _, e := range encoders {
+		in := tetrahedron()
+		var buf bytes.Buffer
+		if err := e.encode(&buf, in, "tetrahedron"); err != nil {
+			t.Fatalf("%s: %v", e.name, err)
+		}
+		out, err := Parse(&buf)
+		if err != nil {
+			t.Fatalf("%s: %v", e.name, err)
+		}
+		// Every corner becomes a vertex of its own
+		if out.VertexCount() != len(in.Indices) || out.TriangleCount() != in.TriangleCount() {
+			t.Fatalf("%s: got %d vertices and %d triangles, want %d and %d", e.name,
+				out.VertexCount(), out.TriangleCount(), len(in.Indices), in.TriangleCount())
+		}
+		pos, got := in.Attribute(mesh.Position), out.Attribute(mesh.Position)
+		for i, v := range in.Indices {
+			p, q := pos.Get(int(v)), got.Get(int(out.Indices[i]))
+			if p[0] != q[0] || p[1] != q[1] || p[2] != q[2] {
+				t.Errorf("%s: corner %d is %v, want %v", e.name, i, q, p)
+			}
+		}
+		// The facet normals point out of the solid
+		normals := out.Attribute(mesh.Normal)
+		for tri := 0; tri < out.TriangleCount(); tri++ {
+			n := normals.Get(3 * tri)
+			p := got.Get(3 * tri)
+			// The centroid of the tetrahedron is inside every face plane
+			c := [3]float32{0.25 - p[0], 0.25 - p[1], 0.375 - p[2]}
+			if n[0]*c[0]+n[1]*c[1]+n[2]*c[2] >= 0 {
+				t.Errorf("%s: normal %v of triangle %d points inwards", e.name, n, tri)
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package stl
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"mesh"
+)
+
+// Encode writes the triangles of m as binary STL, with name in the header.
+func Encode(w io.Writer, m *mesh.Mesh, name string) error {
+	positions, err := triangles(m)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	var header [headerSize + 4]byte
+	copy(header[:headerSize], name)
+	binary.LittleEndian.PutUint32(header[headerSize:], uint32(len(positions)/9))
+	bw.Write(header[:])
+	var facet [facetSize]byte
+	for t := 0; t < len(positions)/9; t++ {
+		p := positions[9*t : 9*t+9]
+		n := facetNormal(p)
+		for k, v := range append(n[:], p...) {
+			binary.LittleEndian.PutUint32(facet[4*k:], math.Float32bits(v))
+		}
+		bw.Write(facet[:])
+	}
+	return bw.Flush()
+}
+
+// EncodeASCII writes the triangles of m as an ASCII STL solid of the given
+// name.
+func EncodeASCII(w io.Writer, m *mesh.Mesh, name string) error {
+	positions, err := triangles(m)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "solid %s\n", name)
+	for t := 0; t < len(positions)/9; t++ {
+		p := positions[9*t : 9*t+9]
+		n := facetNormal(p)
+		fmt.Fprintf(bw, "facet normal %g %g %g\n outer loop\n", n[0], n[1], n[2])
+		for k := 0; k < 3; k++ {
+			fmt.Fprintf(bw, "  vertex %g %g %g\n", p[3*k], p[3*k+1], p[3*k+2])
+		}
+		bw.WriteString(" endloop\nendfacet\n")
+	}
+	fmt.Fprintf(bw, "endsolid %s\n", name)
+	return bw.Flush()
+}
+
+// triangles returns the positions of the corners of every triangle.
+func triangles(m *mesh.Mesh) ([]float32, error) {
+	pos := m.Attribute(mesh.Position)
+	if pos == nil || pos.Size != 3 {
+		return nil, errors.New("stl: mesh needs xyz positions")
+	}
+	out := make([]float32, 0, len(m.Indices)*3)
+	for _, v := range m.Indices {
+		out = append(out, pos.Get(int(v))...)
+	}
+	return out, nil
+}
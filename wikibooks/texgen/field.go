@@ -0,0 +1,101 @@
+package texgen
+
+import (
+	"image"
+	"math"
+
+	"hdr"
+)
+
+// A Field is a grayscale float image, e.g. a noise sample or a height map.
+type Field struct {
+	Width, Height int
+	Values        []float32
+}
+
+func NewField(w, h int) *Field {
+	return &Field{w, h, make([]float32, w*h)}
+}
+
+// Sample evaluates n on a w x h grid. scale is the number of noise units
+// across the width of the texture.
+func Sample(n Noise, w, h int, scale float64) *Field {
+	f := NewField(w, h)
+	step := scale / float64(w)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			f.Values[y*w+x] = float32(n(float64(x)*step, float64(y)*step))
+		}
+	}
+	return f
+}
+
+// At returns the value at (x, y), wrapping around the borders.
+func (f *Field) At(x, y int) float32 {
+	x = (x%f.Width + f.Width) % f.Width
+	y = (y%f.Height + f.Height) % f.Height
+	return f.Values[y*f.Width+x]
+}
+
+// Normalize rescales the values to [0, 1].
+func (f *Field) Normalize() {
+	lo, hi := float32(math.Inf(1)), float32(math.Inf(-1))
+	for _, v := range f.Values {
+		if v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+		}
+	}
+	if hi <= lo {
+		return
+	}
+	for i, v := range f.Values {
+		f.Values[i] = (v - lo) / (hi - lo)
+	}
+}
+
+// Gray returns the values in [0, 1] as an 8 bit image.
+func (f *Field) Gray() *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, f.Width, f.Height))
+	for i, v := range f.Values {
+		img.Pix[i] = uint8(clamp(float64(v))*255 + 0.5)
+	}
+	return img
+}
+
+// Float returns the values as a floating point image, which
+// Device.CreateTexture uploads as a floating point texture.
+func (f *Field) Float() *hdr.Image {
+	img := hdr.NewImage(image.Rect(0, 0, f.Width, f.Height))
+	for i, v := range f.Values {
+		img.Pix[4*i] = v
+		img.Pix[4*i+1] = v
+		img.Pix[4*i+2] = v
+		img.Pix[4*i+3] = 1
+	}
+	return img
+}
+
+// NormalMap derives a tangent space normal map from a height map using
+// central differences. strength scales the slopes. The height map is
+// treated as tiling. Like most tools, +Y points up in the image (towards
+// row 0).
+func NormalMap(height *Field, strength float32) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, height.Width, height.Height))
+	for y := 0; y < height.Height; y++ {
+		for x := 0; x < height.Width; x++ {
+			dx := (height.At(x+1, y) - height.At(x-1, y)) * 0.5 * strength
+			dy := (height.At(x, y-1) - height.At(x, y+1)) * 0.5 * strength
+			nx, ny, nz := -dx, -dy, float32(1)
+			l := float32(math.Sqrt(float64(nx*nx + ny*ny + nz*nz)))
+			i := img.PixOffset(x, y)
+			img.Pix[i] = uint8((nx/l*0.5+0.5)*255 + 0.5)
+			img.Pix[i+1] = uint8((ny/l*0.5+0.5)*255 + 0.5)
+			img.Pix[i+2] = uint8((nz/l*0.5+0.5)*255 + 0.5)
+			img.Pix[i+3] = 255
+		}
+	}
+	return img
+}
@@ -0,0 +1,71 @@
+package texgen
+
+import (
+	imagecolor "image/color"
+	"testing"
+)
+
+// ramp returns a w x h field rising by dx per column and dy per row.
+func ramp(w, h int, dx, dy float32) *Field {
+	f := NewField(w, h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			f.Values[y*w+x] = float32(x)*dx + float32(y)*dy
+		}
+	}
+	return f
+}
+
+func TestField(t *testing.T) {
+	f := ramp(3, 2, 1, 10)
+	if f.At(-1, 0) != 2 || f.At(3, 2) != 0 || f.At(1, -1) != 11 {
+		t.Errorf("At does not wrap: %v %v %v", f.At(-1, 0), f.At(3, 2), f.At(1, -1))
+	}
+	f.Normalize()
+	if f.Values[0] != 0 || f.Values[5] != 1 || f.Values[3] != 10.0/12 {
+		t.Errorf("normalized to %v", f.Values)
+	}
+	gray := f.Gray()
+	if gray.Pix[0] != 0 || gray.Pix[5] != 255 || gray.Pix[1] != 21 {
+		t.Errorf("gray %v", gray.Pix)
+	}
+	img := f.Float()
+	if r, g, b, a := img.RGBAAt(2, 1); r != 1 || g != 1 || b != 1 || a != 1 {
+		t.Errorf("float pixel 2, 1 is %v %v %v %v", r, g, b, a)
+	}
+
+	// Flat fields stay as they are
+	flat := ramp(2, 2, 0, 0)
+	flat.Values[0], flat.Values[1], flat.Values[2], flat.Values[3] = 3, 3, 3, 3
+	flat.Normalize()
+	if flat.Values[0] != 3 {
+		t.Errorf("flat field normalized to %v", flat.Values)
+	}
+
+	s := Sample(func(x, y float64) float64 { return x + 10*y }, 4, 2, 2)
+	if s.Width != 4 || s.Height != 2 || s.Values[1] != 0.5 || s.Values[4] != 5 {
+		t.Errorf("sampled %v", s.Values)
+	}
+}
+
+func TestNormalMap(t *testing.T) {
+	tests := []struct {
+		name     string
+		height   *Field
+		strength float32
+		want     imagecolor.RGBA
+	}{
+		{"flat", ramp(4, 4, 0, 0), 1, imagecolor.RGBA{128, 128, 255, 255}},
+		// A slope of 45 degrees rising to the right tilts the normal left
+		{"right", ramp(4, 4, 1, 0), 1, imagecolor.RGBA{37, 128, 218, 255}},
+		{"strength", ramp(4, 4, 0.5, 0), 2, imagecolor.RGBA{37, 128, 218, 255}},
+		// Rising towards the bottom tilts it up, towards +Y
+		{"down", ramp(4, 4, 0, 1), 1, imagecolor.RGBA{128, 218, 218, 255}},
+	}
+	for _, test := range tests {
+		// Inside, away from the wrapped borders
+		if got := NormalMap(test.height, test.strength).RGBAAt(1, 2); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
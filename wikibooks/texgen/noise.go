@@ -0,0 +1,156 @@
+package texgen
+
+import (
+	"math"
+	"math/rand"
+)
+
+// A Noise function returns a smooth random value for a 2D point. Perlin and
+// simplex noise lie roughly in [-1, 1], Worley noise in [0, 1].
+type Noise func(x, y float64) float64
+
+func permutation(seed int64) []int {
+	p := rand.New(rand.NewSource(seed)).Perm(256)
+	return append(p, p...)
+}
+
+// Perlin returns Ken Perlin's improved gradient noise.
+// See http://mrl.nyu.edu/~perlin/noise/ for the reference implementation.
+func Perlin(seed int64) Noise {
+	p := permutation(seed)
+	grad := func(hash int, x, y float64) float64 {
+		switch hash & 7 {
+		case 0:
+			return x + y
+		case 1:
+			return -x + y
+		case 2:
+			return x - y
+		case 3:
+			return -x - y
+		case 4:
+			return x
+		case 5:
+			return -x
+		case 6:
+			return y
+		}
+		return -y
+	}
+	return func(x, y float64) float64 {
+		xf, yf := math.Floor(x), math.Floor(y)
+		xi, yi := int(xf)&255, int(yf)&255
+		x, y = x-xf, y-yf
+		u, v := fade(x), fade(y)
+		aa := p[p[xi]+yi]
+		ab := p[p[xi]+yi+1]
+		ba := p[p[xi+1]+yi]
+		bb := p[p[xi+1]+yi+1]
+		return lerp(v,
+			lerp(u, grad(aa, x, y), grad(ba, x-1, y)),
+			lerp(u, grad(ab, x, y-1), grad(bb, x-1, y-1)))
+	}
+}
+
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(t, a, b float64) float64 {
+	return a + t*(b-a)
+}
+
+var simplexGrad = [12][2]float64{
+	{1, 1}, {-1, 1}, {1, -1}, {-1, -1},
+	{1, 0}, {-1, 0}, {1, 0}, {-1, 0},
+	{0, 1}, {0, -1}, {0, 1}, {0, -1},
+}
+
+// Simplex returns 2D simplex noise, which has fewer directional artifacts
+// than Perlin noise.
+// See http://staffwww.itn.liu.se/~stegu/simplexnoise/simplexnoise.pdf
+func Simplex(seed int64) Noise {
+	p := permutation(seed)
+	f2 := 0.5 * (math.Sqrt(3) - 1)
+	g2 := (3 - math.Sqrt(3)) / 6
+	return func(x, y float64) float64 {
+		// Skew to find the simplex cell
+		s := (x + y) * f2
+		i := math.Floor(x + s)
+		j := math.Floor(y + s)
+		t := (i + j) * g2
+		x0 := x - (i - t)
+		y0 := y - (j - t)
+		i1, j1 := 0, 1
+		if x0 > y0 {
+			i1, j1 = 1, 0
+		}
+		x1 := x0 - float64(i1) + g2
+		y1 := y0 - float64(j1) + g2
+		x2 := x0 - 1 + 2*g2
+		y2 := y0 - 1 + 2*g2
+		ii, jj := int(i)&255, int(j)&255
+		corners := [3]struct {
+			x, y float64
+			g    int
+		}{
+			{x0, y0, p[ii+p[jj]] % 12},
+			{x1, y1, p[ii+i1+p[jj+j1]] % 12},
+			{x2, y2, p[ii+1+p[jj+1]] % 12},
+		}
+		n := 0.0
+		for _, c := range corners {
+			t := 0.5 - c.x*c.x - c.y*c.y
+			if t > 0 {
+				t *= t
+				n += t * t * (simplexGrad[c.g][0]*c.x + simplexGrad[c.g][1]*c.y)
+			}
+		}
+		return 70 * n
+	}
+}
+
+// Worley returns cellular noise: the distance to the nearest of randomly
+// placed feature points, one per unit cell.
+func Worley(seed int64) Noise {
+	p := permutation(seed)
+	// Feature point of a cell, hashed from its coordinates
+	point := func(cx, cy int) (float64, float64) {
+		h := p[p[cx&255]+cy&255]
+		h2 := p[h+1]
+		return float64(h) / 256, float64(h2) / 256
+	}
+	return func(x, y float64) float64 {
+		xf, yf := math.Floor(x), math.Floor(y)
+		cx, cy := int(xf), int(yf)
+		best := math.Inf(1)
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				px, py := point(cx+dx, cy+dy)
+				ddx := xf + float64(dx) + px - x
+				ddy := yf + float64(dy) + py - y
+				if d := ddx*ddx + ddy*ddy; d < best {
+					best = d
+				}
+			}
+		}
+		return math.Min(math.Sqrt(best), 1)
+	}
+}
+
+// FBM sums octaves of n (fractional Brownian motion). Each octave has
+// lacunarity times the frequency and gain times the amplitude of the
+// previous one. The result is scaled back to the range of n.
+func FBM(n Noise, octaves int, lacunarity, gain float64) Noise {
+	return func(x, y float64) float64 {
+		sum, amplitude, total := 0.0, 1.0, 0.0
+		for i := 0; i < octaves; i++ {
+			sum += amplitude * n(x, y)
+			total += amplitude
+			amplitude *= gain
+			x *= lacunarity
+			y *= lacunarity
+		}
+		return sum / total
+	}
+}
@@ -0,0 +1,95 @@
+package texgen
+
+import (
+	"math"
+	"testing"
+)
+
+var noises = []struct {
+	name   string
+	noise  func(seed int64) Noise
+	lo, hi float64
+}{
+	{"Perlin", Perlin, -1, 1},
+	{"Simplex", Simplex, -1, 1},
+	{"Worley", Worley, 0, 1},
+}
+
+// grid calls f at points spread over a few hundred noise units, including
+// negative ones.
+func grid(f func(x, y float64)) {
+	for y := -100.0; y < 300; y += 3.7 {
+		for x := -100.0; x < 300; x += 2.3 {
+			f(x, y)
+		}
+	}
+}
+
+func TestNoiseSeed(t *testing.T) {
+	for _, n := range noises {
+		a, b, other := n.noise(1), n.noise(1), n.noise(2)
+		differ := false
+		grid(func(x, y float64) {
+			if a(x, y) != b(x, y) {
+				t.Fatalf("%s: seed 1 gives %v and %v at %v, %v", n.name, a(x, y), b(x, y), x, y)
+			}
+			if a(x, y) != other(x, y) {
+				differ = true
+			}
+		})
+		if !differ {
+			t.Errorf("%s: seeds 1 and 2 give the same noise", n.name)
+		}
+	}
+}
+
+func TestNoiseRange(t *testing.T) {
+	for _, n := range noises {
+		for _, noise := range []Noise{n.noise(7), FBM(n.noise(7), 5, 2, 0.5)} {
+			lo, hi := math.Inf(1), math.Inf(-1)
+			grid(func(x, y float64) {
+				v := noise(x, y)
+				lo, hi = math.Min(lo, v), math.Max(hi, v)
+			})
+			if lo < n.lo || hi > n.hi {
+				t.Errorf("%s: values from %v to %v", n.name, lo, hi)
+			}
+			// Not flat either
+			if hi-lo < (n.hi-n.lo)/4 {
+				t.Errorf("%s: values only from %v to %v", n.name, lo, hi)
+			}
+		}
+	}
+}
+
+func TestPerlin(t *testing.T) {
+	p := Perlin(3)
+	// Gradient noise is zero on the lattice and repeats every 256 units
+	for _, x := range []float64{0, 1, -5, 42} {
+		if v := p(x, 7); v != 0 {
+			t.Errorf("%v, 7 is %v, want 0", x, v)
+		}
+	}
+	grid(func(x, y float64) {
+		// Adding 256 rounds the fractions a little
+		if math.Abs(p(x, y)-p(x+256, y)) > 1e-9 || math.Abs(p(x, y)-p(x, y-256)) > 1e-9 {
+			t.Fatalf("%v, %v does not repeat", x, y)
+		}
+	})
+}
+
+// FBM with one octave is the noise itself, and weighs further octaves
+// by gain.
+func TestFBM(t *testing.T) {
+	n := Simplex(5)
+	one := FBM(n, 1, 2, 0.5)
+	two := FBM(n, 2, 2, 0.5)
+	grid(func(x, y float64) {
+		if one(x, y) != n(x, y) {
+			t.Fatalf("one octave: %v, want %v", one(x, y), n(x, y))
+		}
+		if want := (n(x, y) + 0.5*n(2*x, 2*y)) / 1.5; math.Abs(two(x, y)-want) > 1e-12 {
+			t.Fatalf("two octaves: %v, want %v", two(x, y), want)
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package texgen
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"color"
+)
+
+func fill(img *image.RGBA, x, y int, c color.Color) {
+	r, g, b, a := c.SRGB8()
+	i := img.PixOffset(x, y)
+	img.Pix[i] = r
+	img.Pix[i+1] = g
+	img.Pix[i+2] = b
+	img.Pix[i+3] = a
+}
+
+// Checkerboard returns a w x h image of size x size squares, starting with
+// a in the top left corner. Squares are at least one pixel.
+func Checkerboard(w, h, size int, a, b color.Color) *image.RGBA {
+	size = positive(size)
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if (x/size+y/size)%2 == 0 {
+				fill(img, x, y, a)
+			} else {
+				fill(img, x, y, b)
+			}
+		}
+	}
+	return img
+}
+
+// UVGrid returns a UV debug texture with cells x cells colored squares,
+// each labeled with its column letter and row number, e.g. "C4", so that
+// stretched or flipped texture coordinates are easy to spot. Red
+// increases along U (x), green along V (y). There is at least one cell.
+func UVGrid(size, cells int) *image.RGBA {
+	cells = positive(cells)
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	cell := float64(size) / float64(cells)
+	for y := 0; y < size; y++ {
+		cy := int(float64(y) / cell)
+		for x := 0; x < size; x++ {
+			cx := int(float64(x) / cell)
+			c := color.SRGB(float32(cx+1)/float32(cells), float32(cy+1)/float32(cells), 0.5, 1)
+			if (cx+cy)%2 == 1 {
+				c = c.Scaled(0.5)
+			}
+			// Grid lines on the cell borders
+			if x == int(float64(cx)*cell) || y == int(float64(cy)*cell) || x == size-1 || y == size-1 {
+				c = color.Black
+			}
+			fill(img, x, y, c)
+		}
+	}
+	for cy := 0; cy < cells; cy++ {
+		for cx := 0; cx < cells; cx++ {
+			label := fmt.Sprintf("%c%d", 'A'+rune(cx%26), cy)
+			// Glyphs are 3x5 pixels with one pixel spacing
+			scale := int(cell / float64(4*len(label)+2))
+			if scale < 1 {
+				continue
+			}
+			x0 := int(float64(cx)*cell) + (int(cell)-(4*len(label)-1)*scale)/2
+			y0 := int(float64(cy)*cell) + (int(cell)-5*scale)/2
+			drawText(img, label, x0, y0, scale, color.White)
+		}
+	}
+	return img
+}
+
+// LinearGradient blends from a at point p0 to b at point p1. The points are
+// given in texture coordinates, (0, 0) is the top left corner and (1, 1)
+// the bottom right one. Colors are mixed in linear space.
+func LinearGradient(w, h int, p0, p1 [2]float64, a, b color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	dx, dy := p1[0]-p0[0], p1[1]-p0[1]
+	l2 := dx*dx + dy*dy
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			u := (float64(x) + 0.5) / float64(w)
+			v := (float64(y) + 0.5) / float64(h)
+			t := 0.0
+			if l2 > 0 {
+				t = ((u-p0[0])*dx + (v-p0[1])*dy) / l2
+			}
+			fill(img, x, y, a.Lerp(b, float32(clamp(t))))
+		}
+	}
+	return img
+}
+
+// RadialGradient blends from a at center to b at radius and beyond.
+func RadialGradient(w, h int, center [2]float64, radius float64, a, b color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			u := (float64(x)+0.5)/float64(w) - center[0]
+			v := (float64(y)+0.5)/float64(h) - center[1]
+			t := math.Sqrt(u*u+v*v) / radius
+			fill(img, x, y, a.Lerp(b, float32(clamp(t))))
+		}
+	}
+	return img
+}
+
+func positive(n int) int {
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+func clamp(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// glyphs is a 3x5 pixel font, one octal digit per row.
+var glyphs = map[rune][5]uint8{
+	'0': {7, 5, 5, 5, 7}, '1': {2, 6, 2, 2, 7}, '2': {7, 1, 7, 4, 7},
+	'3': {7, 1, 7, 1, 7}, '4': {5, 5, 7, 1, 1}, '5': {7, 4, 7, 1, 7},
+	'6': {7, 4, 7, 5, 7}, '7': {7, 1, 1, 1, 1}, '8': {7, 5, 7, 5, 7},
+	'9': {7, 5, 7, 1, 7},
+	'A': {2, 5, 7, 5, 5}, 'B': {6, 5, 6, 5, 6}, 'C': {3, 4, 4, 4, 3},
+	'D': {6, 5, 5, 5, 6}, 'E': {7, 4, 6, 4, 7}, 'F': {7, 4, 6, 4, 4},
+	'G': {3, 4, 5, 5, 3}, 'H': {5, 5, 7, 5, 5}, 'I': {7, 2, 2, 2, 7},
+	'J': {1, 1, 1, 5, 2}, 'K': {5, 5, 6, 5, 5}, 'L': {4, 4, 4, 4, 7},
+	'M': {5, 7, 7, 5, 5}, 'N': {6, 5, 5, 5, 5}, 'O': {2, 5, 5, 5, 2},
+	'P': {6, 5, 6, 4, 4}, 'Q': {2, 5, 5, 6, 3}, 'R': {6, 5, 6, 5, 5},
+	'S': {3, 4, 2, 1, 6}, 'T': {7, 2, 2, 2, 2}, 'U': {5, 5, 5, 5, 7},
+	'V': {5, 5, 5, 5, 2}, 'W': {5, 5, 7, 7, 5}, 'X': {5, 5, 2, 5, 5},
+	'Y': {5, 5, 2, 2, 2}, 'Z': {7, 1, 2, 4, 7},
+}
+
+func drawText(img *image.RGBA, text string, x0, y0, scale int, c color.Color) {
+	for _, r := range text {
+		glyph := glyphs[r]
+		for row := 0; row < 5; row++ {
+			for col := 0; col < 3; col++ {
+				if glyph[row]&(4>>uint(col)) == 0 {
+					continue
+				}
+				for sy := 0; sy < scale; sy++ {
+					for sx := 0; sx < scale; sx++ {
+						p := image.Pt(x0+col*scale+sx, y0+row*scale+sy)
+						if p.In(img.Rect) {
+							fill(img, p.X, p.Y, c)
+						}
+					}
+				}
+			}
+		}
+		x0 += 4 * scale
+	}
+}
@@ -0,0 +1,97 @@
+package texgen
+
+import (
+	imagecolor "image/color"
+	"testing"
+
+	"color"
+)
+
+var (
+	black = imagecolor.RGBA{0, 0, 0, 255}
+	white = imagecolor.RGBA{255, 255, 255, 255}
+)
+
+func TestCheckerboard(t *testing.T) {
+	img := Checkerboard(4, 4, 2, color.Black, color.White)
+	tests := []struct {
+		x, y int
+		want imagecolor.RGBA
+	}{
+		{0, 0, black}, {1, 1, black}, {2, 0, white}, {3, 1, white},
+		{0, 2, white}, {2, 2, black}, {3, 3, black},
+	}
+	for _, test := range tests {
+		if got := img.RGBAAt(test.x, test.y); got != test.want {
+			t.Errorf("pixel %d, %d is %v, want %v", test.x, test.y, got, test.want)
+		}
+	}
+
+	// Squares of no size are single pixels
+	for _, size := range []int{0, -3} {
+		img := Checkerboard(2, 2, size, color.Black, color.White)
+		if img.RGBAAt(0, 0) != black || img.RGBAAt(1, 0) != white || img.RGBAAt(1, 1) != black {
+			t.Errorf("size %d: got %v", size, img.Pix)
+		}
+	}
+}
+
+func TestUVGrid(t *testing.T) {
+	img := UVGrid(64, 4)
+	if img.RGBAAt(0, 5) != black || img.RGBAAt(16, 5) != black || img.RGBAAt(63, 5) != black {
+		t.Error("no grid lines on the cell borders")
+	}
+	// Red grows along x and green along y; every other cell is darker
+	tests := []struct {
+		x, y int
+		c    color.Color
+	}{
+		{2, 2, color.SRGB(0.25, 0.25, 0.5, 1)},
+		{34, 2, color.SRGB(0.75, 0.25, 0.5, 1)},
+		{2, 50, color.SRGB(0.25, 1, 0.5, 1).Scaled(0.5)},
+	}
+	for _, test := range tests {
+		r, g, b, a := test.c.SRGB8()
+		if got, want := img.RGBAAt(test.x, test.y), (imagecolor.RGBA{r, g, b, a}); got != want {
+			t.Errorf("pixel %d, %d is %v, want %v", test.x, test.y, got, want)
+		}
+	}
+	// The labels are white
+	labels := 0
+	for i := 0; i < len(img.Pix); i += 4 {
+		if img.Pix[i] == 255 && img.Pix[i+1] == 255 && img.Pix[i+2] == 255 {
+			labels++
+		}
+	}
+	if labels == 0 {
+		t.Error("no labels")
+	}
+
+	if img := UVGrid(8, 0); img.Rect.Dx() != 8 {
+		t.Errorf("no cells: got %v", img.Rect)
+	}
+}
+
+func TestGradients(t *testing.T) {
+	// Mixed in linear space from the left edge to the right one
+	img := LinearGradient(4, 1, [2]float64{0, 0}, [2]float64{1, 0}, color.Black, color.White)
+	for x := 0; x < 4; x++ {
+		want := color.EncodeSRGB8((float32(x) + 0.5) / 4)
+		if got := img.RGBAAt(x, 0); got.R != want || got.G != want || got.B != want || got.A != 255 {
+			t.Errorf("linear: pixel %d is %v, want %d", x, got, want)
+		}
+	}
+	// Equal points give the first color
+	img = LinearGradient(2, 2, [2]float64{0.5, 0.5}, [2]float64{0.5, 0.5}, color.White, color.Black)
+	if img.RGBAAt(1, 1) != white {
+		t.Errorf("linear between equal points: got %v", img.RGBAAt(1, 1))
+	}
+
+	img = RadialGradient(5, 5, [2]float64{0.5, 0.5}, 0.5, color.White, color.Black)
+	if img.RGBAAt(2, 2) != white || img.RGBAAt(0, 0) != black || img.RGBAAt(4, 0) != black {
+		t.Errorf("radial: center %v and corners %v, %v", img.RGBAAt(2, 2), img.RGBAAt(0, 0), img.RGBAAt(4, 0))
+	}
+	if c := img.RGBAAt(1, 2); c.R == 0 || c.R == 255 {
+		t.Errorf("radial: between center and edge %v", c)
+	}
+}
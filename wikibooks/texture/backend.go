@@ -0,0 +1,25 @@
+// Package texture connects asset.Loader to a device.Device, so textures
+// decoded in the background are uploaded with Device.CreateTexture.
+package texture
+
+import (
+	"image"
+
+	"device"
+)
+
+// Backend uploads the textures of an asset.Loader to a device, with
+// Options, which may be nil.
+type Backend struct {
+	Device  device.Device
+	Options *device.TextureOptions
+}
+
+func (b Backend) UploadTexture(img image.Image) (uint32, error) {
+	t, err := b.Device.CreateTexture(img, b.Options)
+	return uint32(t), err
+}
+
+func (b Backend) DeleteTexture(texture uint32) {
+	b.Device.DeleteTexture(device.Texture(texture))
+}
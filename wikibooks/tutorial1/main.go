@@ -3,8 +3,9 @@ package main
 import (
 	"fmt"
 
-	gl "github.com/chsc/gogl/gl33"
-	"github.com/jteeuwen/glfw"
+	"app"
+	"app/window"
+	"device"
 )
 
 const (
@@ -33,123 +34,95 @@ var triangleVertices = []float32{
 	0.8, -0.8,
 }
 
-var vs gl.Uint
-var fs gl.Uint
-var program gl.Uint
-var attributeCoord2d gl.Uint
+var dev device.Device
 
-func main() {
-	fmt.Println("OpenGL Programming/Modern OpenGL Introduction")
-	fmt.Println("Tutorial taken from http://en.wikibooks.org/wiki/OpenGL_Programming/Modern_OpenGL_Introduction")
-
-	var err error
-	err = glfw.Init()
-	if err != nil {
-		fmt.Printf("GLFW: %s\n", err)
-		return
-	}
-	defer glfw.Terminate()
+var vboTriangle device.Buffer
 
-	glfw.OpenWindowHint(glfw.OpenGLVersionMajor, 3)
-	glfw.OpenWindowHint(glfw.OpenGLVersionMinor, 3)
-	glfw.OpenWindowHint(glfw.OpenGLProfile, 1)
+var vs device.Shader
+var fs device.Shader
+var program device.Program
+var attributeCoord2d int
 
-	err = glfw.OpenWindow(ScreenWidth, ScreenHeight, 0, 0, 0, 0, 0, 0, glfw.Windowed)
-	if err != nil {
-		fmt.Printf("GLFW: %s\n", err)
-		return
-	}
-	defer glfw.CloseWindow()
-
-	glfw.SetWindowTitle(WindowTitle)
-
-	// Init extension loading
-	err = gl.Init()
-	if err != nil {
-		fmt.Printf("Init OpenGL extension loading failed with %s.\n", err)
-	}
+var config = app.Config{
+	Title:  WindowTitle,
+	Width:  ScreenWidth,
+	Height: ScreenHeight,
+}
 
-	initResources()
+func main() {
+	fmt.Println("OpenGL Programming/Modern OpenGL Introduction")
+	fmt.Println("Tutorial taken from http://en.wikibooks.org/wiki/OpenGL_Programming/Modern_OpenGL_Introduction")
 
-	// Render loop
-	for glfw.WindowParam(glfw.Opened) == 1 {
-		display()
-	}
+	window.Main(config, scene)
+}
 
-	// Free resources
-	free()
+// scene is the tutorial, run by window.Main.
+var scene = app.Scene{
+	Init:     initResources,
+	Render:   func(alpha float64) { display() },
+	Shutdown: free,
 }
 
-func initResources() {
-	var compileOk gl.Int
+func initResources(d device.Device) error {
+	dev = d
+	var err error
 	// Vertex Shader
-	vs = gl.CreateShader(gl.VERTEX_SHADER)
-	vsSrc := gl.GLStringArray(vsSource)
-	defer gl.GLStringArrayFree(vsSrc)
-	gl.ShaderSource(vs, gl.Sizei(len(vsSrc)), &vsSrc[0], nil)
-	gl.CompileShader(vs)
-	gl.GetShaderiv(vs, gl.COMPILE_STATUS, &compileOk)
-	if compileOk == 0 {
-		errNum := gl.GetError()
-		fmt.Printf("Error in vertex shader: %d\n", errNum)
+	vs, err = dev.CreateShader(device.VertexShader, vsSource)
+	if err != nil {
+		return fmt.Errorf("Error in vertex shader: %s", err)
 	}
 
 	// Fragment Shader
-	fs = gl.CreateShader(gl.FRAGMENT_SHADER)
-	fsSrc := gl.GLStringArray(fsSource)
-	defer gl.GLStringArrayFree(fsSrc)
-	gl.ShaderSource(fs, gl.Sizei(1), &fsSrc[0], nil)
-	gl.CompileShader(fs)
-	gl.GetShaderiv(fs, gl.COMPILE_STATUS, &compileOk)
-	if compileOk == 0 {
-		errNum := gl.GetError()
-		fmt.Printf("Error in fragment shader: %d\n", errNum)
+	fs, err = dev.CreateShader(device.FragmentShader, fsSource)
+	if err != nil {
+		return fmt.Errorf("Error in fragment shader: %s", err)
 	}
 
 	// GLSL program
-	program = gl.CreateProgram()
-	gl.AttachShader(program, vs)
-	gl.AttachShader(program, fs)
-	gl.LinkProgram(program)
-	gl.GetProgramiv(program, gl.LINK_STATUS, &compileOk)
-	if compileOk == 0 {
-		fmt.Printf("Error in program.\n")
-
+	program, err = dev.CreateProgram(vs, fs)
+	if err != nil {
+		return fmt.Errorf("Error in program: %s", err)
 	}
 
 	// Get the attribute location from the GLSL program (here from the vertex shader)
-	attributeName := gl.GLString("coord2d")
-	defer gl.GLStringFree(attributeName)
-	attributeTemp := gl.GetAttribLocation(program, attributeName)
-	if attributeTemp == -1 {
-		fmt.Printf("Could not bind attribute %s\n", gl.GoString(attributeName))
+	attributeName := "coord2d"
+	attributeCoord2d = dev.AttribLocation(program, attributeName)
+	if attributeCoord2d == -1 {
+		fmt.Printf("Could not bind attribute %s\n", attributeName)
 	}
-	attributeCoord2d = gl.Uint(attributeTemp)
+
+	// The device reads vertices from buffers only, so the triangle goes
+	// into one
+	vboTriangle = dev.CreateBuffer()
+	dev.BindBuffer(device.ArrayBuffer, vboTriangle)
+	dev.BufferData(device.ArrayBuffer, triangleVertices)
+	dev.BindBuffer(device.ArrayBuffer, 0)
+	return nil
 }
 
 func free() {
 	// Free OpenGL buffers
-	gl.DeleteProgram(program)
+	dev.DeleteProgram(program)
+	dev.DeleteBuffer(vboTriangle)
 }
 
 func display() {
 	// Clear the background as white
-	gl.ClearColor(1.0, 1.0, 1.0, 1.0)
-	gl.Clear(gl.COLOR_BUFFER_BIT)
+	dev.ClearColor(1.0, 1.0, 1.0, 1.0)
+	dev.Clear(device.ColorBuffer)
 
 	// Use the GLSL program
-	gl.UseProgram(program)
+	dev.UseProgram(program)
 
-	gl.EnableVertexAttribArray(attributeCoord2d)
+	dev.BindBuffer(device.ArrayBuffer, vboTriangle)
+	dev.EnableVertexAttribArray(attributeCoord2d)
 
 	// Describe our vertices array to OpenGL (it can't guess its format automatically)
-	gl.VertexAttribPointer(attributeCoord2d, 2, gl.FLOAT, gl.FALSE, 0, gl.Pointer(&triangleVertices[0]))
+	dev.VertexAttribPointer(attributeCoord2d, 2, 0, 0)
 
 	// Push each element in buffer_vertices to the vertex shader
-	gl.DrawArrays(gl.TRIANGLES, 0, 3)
-
-	gl.DisableVertexAttribArray(attributeCoord2d)
+	dev.DrawArrays(0, 3)
 
-	// Display the result
-	glfw.SwapBuffers()
+	dev.DisableVertexAttribArray(attributeCoord2d)
+	dev.BindBuffer(device.ArrayBuffer, 0)
 }
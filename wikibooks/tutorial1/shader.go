@@ -0,0 +1,22 @@
+package main
+
+import (
+	"raster"
+)
+
+// triangleProgram mirrors vsSource and fsSource for the software
+// rasterizer.
+type triangleProgram struct{}
+
+func (triangleProgram) Attributes() []string { return []string{"coord2d"} }
+func (triangleProgram) Varyings() int        { return 0 }
+
+func (triangleProgram) Vertex(in []raster.Vec4, out []float32) raster.Vec4 {
+	coord2d := in[0]
+	return raster.Vec4{coord2d[0], coord2d[1], 0, 1}
+}
+
+// Fragment leaves alpha at 1, which the shader does not set.
+func (triangleProgram) Fragment(in []float32, coord raster.Vec4) raster.Vec4 {
+	return raster.Vec4{0, 0, 1, 1}
+}
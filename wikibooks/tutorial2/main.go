@@ -1,12 +1,11 @@
 package main
 
 import (
-	"errors"
 	"fmt"
-	"io/ioutil"
 
-	gl "github.com/chsc/gogl/gl33"
-	"github.com/jteeuwen/glfw"
+	"app"
+	"app/window"
+	"device"
 )
 
 const (
@@ -15,181 +14,99 @@ const (
 	WindowTitle  = "OpenGL 3 tutorial 2 - Managing shaders"
 )
 
-const (
-	VertexShaderType = iota
-	FragmentShaderType
-)
-
 var triangleVertices = []float32{
 	0.0, 0.8,
 	-0.8, -0.8,
 	0.8, -0.8,
 }
-var vboTriangle gl.Uint
 
-var vs gl.Uint
-var fs gl.Uint
-var program gl.Uint
-var attributeCoord2d gl.Uint
+var dev device.Device
 
-func fileRead(name string) (string, error) {
-	b, err := ioutil.ReadFile(name)
-	if err != nil {
-		return "", err
-	}
-	return string(b), nil
-}
+var vboTriangle device.Buffer
 
-func createShader(name string, shaderType int) (gl.Uint, error) {
-	data, err := fileRead(name)
-	if err != nil {
-		return 0, err
-	}
-	if len(data) == 0 {
-		return 0, errors.New("No shader code.")
-	}
-	var shader gl.Uint
-	switch shaderType {
-	case VertexShaderType:
-		shader = gl.CreateShader(gl.VERTEX_SHADER)
-	case FragmentShaderType:
-		shader = gl.CreateShader(gl.FRAGMENT_SHADER)
-	default:
-		return 0, errors.New("Unknown ShaderType.")
-	}
-	src := gl.GLStringArray(string(data))
-	defer gl.GLStringArrayFree(src)
-	gl.ShaderSource(shader, gl.Sizei(1), &src[0], nil)
-	gl.CompileShader(shader)
-
-	// Similar to print_log in the C code example
-	var length gl.Int
-	gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &length)
-	if length > 1 {
-		glString := gl.GLStringAlloc(gl.Sizei(length))
-		defer gl.GLStringFree(glString)
-		gl.GetShaderInfoLog(shader, gl.Sizei(length), nil, glString)
-		return 0, errors.New(fmt.Sprintf("Shader log: %s", gl.GoString(glString)))
-	}
-	return shader, nil
-}
+var vs device.Shader
+var fs device.Shader
+var program device.Program
+var attributeCoord2d int
+
+func initResources(d device.Device) error {
+	dev = d
+	// Enable transparency in OpenGL
+	dev.Enable(device.Blend)
+	dev.BlendFunc(device.SrcAlpha, device.OneMinusSrcAlpha)
 
-func initResources() {
 	var err error
 	// Load shaders
-	vs, err = createShader("triangle.v.glsl", VertexShaderType)
+	vs, err = device.LoadShader(dev, device.VertexShader, "triangle.v.glsl")
 	if err != nil {
-		fmt.Printf("Shader: %s\n", err)
-		return
+		return fmt.Errorf("Shader: %s", err)
 	}
-	fs, err = createShader("triangle.f.glsl", FragmentShaderType)
+	fs, err = device.LoadShader(dev, device.FragmentShader, "triangle.f.glsl")
 	if err != nil {
-		fmt.Printf("Shader: %s\n", err)
-		return
+		return fmt.Errorf("Shader: %s", err)
 	}
-	var compileOk gl.Int
-	program = gl.CreateProgram()
-	gl.AttachShader(program, vs)
-	gl.AttachShader(program, fs)
-	gl.LinkProgram(program)
-	gl.GetProgramiv(program, gl.LINK_STATUS, &compileOk)
-	if compileOk == 0 {
-		fmt.Printf("Error in program.\n")
+	program, err = dev.CreateProgram(vs, fs)
+	if err != nil {
+		return fmt.Errorf("Error in program: %s", err)
 	}
 
 	// Get the attribute location from the GLSL program (here from the vertex shader)
-	attributeName := gl.GLString("coord2d")
-	defer gl.GLStringFree(attributeName)
-	attributeTemp := gl.GetAttribLocation(program, attributeName)
-	if attributeTemp == -1 {
-		fmt.Printf("Could not bind attribute %s\n", gl.GoString(attributeName))
+	attributeName := "coord2d"
+	attributeCoord2d = dev.AttribLocation(program, attributeName)
+	if attributeCoord2d == -1 {
+		fmt.Printf("Could not bind attribute %s\n", attributeName)
 	}
-	attributeCoord2d = gl.Uint(attributeTemp)
 
 	// Generate a buffer for the VertexBufferObject
-	gl.GenBuffers(1, &vboTriangle)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vboTriangle)
+	vboTriangle = dev.CreateBuffer()
+	dev.BindBuffer(device.ArrayBuffer, vboTriangle)
 	// Submit the vertices of the triangle to the graphic card
-	gl.BufferData(gl.ARRAY_BUFFER, gl.Sizeiptr(len(triangleVertices)*4), gl.Pointer(&triangleVertices[0]), gl.STATIC_DRAW)
+	dev.BufferData(device.ArrayBuffer, triangleVertices)
 	// Unbind the active buffer
-	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	dev.BindBuffer(device.ArrayBuffer, 0)
+	return nil
 }
 
-func main() {
-	var err error
-	err = glfw.Init()
-	if err != nil {
-		fmt.Printf("GLFW: %s\n", err)
-		return
-	}
-	defer glfw.Terminate()
-
-	// You could probably change the required versions down
-	glfw.OpenWindowHint(glfw.OpenGLVersionMajor, 3)
-	glfw.OpenWindowHint(glfw.OpenGLVersionMinor, 3)
-	glfw.OpenWindowHint(glfw.OpenGLProfile, 1)
-
-	// Open Window with 8 bit Alpha
-	err = glfw.OpenWindow(ScreenWidth, ScreenHeight, 0, 0, 0, 8, 0, 0, glfw.Windowed)
-	if err != nil {
-		fmt.Printf("GLFW: %s\n", err)
-		return
-	}
-	defer glfw.CloseWindow()
-
-	glfw.SetWindowTitle(WindowTitle)
-
-	major, minor, rev := glfw.GLVersion()
-	if major < 3 {
-		fmt.Printf("Error your graphic card does not support OpenGL 3.3\n Your GL-Version is: %d, %d, %d\n", major, minor, rev)
-		fmt.Println("You can try to lower the settings in glfw.OpenWindowHint(glfw.OpenGLVersionMajor/Minor.")
-	}
-
-	// Init extension loading
-	err = gl.Init()
-	if err != nil {
-		fmt.Printf("Init OpenGL extension loading failed with %s.\n", err)
-	}
-
-	// Enable transparency in OpenGL
-	gl.Enable(gl.BLEND)
-	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
-
-	initResources()
+var config = app.Config{
+	Title:     WindowTitle,
+	Width:     ScreenWidth,
+	Height:    ScreenHeight,
+	AlphaBits: 8,
+}
 
-	for {
-		display()
-	}
+func main() {
+	window.Main(config, scene)
+}
 
-	// Free resources
-	free()
+// scene is the tutorial, run by window.Main.
+var scene = app.Scene{
+	Init:     initResources,
+	Render:   func(alpha float64) { display() },
+	Shutdown: free,
 }
 
 func free() {
-	gl.DeleteProgram(program)
-	gl.DeleteBuffers(1, &vboTriangle)
+	dev.DeleteProgram(program)
+	dev.DeleteBuffer(vboTriangle)
 }
 
 func display() {
 	// Clear the background as white
-	gl.ClearColor(1.0, 1.0, 1.0, 1.0)
-	gl.Clear(gl.COLOR_BUFFER_BIT)
+	dev.ClearColor(1.0, 1.0, 1.0, 1.0)
+	dev.Clear(device.ColorBuffer)
 
 	// Use the GLSL program
-	gl.UseProgram(program)
+	dev.UseProgram(program)
 
-	gl.BindBuffer(gl.ARRAY_BUFFER, vboTriangle)
-	gl.EnableVertexAttribArray(attributeCoord2d)
+	dev.BindBuffer(device.ArrayBuffer, vboTriangle)
+	dev.EnableVertexAttribArray(attributeCoord2d)
 
 	// Describe our vertices array to OpenGL (it can't guess its format automatically)
-	gl.VertexAttribPointer(attributeCoord2d, 2, gl.FLOAT, gl.FALSE, 0, gl.Pointer(nil))
+	dev.VertexAttribPointer(attributeCoord2d, 2, 0, 0)
 
 	// Push each element in buffer_vertices to the vertex shader
-	gl.DrawArrays(gl.TRIANGLES, 0, 3)
-
-	gl.DisableVertexAttribArray(attributeCoord2d)
-	gl.BindBuffer(gl.ARRAY_BUFFER, 0) // Unbind
+	dev.DrawArrays(0, 3)
 
-	// Display the result
-	glfw.SwapBuffers()
+	dev.DisableVertexAttribArray(attributeCoord2d)
+	dev.BindBuffer(device.ArrayBuffer, 0) // Unbind
 }
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"raster"
+)
+
+// triangleProgram mirrors triangle.v.glsl and triangle.f.glsl for the
+// software rasterizer.
+type triangleProgram struct{}
+
+func (triangleProgram) Attributes() []string { return []string{"coord2d"} }
+func (triangleProgram) Varyings() int        { return 0 }
+
+func (triangleProgram) Vertex(in []raster.Vec4, out []float32) raster.Vec4 {
+	coord2d := in[0]
+	return raster.Vec4{coord2d[0], coord2d[1], 0, 1}
+}
+
+func (triangleProgram) Fragment(in []float32, coord raster.Vec4) raster.Vec4 {
+	return raster.Vec4{coord[0] / 640, coord[1] / 480, 0.5, raster.Floor(raster.Mod(coord[1], 2))}
+}
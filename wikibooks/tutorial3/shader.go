@@ -0,0 +1,23 @@
+package main
+
+import (
+	"raster"
+)
+
+// triangleProgram mirrors triangle.v.glsl and triangle.f.glsl for the
+// software rasterizer.
+type triangleProgram struct{}
+
+func (triangleProgram) Attributes() []string { return []string{"coord2d", "v_color"} }
+func (triangleProgram) Varyings() int        { return 3 }
+
+func (triangleProgram) Vertex(in []raster.Vec4, out []float32) raster.Vec4 {
+	coord2d, vColor := in[0], in[1]
+	copy(out, vColor[:3])
+	return raster.Vec4{coord2d[0], coord2d[1], 0, 1}
+}
+
+func (triangleProgram) Fragment(in []float32, coord raster.Vec4) raster.Vec4 {
+	fColor := in
+	return raster.Vec4{fColor[0], fColor[1], fColor[2], 1}
+}
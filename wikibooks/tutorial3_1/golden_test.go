@@ -0,0 +1,13 @@
+package main
+
+import (
+	"testing"
+
+	"golden"
+)
+
+// TestGolden renders a frame of the tutorial with the software device and
+// compares it with golden.png.
+func TestGolden(t *testing.T) {
+	golden.Test(t, scene, ScreenWidth, ScreenHeight, 1, golden.Files(triangleProgram{}, "triangle.v.glsl", "triangle.f.glsl"))
+}
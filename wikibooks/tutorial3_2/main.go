@@ -2,12 +2,12 @@ package main
 
 import (
 	"fmt"
-	"io/ioutil"
 	"math"
-	"os"
 
-	"gl"
-	"github.com/jteeuwen/glfw"
+	"app"
+	"app/window"
+	"device"
+	"input"
 )
 
 const (
@@ -16,205 +16,141 @@ const (
 	WindowTitle  = "OpenGL 3 tutorial 3 - passing informations to shaders"
 )
 
-const (
-	VertexShaderType = iota
-	FragmentShaderType
-)
-
 var triangleAttributes = []float32{
 	0.0, 0.8, 1.0, 1.0, 0.0,
 	-0.8, -0.8, 0.0, 0.0, 1.0,
 	0.8, -0.8, 1.0, 0.0, 0.0,
 }
 
-var vboTriangle gl.Buffer
+var dev device.Device
 
-var vs gl.Shader
-var fs gl.Shader
-var program gl.Program
+var vboTriangle device.Buffer
 
-var attributeCoord2d gl.AttribLocation
-var attributeColor gl.AttribLocation
-var uniformFade gl.UniformLocation
+var vs device.Shader
+var fs device.Shader
+var program device.Program
 
-func fileRead(name string) (string, os.Error) {
-	b, err := ioutil.ReadFile(name)
-	if err != nil {
-		return "", err
-	}
-	return string(b), nil
-}
+var attributeCoord2d int
+var attributeColor int
+var uniformFade int
 
-func createShader(name string, shaderType int) (gl.Shader, os.Error) {
-	data, err := fileRead(name)
-	if err != nil {
-		return 0, err
-	}
-	if len(data) == 0 {
-		return 0, os.NewError("No shader code.")
-	}
-	var shader gl.Shader
-	switch shaderType {
-	case VertexShaderType:
-		shader = gl.CreateShader(gl.VERTEX_SHADER)
-	case FragmentShaderType:
-		shader = gl.CreateShader(gl.FRAGMENT_SHADER)
-	default:
-		return 0, os.NewError("Unknown ShaderType.")
-	}
-	shader.Source(data)
-	shader.Compile()
-
-	// Similar to print_log in the C code example
-	infoLog := shader.GetInfoLog()
-	if len(infoLog) != 0 {
-		shader.Delete()
-		return 0, err
-	}
-	errNum := gl.GetError()
-	if errNum != 0 {
-		return 0, os.NewError(fmt.Sprintf("Error code: %d", errNum))
-	}
-	return shader, nil
-}
+func initResources(d device.Device) error {
+	dev = d
+	// Enable transparency in OpenGL
+	dev.Enable(device.Blend)
+	dev.BlendFunc(device.SrcAlpha, device.OneMinusSrcAlpha)
 
-func initResources() {
-	var err os.Error
+	var err error
 	// Load shaders
-	vs, err = createShader("triangle.v.glsl", VertexShaderType)
+	vs, err = device.LoadShader(dev, device.VertexShader, "triangle.v.glsl")
 	if err != nil {
-		fmt.Printf("Shader: %s\n", err)
-		return
+		return fmt.Errorf("Shader: %s", err)
 	}
-	fs, err = createShader("triangle.f.glsl", FragmentShaderType)
+	fs, err = device.LoadShader(dev, device.FragmentShader, "triangle.f.glsl")
 	if err != nil {
-		fmt.Printf("Shader: %s\n", err)
-		return
+		return fmt.Errorf("Shader: %s", err)
 	}
 
 	// Create GLSL program with loaded shaders
-	program = gl.CreateProgram()
-	program.AttachShader(vs)
-	program.AttachShader(fs)
-	program.Link()
-	infoLog := program.GetInfoLog()
-	if len(infoLog) != 0 {
-		fmt.Printf("Program: %s\n", infoLog)
+	program, err = dev.CreateProgram(vs, fs)
+	if err != nil {
+		return fmt.Errorf("Program: %s", err)
 	}
 
 	// Generate a buffer for the VertexBufferObject
-	vboTriangle = gl.GenBuffer()
-	vboTriangle.Bind(gl.ARRAY_BUFFER)
+	vboTriangle = dev.CreateBuffer()
+	dev.BindBuffer(device.ArrayBuffer, vboTriangle)
 	// Submit the vertices of the triangle to the graphic card
-	gl.BufferData(gl.ARRAY_BUFFER, len(triangleAttributes)*4, triangleAttributes, gl.STATIC_DRAW)
+	dev.BufferData(device.ArrayBuffer, triangleAttributes)
 	// Unset the active buffer
-	gl.Buffer(0).Bind(gl.ARRAY_BUFFER)
+	dev.BindBuffer(device.ArrayBuffer, 0)
 
 	// Get the attribute location from the GLSL program (here from the vertex shader)
 	attributeName := "coord2d"
-	attributeCoord2d = program.GetAttribLocation(attributeName)
+	attributeCoord2d = dev.AttribLocation(program, attributeName)
 	if attributeCoord2d == -1 {
 		fmt.Printf("Could not bind attribute %s\n", attributeName)
 	}
 
 	attributeName = "v_color"
-	attributeColor = program.GetAttribLocation(attributeName)
+	attributeColor = dev.AttribLocation(program, attributeName)
 	if attributeColor == -1 {
 		fmt.Printf("Could not bind attribute %s\n", attributeName)
 	}
 
 	uniformName := "fade"
-	uniformFade = program.GetUniformLocation(uniformName)
+	uniformFade = dev.UniformLocation(program, uniformName)
 	if uniformFade == -1 {
 		fmt.Printf("Could not bind uniform %s\n", uniformName)
 	}
+	return nil
 }
 
-func main() {
-	var err os.Error
-	err = glfw.Init()
-	if err != nil {
-		fmt.Printf("GLFW: %s\n", err)
-		return
-	}
-	defer glfw.Terminate()
-
-	glfw.OpenWindowHint(glfw.WindowNoResize, 1)
-	glfw.OpenWindowHint(glfw.OpenGLDebugContext, 1)
-
-	// You could probably change the required versions down
-	glfw.OpenWindowHint(glfw.OpenGLVersionMajor, 3)
-	glfw.OpenWindowHint(glfw.OpenGLVersionMinor, 3)
-	glfw.OpenWindowHint(glfw.OpenGLProfile, 1)
-
-	// Open Window with 8 bit Alpha
-	err = glfw.OpenWindow(ScreenWidth, ScreenHeight, 0, 0, 0, 8, 0, 0, glfw.Windowed)
-	if err != nil {
-		fmt.Printf("GLFW: %s\n", err)
-		return
-	}
-	defer glfw.CloseWindow()
-
-	glfw.SetWindowTitle(WindowTitle)
-
-	major, minor, rev := glfw.GLVersion()
-	if major < 3 {
-		fmt.Printf("Error your graphic card does not support OpenGL 3.3\n Your GL-Version is: %d, %d, %d\n", major, minor, rev)
-		fmt.Println("You can try to lower the settings in glfw.OpenWindowHint(glfw.OpenGLVersionMajor/Minor.")
-	}
+var config = app.Config{
+	Title:     WindowTitle,
+	Width:     ScreenWidth,
+	Height:    ScreenHeight,
+	AlphaBits: 8,
+	NoResize:  true,
+	Debug:     true,
+}
 
-	initStatus := gl.Init() // Init glew
-	if initStatus != 0 {
-		fmt.Printf("Error-code: %d Init-Status: %d\n", gl.GetError(), initStatus)
-	}
+func main() {
+	window.Main(config, scene)
+}
 
-	// Enable transparency in OpenGL
-	gl.Enable(gl.BLEND)
-	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+// scene is the tutorial, run by window.Main.
+var scene = app.Scene{
+	Init:     initResources,
+	Update:   update,
+	Render:   render,
+	Shutdown: free,
+}
 
-	initResources()
+func free() {
+	dev.DeleteProgram(program)
+	dev.DeleteBuffer(vboTriangle)
+}
 
-	for {
-		display()
-	}
+// elapsed is the time the animation has run for, lastElapsed the time at
+// the update before.
+var elapsed, lastElapsed float64
 
-	// Free resources
-	free()
+func update(dt float64, in *input.Input, actions *input.Actions) {
+	lastElapsed = elapsed
+	elapsed += dt
 }
 
-func free() {
-	program.Delete()
-	vboTriangle.Delete()
+func render(alpha float64) {
+	display(lastElapsed + alpha*(elapsed-lastElapsed))
 }
 
-func display() {
+// display draws the triangle at time t.
+func display(t float64) {
 	// Clear the background as white
-	gl.ClearColor(1.0, 1.0, 1.0, 1.0)
-	gl.Clear(gl.COLOR_BUFFER_BIT)
+	dev.ClearColor(1.0, 1.0, 1.0, 1.0)
+	dev.Clear(device.ColorBuffer)
 
 	// Use the GLSL program
-	program.Use()
+	dev.UseProgram(program)
 
 	// Faster fade in and out than in the wikibook
-	curFade := math.Sin(glfw.Time())
+	curFade := math.Sin(t)
 
-	uniformFade.Uniform1f(float32(curFade))
+	dev.Uniform1f(uniformFade, float32(curFade))
 
-	vboTriangle.Bind(gl.ARRAY_BUFFER)
+	dev.BindBuffer(device.ArrayBuffer, vboTriangle)
 
-	attributeCoord2d.EnableArray()
+	dev.EnableVertexAttribArray(attributeCoord2d)
 	// Describe our vertices array to OpenGL (it can't guess its format automatically)
-	attributeCoord2d.AttribPointerOffset(2, gl.FLOAT, false, 5*4, 0)
+	dev.VertexAttribPointer(attributeCoord2d, 2, 5*4, 0)
 
-	attributeColor.EnableArray()
-	attributeColor.AttribPointerOffset(3, gl.FLOAT, false, 5*4, 2*4)
+	dev.EnableVertexAttribArray(attributeColor)
+	dev.VertexAttribPointer(attributeColor, 3, 5*4, 2*4)
 
 	// Push each element in buffer_vertices to the vertex shader
-	gl.DrawArrays(gl.TRIANGLES, 0, 3)
-
-	attributeCoord2d.DisableArray()
-	attributeColor.DisableArray()
+	dev.DrawArrays(0, 3)
 
-	// Display the result
-	glfw.SwapBuffers()
+	dev.DisableVertexAttribArray(attributeCoord2d)
+	dev.DisableVertexAttribArray(attributeColor)
 }
@@ -2,16 +2,14 @@ package main
 
 import (
 	"fmt"
-	"io/ioutil"
 	"math"
-	"runtime"
-	"time"
-	"os"
 
 	"math3d"
 
-	"gl"
-	"github.com/jteeuwen/glfw"
+	"app"
+	"app/window"
+	"device"
+	"input"
 )
 
 const (
@@ -20,11 +18,6 @@ const (
 	WindowTitle  = "OpenGL 3 tutorial 4 - transformation matrices"
 )
 
-const (
-	VertexShaderType = iota
-	FragmentShaderType
-)
-
 var triangleAttributes = []float32{
 	-0.5, -0.5, 0.0,
 	1.0, 1.0, 0.0,
@@ -34,203 +27,140 @@ var triangleAttributes = []float32{
 	1.0, 0.0, 0.0,
 }
 
-var vboTriangle gl.Buffer
+var dev device.Device
 
-var vs gl.Shader
-var fs gl.Shader
-var program gl.Program
+var vboTriangle device.Buffer
 
-var attributeCoord3d gl.AttribLocation
-var attributeColor gl.AttribLocation
-var uniformMTransform gl.UniformLocation
+var vs device.Shader
+var fs device.Shader
+var program device.Program
 
-func fileRead(name string) (string, os.Error) {
-	b, err := ioutil.ReadFile(name)
-	if err != nil {
-		return "", err
-	}
-	return string(b), nil
-}
+var attributeCoord3d int
+var attributeColor int
+var uniformMTransform int
 
-func createShader(name string, shaderType int) (gl.Shader, os.Error) {
-	data, err := fileRead(name)
-	if err != nil {
-		return 0, err
-	}
-	if len(data) == 0 {
-		return 0, os.NewError("No shader code.")
-	}
-	var shader gl.Shader
-	switch shaderType {
-	case VertexShaderType:
-		shader = gl.CreateShader(gl.VERTEX_SHADER)
-	case FragmentShaderType:
-		shader = gl.CreateShader(gl.FRAGMENT_SHADER)
-	default:
-		return 0, os.NewError("Unknown ShaderType.")
-	}
-	shader.Source(data)
-	shader.Compile()
-
-	// Similar to print_log in the C code example
-	infoLog := shader.GetInfoLog()
-	if len(infoLog) != 0 {
-		shader.Delete()
-		return 0, err
-	}
-	errNum := gl.GetError()
-	if errNum != 0 {
-		return 0, os.NewError(fmt.Sprintf("Error code: %d", errNum))
-	}
-	return shader, nil
-}
+func initResources(d device.Device) error {
+	dev = d
+	// Enable transparency in OpenGL
+	dev.Enable(device.Blend)
+	dev.BlendFunc(device.SrcAlpha, device.OneMinusSrcAlpha)
 
-func initResources() {
-	var err os.Error
+	var err error
 	// Load shaders
-	vs, err = createShader("triangle.v.glsl", VertexShaderType)
+	vs, err = device.LoadShader(dev, device.VertexShader, "triangle.v.glsl")
 	if err != nil {
-		fmt.Printf("Shader: %s\n", err)
-		return
+		return fmt.Errorf("Shader: %s", err)
 	}
-	fs, err = createShader("triangle.f.glsl", FragmentShaderType)
+	fs, err = device.LoadShader(dev, device.FragmentShader, "triangle.f.glsl")
 	if err != nil {
-		fmt.Printf("Shader: %s\n", err)
-		return
+		return fmt.Errorf("Shader: %s", err)
 	}
 
 	// Create GLSL program with loaded shaders
-	program = gl.CreateProgram()
-	program.AttachShader(vs)
-	program.AttachShader(fs)
-	program.Link()
-	infoLog := program.GetInfoLog()
-	if len(infoLog) != 0 {
-		fmt.Printf("Program: %s\n", infoLog)
+	program, err = dev.CreateProgram(vs, fs)
+	if err != nil {
+		return fmt.Errorf("Program: %s", err)
 	}
 
 	// Generate a buffer for the VertexBufferObject
-	vboTriangle = gl.GenBuffer()
-	vboTriangle.Bind(gl.ARRAY_BUFFER)
+	vboTriangle = dev.CreateBuffer()
+	dev.BindBuffer(device.ArrayBuffer, vboTriangle)
 	// Submit the vertices of the triangle to the graphic card
-	gl.BufferData(gl.ARRAY_BUFFER, len(triangleAttributes)*4, triangleAttributes, gl.STATIC_DRAW)
+	dev.BufferData(device.ArrayBuffer, triangleAttributes)
 	// Unset the active buffer
-	gl.Buffer(0).Bind(gl.ARRAY_BUFFER)
+	dev.BindBuffer(device.ArrayBuffer, 0)
 
 	// Get the attribute location from the GLSL program (here from the vertex shader)
 	attributeName := "coord3d"
-	attributeCoord3d = program.GetAttribLocation(attributeName)
+	attributeCoord3d = dev.AttribLocation(program, attributeName)
 	if attributeCoord3d == -1 {
 		fmt.Printf("Could not bind attribute %s\n", attributeName)
 	}
 
 	attributeName = "v_color"
-	attributeColor = program.GetAttribLocation(attributeName)
+	attributeColor = dev.AttribLocation(program, attributeName)
 	if attributeColor == -1 {
 		fmt.Printf("Could not bind attribute %s\n", attributeName)
 	}
 
 	uniformName := "m_transform"
-	uniformMTransform = program.GetUniformLocation(uniformName)
+	uniformMTransform = dev.UniformLocation(program, uniformName)
 	if uniformMTransform == -1 {
 		fmt.Printf("Could not bind attribute %s\n", uniformName)
 	}
+	return nil
 }
 
-const second = 1e9
+var config = app.Config{
+	Title:     WindowTitle,
+	Width:     ScreenWidth,
+	Height:    ScreenHeight,
+	AlphaBits: 8,
+}
 
 func main() {
-	// We need to lock the goroutine to one thread due time.Ticker
-	runtime.LockOSThread()
-
-	var err os.Error
-	err = glfw.Init()
-	if err != nil {
-		fmt.Printf("GLFW: %s\n", err)
-		return
-	}
-	defer glfw.Terminate()
-
-	// You could probably change the required versions down
-	glfw.OpenWindowHint(glfw.OpenGLVersionMajor, 3)
-	glfw.OpenWindowHint(glfw.OpenGLVersionMinor, 3)
-	glfw.OpenWindowHint(glfw.OpenGLProfile, 1)
-
-	// Open Window with 8 bit Alpha
-	err = glfw.OpenWindow(ScreenWidth, ScreenHeight, 0, 0, 0, 8, 0, 0, glfw.Windowed)
-	if err != nil {
-		fmt.Printf("GLFW: %s\n", err)
-		return
-	}
-	defer glfw.CloseWindow()
-
-	glfw.SetWindowTitle(WindowTitle)
+	window.Main(config, scene)
+}
 
-	major, minor, rev := glfw.GLVersion()
-	if major < 3 {
-		fmt.Printf("Error your graphic card does not support OpenGL 3.3\n Your GL-Version is: %d, %d, %d\n", major, minor, rev)
-		fmt.Println("You can try to lower the settings in glfw.OpenWindowHint(glfw.OpenGLVersionMajor/Minor.")
-	}
+// scene is the tutorial, run by window.Main.
+var scene = app.Scene{
+	Init:     initResources,
+	Update:   update,
+	Render:   render,
+	Shutdown: free,
+}
 
-	initStatus := gl.Init() // Init glew
-	if initStatus != 0 {
-		fmt.Printf("Error-code: %d Init-Status: %d\n", gl.GetError(), initStatus)
-	}
+func free() {
+	dev.DeleteProgram(program)
+	dev.DeleteBuffer(vboTriangle)
+}
 
-	// Enable transparency in OpenGL
-	gl.Enable(gl.BLEND)
-	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
-
-	initResources()
-	// We are limiting the calls to display() (frames per second) to 60. This prevents the 100% cpu usage.
-	ticker := time.NewTicker(int64(second) / 60) // max 60 fps
-	for {
-		<-ticker.C
-		move := float32(math.Sin(glfw.Time()))
-		angle := float32(glfw.Time())
-		matrix = math3d.MakeTranslationMatrix(move, 0.0, 0.0)
-		matrix = matrix.Multiply(math3d.MakeZRotationMatrix(angle)).Transposed()
-		display()
-	}
+var matrix = math3d.MakeIdentity()
 
-	// Free resources
-	free()
+// elapsed is the time the animation has run for, lastElapsed the time at
+// the update before.
+var elapsed, lastElapsed float64
 
-	runtime.UnlockOSThread()
+func update(dt float64, in *input.Input, actions *input.Actions) {
+	lastElapsed = elapsed
+	elapsed += dt
 }
 
-func free() {
-	program.Delete()
-	vboTriangle.Delete()
+func render(alpha float64) {
+	animate(lastElapsed + alpha*(elapsed-lastElapsed))
+	display()
 }
 
-var matrix = math3d.MakeIdentity()
+// animate computes the matrix for time t.
+func animate(t float64) {
+	move := float32(math.Sin(t))
+	angle := float32(t)
+	matrix = math3d.MakeTranslationMatrix(move, 0.0, 0.0)
+	matrix = matrix.Multiply(math3d.MakeZRotationMatrix(angle)).Transposed()
+}
 
 func display() {
 	// Clear the background as white
-	gl.ClearColor(1.0, 1.0, 1.0, 1.0)
-	gl.Clear(gl.COLOR_BUFFER_BIT)
+	dev.ClearColor(1.0, 1.0, 1.0, 1.0)
+	dev.Clear(device.ColorBuffer)
 
 	// Use the GLSL program
-	program.Use()
+	dev.UseProgram(program)
 
-	uniformMTransform.UniformMatrix4fv(1, false, matrix)
+	dev.UniformMatrix4(uniformMTransform, matrix)
 
-	vboTriangle.Bind(gl.ARRAY_BUFFER)
+	dev.BindBuffer(device.ArrayBuffer, vboTriangle)
 
-	attributeCoord3d.EnableArray()
+	dev.EnableVertexAttribArray(attributeCoord3d)
 	// Describe our vertices array to OpenGL (it can't guess its format automatically)
-	attributeCoord3d.AttribPointerOffset(3, gl.FLOAT, false, 6*4, 0)
+	dev.VertexAttribPointer(attributeCoord3d, 3, 6*4, 0)
 
-	attributeColor.EnableArray()
-	attributeColor.AttribPointerOffset(3, gl.FLOAT, false, 6*4, 3*4)
+	dev.EnableVertexAttribArray(attributeColor)
+	dev.VertexAttribPointer(attributeColor, 3, 6*4, 3*4)
 
 	// Push each element in buffer_vertices to the vertex shader
-	gl.DrawArrays(gl.TRIANGLES, 0, 3)
-
-	attributeCoord3d.DisableArray()
-	attributeColor.DisableArray()
+	dev.DrawArrays(0, 3)
 
-	// Display the result
-	glfw.SwapBuffers()
+	dev.DisableVertexAttribArray(attributeCoord3d)
+	dev.DisableVertexAttribArray(attributeColor)
 }
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"raster"
+)
+
+// triangleProgram mirrors triangle.v.glsl and triangle.f.glsl for the
+// software rasterizer.
+type triangleProgram struct {
+	MTransform []float32 // uniform mat4 m_transform, by columns
+}
+
+func (*triangleProgram) Attributes() []string { return []string{"coord3d", "v_color"} }
+func (*triangleProgram) Varyings() int        { return 3 }
+
+func (p *triangleProgram) Uniforms() map[string]interface{} {
+	return map[string]interface{}{"m_transform": &p.MTransform}
+}
+
+func (p *triangleProgram) Vertex(in []raster.Vec4, out []float32) raster.Vec4 {
+	coord3d, vColor := in[0], in[1]
+	copy(out, vColor[:3])
+	return raster.MulMat4(p.MTransform, raster.Vec4{coord3d[0], coord3d[1], coord3d[2], 1})
+}
+
+func (*triangleProgram) Fragment(in []float32, coord raster.Vec4) raster.Vec4 {
+	fColor := in
+	return raster.Vec4{fColor[0], fColor[1], fColor[2], 1}
+}
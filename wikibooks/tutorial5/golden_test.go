@@ -0,0 +1,13 @@
+package main
+
+import (
+	"testing"
+
+	"golden"
+)
+
+// TestGolden runs the tutorial for 60 frames of 1/60 second with the
+// software device and compares the last with golden.png.
+func TestGolden(t *testing.T) {
+	golden.Test(t, scene, ScreenWidth, ScreenHeight, 60, golden.Files(&cubeProgram{}, "cube.v.glsl", "cube.f.glsl"))
+}
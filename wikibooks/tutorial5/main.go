@@ -2,15 +2,17 @@ package main
 
 import (
 	"fmt"
-	"io/ioutil"
-	"runtime"
-	"time"
-	"os"
-
+	"math"
+
+	"app"
+	"app/window"
+	"camera"
+	"color"
+	"device"
+	"input"
 	"math3d"
-
-	"gl"
-	"github.com/jteeuwen/glfw"
+	"mesh"
+	"mesh/gpu"
 )
 
 const (
@@ -20,282 +22,198 @@ const (
 var ScreenHeight = 600
 var ScreenWidth = 800
 
-const (
-	VertexShaderType = iota
-	FragmentShaderType
-)
-
-var cubeVertices = []float32{
-	// front
-	-1.0, -1.0, 1.0,
-	1.0, -1.0, 1.0,
-	1.0, 1.0, 1.0,
-	-1.0, 1.0, 1.0,
-	// back
-	-1.0, -1.0, -1.0,
-	1.0, -1.0, -1.0,
-	1.0, 1.0, -1.0,
-	-1.0, 1.0, -1.0,
-}
-
-var cubeColors = []float32{
-	// front colors
-	1.0, 0.0, 0.0,
-	0.0, 1.0, 0.0,
-	0.0, 0.0, 1.0,
-	1.0, 1.0, 1.0,
-	// back colors
-	1.0, 0.0, 0.0,
-	0.0, 1.0, 0.0,
-	0.0, 0.0, 1.0,
-	1.0, 1.0, 1.0,
+// newCube returns a cube sharing its 8 corners between the faces, with one
+// color per corner. The colors are given in sRGB like in a color picker,
+// but the shaders interpolate them linearly.
+func newCube() *mesh.Mesh {
+	cube := mesh.New()
+	cube.Add(mesh.Position, 3, []float32{
+		// front
+		-1.0, -1.0, 1.0,
+		1.0, -1.0, 1.0,
+		1.0, 1.0, 1.0,
+		-1.0, 1.0, 1.0,
+		// back
+		-1.0, -1.0, -1.0,
+		1.0, -1.0, -1.0,
+		1.0, 1.0, -1.0,
+		-1.0, 1.0, -1.0,
+	})
+	cube.Add(mesh.Color, 3, color.Linearize([]float32{
+		// front colors
+		1.0, 0.0, 0.0,
+		0.0, 1.0, 0.0,
+		0.0, 0.0, 1.0,
+		1.0, 1.0, 1.0,
+		// back colors
+		1.0, 0.0, 0.0,
+		0.0, 1.0, 0.0,
+		0.0, 0.0, 1.0,
+		1.0, 1.0, 1.0,
+	}))
+	cube.Indices = []uint32{
+		// front
+		0, 1, 2,
+		2, 3, 0,
+		// top
+		1, 5, 6,
+		6, 2, 1,
+		// back
+		7, 6, 5,
+		5, 4, 7,
+		// bottom
+		4, 0, 3,
+		3, 7, 4,
+		// left
+		4, 5, 1,
+		1, 0, 4,
+		// right
+		3, 2, 6,
+		6, 7, 3,
+	}
+	return cube
 }
 
-var cubeElements = []gl.GLushort{
-	// front
-	0, 1, 2,
-	2, 3, 0,
-	// top
-	1, 5, 6,
-	6, 2, 1,
-	// back
-	7, 6, 5,
-	5, 4, 7,
-	// bottom
-	4, 0, 3,
-	3, 7, 4,
-	// left
-	4, 5, 1,
-	1, 0, 4,
-	// right
-	3, 2, 6,
-	6, 7, 3,
-}
+var cube *gpu.Mesh
 
-var vboCubeVertices gl.Buffer
-var vboCubeColors gl.Buffer
-var iboCubeElements gl.Buffer
+var dev device.Device
 
-var vs gl.Shader
-var fs gl.Shader
-var program gl.Program
+var vs device.Shader
+var fs device.Shader
+var program device.Program
 
-var attributeCoord3d gl.AttribLocation
-var attributeColor gl.AttribLocation
-var uniformMTransform gl.UniformLocation
+var uniformMTransform int
 
-func fileRead(name string) (string, os.Error) {
-	b, err := ioutil.ReadFile(name)
-	if err != nil {
-		return "", err
-	}
-	return string(b), nil
-}
+func initResources(d device.Device) error {
+	dev = d
+	// Enable transparency in OpenGL
+	dev.Enable(device.Blend)
+	dev.Enable(device.DepthTest)
+	dev.BlendFunc(device.SrcAlpha, device.OneMinusSrcAlpha)
 
-func createShader(name string, shaderType int) (gl.Shader, os.Error) {
-	data, err := fileRead(name)
-	if err != nil {
-		return 0, err
-	}
-	if len(data) == 0 {
-		return 0, os.NewError("No shader code.")
-	}
-	var shader gl.Shader
-	switch shaderType {
-	case VertexShaderType:
-		shader = gl.CreateShader(gl.VERTEX_SHADER)
-	case FragmentShaderType:
-		shader = gl.CreateShader(gl.FRAGMENT_SHADER)
-	default:
-		return 0, os.NewError("Unknown ShaderType.")
-	}
-	shader.Source(data)
-	shader.Compile()
-
-	// Similar to print_log in the C code example
-	infoLog := shader.GetInfoLog()
-	if len(infoLog) != 0 {
-		shader.Delete()
-		return 0, err
-	}
-	errNum := gl.GetError()
-	if errNum != 0 {
-		return 0, os.NewError(fmt.Sprintf("Error code: %d", errNum))
-	}
-	return shader, nil
-}
+	// Let OpenGL encode the linear shader output to sRGB
+	dev.Enable(device.FramebufferSRGB)
 
-func initResources() {
-	var err os.Error
+	var err error
 	// Load shaders
-	vs, err = createShader("cube.v.glsl", VertexShaderType)
+	vs, err = device.LoadShader(dev, device.VertexShader, "cube.v.glsl")
 	if err != nil {
-		fmt.Printf("Shader: %s\n", err)
-		return
+		return fmt.Errorf("Shader: %s", err)
 	}
-	fs, err = createShader("cube.f.glsl", FragmentShaderType)
+	fs, err = device.LoadShader(dev, device.FragmentShader, "cube.f.glsl")
 	if err != nil {
-		fmt.Printf("Shader: %s\n", err)
-		return
+		return fmt.Errorf("Shader: %s", err)
 	}
 
 	// Create GLSL program with loaded shaders
-	program = gl.CreateProgram()
-	program.AttachShader(vs)
-	program.AttachShader(fs)
-	program.Link()
-	infoLog := program.GetInfoLog()
-	if len(infoLog) != 0 {
-		fmt.Printf("Program: %s\n", infoLog)
-	}
-
-	vboCubeColors = gl.GenBuffer()
-	vboCubeColors.Bind(gl.ARRAY_BUFFER)
-	gl.BufferData(gl.ARRAY_BUFFER, len(cubeColors)*4, cubeColors, gl.STATIC_DRAW)
-	gl.Buffer(0).Bind(gl.ARRAY_BUFFER)
-
-	vboCubeVertices = gl.GenBuffer()
-	vboCubeVertices.Bind(gl.ARRAY_BUFFER)
-	gl.BufferData(gl.ARRAY_BUFFER, len(cubeVertices)*4, cubeVertices, gl.STATIC_DRAW)
-	gl.Buffer(0).Bind(gl.ARRAY_BUFFER)
-
-	// Generate a buffer for the IndexBufferObject
-	iboCubeElements = gl.GenBuffer()
-	iboCubeElements.Bind(gl.ELEMENT_ARRAY_BUFFER)
-	// Submit the indexes to the graphic card
-	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(cubeElements)*2, cubeElements, gl.STATIC_DRAW)
-	// Unset the active buffer
-	gl.Buffer(0).Bind(gl.ELEMENT_ARRAY_BUFFER)
-
-	// Get the attribute location from the GLSL program (here from the vertex shader)
-	attributeName := "coord3d"
-	attributeCoord3d = program.GetAttribLocation(attributeName)
-	if attributeCoord3d == -1 {
-		fmt.Printf("Could not bind attribute %s\n", attributeName)
+	program, err = dev.CreateProgram(vs, fs)
+	if err != nil {
+		return fmt.Errorf("Error in program: %s", err)
 	}
 
-	attributeName = "v_color"
-	attributeColor = program.GetAttribLocation(attributeName)
-	if attributeColor == -1 {
-		fmt.Printf("Could not bind attribute %s\n", attributeName)
+	// Submit the vertices, colors and indexes to the graphic card
+	m := newCube()
+	if err := m.Validate(); err != nil {
+		return fmt.Errorf("Cube: %s", err)
 	}
+	cube = gpu.Upload(dev, m)
 
 	uniformName := "mvp"
-	uniformMTransform = program.GetUniformLocation(uniformName)
+	uniformMTransform = dev.UniformLocation(program, uniformName)
 	if uniformMTransform == -1 {
-		fmt.Printf("Could not bind attribute %s\n", uniformName)
+		fmt.Printf("Could not bind uniform %s\n", uniformName)
 	}
+	// Start the animation over and place the camera before the first update
+	elapsed, lastElapsed = 0, 0
+	orbit = newOrbit()
+	orbit.Update(cam, camera.Motion{}, 0)
+	return nil
 }
 
-const second = 1e9
+var config = app.Config{
+	Title:     WindowTitle,
+	Width:     ScreenWidth,
+	Height:    ScreenHeight,
+	AlphaBits: 8,
+	DepthBits: 8,
+}
 
 func main() {
-	// We need to lock the goroutine to one thread due time.Ticker
-	runtime.LockOSThread()
+	window.Main(config, scene)
+}
 
-	var err os.Error
-	err = glfw.Init()
-	if err != nil {
-		fmt.Printf("GLFW: %s\n", err)
-		return
-	}
-	defer glfw.Terminate()
+// scene is the tutorial, run by window.Main.
+var scene = app.Scene{
+	Init:     initResources,
+	Frame:    frame,
+	Update:   update,
+	Render:   render,
+	Resize:   resize,
+	Shutdown: free,
+}
 
-	// You could probably change the required versions down
-	glfw.OpenWindowHint(glfw.OpenGLVersionMajor, 3)
-	glfw.OpenWindowHint(glfw.OpenGLVersionMinor, 3)
-	glfw.OpenWindowHint(glfw.OpenGLProfile, 1)
+func resize(w, h int) {
+	ScreenWidth = w
+	ScreenHeight = h
+	cam.Aspect = float32(w) / float32(h)
+	orbit.Resize(w, h)
+}
 
-	// Open Window with 8 bit Alpha
-	err = glfw.OpenWindow(ScreenWidth, ScreenHeight, 0, 0, 0, 8, 8, 0, glfw.Windowed)
-	if err != nil {
-		fmt.Printf("GLFW: %s\n", err)
-		return
-	}
-	defer glfw.CloseWindow()
+func free() {
+	dev.DeleteProgram(program)
+	cube.Delete()
+}
 
-	glfw.SetWindowTitle(WindowTitle)
-	glfw.SetWindowSizeCallback(onResize)
+var matrix = math3d.MakeIdentity()
 
-	major, minor, rev := glfw.GLVersion()
-	if major < 3 {
-		fmt.Printf("Error your graphic card does not support OpenGL 3.3\n Your GL-Version is: %d, %d, %d\n", major, minor, rev)
-		fmt.Println("You can try to lower the settings in glfw.OpenWindowHint(glfw.OpenGLVersionMajor/Minor.")
-	}
+// cam looks at the cube from above, and orbit turns it around the cube as
+// the mouse drags.
+var cam = camera.New(float32(ScreenWidth) / float32(ScreenHeight))
+var orbit = newOrbit()
 
-	initStatus := gl.Init() // Init glew
-	if initStatus != 0 {
-		fmt.Printf("Error-code: %d Init-Status: %d\n", gl.GetError(), initStatus)
-	}
+func newOrbit() *camera.Orbit {
+	o := camera.NewOrbit(math3d.Vector3{0, 0, -4}, float32(math.Sqrt(20)), 0, -float32(math.Atan2(2, 4)))
+	o.Smoothing = 0.05
+	return o
+}
 
-	// Enable transparency in OpenGL
-	gl.Enable(gl.BLEND)
-	gl.Enable(gl.DEPTH_TEST)
-	//gl.DepthFunc(gl.LESS)
-	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
-
-	initResources()
-
-	// We are limiting the calls to display() (frames per second) to 60. This prevents the 100% cpu usage.
-	ticker := time.NewTicker(int64(second) / 60) // max 60 fps
-	for {
-		<-ticker.C
-		angle := float32(glfw.Time())
-		anim := math3d.MakeYRotationMatrix(angle)
-		model := math3d.MakeTranslationMatrix(0, 0, -4)
-		view := math3d.MakeLookAtMatrix(math3d.Vector3{0, 2, 0}, math3d.Vector3{0, 0, -4}, math3d.Vector3{0, 1, 0})
-		projection := math3d.MakePerspectiveMatrix(45, float32(ScreenWidth)/float32(ScreenHeight), 0.1, 10.0)
-		matrix = math3d.MakeIdentity().Multiply(projection).Multiply(view).Multiply(model).Multiply(anim)
-		program.Use()
-		uniformMTransform.UniformMatrix4fv(1, false, matrix.Transposed())
-		display()
-	}
+// elapsed is the time the animation has run for, lastElapsed the time at
+// the update before.
+var elapsed, lastElapsed float64
 
-	// Free resources
-	free()
+// frame moves the camera, also while the animation is paused.
+func frame(dt float64, in *input.Input, actions *input.Actions) {
+	orbit.Update(cam, camera.FromInput(in, actions), float32(dt))
+}
 
-	runtime.UnlockOSThread()
+func update(dt float64, in *input.Input, actions *input.Actions) {
+	lastElapsed = elapsed
+	elapsed += dt
 }
 
-func onResize(w, h int) {
-	ScreenWidth = w
-	ScreenHeight = h
-	gl.Viewport(0, 0, ScreenWidth, ScreenHeight)
+func render(alpha float64) {
+	animate(lastElapsed + alpha*(elapsed-lastElapsed))
+	display()
 }
 
-func free() {
-	program.Delete()
-	vboCubeColors.Delete()
-	vboCubeVertices.Delete()
-	iboCubeElements.Delete()
+// animate computes the matrix for time t.
+func animate(t float64) {
+	angle := float32(t)
+	anim := math3d.MakeYRotationMatrix(angle)
+	model := math3d.MakeTranslationMatrix(0, 0, -4)
+	matrix = cam.ViewProjection().Multiply(model).Multiply(anim).Transposed()
 }
-// 
-var matrix = math3d.MakeIdentity()
 
 func display() {
 	// Clear the background as white
-	gl.ClearColor(1.0, 1.0, 1.0, 1.0)
-	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	dev.ClearColor(1.0, 1.0, 1.0, 1.0)
+	dev.Clear(device.ColorBuffer | device.DepthBuffer)
 
 	// Use the GLSL program
-	program.Use()
-
-	attributeCoord3d.EnableArray()
-	vboCubeVertices.Bind(gl.ARRAY_BUFFER)
-	attributeCoord3d.AttribPointerOffset(3, gl.FLOAT, false, 0, 0)
-
-	attributeColor.EnableArray()
-	vboCubeColors.Bind(gl.ARRAY_BUFFER)
-	attributeColor.AttribPointerOffset(3, gl.FLOAT, false, 0, 0)
-
-	iboCubeElements.Bind(gl.ELEMENT_ARRAY_BUFFER)
-	//size := []int32{0}
-	//gl.GetBufferParameteriv(gl.ELEMENT_ARRAY_BUFFER, gl.BUFFER_SIZE, size)
-	gl.DrawElementsOffset(gl.TRIANGLES, len(cubeElements), gl.UNSIGNED_SHORT, 0)
+	dev.UseProgram(program)
 
-	attributeCoord3d.DisableArray()
-	attributeColor.DisableArray()
+	dev.UniformMatrix4(uniformMTransform, matrix)
 
-	// Display the result
-	glfw.SwapBuffers()
+	// Enables coord3d and v_color and draws the cube
+	cube.Draw(program)
 }
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"app"
+	"clock"
+	"device"
+	"input"
+)
+
+// record runs the tutorial for frames frames on a Recorder and returns it.
+func record(t *testing.T, frames int) *device.Recorder {
+	r := device.NewRecorder()
+	w := &app.Headless{Dev: r, Width: ScreenWidth, Height: ScreenHeight, Frames: frames}
+	if err := app.Loop(scene.App(input.New(), input.DefaultActions()), w, app.Config{Clock: clock.NewFixed(0, 1.0/60)}); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestCalls(t *testing.T) {
+	r := record(t, 3)
+	for _, err := range r.Errors {
+		t.Error(err)
+	}
+	indices := len(newCube().Indices)
+
+	// The 8 corners fit 16 bit indices
+	var size int
+	for _, c := range r.Filter("BufferData") {
+		if c.Args[0] == device.ElementArrayBuffer {
+			data, ok := c.Args[1].([]uint16)
+			if !ok {
+				t.Fatalf("indices are %T, want []uint16", c.Args[1])
+			}
+			size += len(data) * 2
+		}
+	}
+	if size != indices*2 {
+		t.Errorf("index buffer has %d bytes, want %d", size, indices*2)
+	}
+
+	draws := r.Filter("DrawElements")
+	if len(draws) != 3 {
+		t.Fatalf("got %d draws, want one per frame", len(draws))
+	}
+	for _, c := range draws {
+		if c.Args[0] != indices || c.Args[1] != device.UnsignedShort {
+			t.Errorf("got %v, want DrawElements(%d, UnsignedShort, 0)", c, indices)
+		}
+	}
+}
+
+// The recorder reports the mistakes OpenGL would silently ignore.
+func TestMisuse(t *testing.T) {
+	tests := []struct {
+		name   string
+		misuse func(r *device.Recorder)
+		err    string
+	}{
+		{"attribute -1", func(r *device.Recorder) {
+			// A name the shaders do not declare
+			r.EnableVertexAttribArray(r.AttribLocation(program, "normal"))
+		}, "invalid attribute location -1"},
+		{"no program", func(r *device.Recorder) {
+			defer func(p device.Program) { program = p }(program)
+			program = 0
+			display()
+		}, "no program in use"},
+		{"indices past the buffer", func(r *device.Recorder) {
+			// One index more than the cube has
+			r.UseProgram(program)
+			cube.Bind(program)
+			r.DrawElements(len(newCube().Indices)+1, device.UnsignedShort, 0)
+			cube.Unbind()
+		}, "bytes of indices from buffer"},
+	}
+	for _, test := range tests {
+		r := device.NewRecorder()
+		if err := scene.Init(r); err != nil {
+			t.Fatal(err)
+		}
+		r.Reset()
+		test.misuse(r)
+		free()
+		if err := r.Err(); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"raster"
+)
+
+// cubeProgram mirrors cube.v.glsl and cube.f.glsl for the
+// software rasterizer.
+type cubeProgram struct {
+	MVP []float32 // uniform mat4 mvp, by columns
+}
+
+func (*cubeProgram) Attributes() []string { return []string{"coord3d", "v_color"} }
+func (*cubeProgram) Varyings() int        { return 3 }
+
+func (p *cubeProgram) Uniforms() map[string]interface{} {
+	return map[string]interface{}{"mvp": &p.MVP}
+}
+
+func (p *cubeProgram) Vertex(in []raster.Vec4, out []float32) raster.Vec4 {
+	coord3d, vColor := in[0], in[1]
+	copy(out, vColor[:3])
+	return raster.MulMat4(p.MVP, raster.Vec4{coord3d[0], coord3d[1], coord3d[2], 1})
+}
+
+func (*cubeProgram) Fragment(in []float32, coord raster.Vec4) raster.Vec4 {
+	fColor := in
+	return raster.Vec4{fColor[0], fColor[1], fColor[2], 1}
+}
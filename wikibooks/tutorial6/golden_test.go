@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"device"
+	"golden"
+)
+
+// TestGolden runs the tutorial for 60 frames of 1/60 second with the
+// software device and compares the last with golden.png.
+func TestGolden(t *testing.T) {
+	// Wait for the texture so the first frame already shows it
+	s := scene
+	s.Init = func(d device.Device) error {
+		if err := initResources(d); err != nil {
+			return err
+		}
+		for loader.Pending() > 0 {
+			loader.Update(time.Second)
+			time.Sleep(time.Millisecond)
+		}
+		_, err := cubeTexture.Result()
+		return err
+	}
+	golden.Test(t, s, ScreenWidth, ScreenHeight, 60, golden.Files(&cubeProgram{}, "cube.v.glsl", "cube.f.glsl"))
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"raster"
+)
+
+// cubeProgram mirrors cube.v.glsl and cube.f.glsl for the software
+// rasterizer.
+type cubeProgram struct {
+	MVP       []float32       // uniform mat4 mvp, by columns
+	MyTexture *raster.Texture // uniform sampler2D mytexture
+}
+
+func (*cubeProgram) Attributes() []string { return []string{"coord3d", "texcoord"} }
+func (*cubeProgram) Varyings() int        { return 2 }
+
+func (p *cubeProgram) Uniforms() map[string]interface{} {
+	return map[string]interface{}{"mvp": &p.MVP, "mytexture": &p.MyTexture}
+}
+
+func (p *cubeProgram) Vertex(in []raster.Vec4, out []float32) raster.Vec4 {
+	coord3d, texcoord := in[0], in[1]
+	copy(out, texcoord[:2])
+	return raster.MulMat4(p.MVP, raster.Vec4{coord3d[0], coord3d[1], coord3d[2], 1})
+}
+
+func (p *cubeProgram) Fragment(in []float32, coord raster.Vec4) raster.Vec4 {
+	fTexcoord := in
+	return p.MyTexture.Sample(fTexcoord[0], 1-fTexcoord[1])
+}